@@ -9,6 +9,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/bootstrap/data"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/plugin"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -30,6 +32,8 @@ func Init() {
 }
 
 func Release() {
+	tracing.Shutdown()
+	plugin.Shutdown()
 	db.Close()
 }
 
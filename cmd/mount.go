@@ -0,0 +1,77 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fuse"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd mounts the files locally, it's built with the fuse tag only as it
+// needs cgo and the headers of libfuse, or WinFsp on windows
+var MountCmd = &cobra.Command{
+	Use:   "mount <path> <mountpoint>",
+	Short: "Mount a path of AList at the mountpoint by FUSE",
+	Long: `Mount a path of AList at the mountpoint by FUSE, the files are seen and
+written as the given user with the permissions of FTP.
+The files can only be written as a whole from the start.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		bootstrap.InitPlugins()
+		bootstrap.LoadStorages()
+		bootstrap.InitTaskManager()
+		username, _ := cmd.Flags().GetString("user")
+		opts, _ := cmd.Flags().GetStringArray("option")
+		user, err := op.GetUserByName(username)
+		if err != nil {
+			utils.Log.Fatalf("failed get user [%s]: %+v", username, err)
+		}
+		if user.Disabled {
+			utils.Log.Fatalf("user [%s] is disabled", username)
+		}
+		header := &http.Header{}
+		header.Add("User-Agent", setting.GetStr(conf.FTPProxyUserAgent))
+		ctx := context.WithValue(context.Background(), "user", user)
+		ctx = context.WithValue(ctx, "meta_pass", "")
+		ctx = context.WithValue(ctx, "client_ip", "127.0.0.1")
+		ctx = context.WithValue(ctx, "proxy_header", header)
+		var fuseOpts []string
+		for _, o := range opts {
+			fuseOpts = append(fuseOpts, "-o", o)
+		}
+		sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		utils.Log.Infof("mount [%s] at [%s] as [%s]", args[0], args[1], username)
+		if !fuse.Mount(sigCtx, mountBackend{ftp.NewAferoAdapter(ctx)}, args[0], args[1], fuseOpts) {
+			utils.Log.Errorf("failed mount [%s] at [%s]", args[0], args[1])
+		}
+	},
+}
+
+type mountBackend struct {
+	*ftp.AferoAdapter
+}
+
+func (b mountBackend) OpenFile(name string, flags int, offset int64) (fuse.Handle, error) {
+	return b.GetHandle(name, flags, offset)
+}
+
+func init() {
+	MountCmd.Flags().String("user", "admin", "Username the files are seen and written as")
+	MountCmd.Flags().StringArrayP("option", "o", nil, "Options of the mount, like allow_other")
+	RootCmd.AddCommand(MountCmd)
+}
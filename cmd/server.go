@@ -23,6 +23,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
 	mcpserver "github.com/alist-org/alist/v3/server/mcp"
+	"github.com/alist-org/alist/v3/server/nfs"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -42,10 +43,18 @@ the address is defined in config file`,
 			utils.Log.Infof("delayed start for %d seconds", conf.Conf.DelayedStart)
 			time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 		}
+		bootstrap.InitTracing()
 		bootstrap.InitOfflineDownloadTools()
+		bootstrap.InitPlugins()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
 		bootstrap.InitFRP()
+		bootstrap.InitSnapshot()
+		bootstrap.InitStorageProbe()
+		bootstrap.InitTrash()
+		bootstrap.InitAudit()
+		bootstrap.InitTusExpiry()
+		bootstrap.InitSchedule()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -160,6 +169,17 @@ the address is defined in config file`,
 				}()
 			}
 		}
+		var nfsServer *nfs.Server
+		if conf.Conf.NFS.Listen != "" && conf.Conf.NFS.Enable {
+			nfsServer = nfs.NewServer(conf.Conf.NFS.Exports, conf.Conf.NFS.User)
+			utils.Log.Infof("start nfs server on %s", conf.Conf.NFS.Listen)
+			go func() {
+				err := nfsServer.ListenAndServe(conf.Conf.NFS.Listen)
+				if err != nil {
+					utils.Log.Fatalf("problem nfs server listening: %s", err.Error())
+				}
+			}()
+		}
 		var mcpHttpSrv *http.Server
 		if conf.Conf.MCP.Port != -1 && conf.Conf.MCP.Enable {
 			mcpHandler := mcpserver.NewHTTPHandler()
@@ -234,6 +254,15 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		if nfsServer != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := nfsServer.Close(); err != nil {
+					utils.Log.Fatal("NFS server shutdown err: ", err)
+				}
+			}()
+		}
 		if conf.Conf.MCP.Port != -1 && conf.Conf.MCP.Enable && mcpHttpSrv != nil {
 			wg.Add(1)
 			go func() {
@@ -20,6 +20,7 @@ var config = driver.Config{
 	// OnlyProxy:   true,
 	// OnlyLocal:         true,
 	// NoOverwriteUpload: true,
+	DefaultConcurrency: 2,
 }
 
 func init() {
@@ -247,6 +247,7 @@ func (d *Pan123) Put(ctx context.Context, dstDir model.Obj, file model.FileStrea
 			return err
 		}
 		uploader := s3manager.NewUploader(s)
+		uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 		if file.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
 			uploader.PartSize = file.GetSize() / (s3manager.MaxUploadParts - 1)
 		}
@@ -18,6 +18,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive_open"
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive_share"
 	_ "github.com/alist-org/alist/v3/drivers/azure_blob"
+	_ "github.com/alist-org/alist/v3/drivers/backblaze_b2"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_netdisk"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_photo"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_share"
@@ -13,7 +13,6 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 )
@@ -37,7 +36,7 @@ func (d *AzureBlob) GetAddition() driver.Additional {
 	return &d.Addition
 }
 
-// Init initializes the Azure Blob Storage client using shared key authentication.
+// Init initializes the Azure Blob Storage client using shared key or SAS authentication.
 func (d *AzureBlob) Init(ctx context.Context) error {
 	// Validate the endpoint URL
 	accountName := extractAccountName(d.Addition.Endpoint)
@@ -45,31 +44,40 @@ func (d *AzureBlob) Init(ctx context.Context) error {
 		return fmt.Errorf("invalid storage account name: must be chars of lowercase letters or numbers only")
 	}
 
-	credential, err := azblob.NewSharedKeyCredential(accountName, d.Addition.AccessKey)
-	if err != nil {
-		return fmt.Errorf("failed to create credential: %w", err)
-	}
-
 	// Check if Endpoint is just account name
 	endpoint := d.Addition.Endpoint
 	if accountName == endpoint {
 		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", accountName)
 	}
 	// Initialize Azure Blob client with retry policy
-	client, err := azblob.NewClientWithSharedKeyCredential(endpoint, credential,
-		&azblob.ClientOptions{ClientOptions: azcore.ClientOptions{
-			Retry: policy.RetryOptions{
-				MaxRetries: MaxRetries,
-				RetryDelay: RetryDelay,
-			},
-		}})
+	options := &azblob.ClientOptions{ClientOptions: azcore.ClientOptions{
+		Retry: policy.RetryOptions{
+			MaxRetries: MaxRetries,
+			RetryDelay: RetryDelay,
+		},
+	}}
+	var client *azblob.Client
+	var err error
+	if d.AuthType == AuthTypeSAS {
+		token := strings.TrimPrefix(d.SASToken, "?")
+		if token == "" {
+			return fmt.Errorf("the SAS token is required by the sas auth type")
+		}
+		client, err = azblob.NewClientWithNoCredential(strings.TrimSuffix(endpoint, "/")+"/?"+token, options)
+	} else {
+		credential, cerr := azblob.NewSharedKeyCredential(accountName, d.Addition.AccessKey)
+		if cerr != nil {
+			return fmt.Errorf("failed to create credential: %w", cerr)
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(endpoint, credential, options)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 	d.client = client
 
 	// Ensure container exists or create it
-	containerName := strings.Trim(d.Addition.ContainerName, "/ \\")
+	containerName := d.containerName()
 	if containerName == "" {
 		return fmt.Errorf("container name cannot be empty")
 	}
@@ -88,9 +96,13 @@ func (d *AzureBlob) List(ctx context.Context, dir model.Obj, args model.ListArgs
 
 	pager := d.containerClient.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{
 		Prefix: &prefix,
+		Include: container.ListBlobsInclude{
+			Metadata: true,
+		},
 	})
 
 	var objs []model.Obj
+	dirs := map[string]bool{}
 	for pager.More() {
 		page, err := pager.NextPage(ctx)
 		if err != nil {
@@ -99,6 +111,7 @@ func (d *AzureBlob) List(ctx context.Context, dir model.Obj, args model.ListArgs
 
 		// Process directories
 		for _, blobPrefix := range page.Segment.BlobPrefixes {
+			dirs[strings.TrimSuffix(*blobPrefix.Name, "/")] = true
 			objs = append(objs, &model.Object{
 				Name:     path.Base(strings.TrimSuffix(*blobPrefix.Name, "/")),
 				Path:     *blobPrefix.Name,
@@ -113,6 +126,21 @@ func (d *AzureBlob) List(ctx context.Context, dir model.Obj, args model.ListArgs
 			if strings.HasSuffix(*blob.Name, "/") {
 				continue
 			}
+			// the directories of hierarchical namespace are blobs too, the
+			// empty ones have no prefix listed
+			if isDirectory(*blob) {
+				if !dirs[*blob.Name] {
+					dirs[*blob.Name] = true
+					objs = append(objs, &model.Object{
+						Name:     path.Base(*blob.Name),
+						Path:     *blob.Name,
+						Modified: *blob.Properties.LastModified,
+						Ctime:    *blob.Properties.CreationTime,
+						IsFolder: true,
+					})
+				}
+				continue
+			}
 			objs = append(objs, &model.Object{
 				Name:     path.Base(*blob.Name),
 				Path:     *blob.Name,
@@ -129,9 +157,14 @@ func (d *AzureBlob) List(ctx context.Context, dir model.Obj, args model.ListArgs
 // Link generates a temporary SAS URL for accessing a blob.
 func (d *AzureBlob) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	blobClient := d.containerClient.NewBlobClient(file.GetPath())
-	expireDuration := time.Hour * time.Duration(d.SignURLExpire)
-
-	sasURL, err := blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expireDuration), nil)
+	// the SAS token given may write and delete, it's never handed out, the
+	// blob is read through alist instead
+	if d.AuthType == AuthTypeSAS {
+		return &model.Link{RangeReadCloser: &model.RangeReadCloser{
+			RangeReader: d.rangeReader(blobClient),
+		}}, nil
+	}
+	sasURL, err := d.signURL(blobClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate SAS URL: %w", err)
 	}
@@ -264,6 +297,9 @@ func (d *AzureBlob) Remove(ctx context.Context, obj model.Obj) error {
 
 	// Handle recursive directory deletion
 	if obj.IsDir() {
+		if d.HierarchicalNamespace {
+			return d.deletePath(ctx, path)
+		}
 		return d.deleteFolder(ctx, path)
 	}
 
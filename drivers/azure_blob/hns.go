@@ -0,0 +1,115 @@
+package azure_blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// dfsVersion is the version of the Data Lake Storage Gen2 api called
+const dfsVersion = "2023-11-03"
+
+// dfsSAS returns the SAS token authorizing the Data Lake Storage calls, the
+// one of the account key is made for the container as the blob client can't
+// sign these calls
+func (d *AzureBlob) dfsSAS() (string, error) {
+	if d.AuthType == AuthTypeSAS {
+		return strings.TrimPrefix(d.SASToken, "?"), nil
+	}
+	sasURL, err := d.containerClient.GetSASURL(sas.ContainerPermissions{
+		Read:   true,
+		Add:    true,
+		Create: true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+		Move:   true,
+	}, time.Now().Add(time.Hour), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate container SAS: %w", err)
+	}
+	u, err := url.Parse(sasURL)
+	if err != nil {
+		return "", err
+	}
+	return u.RawQuery, nil
+}
+
+// dfsRequest calls the Data Lake Storage Gen2 api on the path in the container
+// of an account with hierarchical namespace
+// Link: https://learn.microsoft.com/rest/api/storageservices/data-lake-storage-gen2
+func (d *AzureBlob) dfsRequest(ctx context.Context, method, p string, query url.Values, header map[string]string) (*http.Response, error) {
+	token, err := d.dfsSAS()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(d.containerClient.URL())
+	if err != nil {
+		return nil, err
+	}
+	u.Host = strings.Replace(u.Host, ".blob.", ".dfs.", 1)
+	u.RawPath = ""
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Trim(p, "/")
+	u.RawQuery = token
+	if len(query) > 0 {
+		u.RawQuery += "&" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", dfsVersion)
+	for k, v := range header {
+		req.Header.Set(k, strings.ReplaceAll(v, "{sas}", token))
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s failed: %s %s", method, p, res.Status, res.Header.Get("x-ms-error-code"))
+	}
+	return res, nil
+}
+
+// renamePath renames the file or the directory at once
+func (d *AzureBlob) renamePath(ctx context.Context, srcPath, dstPath string) error {
+	source := "/" + d.containerName() + "/" + utils.EncodePath(strings.Trim(srcPath, "/"), true) + "?{sas}"
+	_, err := d.dfsRequest(ctx, http.MethodPut, dstPath, url.Values{"mode": {"legacy"}}, map[string]string{
+		"x-ms-rename-source": source,
+	})
+	return err
+}
+
+// deletePath deletes the directory with all its contents
+func (d *AzureBlob) deletePath(ctx context.Context, p string) error {
+	query := url.Values{"recursive": {"true"}}
+	for {
+		res, err := d.dfsRequest(ctx, http.MethodDelete, p, query, nil)
+		if err != nil {
+			return err
+		}
+		// a directory with many paths is deleted in several calls
+		continuation := res.Header.Get("x-ms-continuation")
+		if continuation == "" {
+			return nil
+		}
+		query.Set("continuation", continuation)
+	}
+}
+
+// createDir creates the directory and its missing parents
+func (d *AzureBlob) createDir(ctx context.Context, p string) error {
+	_, err := d.dfsRequest(ctx, http.MethodPut, p, url.Values{"resource": {"directory"}}, nil)
+	return err
+}
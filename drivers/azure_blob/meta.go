@@ -7,11 +7,21 @@ import (
 
 type Addition struct {
 	Endpoint      string `json:"endpoint" required:"true" default:"https://<accountname>.blob.core.windows.net/" help:"e.g. https://accountname.blob.core.windows.net/. The full endpoint URL for Azure Storage, including the unique storage account name (3 ~ 24 numbers and lowercase letters only)."`
-	AccessKey     string `json:"access_key" required:"true" help:"The access key for Azure Storage, used for authentication. https://learn.microsoft.com/azure/storage/common/storage-account-keys-manage"`
+	AuthType      string `json:"auth_type" type:"select" options:"account_key,sas" default:"account_key" help:"Authenticate with the access key of the account, or with a SAS token."`
+	AccessKey     string `json:"access_key" help:"The access key for Azure Storage, used for authentication. https://learn.microsoft.com/azure/storage/common/storage-account-keys-manage"`
+	SASToken      string `json:"sas_token" help:"The SAS token of the account or of the container, used with the sas auth type. The files are read through alist with it, as the token is never handed out in the links."`
 	ContainerName string `json:"container_name" required:"true" help:"The name of the container in Azure Storage (created in the Azure portal). https://learn.microsoft.com/azure/storage/blobs/blob-containers-portal"`
 	SignURLExpire int    `json:"sign_url_expire" type:"number" default:"4" help:"The expiration time for SAS URLs, in hours."`
+	// the folders of an account with hierarchical namespace are real, they are
+	// created, renamed and deleted at once by the Data Lake Storage Gen2 api
+	HierarchicalNamespace bool `json:"hierarchical_namespace" help:"Enable it if the account has hierarchical namespace (ADLS Gen2), to rename and delete folders at once instead of blob by blob."`
 }
 
+const (
+	AuthTypeAccountKey = "account_key"
+	AuthTypeSAS        = "sas"
+)
+
 // implement GetRootId interface
 func (r Addition) GetRootId() string {
 	return r.ContainerName
@@ -14,10 +14,13 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -45,6 +48,38 @@ func extractAccountName(endpoint string) string {
 	return ""
 }
 
+// containerName returns the container name without surrounding slashes
+func (d *AzureBlob) containerName() string {
+	return strings.Trim(d.Addition.ContainerName, "/ \\")
+}
+
+// signURL returns the URL of the blob readable until the configured expiration,
+// the blob URL carries the SAS token already with the sas auth type, so it's
+// only for the server side copies
+func (d *AzureBlob) signURL(blobClient *blob.Client) (string, error) {
+	if d.AuthType == AuthTypeSAS {
+		return blobClient.URL(), nil
+	}
+	expireDuration := time.Hour * time.Duration(d.SignURLExpire)
+	return blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expireDuration), nil)
+}
+
+// rangeReader reads the range of the blob with the credential of the driver
+func (d *AzureBlob) rangeReader(blobClient *blob.Client) model.RangeReaderFunc {
+	return func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		// a zero count reads to the end
+		r := blob.HTTPRange{Offset: httpRange.Start}
+		if httpRange.Length >= 0 {
+			r.Count = httpRange.Length
+		}
+		resp, err := blobClient.DownloadStream(ctx, &blob.DownloadStreamOptions{Range: r})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download blob: %w", err)
+		}
+		return resp.Body, nil
+	}
+}
+
 // isNotFoundError checks if the error is a "not found" type error
 func isNotFoundError(err error) bool {
 	var storageErr *azcore.ResponseError
@@ -205,9 +240,7 @@ func (d *AzureBlob) copyFile(ctx context.Context, srcPath, dstPath string) error
 	srcBlob := d.containerClient.NewBlobClient(srcPath)
 	dstBlob := d.containerClient.NewBlobClient(dstPath)
 
-	// Use configured expiration time for SAS URL
-	expireDuration := time.Hour * time.Duration(d.SignURLExpire)
-	srcURL, err := srcBlob.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expireDuration), nil)
+	srcURL, err := d.signURL(srcBlob)
 	if err != nil {
 		return fmt.Errorf("failed to generate source SAS URL: %w", err)
 	}
@@ -222,6 +255,11 @@ func (d *AzureBlob) copyFile(ctx context.Context, srcPath, dstPath string) error
 func (d *AzureBlob) createContainerIfNotExists(ctx context.Context, containerName string) error {
 	serviceClient := d.client.ServiceClient()
 	containerClient := serviceClient.NewContainerClient(containerName)
+	// a SAS token may be of the container only, which can't create it
+	if d.AuthType == AuthTypeSAS {
+		d.containerClient = containerClient
+		return nil
+	}
 
 	var options = service.CreateContainerOptions{}
 	_, err := containerClient.Create(ctx, &options)
@@ -238,6 +276,9 @@ func (d *AzureBlob) createContainerIfNotExists(ctx context.Context, containerNam
 
 // mkDir creates a virtual directory marker by uploading an empty blob with metadata.
 func (d *AzureBlob) mkDir(ctx context.Context, fullDirName string) error {
+	if d.HierarchicalNamespace {
+		return d.createDir(ctx, fullDirName)
+	}
 	dirPath := ensureTrailingSlash(fullDirName)
 	blobClient := d.containerClient.NewBlockBlobClient(dirPath)
 
@@ -266,6 +307,10 @@ func ensureTrailingSlash(path string) string {
 
 // moveOrRename moves or renames blobs or directories from source to destination.
 func (d *AzureBlob) moveOrRename(ctx context.Context, srcPath, dstPath string, isDir bool, srcSize int64) error {
+	// the paths of hierarchical namespace are renamed at once, directories included
+	if d.HierarchicalNamespace {
+		return d.renamePath(ctx, srcPath, dstPath)
+	}
 	if isDir {
 		// Normalize paths for directory operations
 		srcPath = ensureTrailingSlash(srcPath)
@@ -0,0 +1,182 @@
+package backblaze_b2
+
+import (
+	"context"
+	"net/url"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+type BackblazeB2 struct {
+	model.Storage
+	Addition
+	mu         sync.RWMutex
+	auth       AuthResp
+	uploadURLs []UploadURL
+	bucketID   string
+	private    bool
+}
+
+func (d *BackblazeB2) Config() driver.Config {
+	return config
+}
+
+func (d *BackblazeB2) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *BackblazeB2) Init(ctx context.Context) error {
+	if err := d.authorize(ctx); err != nil {
+		return err
+	}
+	bucket, err := d.getBucket(ctx)
+	if err != nil {
+		return err
+	}
+	d.bucketID = bucket.BucketID
+	d.private = bucket.BucketType != "allPublic"
+	return nil
+}
+
+func (d *BackblazeB2) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *BackblazeB2) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	files, err := d.listFiles(ctx, getKey(dir.GetPath(), true), true)
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, 0, len(files))
+	for _, f := range files {
+		if (f.Action != "upload" && f.Action != "folder") || stdpath.Base(f.FileName) == placeholder {
+			continue
+		}
+		objs = append(objs, fileToObj(f, dir.GetPath()))
+	}
+	return objs, nil
+}
+
+func (d *BackblazeB2) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	key := getKey(file.GetPath(), false)
+	host := strings.TrimSuffix(d.CustomHost, "/")
+	if host == "" {
+		host = d.getAuth().DownloadURL
+	}
+	link := &model.Link{URL: host + "/file/" + url.PathEscape(d.Bucket) + "/" + utils.EncodePath(key, true)}
+	if !d.private {
+		return link, nil
+	}
+	// at most a week
+	exp := min(time.Duration(d.SignURLExpire)*time.Hour, 7*24*time.Hour)
+	var resp DownloadAuthResp
+	err := d.request(ctx, "b2_get_download_authorization", base.Json{
+		"bucketId":               d.bucketID,
+		"fileNamePrefix":         key,
+		"validDurationInSeconds": int64(exp.Seconds()),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	link.URL += "?Authorization=" + url.QueryEscape(resp.AuthorizationToken)
+	link.Expiration = &exp
+	return link, nil
+}
+
+func (d *BackblazeB2) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	key := getKey(stdpath.Join(parentDir.GetPath(), dirName, placeholder), false)
+	u, err := d.getUploadURL(ctx)
+	if err != nil {
+		return err
+	}
+	// the sha1 of the empty content
+	_, err = post(ctx, u, strings.NewReader(""), 0, "da39a3ee5e6b4b0d3255bfef95601890afd80709", map[string]string{
+		"X-Bz-File-Name": utils.EncodePath(key, true),
+		"Content-Type":   "application/octet-stream",
+	})
+	if err != nil {
+		return err
+	}
+	d.putUploadURL(u)
+	return nil
+}
+
+func (d *BackblazeB2) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.move(ctx, srcObj, stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+}
+
+func (d *BackblazeB2) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.move(ctx, srcObj, stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName))
+}
+
+// move copies the obj to dst then removes it, B2 can't rename files
+func (d *BackblazeB2) move(ctx context.Context, srcObj model.Obj, dst string) error {
+	if err := d.copy(ctx, srcObj, dst); err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *BackblazeB2) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.copy(ctx, srcObj, stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+}
+
+func (d *BackblazeB2) copy(ctx context.Context, srcObj model.Obj, dst string) error {
+	if !srcObj.IsDir() {
+		return d.copyFile(ctx, File{
+			FileID:        srcObj.GetID(),
+			FileName:      getKey(srcObj.GetPath(), false),
+			ContentLength: srcObj.GetSize(),
+		}, getKey(dst, false))
+	}
+	srcPrefix := getKey(srcObj.GetPath(), true)
+	files, err := d.listFiles(ctx, srcPrefix, false)
+	if err != nil {
+		return err
+	}
+	dstPrefix := getKey(dst, true)
+	for _, f := range files {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		if err = d.copyFile(ctx, f, dstPrefix+strings.TrimPrefix(f.FileName, srcPrefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *BackblazeB2) Remove(ctx context.Context, obj model.Obj) error {
+	if !obj.IsDir() {
+		key := getKey(obj.GetPath(), false)
+		return d.removeFiles(ctx, key, []string{key})
+	}
+	prefix := getKey(obj.GetPath(), true)
+	if d.HardDelete {
+		return d.removeFiles(ctx, prefix, nil)
+	}
+	files, err := d.listFiles(ctx, prefix, false)
+	if err != nil {
+		return err
+	}
+	return d.removeFiles(ctx, prefix, utils.MustSliceConvert(files, func(f File) string {
+		return f.FileName
+	}))
+}
+
+func (d *BackblazeB2) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	if stream.GetSize() > d.partSize(stream.GetSize()) {
+		return d.uploadLarge(ctx, key, stream, up)
+	}
+	return d.upload(ctx, key, stream, up)
+}
+
+var _ driver.Driver = (*BackblazeB2)(nil)
@@ -0,0 +1,30 @@
+package backblaze_b2
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	KeyID          string `json:"key_id" required:"true" help:"The keyID of an application key"`
+	ApplicationKey string `json:"application_key" required:"true"`
+	Bucket         string `json:"bucket" required:"true"`
+	CustomHost     string `json:"custom_host" help:"The host the files are downloaded from instead of the download URL of the account, such as a CDN of the Bandwidth Alliance in front of the bucket"`
+	SignURLExpire  int    `json:"sign_url_expire" type:"number" default:"4" help:"The expiration time of the download links of a private bucket, in hours"`
+	ChunkSize      int64  `json:"chunk_size" type:"number" default:"100" help:"The part size of the large file uploads, in MB. The files bigger than it are uploaded in parts"`
+	HardDelete     bool   `json:"hard_delete" help:"Delete all the versions of the files removed instead of hiding them"`
+}
+
+var config = driver.Config{
+	Name:        "BackblazeB2",
+	LocalSort:   true,
+	DefaultRoot: "/",
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &BackblazeB2{}
+	})
+}
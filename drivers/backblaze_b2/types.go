@@ -0,0 +1,101 @@
+package backblaze_b2
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+type ErrorResp struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ErrorResp) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+type AuthResp struct {
+	AccountID               string `json:"accountId"`
+	AuthorizationToken      string `json:"authorizationToken"`
+	APIURL                  string `json:"apiUrl"`
+	DownloadURL             string `json:"downloadUrl"`
+	RecommendedPartSize     int64  `json:"recommendedPartSize"`
+	AbsoluteMinimumPartSize int64  `json:"absoluteMinimumPartSize"`
+}
+
+type Bucket struct {
+	BucketID   string `json:"bucketId"`
+	BucketName string `json:"bucketName"`
+	BucketType string `json:"bucketType"`
+}
+
+type ListBucketsResp struct {
+	Buckets []Bucket `json:"buckets"`
+}
+
+type File struct {
+	FileID          string            `json:"fileId"`
+	FileName        string            `json:"fileName"`
+	ContentLength   int64             `json:"contentLength"`
+	ContentSha1     string            `json:"contentSha1"`
+	ContentType     string            `json:"contentType"`
+	Action          string            `json:"action"`
+	UploadTimestamp int64             `json:"uploadTimestamp"`
+	FileInfo        map[string]string `json:"fileInfo"`
+}
+
+type ListFilesResp struct {
+	Files        []File  `json:"files"`
+	NextFileName *string `json:"nextFileName"`
+	NextFileID   *string `json:"nextFileId"`
+}
+
+type UploadURL struct {
+	UploadURL          string `json:"uploadUrl"`
+	AuthorizationToken string `json:"authorizationToken"`
+}
+
+type DownloadAuthResp struct {
+	AuthorizationToken string `json:"authorizationToken"`
+}
+
+type PartResp struct {
+	ContentSha1 string `json:"contentSha1"`
+}
+
+// sha1 of the file, the large files have it in their info if the uploader
+// told it
+func (f File) sha1() string {
+	sum := strings.TrimPrefix(f.ContentSha1, "unverified:")
+	if sum == "" || sum == "none" {
+		sum = f.FileInfo["large_file_sha1"]
+	}
+	return sum
+}
+
+func fileToObj(f File, dir string) model.Obj {
+	name := path.Base(strings.TrimSuffix(f.FileName, "/"))
+	modified := time.UnixMilli(f.UploadTimestamp)
+	if ms, err := strconv.ParseInt(f.FileInfo["src_last_modified_millis"], 10, 64); err == nil {
+		modified = time.UnixMilli(ms)
+	}
+	obj := &model.Object{
+		ID:       f.FileID,
+		Path:     path.Join(dir, name),
+		Name:     name,
+		Size:     f.ContentLength,
+		Modified: modified,
+		IsFolder: f.Action == "folder",
+	}
+	if sum := f.sha1(); sum != "" && !obj.IsFolder {
+		obj.HashInfo = utils.NewHashInfo(utils.SHA1, sum)
+	}
+	return obj
+}
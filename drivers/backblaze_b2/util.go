@@ -0,0 +1,432 @@
+package backblaze_b2
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	authURL = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+	// placeholder is the empty file keeping an empty folder, as the web UI of B2
+	placeholder = ".bzEmpty"
+	// maxPartSize is the max size of a single upload or copy, and of a part
+	maxPartSize = 5 * 1000 * 1000 * 1000
+	maxParts    = 10000
+)
+
+func getKey(p string, dir bool) string {
+	p = strings.TrimPrefix(p, "/")
+	if p != "" && dir {
+		p += "/"
+	}
+	return p
+}
+
+// authorize gets a new authorization token of the account, it's valid for 24
+// hours and is renewed by request once expired
+func (d *BackblazeB2) authorize(ctx context.Context) error {
+	var auth AuthResp
+	var e ErrorResp
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetBasicAuth(d.KeyID, d.ApplicationKey).
+		SetResult(&auth).SetError(&e).
+		Get(authURL)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return &e
+	}
+	d.mu.Lock()
+	d.auth = auth
+	// the upload urls are bound to the former token
+	d.uploadURLs = nil
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *BackblazeB2) getAuth() AuthResp {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.auth
+}
+
+func isAuthExpired(status int, e *ErrorResp) bool {
+	return status == http.StatusUnauthorized && (e.Code == "expired_auth_token" || e.Code == "bad_auth_token")
+}
+
+// request calls the api of B2, authorizing again if the token expired
+func (d *BackblazeB2) request(ctx context.Context, api string, body, result any) error {
+	for retried := false; ; retried = true {
+		auth := d.getAuth()
+		var e ErrorResp
+		res, err := base.RestyClient.R().SetContext(ctx).
+			SetHeader("Authorization", auth.AuthorizationToken).
+			SetBody(body).SetResult(result).SetError(&e).
+			Post(auth.APIURL + "/b2api/v2/" + api)
+		if err != nil {
+			return err
+		}
+		if !res.IsError() {
+			return nil
+		}
+		if !retried && isAuthExpired(res.StatusCode(), &e) {
+			if err = d.authorize(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		if res.StatusCode() == http.StatusNotFound {
+			return errors.WithMessage(errs.ObjectNotFound, e.Message)
+		}
+		return &e
+	}
+}
+
+func (d *BackblazeB2) getBucket(ctx context.Context) (*Bucket, error) {
+	var resp ListBucketsResp
+	err := d.request(ctx, "b2_list_buckets", base.Json{
+		"accountId":  d.getAuth().AccountID,
+		"bucketName": d.Bucket,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range resp.Buckets {
+		if b.BucketName == d.Bucket {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("bucket %s not found", d.Bucket)
+}
+
+// listFiles lists the file names under prefix, the folders directly under it
+// only if delimiter is set
+func (d *BackblazeB2) listFiles(ctx context.Context, prefix string, delimiter bool) ([]File, error) {
+	var files []File
+	data := base.Json{
+		"bucketId":     d.bucketID,
+		"prefix":       prefix,
+		"maxFileCount": 1000,
+	}
+	if delimiter {
+		data["delimiter"] = "/"
+	}
+	for {
+		var resp ListFilesResp
+		if err := d.request(ctx, "b2_list_file_names", data, &resp); err != nil {
+			return nil, err
+		}
+		files = append(files, resp.Files...)
+		if resp.NextFileName == nil {
+			return files, nil
+		}
+		data["startFileName"] = *resp.NextFileName
+	}
+}
+
+// listVersions lists all the versions of the files under prefix
+func (d *BackblazeB2) listVersions(ctx context.Context, prefix string) ([]File, error) {
+	var files []File
+	data := base.Json{
+		"bucketId":     d.bucketID,
+		"prefix":       prefix,
+		"maxFileCount": 1000,
+	}
+	for {
+		var resp ListFilesResp
+		if err := d.request(ctx, "b2_list_file_versions", data, &resp); err != nil {
+			return nil, err
+		}
+		files = append(files, resp.Files...)
+		if resp.NextFileName == nil {
+			return files, nil
+		}
+		data["startFileName"] = *resp.NextFileName
+		data["startFileId"] = *resp.NextFileID
+	}
+}
+
+// removeFiles hides the files of names, or deletes all their versions with
+// hard_delete, prefix is the common prefix of them to list the versions. All
+// the versions under prefix are deleted if names is nil.
+func (d *BackblazeB2) removeFiles(ctx context.Context, prefix string, names []string) error {
+	if !d.HardDelete {
+		for _, name := range names {
+			if err := d.request(ctx, "b2_hide_file", base.Json{
+				"bucketId": d.bucketID,
+				"fileName": name,
+			}, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	versions, err := d.listVersions(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if names != nil && !utils.SliceContains(names, v.FileName) {
+			continue
+		}
+		if err := d.request(ctx, "b2_delete_file_version", base.Json{
+			"fileName": v.FileName,
+			"fileId":   v.FileID,
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *BackblazeB2) copyFile(ctx context.Context, src File, dstKey string) error {
+	if src.ContentLength <= maxPartSize {
+		return d.request(ctx, "b2_copy_file", base.Json{
+			"sourceFileId": src.FileID,
+			"fileName":     dstKey,
+		}, nil)
+	}
+	fileID, err := d.startLargeFile(ctx, dstKey, src.ContentType, src.FileInfo)
+	if err != nil {
+		return err
+	}
+	var sums []string
+	for part, start := 1, int64(0); start < src.ContentLength; part++ {
+		end := min(start+maxPartSize, src.ContentLength) - 1
+		var resp PartResp
+		err = d.request(ctx, "b2_copy_part", base.Json{
+			"sourceFileId": src.FileID,
+			"largeFileId":  fileID,
+			"partNumber":   part,
+			"range":        fmt.Sprintf("bytes=%d-%d", start, end),
+		}, &resp)
+		if err != nil {
+			d.cancelLargeFile(fileID)
+			return err
+		}
+		sums = append(sums, resp.ContentSha1)
+		start = end + 1
+	}
+	return d.finishLargeFile(ctx, fileID, sums)
+}
+
+func (d *BackblazeB2) startLargeFile(ctx context.Context, key, contentType string, info map[string]string) (string, error) {
+	if contentType == "" {
+		contentType = "b2/x-auto"
+	}
+	var resp File
+	err := d.request(ctx, "b2_start_large_file", base.Json{
+		"bucketId":    d.bucketID,
+		"fileName":    key,
+		"contentType": contentType,
+		"fileInfo":    info,
+	}, &resp)
+	return resp.FileID, err
+}
+
+func (d *BackblazeB2) finishLargeFile(ctx context.Context, fileID string, sums []string) error {
+	return d.request(ctx, "b2_finish_large_file", base.Json{
+		"fileId":        fileID,
+		"partSha1Array": sums,
+	}, nil)
+}
+
+// cancelLargeFile discards the parts uploaded, not to be billed for them
+func (d *BackblazeB2) cancelLargeFile(fileID string) {
+	err := d.request(context.Background(), "b2_cancel_large_file", base.Json{"fileId": fileID}, nil)
+	if err != nil {
+		utils.Log.Warnf("[backblaze_b2] failed cancel large file %s: %+v", fileID, err)
+	}
+}
+
+// getUploadURL takes an upload url of the bucket, an upload url serves one
+// upload at a time, so they are pooled and given back once the upload is done
+func (d *BackblazeB2) getUploadURL(ctx context.Context) (*UploadURL, error) {
+	d.mu.Lock()
+	if n := len(d.uploadURLs); n > 0 {
+		u := d.uploadURLs[n-1]
+		d.uploadURLs = d.uploadURLs[:n-1]
+		d.mu.Unlock()
+		return &u, nil
+	}
+	d.mu.Unlock()
+	var u UploadURL
+	err := d.request(ctx, "b2_get_upload_url", base.Json{"bucketId": d.bucketID}, &u)
+	return &u, err
+}
+
+func (d *BackblazeB2) putUploadURL(u *UploadURL) {
+	d.mu.Lock()
+	d.uploadURLs = append(d.uploadURLs, *u)
+	d.mu.Unlock()
+}
+
+// post posts the content to an upload url, sum is the hex sha1 of it or
+// hex_digits_at_end if it's appended to the content
+func post(ctx context.Context, u *UploadURL, body io.Reader, size int64, sum string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.UploadURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Authorization", u.AuthorizationToken)
+	req.Header.Set("X-Bz-Content-Sha1", sum)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		var e ErrorResp
+		if err = utils.Json.Unmarshal(data, &e); err != nil || e.Code == "" {
+			return nil, fmt.Errorf("failed to upload: %s", res.Status)
+		}
+		return nil, &e
+	}
+	return data, nil
+}
+
+// sumReader reads the hex of the sum of h once the content is read, for the
+// hex_digits_at_end uploads
+type sumReader struct {
+	h   hash.Hash
+	sum io.Reader
+}
+
+func (r *sumReader) Read(p []byte) (int, error) {
+	if r.sum == nil {
+		r.sum = strings.NewReader(hex.EncodeToString(r.h.Sum(nil)))
+	}
+	return r.sum.Read(p)
+}
+
+func srcInfo(stream model.FileStreamer) map[string]string {
+	return map[string]string{
+		"src_last_modified_millis": strconv.FormatInt(stream.ModTime().UnixMilli(), 10),
+	}
+}
+
+func (d *BackblazeB2) upload(ctx context.Context, key string, stream model.FileStreamer, up driver.UpdateProgress) error {
+	u, err := d.getUploadURL(ctx)
+	if err != nil {
+		return err
+	}
+	var body io.Reader = driver.NewLimitedUploadStream(ctx, &driver.ReaderUpdatingProgress{
+		Reader:         stream,
+		UpdateProgress: up,
+	})
+	size := stream.GetSize()
+	sum := stream.GetHash().GetHash(utils.SHA1)
+	if sum == "" {
+		h := sha1.New()
+		body = io.MultiReader(io.TeeReader(body, h), &sumReader{h: h})
+		size += sha1.Size * 2
+		sum = "hex_digits_at_end"
+	}
+	contentType := stream.GetMimetype()
+	if contentType == "" {
+		contentType = "b2/x-auto"
+	}
+	_, err = post(ctx, u, body, size, sum, map[string]string{
+		"X-Bz-File-Name":                     utils.EncodePath(key, true),
+		"Content-Type":                       contentType,
+		"X-Bz-Info-src_last_modified_millis": srcInfo(stream)["src_last_modified_millis"],
+	})
+	if err != nil {
+		// the upload url may be busy or expired, it's not reused
+		return err
+	}
+	d.putUploadURL(u)
+	return nil
+}
+
+func (d *BackblazeB2) partSize(size int64) int64 {
+	partSize := d.ChunkSize * utils.MB
+	if partSize <= 0 {
+		partSize = d.getAuth().RecommendedPartSize
+	}
+	partSize = min(max(partSize, d.getAuth().AbsoluteMinimumPartSize), maxPartSize)
+	// at most maxParts parts
+	return max(partSize, (size+maxParts-1)/maxParts)
+}
+
+// uploadLarge uploads the file in parts by the large file api, a part is
+// retried with a new upload url if it fails
+func (d *BackblazeB2) uploadLarge(ctx context.Context, key string, stream model.FileStreamer, up driver.UpdateProgress) error {
+	fileID, err := d.startLargeFile(ctx, key, stream.GetMimetype(), srcInfo(stream))
+	if err != nil {
+		return err
+	}
+	size := stream.GetSize()
+	partSize := d.partSize(size)
+	buf := make([]byte, partSize)
+	var sums []string
+	var u *UploadURL
+	for part, done := 1, int64(0); done < size; part++ {
+		if utils.IsCanceled(ctx) {
+			d.cancelLargeFile(fileID)
+			return ctx.Err()
+		}
+		data := buf[:min(partSize, size-done)]
+		if _, err = io.ReadFull(stream, data); err != nil {
+			d.cancelLargeFile(fileID)
+			return err
+		}
+		h := sha1.Sum(data)
+		sum := hex.EncodeToString(h[:])
+		for attempt := 1; ; attempt++ {
+			if u == nil {
+				u = &UploadURL{}
+				if err = d.request(ctx, "b2_get_upload_part_url", base.Json{"fileId": fileID}, u); err != nil {
+					break
+				}
+			}
+			_, err = post(ctx, u, driver.NewLimitedUploadStream(ctx, bytes.NewReader(data)), int64(len(data)), sum, map[string]string{
+				"X-Bz-Part-Number": strconv.Itoa(part),
+			})
+			if err == nil || attempt >= 3 || utils.IsCanceled(ctx) {
+				break
+			}
+			u = nil
+			backoff := time.Duration(1<<attempt) * time.Second
+			utils.Log.Warnf("[backblaze_b2] failed upload part %d of %s, retrying after %v: %+v", part, key, backoff, err)
+			time.Sleep(backoff)
+		}
+		if err != nil {
+			d.cancelLargeFile(fileID)
+			return err
+		}
+		sums = append(sums, sum)
+		done += int64(len(data))
+		up(float64(done) * 100 / float64(size))
+	}
+	if err = d.finishLargeFile(ctx, fileID, sums); err != nil {
+		d.cancelLargeFile(fileID)
+		return err
+	}
+	return nil
+}
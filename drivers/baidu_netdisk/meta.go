@@ -35,8 +35,9 @@ const (
 )
 
 var config = driver.Config{
-	Name:        "BaiduNetdisk",
-	DefaultRoot: "/",
+	Name:               "BaiduNetdisk",
+	DefaultRoot:        "/",
+	DefaultConcurrency: 3,
 }
 
 func init() {
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package local
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func (d *Local) GetDiskUsage(ctx context.Context) (*model.DiskUsage, error) {
+	return nil, errs.NotSupport
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package local
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"golang.org/x/sys/unix"
+)
+
+func (d *Local) GetDiskUsage(ctx context.Context) (*model.DiskUsage, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(d.GetRootPath(), &stat); err != nil {
+		return nil, err
+	}
+	return &model.DiskUsage{
+		TotalSpace: int64(stat.Blocks) * int64(stat.Bsize),
+		// the space the unprivileged users can use
+		FreeSpace: int64(stat.Bavail) * int64(stat.Bsize),
+	}, nil
+}
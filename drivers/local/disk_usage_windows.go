@@ -0,0 +1,25 @@
+//go:build windows
+
+package local
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"golang.org/x/sys/windows"
+)
+
+func (d *Local) GetDiskUsage(ctx context.Context) (*model.DiskUsage, error) {
+	root, err := windows.UTF16PtrFromString(d.GetRootPath())
+	if err != nil {
+		return nil, err
+	}
+	var free, total uint64
+	if err = windows.GetDiskFreeSpaceEx(root, &free, &total, nil); err != nil {
+		return nil, err
+	}
+	return &model.DiskUsage{
+		TotalSpace: int64(total),
+		FreeSpace:  int64(free),
+	}, nil
+}
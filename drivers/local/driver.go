@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
 	"os"
@@ -45,6 +46,9 @@ type Local struct {
 
 	// use ffmpeg
 	useFFmpeg bool
+
+	// root path with symlinks resolved
+	realRootPath string
 }
 
 func (d *Local) Config() driver.Config {
@@ -71,6 +75,11 @@ func (d *Local) Init(ctx context.Context) error {
 		}
 		d.Addition.RootFolderPath = abs
 	}
+	realRoot, err := filepath.EvalSymlinks(d.GetRootPath())
+	if err != nil {
+		return err
+	}
+	d.realRootPath = realRoot
 
 	d.useFFmpeg = d.UseFFmpeg
 
@@ -159,6 +168,26 @@ func (d *Local) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 		if !d.ShowHidden && strings.HasPrefix(f.Name(), ".") {
 			continue
 		}
+		if isSymlink(f) {
+			filePath := filepath.Join(fullPath, f.Name())
+			switch d.SymlinkPolicy {
+			case SymlinkFollow:
+				if _, err := d.resolveSymlink(filePath); err != nil {
+					log.Warnf("[local] skip symlink: %+v", err)
+					continue
+				}
+				info, err := os.Stat(filePath)
+				if err != nil {
+					continue
+				}
+				f = info
+			case SymlinkLinkObject:
+				files = append(files, symlinkToObj(f, filePath))
+				continue
+			default:
+				continue
+			}
+		}
 		file := d.FileInfoToObj(ctx, f, args.ReqPath, fullPath)
 		files = append(files, file)
 	}
@@ -219,6 +248,13 @@ func (d *Local) GetMeta(ctx context.Context, path string) (model.Obj, error) {
 
 func (d *Local) Get(ctx context.Context, path string) (model.Obj, error) {
 	path = filepath.Join(d.GetRootPath(), path)
+	link, err := d.checkSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil {
+		return link, nil
+	}
 	f, err := os.Stat(path)
 	if err != nil {
 		if strings.Contains(err.Error(), "cannot find the file") {
@@ -250,6 +286,9 @@ func (d *Local) Get(ctx context.Context, path string) (model.Obj, error) {
 }
 
 func (d *Local) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	if _, ok := model.GetLinkTarget(file); ok {
+		return nil, errs.NotSupport
+	}
 	fullPath := file.GetPath()
 	var link model.Link
 	if args.Type == "thumb" && utils.Ext(file.GetName()) != "svg" {
@@ -364,6 +403,19 @@ func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	return nil
 }
 
+func (d *Local) HardLink(ctx context.Context, srcObj, dstObj model.Obj) error {
+	// link beside the dst first, so the dst is only replaced once linked
+	tmpPath := fmt.Sprintf("%s.%d.link", dstObj.GetPath(), time.Now().UnixNano())
+	if err := os.Link(srcObj.GetPath(), tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dstObj.GetPath()); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	fullPath := filepath.Join(dstDir.GetPath(), stream.GetName())
 	out, err := os.Create(fullPath)
@@ -387,4 +439,15 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) Append(ctx context.Context, dstDir model.Obj, name string, reader io.Reader) error {
+	fullPath := filepath.Join(dstDir.GetPath(), name)
+	out, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return utils.CopyWithCtx(ctx, out, reader, 0, nil)
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.Quota = (*Local)(nil)
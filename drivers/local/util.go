@@ -13,14 +13,104 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/disintegration/imaging"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
+const (
+	SymlinkSkip       = "skip"
+	SymlinkFollow     = "follow"
+	SymlinkLinkObject = "link_object"
+)
+
+func isSymlink(f fs.FileInfo) bool {
+	return f.Mode()&os.ModeSymlink == os.ModeSymlink || (runtime.GOOS == "windows" && f.Mode()&os.ModeIrregular != 0)
+}
+
+// isSubFilePath reports whether path is base itself or inside base
+func isSubFilePath(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSymlink returns the real path of the symlink at path. Targets outside of
+// the root folder are rejected unless allowed, and so are targets that are an ancestor
+// of the link itself, since following them would make recursive walks loop forever.
+func (d *Local) resolveSymlink(path string) (string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	if !d.SymlinkOutsideRoot && !isSubFilePath(d.realRootPath, target) {
+		return "", fmt.Errorf("symlink %s points outside of the root folder", path)
+	}
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil && isSubFilePath(target, parent) {
+		return "", fmt.Errorf("symlink %s points to its ancestor %s", path, target)
+	}
+	return target, nil
+}
+
+// checkSymlinks applies the symlink policy to every component of path below the
+// root folder. A non-nil obj is returned when the last component is a symlink
+// which should be presented as a link object.
+func (d *Local) checkSymlinks(path string) (model.Obj, error) {
+	rel, err := filepath.Rel(d.GetRootPath(), path)
+	if err != nil || rel == "." {
+		return nil, nil
+	}
+	cur := d.GetRootPath()
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		cur = filepath.Join(cur, part)
+		f, err := os.Lstat(cur)
+		if err != nil {
+			// let the caller report it
+			return nil, nil
+		}
+		if !isSymlink(f) {
+			continue
+		}
+		switch d.SymlinkPolicy {
+		case SymlinkFollow:
+			if _, err := d.resolveSymlink(cur); err != nil {
+				return nil, errs.ObjectNotFound
+			}
+		case SymlinkLinkObject:
+			if i != len(parts)-1 {
+				return nil, errs.ObjectNotFound
+			}
+			return symlinkToObj(f, cur), nil
+		default:
+			return nil, errs.ObjectNotFound
+		}
+	}
+	return nil, nil
+}
+
+func symlinkToObj(f fs.FileInfo, path string) model.Obj {
+	target, _ := os.Readlink(path)
+	return &model.ObjLink{
+		Object: model.Object{
+			Path:     path,
+			Name:     f.Name(),
+			Modified: f.ModTime(),
+		},
+		Target: target,
+	}
+}
+
 func isLinkedDir(f fs.FileInfo, path string) bool {
-	if f.Mode()&os.ModeSymlink == os.ModeSymlink || (runtime.GOOS == "windows" && f.Mode()&os.ModeIrregular != 0) {
+	if isSymlink(f) {
 		dst, err := os.Readlink(path)
 		if err != nil {
 			return false
@@ -188,6 +188,7 @@ func (d *MediaTrack) Put(ctx context.Context, dstDir model.Obj, file model.FileS
 		_ = tempFile.Close()
 	}()
 	uploader := s3manager.NewUploader(s)
+	uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 	if file.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
 		uploader.PartSize = file.GetSize() / (s3manager.MaxUploadParts - 1)
 	}
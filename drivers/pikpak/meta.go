@@ -23,9 +23,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "PikPak",
-	LocalSort:   true,
-	DefaultRoot: "",
+	Name:               "PikPak",
+	LocalSort:          true,
+	DefaultRoot:        "",
+	DefaultConcurrency: 4,
 }
 
 func init() {
@@ -385,6 +385,7 @@ func (d *Quqi) Put(ctx context.Context, dstDir model.Obj, stream model.FileStrea
 		return nil, err
 	}
 	uploader := s3manager.NewUploader(s)
+	uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 	buf := make([]byte, 1024*1024*2)
 	fup := &driver.ReaderUpdatingProgress{
 		Reader: &driver.SimpleReaderWithSize{
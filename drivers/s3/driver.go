@@ -199,6 +199,7 @@ func (d *S3) Remove(ctx context.Context, obj model.Obj) error {
 
 func (d *S3) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
 	uploader := s3manager.NewUploader(d.Session)
+	uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 	if s.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
 		uploader.PartSize = s.GetSize() / (s3manager.MaxUploadParts - 1)
 	}
@@ -249,6 +249,7 @@ func (d *Teambition) newUpload(ctx context.Context, dstDir model.Obj, stream mod
 		return err
 	}
 	uploader := s3manager.NewUploader(ss)
+	uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 	if stream.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
 		uploader.PartSize = stream.GetSize() / (s3manager.MaxUploadParts - 1)
 	}
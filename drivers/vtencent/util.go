@@ -270,6 +270,7 @@ func (d *Vtencent) FileUpload(ctx context.Context, dstDir model.Obj, stream mode
 		return err
 	}
 	uploader := s3manager.NewUploader(ss)
+	uploader.Concurrency = d.UploadPartConcurrency(s3manager.DefaultUploadConcurrency)
 	if stream.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
 		uploader.PartSize = stream.GetSize() / (s3manager.MaxUploadParts - 1)
 	}
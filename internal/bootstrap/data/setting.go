@@ -124,6 +124,12 @@ func InitialSettings() []model.SettingItem {
 		//{Key: conf.OfficeTypes, Value: "doc,docx,xls,xlsx,ppt,pptx", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
 		{Key: conf.ProxyTypes, Value: "m3u8,url", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
 		{Key: conf.ProxyIgnoreHeaders, Value: "authorization,referer", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
+		{Key: conf.ProxyContentEncoding, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `serve .gz/.br/.zst files on the proxy path as their uncompressed name with Content-Encoding, decompressing for clients which don't accept the encoding`},
+		{Key: conf.PreviewConvert, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `convert the previews of preview_convert_types to an image browsers can display, requires ImageMagick`},
+		{Key: conf.PreviewConvertTypes, Value: "heic,heif,dng,cr2,cr3,nef,arw,orf,rw2,raf", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
+		{Key: conf.PreviewConvertFormat, Value: "jpeg", Type: conf.TypeSelect, Options: "jpeg,webp", Group: model.PREVIEW, Flag: model.PRIVATE},
+		{Key: conf.PreviewConvertThreads, Value: "2", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `max conversions running at once`},
+		{Key: conf.PreviewCacheSize, Value: "1024", Type: conf.TypeNumber, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `max size in MB of the converted previews kept, the least recently used are removed beyond it`},
 		{Key: "external_previews", Value: `{}`, Type: conf.TypeText, Group: model.PREVIEW},
 		{Key: "iframe_previews", Value: `{
 	"doc,docx,xls,xlsx,ppt,pptx": {
@@ -172,13 +178,38 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.DeviceEvictPolicy, Value: "deny", Type: conf.TypeSelect, Options: "deny,evict_oldest", Group: model.GLOBAL},
 		{Key: conf.DeviceSessionTTL, Value: "86400", Type: conf.TypeNumber, Group: model.GLOBAL},
 		{Key: conf.MetaNotFoundCacheExpire, Value: "60", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Negative cache expiration for missing meta records, in seconds. Set 0 to disable."},
+		{Key: conf.OverwriteReadingPolicy, Value: "wait", Type: conf.TypeSelect, Options: "wait,rename,reject", Group: model.GLOBAL, Flag: model.PRIVATE, Help: `how to overwrite a file while it is read through the proxy, webdav, ftp, sftp, nfs or s3. wait: wait up to 10 minutes for the reads to finish; rename: move the old file aside and remove it after the reads; reject: fail the overwrite`},
+		{Key: conf.WebdavPathNormalization, Value: "resolve", Type: conf.TypeSelect, Options: "resolve,reject", Group: model.GLOBAL, Flag: model.PRIVATE, Help: `duplicate slashes and "." segments of WebDAV paths are always collapsed, ".." segments are either resolved within the base path or rejected`},
+		{Key: conf.UploadToFolderPolicy, Value: "reject", Type: conf.TypeSelect, Options: "reject,inside", Group: model.GLOBAL, Flag: model.PRIVATE, Help: `how to handle an upload to a path which is a folder. reject: fail with a conflict error; inside: put the file inside the folder with its original name`},
+		{Key: conf.EmptyPathPolicy, Value: "root", Type: conf.TypeSelect, Options: "root,reject", Group: model.GLOBAL, Flag: model.PRIVATE, Help: `how the write APIs handle an empty path field. root: use the base path of the user; reject: fail naming the field`},
+		{Key: conf.RefreshAfterWrite, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `re-list the destination folder after a mkdir, move, copy or upload so the next read is fresh and cached, at the cost of a driver call. Can be requested per call with the Refresh-After-Write: true header`},
+		{Key: conf.RecursiveExclude, Value: ".DS_Store\nThumbs.db\ndesktop.ini\n._*\n.Spotlight-V100\n.Trashes\n.fseventsd", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `names skipped by the recursive copy and move, one glob per line, such as .* for all the dotfiles. Can be bypassed per call with include_all`},
+		{Key: conf.TrashEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `move the removed objs to the .trash folder of their storage, they can be restored until they expire. Can be bypassed per call with permanent`},
+		{Key: conf.TrashRetention, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days the trashed objs are kept, 0 to keep them until purged`},
+		{Key: conf.RCEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `serve the remote control api of rclone at /rc, for the tools made for it`},
+		{Key: conf.StorageProbeInterval, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `interval of the latency probes of the storages in seconds, 0 to disable`},
+		{Key: conf.StorageProbeOps, Value: "list", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `operations probed, comma separated among list and get. get is only probed on the drivers supporting it`},
+		{Key: conf.StorageProbeWindow, Value: "60", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `minutes of probes the latency percentiles are computed over`},
+		{Key: conf.AuditLogEnabled, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `record the user of each file mutation, used by /api/fs/activity, and the logins, permission and storage changes`},
+		{Key: conf.AuditLogRetention, Value: "180", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days the audit logs are kept, 0 to keep them forever`},
+		{Key: conf.MetricsToken, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `bearer token required to scrape /metrics, empty to disable the endpoint`},
+		{Key: conf.SnapshotPaths, Value: "", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `directories snapshotted periodically, one per line`},
+		{Key: conf.SnapshotInterval, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `interval of automatic snapshots in minutes, 0 to disable`},
+		{Key: conf.SnapshotRetention, Value: "7", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `number of snapshots kept for each directory`},
+		{Key: conf.SnapshotBackupDir, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `default directory holding a backup copy of the snapshotted paths, restored files are copied from it`},
+		{Key: conf.ContentRouteRules, Value: "[]", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `JSON rules used by /api/fs/route, e.g. [{"types":["image"],"dst_dir":"/photos"},{"exts":["mp4","mkv"],"dst_dir":"/media"},{"mime":"audio/","dst_dir":"/music"}]`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
-		{Key: conf.SearchIndex, Value: "none", Type: conf.TypeSelect, Options: "database,database_non_full_text,bleve,meilisearch,no_index,none", Group: model.INDEX},
+		{Key: conf.SearchIndex, Value: "none", Type: conf.TypeSelect, Options: "database,database_non_full_text,bleve,meilisearch,elasticsearch,no_index,none", Group: model.INDEX},
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.IndexOpsPerSecond, Value: "0", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `dirs listed per second while indexing, 0 for no limit`},
+		{Key: conf.IndexPeakHours, Value: "", Type: conf.TypeString, Group: model.INDEX, Flag: model.PRIVATE, Help: `hours the indexing is paused in, such as 9-18, empty for none`},
+		{Key: conf.IndexLatencyThreshold, Value: "0", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `pause the indexing while the average latency of the requests is over this in milliseconds, 0 to disable`},
+		{Key: conf.IndexContent, Value: "false", Type: conf.TypeBool, Group: model.INDEX, Flag: model.PRIVATE, Help: `index the text of the txt, md, pdf and docx files, so searching matches their content`},
+		{Key: conf.IndexContentMaxSize, Value: "10", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `the content of the larger files isn't indexed, in MB`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
@@ -187,6 +218,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.SSOClientId, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOClientSecret, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOOIDCUsernameKey, Value: "name", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
+		// the claim of the roles, like groups or realm_access.roles, empty not to map them
+		{Key: conf.SSOOIDCRoleClaim, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
+		// a line of "claim value:role name" for each role given by a value
+		{Key: conf.SSOOIDCRoleMapping, Value: "", Type: conf.TypeText, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOOrganizationName, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOApplicationName, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOEndpointName, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
@@ -207,6 +242,11 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.LdapDefaultDir, Value: "/", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapDefaultPermission, Value: "0", Type: conf.TypeNumber, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapLoginTips, Value: "login with ldap", Type: conf.TypeString, Group: model.LDAP, Flag: model.PUBLIC},
+		{Key: conf.LdapAutoRegister, Value: "true", Type: conf.TypeBool, Group: model.LDAP, Flag: model.PRIVATE},
+		// the attribute of the groups of the users, like memberOf, empty not to map them
+		{Key: conf.LdapGroupAttribute, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE},
+		// a line of "group dn:role name" for each role given by a group
+		{Key: conf.LdapGroupRoleMapping, Value: "", Type: conf.TypeText, Group: model.LDAP, Flag: model.PRIVATE},
 
 		// s3 settings
 		{Key: conf.S3AccessKeyId, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
@@ -238,17 +278,39 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.FRPSTCPSecretKey, Value: "", Type: conf.TypeString, Group: model.FRP, Flag: model.PRIVATE, Help: "Required for stcp proxy type"},
 		{Key: conf.FRPStatus, Value: "stopped", Type: conf.TypeString, Group: model.FRP, Flag: model.READONLY},
 
+		// notify settings
+		{Key: conf.NotifyEvents, Value: "task_failed,storage_offline,login_anomaly", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `comma separated events notified, among task_failed, storage_offline and login_anomaly`},
+		{Key: conf.NotifyTelegramBotToken, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifyTelegramChatID, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySMTPHost, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySMTPPort, Value: "587", Type: conf.TypeNumber, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `465 for implicit TLS, STARTTLS is used on the other ports if the server supports it`},
+		{Key: conf.NotifySMTPUsername, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySMTPPassword, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySMTPFrom, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySMTPTo, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `comma separated recipients`},
+		{Key: conf.NotifyWebhookURL, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `url POSTed a json of each notification`},
+		{Key: conf.NotifyWebhookSecret, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `if set, the body of the webhook is signed with HMAC-SHA256 in the X-Alist-Signature header`},
+		{Key: conf.NotifyGotifyURL, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `url of the gotify server, such as https://gotify.example.com`},
+		{Key: conf.NotifyGotifyToken, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `token of the gotify application`},
+
 		// traffic settings
 		{Key: conf.TaskOfflineDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Download.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskOfflineDownloadTransferThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Transfer.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskUploadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Upload.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskCopyThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Copy.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.CopySplitThreshold, Value: "0", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `a folder copied between storages with more children than this is split into sub-tasks under a parent task, 0 to disable`},
+		{Key: conf.CopySplitBatchSize, Value: "0", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `number of files copied by each sub-task of a split copy, 0 or 1 for a sub-task per file; each child folder always gets its own sub-task`},
+		{Key: conf.MovePreserveMtime, Value: "true", Type: conf.TypeBool, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `a file moved between storages keeps its modification time where the destination storage can set it`},
 		{Key: conf.TaskDecompressDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Decompress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressUploadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.DecompressUpload.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskWebhookURL, Value: "", Type: conf.TypeString, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `url POSTed a json about the copy, move, upload and offline download tasks which end, empty to disable`},
+		{Key: conf.TaskWebhookEvents, Value: "succeeded,failed,canceled", Type: conf.TypeString, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `comma separated events firing the task webhook, among succeeded, failed and canceled`},
+		{Key: conf.TaskRetryPolicies, Value: "{}", Type: conf.TypeText, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `JSON retry policies of the copy, move, upload, offline_download and offline_download_transfer tasks, "*" for all of them, e.g. {"copy":{"max_retry":5,"backoff":"exponential","delay":10,"max_delay":600,"retry_on":["timeout","network","rate_limit","server"]}}`},
+		{Key: conf.TaskWebhookSecret, Value: "", Type: conf.TypeString, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `if set, the body of the task webhook is signed with HMAC-SHA256 in the X-Alist-Signature header`},
 	}
 	initialSettingItems = append(initialSettingItems, tool.Tools.Items()...)
 	if flags.Dev {
@@ -24,6 +24,12 @@ func InitialTasks() []model.TaskItem {
 		{Key: "copy", PersistData: "[]"},
 		{Key: "download", PersistData: "[]"},
 		{Key: "transfer", PersistData: "[]"},
+		{Key: "upload", PersistData: "[]"},
+		{Key: "move", PersistData: "[]"},
+		{Key: "decompress", PersistData: "[]"},
+		{Key: "s3_transition", PersistData: "[]"},
+		{Key: "rehash", PersistData: "[]"},
+		{Key: "fs_transfer", PersistData: "[]"},
 	}
 	return initialTaskItems
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/snapshot"
+	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/xhofe/tache"
 )
 
@@ -18,21 +20,27 @@ func taskFilterNegative(num int) int64 {
 }
 
 func InitTaskManager() {
-	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry)) //upload will not support persist
+	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("upload", conf.Conf.Tasks.Upload.TaskPersistant), db.UpdateTaskDataFunc("upload", conf.Conf.Tasks.Upload.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry))
+	fs.CleanUploadTaskDir()
 	op.RegisterSettingChangingCallback(func() {
 		fs.UploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)))
 	})
-	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
+	// the copy and offline download managers run their tasks as they come, the
+	// pools keep them to the number of workers in the order of their priority
+	fs.CopyTaskPool = task.NewPriorityPool(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers))
+	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(task.PoolWorkers), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
-		fs.CopyTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)))
+		fs.CopyTaskPool.SetSize(int(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers))))
 	})
-	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
+	tool.DownloadTaskPool = task.NewPriorityPool(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers))
+	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(task.PoolWorkers), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
-		tool.DownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
+		tool.DownloadTaskPool.SetSize(int(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers))))
 	})
-	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	tool.TransferTaskPool = task.NewPriorityPool(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers))
+	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(task.PoolWorkers), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
-		tool.TransferTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
+		tool.TransferTaskPool.SetSize(int(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers))))
 	})
 	if len(tool.TransferTaskManager.GetAll()) == 0 { //prevent offline downloaded files from being deleted
 		CleanTempDir()
@@ -49,6 +57,17 @@ func InitTaskManager() {
 		),
 		tache.WithMaxRetry(conf.Conf.Tasks.S3Transition.MaxRetry),
 	)
+	fs.RehashTaskManager = tache.NewManager[*fs.RehashTask](tache.WithWorks(conf.Conf.Tasks.Rehash.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("rehash", conf.Conf.Tasks.Rehash.TaskPersistant), db.UpdateTaskDataFunc("rehash", conf.Conf.Tasks.Rehash.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Rehash.MaxRetry))
+	fs.ArchiveVerifyTaskManager = tache.NewManager[*fs.ArchiveVerifyTask](tache.WithWorks(conf.Conf.Tasks.ArchiveVerify.Workers), tache.WithMaxRetry(conf.Conf.Tasks.ArchiveVerify.MaxRetry))
+	fs.ExtCheckTaskManager = tache.NewManager[*fs.ExtCheckTask](tache.WithWorks(conf.Conf.Tasks.ExtCheck.Workers), tache.WithMaxRetry(conf.Conf.Tasks.ExtCheck.MaxRetry))
+	fs.TransferTaskManager = tache.NewManager[*fs.TransferTask](tache.WithWorks(conf.Conf.Tasks.FsTransfer.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("fs_transfer", conf.Conf.Tasks.FsTransfer.TaskPersistant), db.UpdateTaskDataFunc("fs_transfer", conf.Conf.Tasks.FsTransfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.FsTransfer.MaxRetry))
+	fs.MoveTaskManager = tache.NewManager[*fs.MoveTask](tache.WithWorks(conf.Conf.Tasks.Move.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant), db.UpdateTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Move.MaxRetry))
+	fs.CompressTaskManager = tache.NewManager[*fs.CompressTask](tache.WithWorks(conf.Conf.Tasks.Compress.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Compress.MaxRetry))
+	fs.LongPathTrimTaskManager = tache.NewManager[*fs.LongPathTrimTask](tache.WithWorks(conf.Conf.Tasks.PathTrim.Workers), tache.WithMaxRetry(conf.Conf.Tasks.PathTrim.MaxRetry))
+	fs.SyncTaskManager = tache.NewManager[*fs.SyncTask](tache.WithWorks(conf.Conf.Tasks.Sync.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
+	fs.SyncPairTaskManager = tache.NewManager[*fs.SyncPairTask](tache.WithWorks(conf.Conf.Tasks.Sync.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
+	fs.DedupTaskManager = tache.NewManager[*fs.DedupTask](tache.WithWorks(conf.Conf.Tasks.Dedup.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Dedup.MaxRetry))
+	snapshot.TaskManager = tache.NewManager[*snapshot.SnapshotTask](tache.WithWorks(conf.Conf.Tasks.Snapshot.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Snapshot.MaxRetry))
 	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
@@ -0,0 +1,10 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/tracing"
+)
+
+func InitTracing() {
+	tracing.Init(conf.Conf.Tracing)
+}
@@ -26,6 +26,15 @@ type Meilisearch struct {
 	IndexPrefix string `json:"index_prefix" env:"INDEX_PREFIX"`
 }
 
+type Elasticsearch struct {
+	Host string `json:"host" env:"HOST"`
+	// Username and Password are for the basic auth, APIKey is used instead if set
+	Username    string `json:"username" env:"USERNAME"`
+	Password    string `json:"password" env:"PASSWORD"`
+	APIKey      string `json:"api_key" env:"API_KEY"`
+	IndexPrefix string `json:"index_prefix" env:"INDEX_PREFIX"`
+}
+
 type Scheme struct {
 	Address      string `json:"address" env:"ADDR"`
 	HttpPort     int    `json:"http_port" env:"HTTP_PORT"`
@@ -58,9 +67,19 @@ type TasksConfig struct {
 	Transfer           TaskConfig `json:"transfer" envPrefix:"TRANSFER_"`
 	Upload             TaskConfig `json:"upload" envPrefix:"UPLOAD_"`
 	Copy               TaskConfig `json:"copy" envPrefix:"COPY_"`
+	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
+	Compress           TaskConfig `json:"compress" envPrefix:"COMPRESS_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
 	S3Transition       TaskConfig `json:"s3_transition" envPrefix:"S3_TRANSITION_"`
+	Rehash             TaskConfig `json:"rehash" envPrefix:"REHASH_"`
+	ArchiveVerify      TaskConfig `json:"archive_verify" envPrefix:"ARCHIVE_VERIFY_"`
+	ExtCheck           TaskConfig `json:"ext_check" envPrefix:"EXT_CHECK_"`
+	FsTransfer         TaskConfig `json:"fs_transfer" envPrefix:"FS_TRANSFER_"`
+	PathTrim           TaskConfig `json:"path_trim" envPrefix:"PATH_TRIM_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
+	Dedup              TaskConfig `json:"dedup" envPrefix:"DEDUP_"`
+	Snapshot           TaskConfig `json:"snapshot" envPrefix:"SNAPSHOT_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -94,35 +113,74 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+// NFS exports the dirs of Exports read-only over NFSv3 as User. NFS has no
+// password, so the clients are only told apart by the allowed IPs of the user.
+type NFS struct {
+	Enable bool   `json:"enable" env:"ENABLE"`
+	Listen string `json:"listen" env:"LISTEN"`
+	// Exports are the dirs which can be mounted, separated by commas, relative
+	// to the base path of the user
+	Exports string `json:"exports" env:"EXPORTS"`
+	User    string `json:"user" env:"USER"`
+}
+
+// OpTimeouts are the timeouts in seconds of single driver operations, zero means no timeout.
+// They bound the calls to the driver only, unlike the timeouts of the http requests.
+type OpTimeouts struct {
+	List int `json:"list"`
+	Get  int `json:"get"`
+	Link int `json:"link"`
+	Put  int `json:"put"`
+}
+
+// Tracing exports the spans of the requests, down to the driver calls, to an
+// OTLP/HTTP endpoint such as http://localhost:4318
+type Tracing struct {
+	Enable      bool              `json:"enable" env:"ENABLE"`
+	Endpoint    string            `json:"endpoint" env:"ENDPOINT"`
+	ServiceName string            `json:"service_name" env:"SERVICE_NAME"`
+	SampleRatio float64           `json:"sample_ratio" env:"SAMPLE_RATIO"`
+	Headers     map[string]string `json:"headers"`
+}
+
 type MCP struct {
 	Enable bool `json:"enable" env:"ENABLE"`
 	Port   int  `json:"port" env:"PORT"`
 }
 
 type Config struct {
-	Force                 bool        `json:"force" env:"FORCE"`
-	SiteURL               string      `json:"site_url" env:"SITE_URL"`
-	Cdn                   string      `json:"cdn" env:"CDN"`
-	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	Database              Database    `json:"database" envPrefix:"DB_"`
-	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
-	Scheme                Scheme      `json:"scheme"`
-	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
-	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
-	DistDir               string      `json:"dist_dir"`
-	Log                   LogConfig   `json:"log"`
-	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
-	MaxConnections        int         `json:"max_connections" env:"MAX_CONNECTIONS"`
-	MaxConcurrency        int         `json:"max_concurrency" env:"MAX_CONCURRENCY"`
-	TlsInsecureSkipVerify bool        `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
-	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
-	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
-	S3                    S3          `json:"s3" envPrefix:"S3_"`
-	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
-	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
-	MCP                   MCP         `json:"mcp" envPrefix:"MCP_"`
-	LastLaunchedVersion   string      `json:"last_launched_version"`
+	Force                 bool          `json:"force" env:"FORCE"`
+	SiteURL               string        `json:"site_url" env:"SITE_URL"`
+	Cdn                   string        `json:"cdn" env:"CDN"`
+	JwtSecret             string        `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn        int           `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	Database              Database      `json:"database" envPrefix:"DB_"`
+	Meilisearch           Meilisearch   `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Elasticsearch         Elasticsearch `json:"elasticsearch" envPrefix:"ELASTICSEARCH_"`
+	Scheme                Scheme        `json:"scheme"`
+	TempDir               string        `json:"temp_dir" env:"TEMP_DIR"`
+	BleveDir              string        `json:"bleve_dir" env:"BLEVE_DIR"`
+	DistDir               string        `json:"dist_dir"`
+	Log                   LogConfig     `json:"log"`
+	DelayedStart          int           `json:"delayed_start" env:"DELAYED_START"`
+	MaxConnections        int           `json:"max_connections" env:"MAX_CONNECTIONS"`
+	MaxConcurrency        int           `json:"max_concurrency" env:"MAX_CONCURRENCY"`
+	TlsInsecureSkipVerify bool          `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	Tasks                 TasksConfig   `json:"tasks" envPrefix:"TASKS_"`
+	Cors                  Cors          `json:"cors" envPrefix:"CORS_"`
+	S3                    S3            `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP           `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP          `json:"sftp" envPrefix:"SFTP_"`
+	NFS                   NFS           `json:"nfs" envPrefix:"NFS_"`
+	MCP                   MCP           `json:"mcp" envPrefix:"MCP_"`
+	Tracing               Tracing       `json:"tracing" envPrefix:"TRACING_"`
+	// TrustedProxies are the addresses or ranges of the reverse proxies whose
+	// X-Forwarded-For is taken as the client ip, none are trusted by default
+	TrustedProxies []string `json:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// DriverTimeouts are the default operation timeouts by driver name,
+	// used for the storages which don't set their own
+	DriverTimeouts      map[string]OpTimeouts `json:"driver_timeouts"`
+	LastLaunchedVersion string                `json:"last_launched_version"`
 }
 
 func DefaultConfig() *Config {
@@ -152,6 +210,9 @@ func DefaultConfig() *Config {
 		Meilisearch: Meilisearch{
 			Host: "http://localhost:7700",
 		},
+		Elasticsearch: Elasticsearch{
+			Host: "http://localhost:9200",
+		},
 		BleveDir: indexDir,
 		Log: LogConfig{
 			Enable:     true,
@@ -165,22 +226,32 @@ func DefaultConfig() *Config {
 		TlsInsecureSkipVerify: false,
 		Tasks: TasksConfig{
 			Download: TaskConfig{
-				Workers:  5,
-				MaxRetry: 1,
-				// TaskPersistant: true,
+				Workers:        5,
+				MaxRetry:       1,
+				TaskPersistant: true,
 			},
 			Transfer: TaskConfig{
-				Workers:  5,
-				MaxRetry: 2,
-				// TaskPersistant: true,
+				Workers:        5,
+				MaxRetry:       2,
+				TaskPersistant: true,
 			},
 			Upload: TaskConfig{
 				Workers: 5,
+				// the content of the persisted uploads is kept in the temp dir
+				TaskPersistant: true,
 			},
 			Copy: TaskConfig{
+				Workers:        5,
+				MaxRetry:       2,
+				TaskPersistant: true,
+			},
+			Move: TaskConfig{
 				Workers:  5,
 				MaxRetry: 2,
-				// TaskPersistant: true,
+			},
+			Compress: TaskConfig{
+				Workers:  2,
+				MaxRetry: 1,
 			},
 			Decompress: TaskConfig{
 				Workers:  5,
@@ -196,6 +267,40 @@ func DefaultConfig() *Config {
 				MaxRetry: 2,
 				// TaskPersistant: true,
 			},
+			Rehash: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+			},
+			ArchiveVerify: TaskConfig{
+				Workers:  2,
+				MaxRetry: 1,
+			},
+			ExtCheck: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+			},
+			FsTransfer: TaskConfig{
+				Workers:  2,
+				MaxRetry: 3,
+				// resuming after a restart needs the offset persisted
+				TaskPersistant: true,
+			},
+			PathTrim: TaskConfig{
+				Workers:  1,
+				MaxRetry: 0,
+			},
+			Sync: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+			},
+			Dedup: TaskConfig{
+				Workers:  1,
+				MaxRetry: 0,
+			},
+			Snapshot: TaskConfig{
+				Workers:  1,
+				MaxRetry: 0,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -224,10 +329,22 @@ func DefaultConfig() *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		NFS: NFS{
+			Enable:  false,
+			Listen:  ":5249",
+			Exports: "/",
+			User:    "guest",
+		},
 		MCP: MCP{
 			Enable: false,
 			Port:   5248,
 		},
+		Tracing: Tracing{
+			Enable:      false,
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "alist",
+			SampleRatio: 1,
+		},
 		LastLaunchedVersion: "",
 	}
 }
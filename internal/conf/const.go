@@ -32,6 +32,12 @@ const (
 	ImageTypes               = "image_types"
 	ProxyTypes               = "proxy_types"
 	ProxyIgnoreHeaders       = "proxy_ignore_headers"
+	ProxyContentEncoding     = "proxy_content_encoding"
+	PreviewConvert           = "preview_convert"
+	PreviewConvertTypes      = "preview_convert_types"
+	PreviewConvertFormat     = "preview_convert_format"
+	PreviewConvertThreads    = "preview_convert_threads"
+	PreviewCacheSize         = "preview_cache_size"
 	AudioAutoplay            = "audio_autoplay"
 	VideoAutoplay            = "video_autoplay"
 	ThumbnailSize            = "thumbnail_size"
@@ -54,12 +60,46 @@ const (
 	DeviceEvictPolicy       = "device_evict_policy"
 	DeviceSessionTTL        = "device_session_ttl"
 	MetaNotFoundCacheExpire = "meta_not_found_cache_expire"
+	ContentRouteRules       = "content_route_rules"
+	OverwriteReadingPolicy  = "overwrite_reading_policy"
+	AuditLogEnabled         = "audit_log_enabled"
+	AuditLogRetention       = "audit_log_retention"
+	MetricsToken            = "metrics_token"
+	WebdavPathNormalization = "webdav_path_normalization"
+	UploadToFolderPolicy    = "upload_to_folder_policy"
+	EmptyPathPolicy         = "empty_path_policy"
+	RefreshAfterWrite       = "refresh_after_write"
+	RecursiveExclude        = "recursive_exclude"
+	TrashEnabled            = "trash_enabled"
+	TrashRetention          = "trash_retention"
+	RCEnabled               = "rc_enabled"
+
+	// storage probe
+	StorageProbeInterval = "storage_probe_interval"
+	StorageProbeOps      = "storage_probe_ops"
+	StorageProbeWindow   = "storage_probe_window"
+
+	// snapshot
+	SnapshotPaths     = "snapshot_paths"
+	SnapshotInterval  = "snapshot_interval"
+	SnapshotRetention = "snapshot_retention"
+	SnapshotBackupDir = "snapshot_backup_dir"
 
 	// index
 	SearchIndex     = "search_index"
 	AutoUpdateIndex = "auto_update_index"
 	IgnorePaths     = "ignore_paths"
 	MaxIndexDepth   = "max_index_depth"
+	// IndexOpsPerSecond limits the dirs listed per second by the indexer, 0 for no limit
+	IndexOpsPerSecond = "index_ops_per_second"
+	// IndexPeakHours holds the indexer within these hours, such as 9-18
+	IndexPeakHours = "index_peak_hours"
+	// IndexLatencyThreshold holds the indexer while the user requests are slower than this in milliseconds
+	IndexLatencyThreshold = "index_latency_threshold"
+	// IndexContent indexes the text of the txt, md, pdf and docx files besides their names
+	IndexContent = "index_content"
+	// IndexContentMaxSize is the size in MB over which the content of a file isn't indexed
+	IndexContentMaxSize = "index_content_max_size"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -91,6 +131,8 @@ const (
 	SSOLoginEnabled      = "sso_login_enabled"
 	SSOLoginPlatform     = "sso_login_platform"
 	SSOOIDCUsernameKey   = "sso_oidc_username_key"
+	SSOOIDCRoleClaim     = "sso_oidc_role_claim"
+	SSOOIDCRoleMapping   = "sso_oidc_role_mapping"
 	SSOOrganizationName  = "sso_organization_name"
 	SSOApplicationName   = "sso_application_name"
 	SSOEndpointName      = "sso_endpoint_name"
@@ -111,6 +153,9 @@ const (
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
 	LdapLoginTips         = "ldap_login_tips"
+	LdapAutoRegister      = "ldap_auto_register"
+	LdapGroupAttribute    = "ldap_group_attribute"
+	LdapGroupRoleMapping  = "ldap_group_role_mapping"
 
 	// s3
 	S3Buckets         = "s3_buckets"
@@ -145,17 +190,39 @@ const (
 	FRPSTCPSecretKey = "frp_stcp_secret_key"
 	FRPStatus        = "frp_status"
 
+	// notify
+	NotifyEvents           = "notify_events"
+	NotifyTelegramBotToken = "notify_telegram_bot_token"
+	NotifyTelegramChatID   = "notify_telegram_chat_id"
+	NotifySMTPHost         = "notify_smtp_host"
+	NotifySMTPPort         = "notify_smtp_port"
+	NotifySMTPUsername     = "notify_smtp_username"
+	NotifySMTPPassword     = "notify_smtp_password"
+	NotifySMTPFrom         = "notify_smtp_from"
+	NotifySMTPTo           = "notify_smtp_to"
+	NotifyWebhookURL       = "notify_webhook_url"
+	NotifyWebhookSecret    = "notify_webhook_secret"
+	NotifyGotifyURL        = "notify_gotify_url"
+	NotifyGotifyToken      = "notify_gotify_token"
+
 	// traffic
 	TaskOfflineDownloadThreadsNum         = "offline_download_task_threads_num"
 	TaskOfflineDownloadTransferThreadsNum = "offline_download_transfer_task_threads_num"
 	TaskUploadThreadsNum                  = "upload_task_threads_num"
 	TaskCopyThreadsNum                    = "copy_task_threads_num"
+	CopySplitThreshold                    = "copy_split_threshold"
+	CopySplitBatchSize                    = "copy_split_batch_size"
+	MovePreserveMtime                     = "move_preserve_mtime"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
 	StreamMaxServerUploadSpeed            = "max_server_upload_speed"
+	TaskWebhookURL                        = "task_webhook_url"
+	TaskWebhookEvents                     = "task_webhook_events"
+	TaskWebhookSecret                     = "task_webhook_secret"
+	TaskRetryPolicies                     = "task_retry_policies"
 )
 
 const (
@@ -172,4 +239,6 @@ const (
 const (
 	NoTaskKey       = "no_task"
 	SkipExistingKey = "skip_existing"
+	IncludeAllKey   = "include_all"
+	TaskPriorityKey = "task_priority"
 )
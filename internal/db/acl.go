@@ -0,0 +1,46 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetACLEntries() ([]model.ACLEntry, error) {
+	var entries []model.ACLEntry
+	if err := db.Order(columnName("id")).Find(&entries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl entries")
+	}
+	return entries, nil
+}
+
+func GetACLEntryById(id uint) (*model.ACLEntry, error) {
+	var e model.ACLEntry
+	if err := db.First(&e, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl entry")
+	}
+	return &e, nil
+}
+
+func GetACLEntry(path, subjectType string, subjectID uint) (*model.ACLEntry, error) {
+	var e model.ACLEntry
+	if err := db.Where("path = ? AND subject_type = ? AND subject_id = ?", path, subjectType, subjectID).First(&e).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl entry")
+	}
+	return &e, nil
+}
+
+func CreateACLEntry(e *model.ACLEntry) error {
+	return errors.WithStack(db.Create(e).Error)
+}
+
+func UpdateACLEntry(e *model.ACLEntry) error {
+	return errors.WithStack(db.Save(e).Error)
+}
+
+func DeleteACLEntryById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ACLEntry{}, id).Error)
+}
+
+func DeleteACLEntriesBySubject(subjectType string, subjectID uint) error {
+	return errors.WithStack(db.Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).Delete(&model.ACLEntry{}).Error)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAPITokensByUser(userID uint, pageIndex, pageSize int) (tokens []model.APIToken, count int64, err error) {
+	tokenDB := db.Model(&model.APIToken{}).Where("user_id = ?", userID)
+	if err := tokenDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's api tokens count")
+	}
+	if err := tokenDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's api tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetAPITokenByHash(hash string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find api token")
+	}
+	return &t, nil
+}
+
+func CreateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func TouchAPIToken(id uint, usedAt time.Time) error {
+	return errors.WithStack(db.Model(&model.APIToken{ID: id}).Update("last_used_at", usedAt).Error)
+}
+
+// DeleteAPIToken deletes the token of the user, it returns false if the user
+// has no such token
+func DeleteAPIToken(userID, id uint) (bool, error) {
+	res := db.Where("user_id = ?", userID).Delete(&model.APIToken{}, id)
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func DeleteAPITokensByUser(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.APIToken{}).Error)
+}
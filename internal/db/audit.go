@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAuditLog(log *model.AuditLog) error {
+	return errors.WithStack(db.Create(log).Error)
+}
+
+// GetAuditLogs returns the logs of the objects under q.Parent, newest first
+func GetAuditLogs(q model.AuditLogQuery) (logs []model.AuditLog, count int64, err error) {
+	tx := db.Model(&model.AuditLog{})
+	if q.UserID != 0 {
+		tx = tx.Where("user_id = ?", q.UserID)
+	}
+	if q.Parent != "" && q.Parent != "/" {
+		tx = tx.Where(fmt.Sprintf("(%s = ? OR %s LIKE ? ESCAPE '!')", columnName("path"), columnName("path")),
+			q.Parent, likeEscaper.Replace(q.Parent)+"/%")
+	}
+	if len(q.Actions) > 0 {
+		tx = tx.Where("action IN ?", q.Actions)
+	}
+	if q.From != nil {
+		tx = tx.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		tx = tx.Where("created_at <= ?", *q.To)
+	}
+	if q.Username != "" {
+		tx = tx.Where("username = ?", q.Username)
+	}
+	switch {
+	case q.FailedOnly:
+		tx = tx.Where("failed = ?", true)
+	case !q.WithFailed:
+		tx = tx.Where("failed = ?", false)
+	}
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err = tx.Order("created_at desc").Offset((q.Page - 1) * q.PerPage).Limit(q.PerPage).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+func DeleteAuditLogsBefore(t time.Time) error {
+	return errors.WithStack(db.Where("created_at < ?", t).Delete(&model.AuditLog{}).Error)
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateCopyJob(job *model.CopyJob) error {
+	return errors.WithStack(db.Create(job).Error)
+}
+
+func UpdateCopyJob(job *model.CopyJob) error {
+	return errors.WithStack(db.Save(job).Error)
+}
+
+func GetCopyJobById(id uint) (*model.CopyJob, error) {
+	var job model.CopyJob
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get copy job")
+	}
+	return &job, nil
+}
+
+func GetCopyJobs() ([]model.CopyJob, error) {
+	var jobs []model.CopyJob
+	if err := db.Order(columnName("id")).Find(&jobs).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return jobs, nil
+}
+
+func SetCopyJobLastRun(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.CopyJob{ID: id}).Update("last_run_at", t).Error)
+}
+
+// DeleteCopyJobById deletes the job and its runs
+func DeleteCopyJobById(id uint) error {
+	if err := db.Where("job_id = ?", id).Delete(&model.CopyJobRun{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.CopyJob{}, id).Error)
+}
+
+func CreateCopyJobRun(run *model.CopyJobRun) error {
+	return errors.WithStack(db.Create(run).Error)
+}
+
+// GetCopyJobRuns returns the runs of a job, newest first
+func GetCopyJobRuns(jobID uint, pageIndex, pageSize int) (runs []model.CopyJobRun, count int64, err error) {
+	tx := db.Model(&model.CopyJobRun{}).Where("job_id = ?", jobID)
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	if err = tx.Order("started_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	return runs, count, nil
+}
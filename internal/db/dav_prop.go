@@ -0,0 +1,88 @@
+package db
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func davPropPathHash(path string) string {
+	return utils.HashData(utils.SHA1, []byte(path))
+}
+
+// whereDavPropsUnder matches the props of path and the paths under it
+func whereDavPropsUnder(tx *gorm.DB, storageID uint, path string) *gorm.DB {
+	tx = tx.Where("storage_id = ?", storageID)
+	if path == "/" {
+		return tx
+	}
+	return tx.Where(tx.Session(&gorm.Session{NewDB: true}).
+		Where("path_hash = ?", davPropPathHash(path)).
+		Or("path LIKE ?", path+"/%"))
+}
+
+func GetDavProps(storageID uint, path string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	if err := db.Where("storage_id = ? AND path_hash = ?", storageID, davPropPathHash(path)).
+		Order("id").Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dav props")
+	}
+	return props, nil
+}
+
+// ReplaceDavProps replaces the props of path by props
+func ReplaceDavProps(storageID uint, path string, props []model.DavProp) error {
+	pathHash := davPropPathHash(path)
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("storage_id = ? AND path_hash = ?", storageID, pathHash).
+			Delete(&model.DavProp{}).Error; err != nil {
+			return err
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].StorageID, props[i].Path, props[i].PathHash = storageID, path, pathHash
+		}
+		return tx.Create(&props).Error
+	}))
+}
+
+// DeleteDavProps deletes the props of path and the paths under it
+func DeleteDavProps(storageID uint, path string) error {
+	return errors.WithStack(whereDavPropsUnder(db, storageID, path).Delete(&model.DavProp{}).Error)
+}
+
+// CopyDavProps copies the props of src and the paths under it to dst, which
+// loses its own, then removes the ones of src if move
+func CopyDavProps(srcStorageID uint, src string, dstStorageID uint, dst string, move bool) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var props []model.DavProp
+		if err := whereDavPropsUnder(tx, srcStorageID, src).Find(&props).Error; err != nil {
+			return err
+		}
+		if err := whereDavPropsUnder(tx, dstStorageID, dst).Delete(&model.DavProp{}).Error; err != nil {
+			return err
+		}
+		if move {
+			if err := whereDavPropsUnder(tx, srcStorageID, src).Delete(&model.DavProp{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].StorageID = dstStorageID
+			props[i].Path = stdpath.Join(dst, strings.TrimPrefix(props[i].Path, src))
+			props[i].PathHash = davPropPathHash(props[i].Path)
+		}
+		return tx.CreateInBatches(&props, 100).Error
+	}))
+}
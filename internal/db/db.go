@@ -1,6 +1,8 @@
 package db
 
 import (
+	"context"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -12,7 +14,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share), new(model.Snapshot), new(model.AuditLog), new(model.FileHash), new(model.TrashItem), new(model.FileVersion), new(model.TusUpload), new(model.CopyJob), new(model.CopyJobRun), new(model.SyncPair), new(model.SyncPairState), new(model.SyncConflict), new(model.DavProp), new(model.APIToken), new(model.ACLEntry), new(model.ShareAccess), new(model.Webhook), new(model.DriverPlugin))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -32,6 +34,15 @@ func GetDb() *gorm.DB {
 	return db
 }
 
+// Ping checks the connection to the database
+func Ping(ctx context.Context) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func Close() {
 	log.Info("closing db")
 	sqlDB, err := db.DB()
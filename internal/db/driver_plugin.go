@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetDriverPlugins() ([]model.DriverPlugin, error) {
+	var plugins []model.DriverPlugin
+	if err := db.Order(columnName("id")).Find(&plugins).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get driver plugins")
+	}
+	return plugins, nil
+}
+
+func GetDriverPluginById(id uint) (*model.DriverPlugin, error) {
+	var p model.DriverPlugin
+	if err := db.First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get driver plugin")
+	}
+	return &p, nil
+}
+
+func CreateDriverPlugin(p *model.DriverPlugin) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func UpdateDriverPlugin(p *model.DriverPlugin) error {
+	return errors.WithStack(db.Save(p).Error)
+}
+
+func DeleteDriverPluginById(id uint) error {
+	return errors.WithStack(db.Delete(&model.DriverPlugin{}, id).Error)
+}
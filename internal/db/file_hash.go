@@ -0,0 +1,25 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func GetFileHash(path string) (*model.FileHash, error) {
+	var h model.FileHash
+	if err := db.Where("path_hash = ?", utils.HashData(utils.SHA1, []byte(path))).First(&h).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file hash")
+	}
+	return &h, nil
+}
+
+// SaveFileHash creates or replaces the record of h.Path
+func SaveFileHash(h *model.FileHash) error {
+	h.PathHash = utils.HashData(utils.SHA1, []byte(h.Path))
+	var old model.FileHash
+	if err := db.Where("path_hash = ?", h.PathHash).First(&old).Error; err == nil {
+		h.ID = old.ID
+	}
+	return errors.WithStack(db.Save(h).Error)
+}
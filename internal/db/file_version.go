@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func CreateFileVersion(v *model.FileVersion) error {
+	v.PathHash = utils.HashData(utils.SHA1, []byte(v.Path))
+	return errors.WithStack(db.Create(v).Error)
+}
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	var v model.FileVersion
+	if err := db.First(&v, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file version")
+	}
+	return &v, nil
+}
+
+// GetFileVersions returns the versions kept of the file at path, newest first
+func GetFileVersions(path string) ([]model.FileVersion, error) {
+	var versions []model.FileVersion
+	if err := db.Where("path_hash = ?", utils.HashData(utils.SHA1, []byte(path))).
+		Order("created_at desc").Order("id desc").Find(&versions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find file versions")
+	}
+	return versions, nil
+}
+
+func DeleteFileVersionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.FileVersion{}, id).Error)
+}
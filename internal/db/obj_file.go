@@ -29,3 +29,22 @@ func CreateObjFile(obj model.ObjFile) error {
 	}
 	return nil
 }
+
+// GetObjFilesByPath Get all files of the path and name regardless of the user
+func GetObjFilesByPath(path, name string) ([]model.ObjFile, error) {
+	var objFiles []model.ObjFile
+	if err := db.Where("path = ?", path).Where("name = ?", name).Find(&objFiles).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return objFiles, nil
+}
+
+func UpdateObjFileHash(obj model.ObjFile) error {
+	return errors.WithStack(db.Model(&model.ObjFile{}).
+		Where("path = ?", obj.Path).Where("name = ?", obj.Name).Where("user_id = ?", obj.UserId).
+		Updates(map[string]any{
+			"size":          obj.Size,
+			"modified":      obj.Modified,
+			"hash_info_str": obj.HashInfoStr,
+		}).Error)
+}
@@ -35,7 +35,7 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
+	dir, name := stdpath.Dir(path), stdpath.Base(path)
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
 		dir, name).Delete(&model.SearchNode{}).Error
@@ -54,22 +54,152 @@ func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
 	return nodes, nil
 }
 
+// searchFilters applies the filters of req but the scope
+func searchFilters(searchDB *gorm.DB, req model.SearchReq) *gorm.DB {
+	if req.FilesOnly() {
+		searchDB = searchDB.Where("is_dir = ?", false)
+	}
+	if req.MinSize > 0 {
+		searchDB = searchDB.Where("size >= ?", req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		searchDB = searchDB.Where("size <= ?", req.MaxSize)
+	}
+	if req.ModifiedAfter != nil {
+		searchDB = searchDB.Where("modified >= ?", *req.ModifiedAfter)
+	}
+	if req.ModifiedBefore != nil {
+		searchDB = searchDB.Where("modified <= ?", *req.ModifiedBefore)
+	}
+	if len(req.Exts) > 0 {
+		extsClause := db.Where("LOWER(name) LIKE ?", "%."+req.Exts[0])
+		for _, ext := range req.Exts[1:] {
+			extsClause = extsClause.Or("LOWER(name) LIKE ?", "%."+ext)
+		}
+		searchDB = searchDB.Where(extsClause)
+	}
+	return searchDB
+}
+
+// parentClause matches the nodes in dir, or under it if sub
+func parentClause(dir string, sub bool) (string, []any) {
+	parent := columnName("parent")
+	if !sub {
+		return parent + " = ?", []any{dir}
+	}
+	if dir == "/" {
+		return "1 = 1", nil
+	}
+	return fmt.Sprintf("(%s = ? OR %s LIKE ?)", parent, parent), []any{dir, dir + "/%"}
+}
+
+var likeReplacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "*", "%")
+
+// likeEscaper escapes a literal for LIKE ... ESCAPE '!'
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// searchRestriction leaves out what the user can't see, the hide rules which
+// aren't globs are left to the caller. LIKE may ignore the case, then a rule
+// hides the names of other cases too.
+func searchRestriction(searchDB *gorm.DB, r *model.SearchRestriction) *gorm.DB {
+	if r == nil {
+		return searchDB
+	}
+	if r.Roots != nil {
+		conds, args := []string{"1 = 0"}, []any{}
+		for _, root := range r.Roots {
+			cond, arg := parentClause(root, true)
+			conds = append(conds, cond, fmt.Sprintf("(%s = ? AND %s = ?)", columnName("parent"), columnName("name")))
+			args = append(append(args, arg...), stdpath.Dir(root), stdpath.Base(root))
+		}
+		searchDB = searchDB.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+	for _, hide := range r.Hides {
+		if hide.Glob == "" {
+			continue
+		}
+		cond, args := parentClause(hide.Dir, hide.Sub)
+		conds := []string{cond, columnName("name") + " LIKE ? ESCAPE '!'"}
+		args = append(args, likeReplacer.Replace(hide.Glob))
+		for _, except := range hide.Except {
+			cond, arg := parentClause(except, true)
+			conds = append(conds, "NOT "+cond)
+			args = append(args, arg...)
+		}
+		searchDB = searchDB.Where("NOT ("+strings.Join(conds, " AND ")+")", args...)
+	}
+	return searchDB
+}
+
+// searchOrder orders the results of req and seeks after its cursor, the
+// relevance isn't computed so it's ordered by name
+func searchOrder(searchDB *gorm.DB, req model.SearchReq) *gorm.DB {
+	var (
+		cols []string
+		vals []any
+	)
+	after := req.After
+	if after == nil {
+		after = &model.SearchCursor{}
+	}
+	switch req.OrderBy {
+	case model.SearchOrderSize:
+		cols, vals = []string{"size", "parent", "name"}, []any{after.Size, after.Parent, after.Name}
+	case model.SearchOrderModified:
+		cols, vals = []string{"modified", "parent", "name"}, []any{after.Modified, after.Parent, after.Name}
+	default:
+		cols, vals = []string{"name", "parent"}, []any{after.Name, after.Parent}
+	}
+	dir, cmp := "asc", ">"
+	if req.Desc() {
+		dir, cmp = "desc", "<"
+	}
+	orders := make([]string, len(cols))
+	for i, col := range cols {
+		cols[i] = columnName(col)
+		orders[i] = cols[i] + " " + dir
+	}
+	searchDB = searchDB.Order(strings.Join(orders, ", "))
+	if !req.Seek() {
+		return searchDB
+	}
+	// (c1, c2, c3) > (v1, v2, v3) spelled out, as not all the dbs compare rows
+	var (
+		conds []string
+		args  []any
+	)
+	for i := range cols {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, cols[j]+" = ?")
+			args = append(args, vals[j])
+		}
+		parts = append(parts, cols[i]+" "+cmp+" ?")
+		args = append(args, vals[i])
+		conds = append(conds, "("+strings.Join(parts, " AND ")+")")
+	}
+	return searchDB.Where("("+strings.Join(conds, " OR ")+")", args...)
+}
+
 func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
 	var searchDB *gorm.DB
 	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
 		keywordsClause := db.Where("1 = 1")
 		for _, keyword := range strings.Fields(req.Keywords) {
-			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
+			like := fmt.Sprintf("%%%s%%", keyword)
+			keywordsClause = keywordsClause.Where(db.Where("name LIKE ?", like).Or("content LIKE ?", like))
 		}
 		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
 	} else {
 		switch conf.Conf.Database.Type {
 		case "mysql":
 			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
+				Where(db.Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'").
+					Or("MATCH (content) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'"))
 		case "postgres":
+			query := strings.Join(strings.Fields(req.Keywords), " & ")
 			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
+				Where(db.Where("to_tsvector(name) @@ to_tsquery(?)", query).Or("to_tsvector(content) @@ to_tsquery(?)", query))
 		}
 	}
 
@@ -77,13 +207,21 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = searchFilters(searchDB, req)
+	searchDB = searchRestriction(searchDB, req.Restrict)
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	offset := req.Offset()
+	searchDB = searchOrder(searchDB, req)
+	if req.Seek() {
+		offset = 0
+	}
+	// the content is only matched, it's not worth loading
+	if err := searchDB.Omit("content").Offset(offset).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
@@ -67,3 +67,7 @@ func ListSessions() ([]model.Session, error) {
 func MarkInactive(sessionID string) error {
 	return errors.WithStack(db.Model(&model.Session{}).Where("device_key = ?", sessionID).Update("status", model.SessionInactive).Error)
 }
+
+func MarkUserSessionsInactive(userID uint) error {
+	return errors.WithStack(db.Model(&model.Session{}).Where("user_id = ?", userID).Update("status", model.SessionInactive).Error)
+}
@@ -58,8 +58,22 @@ func GetSharesByCreator(creatorID uint, pageIndex, pageSize int) (shares []model
 	return
 }
 
+func GetShares(pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	tx := db.Model(&model.Share{})
+	err = tx.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = tx.Order("created_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error
+	return
+}
+
 func DeleteShareByShareID(creatorID uint, shareID string) error {
-	return db.Where("creator_id = ? AND share_id = ?", creatorID, shareID).Delete(&model.Share{}).Error
+	res := db.Where("creator_id = ? AND share_id = ?", creatorID, shareID).Delete(&model.Share{})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return DeleteShareAccesses(shareID)
 }
 
 func DisableShareByShareID(creatorID uint, shareID string) error {
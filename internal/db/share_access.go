@@ -0,0 +1,63 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateShareAccess(a *model.ShareAccess) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+// GetShareAccesses returns the accesses of the share, newest first
+func GetShareAccesses(shareID string, pageIndex, pageSize int) (accesses []model.ShareAccess, count int64, err error) {
+	tx := db.Model(&model.ShareAccess{}).Where("share_id = ?", shareID)
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get share accesses count")
+	}
+	if err = tx.Order("created_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&accesses).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find share accesses")
+	}
+	return accesses, count, nil
+}
+
+func GetShareStats(shareID string) (*model.ShareStats, error) {
+	var kinds []struct {
+		Kind  string
+		Count int64
+		Bytes int64
+	}
+	if err := db.Model(&model.ShareAccess{}).Select("kind, count(*) as count, sum(bytes) as bytes").
+		Where("share_id = ?", shareID).Group("kind").Scan(&kinds).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share stats")
+	}
+	stats := &model.ShareStats{}
+	for _, k := range kinds {
+		switch k.Kind {
+		case model.ShareAccessView:
+			stats.Views = k.Count
+		case model.ShareAccessDownload:
+			stats.Downloads = k.Count
+		case model.ShareAccessPreview:
+			stats.Previews = k.Count
+		}
+		stats.BytesServed += k.Bytes
+	}
+	if err := db.Model(&model.ShareAccess{}).Where("share_id = ?", shareID).
+		Distinct("ip").Count(&stats.UniqueIPs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share unique ips")
+	}
+	var last model.ShareAccess
+	if err := db.Where("share_id = ?", shareID).Order("created_at desc").Limit(1).Find(&last).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share last access")
+	}
+	if last.ID != 0 {
+		t := last.CreatedAt
+		stats.LastAccessAt = &t
+	}
+	return stats, nil
+}
+
+func DeleteShareAccesses(shareID string) error {
+	return errors.WithStack(db.Where("share_id = ?", shareID).Delete(&model.ShareAccess{}).Error)
+}
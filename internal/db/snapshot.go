@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSnapshot(snapshot *model.Snapshot) error {
+	return errors.WithStack(db.Create(snapshot).Error)
+}
+
+func GetSnapshotById(id uint) (*model.Snapshot, error) {
+	var snapshot model.Snapshot
+	if err := db.First(&snapshot, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get snapshot")
+	}
+	return &snapshot, nil
+}
+
+// GetSnapshots returns the snapshots without entries, newest first
+func GetSnapshots(path string) ([]model.Snapshot, error) {
+	var snapshots []model.Snapshot
+	tx := db.Omit("entries").Order("created_at desc")
+	if path != "" {
+		tx = tx.Where("path = ?", path)
+	}
+	if err := tx.Find(&snapshots).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return snapshots, nil
+}
+
+func DeleteSnapshotById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Snapshot{}, id).Error)
+}
+
+// DeleteAutoSnapshotsExceptLatest keeps the latest keep automatic snapshots of path
+func DeleteAutoSnapshotsExceptLatest(path string, keep int) error {
+	var ids []uint
+	if err := db.Model(&model.Snapshot{}).Where("path = ? AND auto = ?", path, true).
+		Order("created_at desc").Pluck("id", &ids).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if len(ids) <= keep {
+		return nil
+	}
+	return errors.WithStack(db.Delete(&model.Snapshot{}, ids[keep:]).Error)
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateSyncPair(pair *model.SyncPair) error {
+	return errors.WithStack(db.Create(pair).Error)
+}
+
+func UpdateSyncPair(pair *model.SyncPair) error {
+	return errors.WithStack(db.Save(pair).Error)
+}
+
+func GetSyncPairById(id uint) (*model.SyncPair, error) {
+	var pair model.SyncPair
+	if err := db.First(&pair, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync pair")
+	}
+	return &pair, nil
+}
+
+func GetSyncPairs() ([]model.SyncPair, error) {
+	var pairs []model.SyncPair
+	if err := db.Order(columnName("id")).Find(&pairs).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return pairs, nil
+}
+
+func SetSyncPairLastRun(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.SyncPair{ID: id}).Update("last_run_at", t).Error)
+}
+
+// DeleteSyncPairById deletes the pair with its states and conflicts
+func DeleteSyncPairById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pair_id = ?", id).Delete(&model.SyncPairState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("pair_id = ?", id).Delete(&model.SyncConflict{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.SyncPair{}, id).Error
+	}))
+}
+
+func GetSyncPairStates(pairID uint) ([]model.SyncPairState, error) {
+	var states []model.SyncPairState
+	if err := db.Where("pair_id = ?", pairID).Find(&states).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return states, nil
+}
+
+// ReplaceSyncPairStates replaces the states of the pair by those of a run
+func ReplaceSyncPairStates(pairID uint, states []model.SyncPairState) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pair_id = ?", pairID).Delete(&model.SyncPairState{}).Error; err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(states, 100).Error
+	}))
+}
+
+// SetSyncPairStates replaces the states of the pair having the paths of states
+func SetSyncPairStates(pairID uint, states []model.SyncPairState) error {
+	paths := make([]string, 0, len(states))
+	for _, st := range states {
+		paths = append(paths, st.Path)
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pair_id = ? AND path IN ?", pairID, paths).Delete(&model.SyncPairState{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&states).Error
+	}))
+}
+
+func CreateSyncConflict(conflict *model.SyncConflict) error {
+	return errors.WithStack(db.Create(conflict).Error)
+}
+
+func GetSyncConflicts(pairID uint) ([]model.SyncConflict, error) {
+	var conflicts []model.SyncConflict
+	if err := db.Where("pair_id = ?", pairID).Order(columnName("id")).Find(&conflicts).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return conflicts, nil
+}
+
+func GetSyncConflictById(id uint) (*model.SyncConflict, error) {
+	var conflict model.SyncConflict
+	if err := db.First(&conflict, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync conflict")
+	}
+	return &conflict, nil
+}
+
+func DeleteSyncConflictById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SyncConflict{}, id).Error)
+}
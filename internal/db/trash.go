@@ -0,0 +1,57 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+// GetTrashItemByOriginalPath returns the item last trashed from path
+func GetTrashItemByOriginalPath(path string) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.Where("original_path = ?", path).Order("deleted_at desc").First(&item).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+// GetTrashItems returns the items trashed by the user, or by anyone if userID
+// is 0, newest first
+func GetTrashItems(userID uint, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	tx := db.Model(&model.TrashItem{})
+	if userID != 0 {
+		tx = tx.Where("user_id = ?", userID)
+	}
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err = tx.Order("deleted_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+func GetTrashItemsBefore(t time.Time) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := db.Where("deleted_at < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTusUpload(u *model.TusUpload) error {
+	return errors.WithStack(db.Create(u).Error)
+}
+
+func GetTusUploadById(id string) (*model.TusUpload, error) {
+	var u model.TusUpload
+	if err := db.Where("id = ?", id).First(&u).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get tus upload")
+	}
+	return &u, nil
+}
+
+func UpdateTusUploadOffset(u *model.TusUpload) error {
+	return errors.WithStack(db.Model(u).Update("upload_offset", u.Offset).Error)
+}
+
+// GetTusUploadsBefore returns the uploads which received nothing since t
+func GetTusUploadsBefore(t time.Time) ([]model.TusUpload, error) {
+	var uploads []model.TusUpload
+	if err := db.Where("updated_at < ?", t).Find(&uploads).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return uploads, nil
+}
+
+func DeleteTusUploadById(id string) error {
+	return errors.WithStack(db.Where("id = ?", id).Delete(&model.TusUpload{}).Error)
+}
@@ -95,6 +95,28 @@ func DeleteUserById(id uint) error {
 	return errors.WithStack(db.Delete(&model.User{}, id).Error)
 }
 
+func AddUserUploadUsed(id uint, size int64) error {
+	return errors.WithStack(db.Model(&model.User{ID: id}).Update("upload_used", gorm.Expr("upload_used + ?", size)).Error)
+}
+
+func ResetUserUploadUsed(id uint) error {
+	return errors.WithStack(db.Model(&model.User{ID: id}).Update("upload_used", 0).Error)
+}
+
+func GetUsersWithHomeQuota() ([]model.User, error) {
+	var users []model.User
+	if err := db.Where("home_quota > 0").Find(&users).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return users, nil
+}
+
+// AddUserHomeUsed adds delta to the home usage of a user, it never goes below 0
+func AddUserHomeUsed(id uint, delta int64) error {
+	return errors.WithStack(db.Model(&model.User{ID: id}).
+		Update("home_used", gorm.Expr("CASE WHEN home_used + ? < 0 THEN 0 ELSE home_used + ? END", delta, delta)).Error)
+}
+
 func UpdateAuthn(userID uint, authn string) error {
 	return db.Model(&model.User{ID: userID}).Update("authn", authn).Error
 }
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhooks() ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	if err := db.Order(columnName("id")).Find(&hooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhooks")
+	}
+	return hooks, nil
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var h model.Webhook
+	if err := db.First(&h, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &h, nil
+}
+
+func CreateWebhook(h *model.Webhook) error {
+	return errors.WithStack(db.Create(h).Error)
+}
+
+func UpdateWebhook(h *model.Webhook) error {
+	return errors.WithStack(db.Save(h).Error)
+}
+
+func DeleteWebhookById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+// UpdateWebhookDelivery records the result of the last delivery only, not to
+// overwrite a concurrent edit of the webhook
+func UpdateWebhookDelivery(id uint, status int, errMsg string, sentAt time.Time) error {
+	return errors.WithStack(db.Model(&model.Webhook{}).Where("id = ?", id).Updates(map[string]any{
+		"last_status":  status,
+		"last_error":   errMsg,
+		"last_sent_at": sentAt,
+	}).Error)
+}
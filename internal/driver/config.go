@@ -13,6 +13,9 @@ type Config struct {
 	Alert             string `json:"alert"` //info,success,warning,danger
 	NoOverwriteUpload bool   `json:"-"`     // whether to support overwrite upload
 	ProxyRangeOption  bool   `json:"-"`
+	// DefaultConcurrency is the default max_concurrency of the storages, for the
+	// providers banning accounts which send too many requests at once
+	DefaultConcurrency int `json:"-"`
 }
 
 func (c Config) MustProxy() bool {
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"io"
 
 	"github.com/alist-org/alist/v3/internal/model"
 )
@@ -110,6 +111,23 @@ type PutURL interface {
 	PutURL(ctx context.Context, dstDir model.Obj, name, url string) error
 }
 
+type Append interface {
+	// Append writes reader to the end of the file name under dstDir, creating it if it doesn't exist.
+	// It is used to resume interrupted transfers, so what was written before an error must be kept.
+	Append(ctx context.Context, dstDir model.Obj, name string, reader io.Reader) error
+}
+
+type HardLink interface {
+	// HardLink replaces the file dstObj by a hard link to the file srcObj, so they share their content.
+	// It must not leave dstObj removed if linking fails.
+	HardLink(ctx context.Context, srcObj, dstObj model.Obj) error
+}
+
+type Quota interface {
+	// GetDiskUsage returns the space of the storage.
+	GetDiskUsage(ctx context.Context) (*model.DiskUsage, error)
+}
+
 //type WriteResult interface {
 //	MkdirResult
 //	MoveResult
@@ -5,4 +5,6 @@ import "errors"
 var (
 	PermissionDenied = errors.New("permission denied")
 	InvalidName      = errors.New("invalid file name")
+	OperationTimeout = errors.New("storage operation timeout")
+	FileInUse        = errors.New("file is being read, try again later")
 )
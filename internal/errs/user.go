@@ -3,8 +3,12 @@ package errs
 import "errors"
 
 var (
-	EmptyUsername      = errors.New("username is empty")
-	EmptyPassword      = errors.New("password is empty")
-	WrongPassword      = errors.New("password is incorrect")
-	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	EmptyUsername       = errors.New("username is empty")
+	EmptyPassword       = errors.New("password is empty")
+	WrongPassword       = errors.New("password is incorrect")
+	DeleteAdminOrGuest  = errors.New("cannot delete admin or guest")
+	UploadQuotaExceeded = errors.New("upload quota exceeded")
+	HomeQuotaExceeded   = errors.New("home quota exceeded")
+	InvalidAPIToken     = errors.New("api token is invalid")
+	APITokenExpired     = errors.New("api token has expired")
 )
@@ -0,0 +1,46 @@
+package event
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// the events published while the subscribers are behind by that many are
+// dropped, not to slow the file operations down
+const queueSize = 1024
+
+type Subscriber = func(e model.FileEvent)
+
+var (
+	subscribers []Subscriber
+	queue       = make(chan model.FileEvent, queueSize)
+	startOnce   sync.Once
+)
+
+// Subscribe registers fn to be called with every event, in the order they
+// are published. It's meant to be called on init.
+func Subscribe(fn Subscriber) {
+	subscribers = append(subscribers, fn)
+}
+
+// Publish queues e for the subscribers, without waiting for them
+func Publish(e model.FileEvent) {
+	startOnce.Do(func() {
+		go dispatch()
+	})
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("event queue is full, dropped the %s event of %s", e.Type, e.Path)
+	}
+}
+
+func dispatch() {
+	for e := range queue {
+		for _, fn := range subscribers {
+			fn(e)
+		}
+	}
+}
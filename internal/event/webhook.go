@@ -0,0 +1,114 @@
+package event
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const webhookAttempts = 3
+
+func init() {
+	Subscribe(notifyWebhooks)
+}
+
+func notifyWebhooks(e model.FileEvent) {
+	hooks, err := op.GetWebhooks()
+	if err != nil {
+		log.Errorf("failed get webhooks: %+v", err)
+		return
+	}
+	for _, h := range hooks {
+		if h.Disabled || !WebhookMatches(h, e) {
+			continue
+		}
+		go func(h model.Webhook) {
+			var status int
+			var err error
+			for i := 0; i < webhookAttempts; i++ {
+				if i > 0 {
+					time.Sleep(time.Duration(i) * time.Second)
+				}
+				if status, err = SendWebhook(h, e); err == nil {
+					break
+				}
+			}
+			errMsg := ""
+			if err != nil {
+				errMsg = err.Error()
+				log.Warnf("failed post the %s event of %s to webhook %s: %+v", e.Type, e.Path, h.URL, err)
+			}
+			if err := op.RecordWebhookDelivery(h.ID, status, errMsg); err != nil {
+				log.Errorf("failed record the delivery of webhook %d: %+v", h.ID, err)
+			}
+		}(h)
+	}
+}
+
+// WebhookMatches reports whether e is to be sent to h, by its type and by its
+// path or former path
+func WebhookMatches(h model.Webhook, e model.FileEvent) bool {
+	if events := strings.TrimSpace(h.Events); events != "" {
+		if !utils.SliceContains(utils.MustSliceConvert(strings.Split(events, ","), strings.TrimSpace), e.Type) {
+			return false
+		}
+	}
+	filters := strings.TrimSpace(h.PathFilters)
+	if filters == "" {
+		return true
+	}
+	for _, filter := range strings.Split(filters, "\n") {
+		if filter = strings.TrimSpace(filter); filter == "" {
+			continue
+		}
+		if pathMatches(filter, e.Path) || (e.SrcPath != "" && pathMatches(filter, e.SrcPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathMatches matches p against a glob filter, or a folder p has to be under
+func pathMatches(filter, p string) bool {
+	if strings.ContainsAny(filter, "*?[") {
+		ok, _ := stdpath.Match(filter, p)
+		return ok
+	}
+	return utils.IsSubPath(filter, p)
+}
+
+// SendWebhook posts e to h once, returning the status code of the response
+func SendWebhook(h model.Webhook, e model.FileEvent) (int, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	req := base.RestyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Alist-Event", e.Type).
+		SetBody(body)
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.SetHeader("X-Alist-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := req.Post(h.URL)
+	if err != nil {
+		return 0, err
+	}
+	if res.IsError() {
+		return res.StatusCode(), errors.Errorf("webhook responded %s", res.Status())
+	}
+	return res.StatusCode(), nil
+}
@@ -125,6 +125,7 @@ func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadT
 		DstDirPath:   t.DstDirPath,
 		dstStorage:   t.dstStorage,
 		DstStorageMp: t.DstStorageMp,
+		SkipExisting: t.SkipExisting,
 	}
 	return uploadTask, nil
 }
@@ -140,6 +141,7 @@ type ArchiveContentUploadTask struct {
 	DstDirPath   string
 	dstStorage   driver.Driver
 	DstStorageMp string
+	SkipExisting bool
 	finalized    bool
 }
 
@@ -207,6 +209,7 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 				DstDirPath:   nextDstPath,
 				dstStorage:   t.dstStorage,
 				DstStorageMp: t.DstStorageMp,
+				SkipExisting: t.SkipExisting,
 			})
 			if err != nil {
 				es = stderrors.Join(es, err)
@@ -216,6 +219,13 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 			return es
 		}
 	} else {
+		if t.SkipExisting {
+			if _, err := op.Get(t.Ctx(), t.dstStorage, stdpath.Join(t.DstDirPath, t.ObjName)); err == nil {
+				t.status = "skipped, exists in the destination"
+				t.deleteSrcFile()
+				return nil
+			}
+		}
 		t.SetTotalBytes(info.Size())
 		file, err := os.Open(t.FilePath)
 		if err != nil {
@@ -345,7 +355,8 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	// the drivers decompressing by themselves overwrite the existing files
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && !args.SkipExisting {
 		err = op.ArchiveDecompress(ctx, srcStorage, srcObjActualPath, dstDirActualPath, args, lazyCache...)
 		if !errors.Is(err, errs.NotImplement) {
 			return nil, err
@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/archive/tool"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// isArchiveName reports whether an archive tool reads the file, by its name
+func isArchiveName(name string) bool {
+	if _, ext, found := strings.Cut(name, "."); found {
+		if _, _, err := tool.GetArchiveTool("." + ext); err == nil {
+			return true
+		}
+	}
+	_, _, err := tool.GetArchiveTool(stdpath.Ext(name))
+	return err == nil
+}
+
+// SplitArchivePath finds the archive file path is in, so that /a/b.zip/c/d is
+// /c/d inside /a/b.zip and /a/b.zip is / inside itself. It returns false if
+// neither path nor any of its parents is an archive file.
+func SplitArchivePath(ctx context.Context, path string) (string, string, bool) {
+	path = utils.FixAndCleanPath(path)
+	for p := path; p != "/"; p = stdpath.Dir(p) {
+		if !isArchiveName(stdpath.Base(p)) {
+			continue
+		}
+		storage, actualPath, err := op.GetStorageAndActualPath(p)
+		if err != nil {
+			return "", "", false
+		}
+		obj, err := op.Get(ctx, storage, actualPath)
+		if err != nil || obj.IsDir() {
+			continue
+		}
+		innerPath := strings.TrimPrefix(path, p)
+		if innerPath == "" {
+			innerPath = "/"
+		}
+		return p, innerPath, true
+	}
+	return "", "", false
+}
+
+func archiveInnerListArgs(innerPath, password string, refresh bool) model.ArchiveListArgs {
+	return model.ArchiveListArgs{
+		ArchiveInnerArgs: model.ArchiveInnerArgs{
+			ArchiveArgs: model.ArchiveArgs{Password: password},
+			InnerPath:   innerPath,
+		},
+		Refresh: refresh,
+	}
+}
+
+// archiveGet gets an entry of an archive as if it were a plain obj
+func archiveGet(ctx context.Context, archivePath, innerPath, password string) (model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(archivePath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	_, obj, err := op.ArchiveGet(ctx, storage, actualPath, archiveInnerListArgs(innerPath, password, false))
+	return obj, err
+}
@@ -0,0 +1,240 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// ArchiveVerifySyncSize is the size up to which archives are verified within
+// the request, larger archives are verified by an ArchiveVerifyTask
+const ArchiveVerifySyncSize = 64 << 20
+
+type ArchiveVerifyResult struct {
+	Entries int  `json:"entries"`
+	Corrupt bool `json:"corrupt"`
+	// CorruptEntry is the first entry failing verification, it is empty
+	// when the archive itself can't be read, e.g. it is truncated
+	CorruptEntry string `json:"corrupt_entry,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	// Skipped lists the encrypted entries, which can't be verified without the password
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// ArchiveVerifyTask streams an archive through and checks the checksums of its
+// entries without extracting them. Only zip and gzip-compressed tar are supported.
+type ArchiveVerifyTask struct {
+	task.TaskExtension
+	ArchiveVerifyResult
+	Status string `json:"-"`
+	Path   string `json:"path"`
+}
+
+var ArchiveVerifyTaskManager *tache.Manager[*ArchiveVerifyTask]
+
+var _ task.TaskExtensionInfo = (*ArchiveVerifyTask)(nil)
+
+func (t *ArchiveVerifyTask) GetName() string {
+	return fmt.Sprintf("verify archive [%s]", t.Path)
+}
+
+func (t *ArchiveVerifyTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ArchiveVerifyTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Status = "verifying"
+	res, err := VerifyArchive(t.Ctx(), t.Path, t.SetProgress)
+	if err != nil {
+		return err
+	}
+	t.ArchiveVerifyResult = *res
+	if res.Corrupt {
+		t.Status = "corrupt: " + res.Reason
+	} else {
+		t.Status = fmt.Sprintf("ok, %d entries", res.Entries)
+	}
+	return nil
+}
+
+func IsVerifiableArchive(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// VerifyArchive verifies the archive at path. A corrupt archive is reported by
+// the result, errors are only returned when the archive can't be read from the storage.
+func VerifyArchive(ctx context.Context, path string, up model.UpdateProgress) (*ArchiveVerifyResult, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	l, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	if !IsVerifiableArchive(obj.GetName()) {
+		if l.MFile != nil {
+			_ = l.MFile.Close()
+		}
+		if l.RangeReadCloser != nil {
+			_ = l.RangeReadCloser.Close()
+		}
+		return nil, errors.Errorf("[%s] is neither a zip nor a tar.gz archive", obj.GetName())
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: obj}, l)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	if strings.HasSuffix(strings.ToLower(obj.GetName()), ".zip") {
+		return verifyZip(ctx, ss, up)
+	}
+	return verifyTarGz(ctx, ss, up)
+}
+
+// sourceReader keeps the errors of the storage apart from the corruption
+// found while decoding what it returned
+type sourceReader struct {
+	r   io.Reader
+	ra  io.ReaderAt
+	n   int64
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	s.record(err)
+	return n, err
+}
+
+func (s *sourceReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := s.ra.ReadAt(p, off)
+	s.record(err)
+	return n, err
+}
+
+func (s *sourceReader) record(err error) {
+	if err != nil && err != io.EOF && s.err == nil {
+		s.err = err
+	}
+}
+
+func verifyZip(ctx context.Context, ss *stream.SeekableStream, up model.UpdateProgress) (*ArchiveVerifyResult, error) {
+	ra, err := stream.NewReadAtSeeker(ss, 0)
+	if err != nil {
+		return nil, err
+	}
+	src := &sourceReader{ra: ra}
+	res := &ArchiveVerifyResult{}
+	zr, err := zip.NewReader(src, ss.GetSize())
+	if err != nil {
+		if src.err != nil {
+			return nil, src.err
+		}
+		res.Corrupt, res.Reason = true, err.Error()
+		return res, nil
+	}
+	var total, done uint64
+	for _, f := range zr.File {
+		total += f.CompressedSize64
+	}
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Entries++
+		if f.Flags&0x1 != 0 {
+			res.Skipped = append(res.Skipped, f.Name)
+		} else if err := discardEntry(f.Open); err != nil {
+			if src.err != nil {
+				return nil, src.err
+			}
+			res.Corrupt, res.CorruptEntry, res.Reason = true, f.Name, err.Error()
+			return res, nil
+		}
+		done += f.CompressedSize64
+		if total > 0 {
+			up(float64(done) / float64(total) * 100)
+		}
+	}
+	up(100)
+	return res, nil
+}
+
+// discardEntry reads an entry to the end, archive/zip checks the CRC-32 at EOF
+func discardEntry(open func() (io.ReadCloser, error)) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+func verifyTarGz(ctx context.Context, ss *stream.SeekableStream, up model.UpdateProgress) (*ArchiveVerifyResult, error) {
+	src := &sourceReader{r: ss}
+	res := &ArchiveVerifyResult{}
+	corrupt := func(entry string, err error) (*ArchiveVerifyResult, error) {
+		if src.err != nil {
+			return nil, src.err
+		}
+		res.Corrupt, res.CorruptEntry, res.Reason = true, entry, err.Error()
+		return res, nil
+	}
+	// the gzip trailer holds the CRC-32 and the size of the whole tar stream,
+	// they are checked when the last entry has been read
+	gr, err := gzip.NewReader(src)
+	if err != nil {
+		return corrupt("", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	size := ss.GetSize()
+	var name string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return corrupt(name, err)
+		}
+		name = hdr.Name
+		res.Entries++
+		if _, err = io.Copy(io.Discard, tr); err != nil {
+			return corrupt(name, err)
+		}
+		if size > 0 {
+			up(float64(src.n) / float64(size) * 100)
+		}
+	}
+	// drain the tar padding so the gzip trailer is verified
+	if _, err = io.Copy(io.Discard, gr); err != nil {
+		return corrupt(name, err)
+	}
+	up(100)
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordAudit records the mutation made by the user of ctx, failed or not,
+// mutations made without a user (by the system) are not recorded
+func recordAudit(ctx context.Context, action, path, srcPath string, err error) {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok || user == nil || !setting.GetBool(conf.AuditLogEnabled) {
+		return
+	}
+	entry := &model.AuditLog{
+		UserID:   user.ID,
+		Username: user.Username,
+		Action:   action,
+		Path:     utils.FixAndCleanPath(path),
+	}
+	if srcPath != "" {
+		entry.SrcPath = utils.FixAndCleanPath(srcPath)
+	}
+	// the mutations of the api are made in the context of the request
+	if c, ok := ctx.(interface{ ClientIP() string }); ok {
+		entry.IP = c.ClientIP()
+	}
+	if err != nil {
+		entry.Failed, entry.Error = true, err.Error()
+	}
+	if err := db.CreateAuditLog(entry); err != nil {
+		log.Errorf("failed record audit log of %s %s: %+v", action, path, err)
+	}
+}
+
+var auditExpiryOnce sync.Once
+
+// StartAuditExpiry removes the audit logs older than audit_log_retention days
+func StartAuditExpiry() {
+	auditExpiryOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for ; true; <-ticker.C {
+				days := setting.GetInt(conf.AuditLogRetention, 180)
+				if days <= 0 {
+					continue
+				}
+				if err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+					log.Errorf("failed remove expired audit logs: %+v", err)
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,193 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// bundleWriter writes the objs walked into an archive
+type bundleWriter interface {
+	addDir(entry string, modified time.Time) error
+	addFile(entry string, obj model.Obj, r io.Reader) error
+	Close() error
+}
+
+type zipBundle struct {
+	zw *zip.Writer
+}
+
+func (b *zipBundle) addDir(entry string, modified time.Time) error {
+	_, err := b.zw.CreateHeader(&zip.FileHeader{Name: entry + "/", Modified: modified})
+	return err
+}
+
+func (b *zipBundle) addFile(entry string, obj model.Obj, r io.Reader) error {
+	fw, err := b.zw.CreateHeader(&zip.FileHeader{
+		Name:     entry,
+		Method:   zip.Deflate,
+		Modified: obj.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+func (b *zipBundle) Close() error {
+	return b.zw.Close()
+}
+
+type tarGzBundle struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func (b *tarGzBundle) addDir(entry string, modified time.Time) error {
+	return b.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     entry + "/",
+		Mode:     0755,
+		ModTime:  modified,
+	})
+}
+
+func (b *tarGzBundle) addFile(entry string, obj model.Obj, r io.Reader) error {
+	err := b.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry,
+		Mode:     0644,
+		Size:     obj.GetSize(),
+		ModTime:  obj.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(b.tw, r)
+	return err
+}
+
+func (b *tarGzBundle) Close() error {
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gw.Close()
+}
+
+// WriteZip streams a zip of the objs of dir named in names to w, walking into
+// the sub dirs. The dirs the user can't access and the files which can't be
+// read are left out, the response has already started when they are found.
+func WriteZip(ctx context.Context, w io.Writer, dir, password string, names []string) error {
+	return writeBundle(ctx, &zipBundle{zw: zip.NewWriter(w)}, dir, password, names)
+}
+
+// WriteTarGz is WriteZip writing a gzip-compressed tar
+func WriteTarGz(ctx context.Context, w io.Writer, dir, password string, names []string) error {
+	gw := gzip.NewWriter(w)
+	return writeBundle(ctx, &tarGzBundle{gw: gw, tw: tar.NewWriter(gw)}, dir, password, names)
+}
+
+func writeBundle(ctx context.Context, b bundleWriter, dir, password string, names []string) error {
+	user, _ := ctx.Value("user").(*model.User)
+	for _, name := range names {
+		path := stdpath.Join(dir, name)
+		// the check of the dir by the caller doesn't cover the metas and the
+		// role paths of the named objs themselves
+		if user != nil {
+			meta, _ := op.GetNearestMeta(path)
+			if !common.CanAccessWithRoles(user, meta, path, password) {
+				log.Warnf("bundle: %s can't access %s", user.Username, path)
+				continue
+			}
+		}
+		obj, err := get(ctx, path)
+		if err != nil {
+			log.Warnf("bundle: failed get %s: %+v", path, err)
+			continue
+		}
+		if obj.IsDir() {
+			err = bundleDir(ctx, b, user, path, name, password, obj.ModTime())
+		} else {
+			err = bundleFile(ctx, b, path, name, obj)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return b.Close()
+}
+
+func bundleDir(ctx context.Context, b bundleWriter, user *model.User, path, entry, password string, modified time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	meta, _ := op.GetNearestMeta(path)
+	if user != nil && !common.CanAccessWithRoles(user, meta, path, password) {
+		return nil
+	}
+	// the hide rules and the file drops are applied by the list
+	objs, err := List(context.WithValue(ctx, "meta", meta), path, &ListArgs{NoLog: true})
+	if err != nil {
+		log.Warnf("bundle: failed list %s: %+v", path, err)
+		return nil
+	}
+	if err = b.addDir(entry, modified); err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		objPath, objEntry := stdpath.Join(path, obj.GetName()), stdpath.Join(entry, obj.GetName())
+		// the objs are filtered like they are listed
+		if user != nil && !common.CanReadPathByRole(user, objPath) {
+			continue
+		}
+		if obj.IsDir() {
+			// the password only unlocks the requested dir
+			err = bundleDir(ctx, b, user, objPath, objEntry, "", obj.ModTime())
+		} else {
+			err = bundleFile(ctx, b, objPath, objEntry, obj)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleFile only fails when writing the archive fails, a file which can't be
+// read from its storage is skipped
+func bundleFile(ctx context.Context, b bundleWriter, path, entry string, obj model.Obj) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		log.Warnf("bundle: failed get storage of %s: %+v", path, err)
+		return nil
+	}
+	l, file, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		log.Warnf("bundle: failed get %s link: %+v", path, err)
+		return nil
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: file}, l)
+	if err != nil {
+		log.Warnf("bundle: failed open %s: %+v", path, err)
+		return nil
+	}
+	defer ss.Close()
+	// the entry is already started, a failed read leaves the archive broken
+	if err = b.addFile(entry, obj, ss); err != nil {
+		return errors.WithMessagef(err, "failed read [%s]", path)
+	}
+	return nil
+}
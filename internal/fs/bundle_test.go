@@ -0,0 +1,94 @@
+package fs_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:fs?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestWriteZip(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"closed.txt", "open/a.txt", "open/h.txt", "open/box/secret.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/bundle",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, dir),
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	metas := []model.Meta{
+		{Path: "/bundle/open", Hide: "^h"},
+		{Path: "/bundle/open/box", Drop: true},
+	}
+	for i := range metas {
+		if err := op.CreateMeta(&metas[i]); err != nil {
+			t.Fatalf("failed to create meta: %+v", err)
+		}
+	}
+	role := model.Role{ID: 10, Name: "bundle", PermissionScopes: []model.PermissionEntry{{Path: "/bundle/open"}}}
+	if err := op.CreateRole(&role); err != nil {
+		t.Fatalf("failed to create role: %+v", err)
+	}
+	user := &model.User{ID: 100, Username: "bundle", Role: model.Roles{int(role.ID)}}
+	var buf bytes.Buffer
+	ctx := context.WithValue(context.Background(), "user", user)
+	if err := fs.WriteZip(ctx, &buf, "/", "", []string{"bundle"}); err != nil {
+		t.Fatalf("failed to write zip: %+v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %+v", err)
+	}
+	// the objs are bundled like they are listed to the user
+	entries := map[string]bool{}
+	for _, f := range zr.File {
+		entries[f.Name] = true
+	}
+	var datas = []struct {
+		name    string
+		bundled bool
+	}{
+		{name: "bundle/", bundled: true},
+		{name: "bundle/open/", bundled: true},
+		{name: "bundle/open/a.txt", bundled: true},
+		{name: "bundle/closed.txt", bundled: false},
+		{name: "bundle/open/h.txt", bundled: false},
+		{name: "bundle/open/box/", bundled: true},
+		{name: "bundle/open/box/secret.txt", bundled: false},
+	}
+	for i, data := range datas {
+		if entries[data.name] != data.bundled {
+			t.Errorf("TestWriteZip %d failed, got %v", i, entries)
+		}
+	}
+}
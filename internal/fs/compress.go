@@ -0,0 +1,129 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+const (
+	CompressZip   = "zip"
+	CompressTarGz = "tar.gz"
+)
+
+// CompressTask writes an archive of some objs of a dir to a temp file, then
+// uploads it to the dst dir
+type CompressTask struct {
+	task.TaskExtension
+	Status     string   `json:"-"`
+	SrcDirPath string   `json:"src_dir"`
+	Names      []string `json:"names"`
+	DstDirPath string   `json:"dst_dir"`
+	DstName    string   `json:"dst_name"`
+	Format     string   `json:"format"`
+	// password unlocks SrcDirPath
+	password string
+}
+
+var CompressTaskManager *tache.Manager[*CompressTask]
+
+var _ task.TaskExtensionInfo = (*CompressTask)(nil)
+
+func (t *CompressTask) GetName() string {
+	return fmt.Sprintf("compress %d objs of [%s] to [%s]", len(t.Names), t.SrcDirPath, stdpath.Join(t.DstDirPath, t.DstName))
+}
+
+func (t *CompressTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *CompressTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "compress-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	// the walk sees what the creator sees
+	ctx := context.WithValue(t.Ctx(), "user", t.GetCreator())
+	t.Status = "compressing"
+	if t.Format == CompressTarGz {
+		err = WriteTarGz(ctx, tmp, t.SrcDirPath, t.password, t.Names)
+	} else {
+		err = WriteZip(ctx, tmp, t.SrcDirPath, t.password, t.Names)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed compress")
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = tmp.Seek(0, 0); err != nil {
+		return errors.WithStack(err)
+	}
+	storage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	t.Status = "uploading"
+	t.SetTotalBytes(info.Size())
+	file := &stream.FileStream{
+		Ctx: t.Ctx(),
+		Obj: &model.Object{
+			Name:     t.DstName,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype:     mime.TypeByExtension(stdpath.Ext(t.DstName)),
+		WebPutAsTask: true,
+		Reader:       tmp,
+	}
+	if err = op.Put(t.Ctx(), storage, dstDirActualPath, file, t.SetProgress, true); err != nil {
+		return err
+	}
+	t.Status = "done"
+	return nil
+}
+
+// compressAsTask adds a CompressTask archiving the objs of srcDirPath named in
+// names as dstName in dstDirPath
+func compressAsTask(ctx context.Context, srcDirPath, password string, names []string, dstDirPath, dstName, format string) (task.TaskExtensionInfo, error) {
+	if format != CompressZip && format != CompressTarGz {
+		return nil, errors.Errorf("unsupported format: %s", format)
+	}
+	if _, _, err := op.GetStorageAndActualPath(dstDirPath); err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &CompressTask{
+		TaskExtension: task.TaskExtension{
+			Creator: taskCreator,
+		},
+		SrcDirPath: srcDirPath,
+		Names:      names,
+		DstDirPath: dstDirPath,
+		DstName:    dstName,
+		Format:     format,
+		password:   password,
+	}
+	CompressTaskManager.Add(t)
+	return t, nil
+}
@@ -29,22 +29,143 @@ type CopyTask struct {
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
 	SkipExisting bool          `json:"skip_existing"`
+	// DstName is the name of the copy of SrcObjPath, its own name if empty
+	DstName string `json:"dst_name,omitempty"`
+	// IncludeAll copies the objs matching recursive_exclude too
+	IncludeAll bool `json:"include_all,omitempty"`
+	// Excluded is the number of children skipped by recursive_exclude
+	Excluded int `json:"excluded,omitempty"`
+	// SrcObjNames are the names of the objs in SrcObjPath copied by a batch sub-task
+	SrcObjNames []string `json:"src_obj_names,omitempty"`
+	// Tree copies the whole subtree within the task instead of adding a task per child
+	Tree       bool     `json:"tree,omitempty"`
+	ParentID   string   `json:"parent_id,omitempty"`
+	SubTaskIDs []string `json:"sub_task_ids,omitempty"`
+	// Attempted tells a run was started, a run after a restart or a failure
+	// keeps the files which a previous run copied whole
+	Attempted bool `json:"attempted,omitempty"`
+	rerun     bool
+	// releaseTurn gives back the slot of CopyTaskPool
+	releaseTurn func()
+	// waitingSubs tells the run has ended but not the sub-tasks
+	waitingSubs bool
 }
 
 func (t *CopyTask) GetName() string {
+	if len(t.SrcObjNames) > 0 {
+		return fmt.Sprintf("copy %d objs of [%s](%s) to [%s](%s)", len(t.SrcObjNames), t.SrcStorageMp, t.SrcObjPath, t.DstStorageMp, t.DstDirPath)
+	}
 	return fmt.Sprintf("copy [%s](%s) to [%s](%s)", t.SrcStorageMp, t.SrcObjPath, t.DstStorageMp, t.DstDirPath)
 }
 
 func (t *CopyTask) GetStatus() string {
+	if t.Excluded > 0 {
+		return fmt.Sprintf("%s (%d excluded)", t.Status, t.Excluded)
+	}
 	return t.Status
 }
 
+func (t *CopyTask) Retryable() bool {
+	return t.RetryableAs("copy")
+}
+
+func (t *CopyTask) OnBeforeRetry() {
+	t.WaitBackoffAs("copy")
+}
+
+func (t *CopyTask) OnSucceeded() {
+	if len(t.SubTaskIDs) > 0 {
+		t.waitSplitCopy()
+		return
+	}
+	t.fireWebhook()
+	t.endSubCopy()
+}
+
+func (t *CopyTask) OnFailed() {
+	t.fireWebhook()
+	t.endSubCopy()
+}
+
+// Cancel cancels the sub-tasks with the task
+func (t *CopyTask) Cancel() {
+	t.TaskExtension.Cancel()
+	t.cancelSplitCopy()
+	// a queued task ends without running
+	t.endSubCopy()
+}
+
+func (t *CopyTask) fireWebhook() {
+	task.FireWebhook(t, "copy", stdpath.Join(t.SrcStorageMp, t.SrcObjPath), stdpath.Join(t.DstStorageMp, t.DstDirPath))
+}
+
+// yieldTurn gives back the slot of the task early, such as while it waits for
+// a task slot of a storage
+func (t *CopyTask) yieldTurn() {
+	if t.releaseTurn != nil {
+		t.releaseTurn()
+		t.releaseTurn = nil
+	}
+}
+
+// acquireTask takes a task slot of the storage, the turn of the task is given
+// back while it waits not to hold up the copies to the other storages
+func (t *CopyTask) acquireTask(storage driver.Driver) (func(), error) {
+	if release, ok := op.TryAcquireTask(storage); ok {
+		return release, nil
+	}
+	hadTurn := t.releaseTurn != nil
+	t.yieldTurn()
+	t.Status = "waiting for a task slot of the dst storage"
+	release, err := op.AcquireTask(t.Ctx(), storage)
+	if err != nil || !hadTurn {
+		return release, err
+	}
+	t.Status = "waiting in the queue"
+	turn, err := t.WaitTurn(CopyTaskPool)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	t.releaseTurn = turn
+	return release, nil
+}
+
+// listChildren lists a dir of the src dropping the excluded objs
+func (t *CopyTask) listChildren(srcStorage driver.Driver, srcDirPath string) ([]model.Obj, error) {
+	objs, err := op.List(t.Ctx(), srcStorage, srcDirPath, model.ListArgs{})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list src [%s] objs", srcDirPath)
+	}
+	objs, excluded := filterExcluded(objs, t.IncludeAll)
+	t.Excluded += excluded
+	return objs, nil
+}
+
+// dstNameOf returns the name of the copy of srcObj, only the obj the task was
+// added for is renamed
+func (t *CopyTask) dstNameOf(srcObjPath string, srcObj model.Obj) string {
+	if t.DstName != "" && srcObjPath == t.SrcObjPath {
+		return t.DstName
+	}
+	return srcObj.GetName()
+}
+
 func (t *CopyTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	var err error
+	t.Status = "waiting in the queue"
+	release, err := t.WaitTurn(CopyTaskPool)
+	if err != nil {
+		return err
+	}
+	t.releaseTurn = release
+	defer t.yieldTurn()
+	t.rerun = t.Attempted
+	t.Attempted = true
+	t.Persist()
 	if t.srcStorage == nil {
 		t.srcStorage, err = op.GetStorageByMountPath(t.SrcStorageMp)
 	}
@@ -54,14 +175,31 @@ func (t *CopyTask) Run() error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if len(t.SrcObjNames) > 0 {
+		for _, name := range t.SrcObjNames {
+			if err = copyTreeBetween2Storages(t, t.srcStorage, t.dstStorage, stdpath.Join(t.SrcObjPath, name), t.DstDirPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if t.Tree {
+		return copyTreeBetween2Storages(t, t.srcStorage, t.dstStorage, t.SrcObjPath, t.DstDirPath)
+	}
 	return copyBetween2Storages(t, t.srcStorage, t.dstStorage, t.SrcObjPath, t.DstDirPath)
 }
 
 var CopyTaskManager *tache.Manager[*CopyTask]
 
+// CopyTaskPool limits the running copy tasks, taking the urgent ones first
+var CopyTaskPool *task.PriorityPool
+
 // Copy if in the same storage, call move method
-// if not, add copy task
-func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+// if not, add copy task. The copy is named dstName, or as the src if empty.
+func _copy(ctx context.Context, srcObjPath, dstDirPath, dstName string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	if managedDirDenied(ctx, srcObjPath) {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
@@ -71,18 +209,30 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
 	skipExisting := ctx.Value(conf.SkipExistingKey) != nil
-	// copy if in the same storage, just call driver.Copy
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	includeAll := ctx.Value(conf.IncludeAllKey) != nil
+	if dstName == stdpath.Base(srcObjActualPath) {
+		dstName = ""
+	}
+	// copy if in the same storage, just call driver.Copy, which keeps the name
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && dstName == "" {
 		if skipExisting {
 			if srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath); err == nil && !srcObj.IsDir() {
 				dstFilePath := stdpath.Join(dstDirActualPath, srcObj.GetName())
-				if dstFile, err := op.Get(ctx, dstStorage, dstFilePath); err == nil &&
+				if dstFile, err := op.GetCollision(ctx, dstStorage, dstFilePath); err == nil &&
 					!dstFile.IsDir() && dstFile.GetSize() == srcObj.GetSize() {
 					return nil, nil
 				}
 			}
 		}
+		dstObjPath := stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))
+		size := homeObjSize(ctx, srcObjPath, dstObjPath)
+		if err = op.CheckHomeQuota(dstObjPath, size); err != nil {
+			return nil, err
+		}
 		err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		if err == nil {
+			countHomeUsed(dstObjPath, size)
+		}
 		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
 			return nil, err
 		}
@@ -94,6 +244,7 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		}
 		if !srcObj.IsDir() {
 			// copy file directly
+			defer op.KeepStorage(srcStorage)()
 			link, _, err := op.Link(ctx, srcStorage, srcObjActualPath, model.LinkArgs{
 				Header: http.Header{},
 			})
@@ -104,19 +255,31 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 				Obj: srcObj,
 				Ctx: ctx,
 			}
+			if dstName != "" {
+				fs.Obj = &model.ObjWrapName{Name: dstName, Obj: srcObj}
+			}
 			// any link provided is seekable
 			ss, err := stream.NewSeekableStream(fs, link)
 			if err != nil {
 				return nil, errors.WithMessagef(err, "failed get [%s] stream", srcObjPath)
 			}
-			return nil, op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			dstObjPath := stdpath.Join(dstDirPath, ss.GetName())
+			if err = op.CheckHomeQuota(dstObjPath, srcObj.GetSize()); err != nil {
+				return nil, err
+			}
+			if err = op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false); err != nil {
+				return nil, err
+			}
+			countHomeUsed(dstObjPath, srcObj.GetSize())
+			return nil, nil
 		}
 	}
 	// not in the same storage
 	taskCreator, _ := ctx.Value("user").(*model.User)
 	t := &CopyTask{
 		TaskExtension: task.TaskExtension{
-			Creator: taskCreator,
+			Creator:  taskCreator,
+			Priority: task.PriorityOf(ctx),
 		},
 		srcStorage:   srcStorage,
 		dstStorage:   dstStorage,
@@ -125,6 +288,8 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		SrcStorageMp: srcStorage.GetStorage().MountPath,
 		DstStorageMp: dstStorage.GetStorage().MountPath,
 		SkipExisting: skipExisting,
+		DstName:      dstName,
+		IncludeAll:   includeAll,
 	}
 	CopyTaskManager.Add(t)
 	return t, nil
@@ -138,19 +303,24 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 	}
 	if srcObj.IsDir() {
 		t.Status = "src object is dir, listing objs"
-		objs, err := op.List(t.Ctx(), srcStorage, srcObjPath, model.ListArgs{})
+		objs, err := t.listChildren(srcStorage, srcObjPath)
 		if err != nil {
-			return errors.WithMessagef(err, "failed list src [%s] objs", srcObjPath)
+			return err
+		}
+		dstName := t.dstNameOf(srcObjPath, srcObj)
+		if shouldSplitCopy(t, len(objs)) {
+			return splitCopy(t, srcStorage, dstStorage, srcObjPath, stdpath.Join(dstDirPath, dstName), objs)
 		}
 		for _, obj := range objs {
 			if utils.IsCanceled(t.Ctx()) {
 				return nil
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
-			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
+			dstObjPath := stdpath.Join(dstDirPath, dstName)
 			CopyTaskManager.Add(&CopyTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.GetCreator(),
+					Creator:  t.GetCreator(),
+					Priority: t.Priority,
 				},
 				srcStorage:   srcStorage,
 				dstStorage:   dstStorage,
@@ -159,6 +329,9 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 				SrcStorageMp: srcStorage.GetStorage().MountPath,
 				DstStorageMp: dstStorage.GetStorage().MountPath,
 				SkipExisting: t.SkipExisting,
+				IncludeAll:   t.IncludeAll,
+				// the objs of a rerun dir may have been copied by the previous run
+				Attempted: t.rerun,
 			})
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
@@ -173,16 +346,28 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
 	}
 	tsk.SetTotalBytes(srcFile.GetSize())
-	if tsk.SkipExisting {
-		dstFilePath := stdpath.Join(dstDirPath, srcFile.GetName())
+	dstName := tsk.dstNameOf(srcFilePath, srcFile)
+	if tsk.SkipExisting || tsk.rerun {
+		dstFilePath := stdpath.Join(dstDirPath, dstName)
 		// a failed probe falls through to a normal copy, worst case is a redundant transfer
-		if dstFile, err := op.Get(tsk.Ctx(), dstStorage, dstFilePath); err == nil &&
+		if dstFile, err := op.GetCollision(tsk.Ctx(), dstStorage, dstFilePath); err == nil &&
 			!dstFile.IsDir() && dstFile.GetSize() == srcFile.GetSize() {
 			tsk.Status = "skipped: destination file already exists"
 			tsk.SetProgress(100)
 			return nil
 		}
 	}
+	dstFileMountPath := stdpath.Join(tsk.DstStorageMp, dstDirPath, dstName)
+	if err = op.CheckHomeQuota(dstFileMountPath, srcFile.GetSize()); err != nil {
+		return err
+	}
+	release, err := tsk.acquireTask(dstStorage)
+	if err != nil {
+		return err
+	}
+	defer release()
+	tsk.Status = "copying"
+	defer op.KeepStorage(srcStorage)()
 	link, _, err := op.Link(tsk.Ctx(), srcStorage, srcFilePath, model.LinkArgs{
 		Header: http.Header{},
 	})
@@ -193,10 +378,17 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 		Obj: srcFile,
 		Ctx: tsk.Ctx(),
 	}
+	if dstName != srcFile.GetName() {
+		fs.Obj = &model.ObjWrapName{Name: dstName, Obj: srcFile}
+	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(fs, link)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	if err = op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true); err != nil {
+		return err
+	}
+	countHomeUsed(dstFileMountPath, srcFile.GetSize())
+	return nil
 }
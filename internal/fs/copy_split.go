@@ -0,0 +1,181 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// splitCopiesMu guards the parents waiting for their sub-tasks
+var splitCopiesMu sync.Mutex
+
+// shouldSplitCopy reports whether the copy of a dir with n children is split into
+// sub-tasks, sub-tasks are never split again
+func shouldSplitCopy(t *CopyTask, n int) bool {
+	threshold := setting.GetInt(conf.CopySplitThreshold, 0)
+	return threshold > 0 && n > threshold && t.ParentID == ""
+}
+
+// splitCopy adds a sub-task per child dir, and a sub-task per child file or per
+// batch of copy_split_batch_size files. The run of the parent ends there not to
+// hold a worker, the parent is kept running until its sub-tasks end, see
+// endSplitCopy. When the parent is retried, only its failed or canceled
+// sub-tasks are retried.
+func splitCopy(t *CopyTask, srcStorage, dstStorage driver.Driver, srcDirPath, dstDirPath string, objs []model.Obj) error {
+	if len(t.SubTaskIDs) == 0 {
+		batchSize := setting.GetInt(conf.CopySplitBatchSize, 0)
+		var batch []string
+		addSub := func(sub *CopyTask) {
+			sub.TaskExtension = task.TaskExtension{Creator: t.GetCreator(), Priority: t.Priority}
+			sub.srcStorage, sub.dstStorage = srcStorage, dstStorage
+			sub.SrcStorageMp = srcStorage.GetStorage().MountPath
+			sub.DstStorageMp = dstStorage.GetStorage().MountPath
+			sub.DstDirPath = dstDirPath
+			sub.SkipExisting = t.SkipExisting
+			sub.IncludeAll = t.IncludeAll
+			sub.ParentID = t.GetID()
+			CopyTaskManager.Add(sub)
+			t.SubTaskIDs = append(t.SubTaskIDs, sub.GetID())
+		}
+		for _, obj := range objs {
+			switch {
+			case obj.IsDir():
+				addSub(&CopyTask{SrcObjPath: stdpath.Join(srcDirPath, obj.GetName()), Tree: true})
+			case batchSize > 1:
+				if batch = append(batch, obj.GetName()); len(batch) == batchSize {
+					addSub(&CopyTask{SrcObjPath: srcDirPath, SrcObjNames: batch})
+					batch = nil
+				}
+			default:
+				addSub(&CopyTask{SrcObjPath: stdpath.Join(srcDirPath, obj.GetName())})
+			}
+		}
+		if len(batch) > 0 {
+			addSub(&CopyTask{SrcObjPath: srcDirPath, SrcObjNames: batch})
+		}
+		t.Persist()
+	} else {
+		for _, id := range t.SubTaskIDs {
+			if sub, ok := CopyTaskManager.GetByID(id); ok &&
+				(sub.GetState() == tache.StateFailed || sub.GetState() == tache.StateCanceled) {
+				CopyTaskManager.Retry(id)
+			}
+		}
+	}
+	t.Status = fmt.Sprintf("%d sub-tasks added", len(t.SubTaskIDs))
+	return nil
+}
+
+// waitSplitCopy keeps the parent running once its run ends until its sub-tasks
+// end, it's called when the run succeeded
+func (t *CopyTask) waitSplitCopy() {
+	splitCopiesMu.Lock()
+	t.waitingSubs = true
+	t.SetState(tache.StateRunning)
+	splitCopiesMu.Unlock()
+	t.updateSplitCopy()
+}
+
+// updateSplitCopy aggregates the progress of the sub-tasks of t, which ends
+// when they all have ended
+func (t *CopyTask) updateSplitCopy() {
+	splitCopiesMu.Lock()
+	defer splitCopiesMu.Unlock()
+	done, failed, progress := 0, 0, 0.0
+	for _, id := range t.SubTaskIDs {
+		sub, ok := CopyTaskManager.GetByID(id)
+		if !ok {
+			// removed from the task list
+			failed++
+			continue
+		}
+		switch sub.GetState() {
+		case tache.StateSucceeded:
+			done++
+			progress += 100
+		case tache.StateFailed, tache.StateCanceling, tache.StateCanceled:
+			failed++
+		default:
+			progress += sub.GetProgress()
+		}
+	}
+	t.SetProgress(progress / float64(len(t.SubTaskIDs)))
+	t.Status = fmt.Sprintf("%d of %d sub-tasks done, %d failed", done, len(t.SubTaskIDs), failed)
+	if !t.waitingSubs || done+failed < len(t.SubTaskIDs) {
+		return
+	}
+	t.waitingSubs = false
+	t.SetEndTime(time.Now())
+	if failed > 0 {
+		t.SetErr(errors.Errorf("%d of %d sub-tasks failed", failed, len(t.SubTaskIDs)))
+		t.SetState(tache.StateFailed)
+	} else {
+		t.SetState(tache.StateSucceeded)
+	}
+	t.Persist()
+	t.fireWebhook()
+}
+
+// cancelSplitCopy cancels the sub-tasks of t, and t if it's waiting for them
+func (t *CopyTask) cancelSplitCopy() {
+	for _, id := range t.SubTaskIDs {
+		CopyTaskManager.Cancel(id)
+	}
+	splitCopiesMu.Lock()
+	defer splitCopiesMu.Unlock()
+	if !t.waitingSubs {
+		return
+	}
+	t.waitingSubs = false
+	t.SetEndTime(time.Now())
+	t.SetErr(context.Canceled)
+	t.SetState(tache.StateCanceled)
+	t.Persist()
+}
+
+// endSubCopy updates the parent of a sub-task which has ended
+func (t *CopyTask) endSubCopy() {
+	if t.ParentID == "" {
+		return
+	}
+	if parent, ok := CopyTaskManager.GetByID(t.ParentID); ok {
+		parent.updateSplitCopy()
+	}
+}
+
+// copyTreeBetween2Storages copies the obj and everything under it within the task
+func copyTreeBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) error {
+	if err := t.Ctx().Err(); err != nil {
+		return err
+	}
+	srcObj, err := op.Get(t.Ctx(), srcStorage, srcObjPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+	}
+	if !srcObj.IsDir() {
+		t.Status = "copying " + srcObjPath
+		return copyFileBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstDirPath)
+	}
+	objs, err := t.listChildren(srcStorage, srcObjPath)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		err = copyTreeBetween2Storages(t, srcStorage, dstStorage, stdpath.Join(srcObjPath, obj.GetName()), stdpath.Join(dstDirPath, t.dstNameOf(srcObjPath, srcObj)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
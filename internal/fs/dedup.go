@@ -0,0 +1,274 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// the actions applied to the duplicates of a set
+const (
+	DedupRemove = "remove"
+	// DedupLink replaces the duplicates by hard links, for the storages
+	// supporting them and within a storage only
+	DedupLink = "link"
+)
+
+type DupFile struct {
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+}
+
+// DupSet are files of the same size and hash
+type DupSet struct {
+	Size     int64     `json:"size"`
+	HashType string    `json:"hash_type"`
+	Hash     string    `json:"hash"`
+	Files    []DupFile `json:"files"`
+}
+
+// DedupTask scans the Paths for duplicate files: the files are grouped by
+// size, and only the files sharing a size are hashed, with a hash type their
+// storages provide else md5. The empty files are skipped.
+type DedupTask struct {
+	task.TaskExtension
+	Status  string   `json:"-"`
+	Paths   []string `json:"paths"`
+	MinSize int64    `json:"min_size"`
+	Scanned int      `json:"scanned"`
+	Sets    []DupSet `json:"sets"`
+}
+
+var DedupTaskManager *tache.Manager[*DedupTask]
+
+var _ task.TaskExtensionInfo = (*DedupTask)(nil)
+
+func (t *DedupTask) GetName() string {
+	return fmt.Sprintf("find duplicates in [%s]", strings.Join(t.Paths, ", "))
+}
+
+func (t *DedupTask) GetStatus() string {
+	return t.Status
+}
+
+type dupCandidate struct {
+	path string
+	obj  model.Obj
+}
+
+func (t *DedupTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Scanned, t.Sets = 0, nil
+	bySize := make(map[int64][]dupCandidate)
+	seen := make(map[string]bool)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if err := t.Ctx().Err(); err != nil {
+			return err
+		}
+		t.Status = "listing " + dir
+		objs, err := List(t.Ctx(), dir, &ListArgs{NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", dir)
+		}
+		for _, obj := range objs {
+			path := stdpath.Join(dir, obj.GetName())
+			if obj.IsDir() {
+				if err = walk(path); err != nil {
+					return err
+				}
+				continue
+			}
+			// the paths may overlap
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			t.Scanned++
+			if obj.GetSize() > 0 && obj.GetSize() >= t.MinSize {
+				bySize[obj.GetSize()] = append(bySize[obj.GetSize()], dupCandidate{path: path, obj: obj})
+			}
+		}
+		return nil
+	}
+	for _, path := range t.Paths {
+		if err := walk(path); err != nil {
+			return err
+		}
+	}
+	var sizes []int64
+	for size, files := range bySize {
+		if len(files) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	// the largest duplicates first, they waste the most
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+	for i, size := range sizes {
+		sets, err := t.hashGroup(size, bySize[size])
+		if err != nil {
+			return err
+		}
+		t.Sets = append(t.Sets, sets...)
+		t.SetProgress(float64(i+1) / float64(len(sizes)) * 100)
+	}
+	t.Status = fmt.Sprintf("done, %d duplicate sets in %d files", len(t.Sets), t.Scanned)
+	return nil
+}
+
+// hashGroup splits files of the same size by hash
+func (t *DedupTask) hashGroup(size int64, files []dupCandidate) ([]DupSet, error) {
+	ht := dupHashType(files)
+	byHash := make(map[string]*DupSet)
+	var hashes []string
+	for _, f := range files {
+		if err := t.Ctx().Err(); err != nil {
+			return nil, err
+		}
+		t.Status = "hashing " + f.path
+		res, err := FileHashes(t.Ctx(), f.path, []*utils.HashType{ht}, false)
+		if err != nil {
+			return nil, err
+		}
+		h := strings.ToLower(res.Hashes[ht.Name])
+		set, ok := byHash[h]
+		if !ok {
+			set = &DupSet{Size: size, HashType: ht.Name, Hash: h}
+			byHash[h] = set
+			hashes = append(hashes, h)
+		}
+		set.Files = append(set.Files, DupFile{Path: f.path, Modified: f.obj.ModTime()})
+	}
+	var sets []DupSet
+	for _, h := range hashes {
+		if set := byHash[h]; len(set.Files) > 1 {
+			sets = append(sets, *set)
+		}
+	}
+	return sets, nil
+}
+
+// dupHashType returns a hash type the storages provide for all the files, so
+// none of them has to be read, else md5
+func dupHashType(files []dupCandidate) *utils.HashType {
+	for ht := range files[0].obj.GetHash().All() {
+		shared := true
+		for _, f := range files[1:] {
+			if f.obj.GetHash().GetHash(ht) == "" {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			return ht
+		}
+	}
+	return utils.MD5
+}
+
+// dedupAsTask adds a DedupTask scanning paths
+func dedupAsTask(ctx context.Context, paths []string, minSize int64) (task.TaskExtensionInfo, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no paths to scan")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &DedupTask{
+		TaskExtension: task.TaskExtension{
+			Creator:  taskCreator,
+			Priority: task.PriorityOf(ctx),
+		},
+		Status:  "queued",
+		Paths:   paths,
+		MinSize: minSize,
+	}
+	DedupTaskManager.Add(t)
+	return t, nil
+}
+
+// dedupSet applies action to the files of set except keep, and returns the
+// paths it applied to. Every file is checked against the set first, as it may
+// have changed since the scan.
+func dedupSet(ctx context.Context, set DupSet, keep, action string) ([]string, error) {
+	if action != DedupRemove && action != DedupLink {
+		return nil, errors.Errorf("invalid action: %s", action)
+	}
+	ht, ok := utils.GetHashByName(set.HashType)
+	if !ok {
+		return nil, errors.Errorf("unknown hash type: %s", set.HashType)
+	}
+	var dups []string
+	kept := false
+	for _, f := range set.Files {
+		if f.Path == keep {
+			kept = true
+		} else {
+			dups = append(dups, f.Path)
+		}
+	}
+	if !kept {
+		return nil, errors.Errorf("[%s] is not in the set", keep)
+	}
+	for _, path := range append([]string{keep}, dups...) {
+		if err := checkDup(ctx, set, ht, path); err != nil {
+			return nil, err
+		}
+	}
+	var done []string
+	for _, path := range dups {
+		var err error
+		if action == DedupRemove {
+			err = Remove(ctx, path)
+		} else {
+			err = hardLink(ctx, keep, path)
+		}
+		if err != nil {
+			return done, errors.WithMessagef(err, "failed %s [%s]", action, path)
+		}
+		done = append(done, path)
+	}
+	return done, nil
+}
+
+func checkDup(ctx context.Context, set DupSet, ht *utils.HashType, path string) error {
+	res, err := FileHashes(ctx, path, []*utils.HashType{ht}, false)
+	if err != nil {
+		return err
+	}
+	obj, err := get(ctx, path)
+	if err != nil {
+		return err
+	}
+	if obj.GetSize() != set.Size || !strings.EqualFold(res.Hashes[ht.Name], set.Hash) {
+		return errors.Errorf("[%s] changed since the scan", path)
+	}
+	return nil
+}
+
+func hardLink(ctx context.Context, srcPath, dstPath string) error {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(dstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	if srcStorage.GetStorage().MountPath != dstStorage.GetStorage().MountPath {
+		return errors.WithMessage(errs.NotImplement, "can't link across storages")
+	}
+	return op.HardLink(ctx, srcStorage, srcActualPath, dstActualPath)
+}
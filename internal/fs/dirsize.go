@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// how long a computed size is reused unless a refresh is asked
+const dirSizeCacheExpire = 10 * time.Minute
+
+// the objs a request walks at most, the result is truncated past them
+const maxDirSizeObjs = 100000
+
+type DirSizeResult struct {
+	Size    int64 `json:"size"`
+	Files   int   `json:"files"`
+	Folders int   `json:"folders"`
+	// Skipped are the dirs which can't be listed or accessed, their content isn't counted
+	Skipped []string `json:"skipped"`
+	// Truncated is set if the walk stopped at the limit of objs, the
+	// result only counts the objs walked until then
+	Truncated  bool      `json:"truncated"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+var dirSizeCache = cache.NewMemCache(cache.WithShards[*DirSizeResult](16))
+var dirSizeG singleflight.Group[*DirSizeResult]
+
+// DirSize walks path and sums the sizes of the files under it. The result depends
+// on the dirs the user can access, so it's cached per user and password.
+func DirSize(ctx context.Context, path, password string, refresh bool) (*DirSizeResult, error) {
+	user, _ := ctx.Value("user").(*model.User)
+	key := path
+	if user != nil {
+		key = user.Username + "\x00" + utils.GetMD5EncodeStr(password) + "\x00" + path
+	}
+	if !refresh {
+		if res, ok := dirSizeCache.Get(key); ok {
+			return res, nil
+		}
+	}
+	res, err, _ := dirSizeG.Do(key, func() (*DirSizeResult, error) {
+		obj, err := get(ctx, path)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get [%s]", path)
+		}
+		res := &DirSizeResult{Skipped: []string{}}
+		if !obj.IsDir() {
+			res.Size, res.Files = obj.GetSize(), 1
+		} else if err = walkDirSize(ctx, user, path, password, res, maxDirSizeObjs); err != nil {
+			return nil, err
+		}
+		res.ComputedAt = time.Now()
+		dirSizeCache.Set(key, res, cache.WithEx[*DirSizeResult](dirSizeCacheExpire))
+		return res, nil
+	})
+	return res, err
+}
+
+// walkDirSize sums the sizes under path into res, it stops after limit objs
+// unless limit is 0
+func walkDirSize(ctx context.Context, user *model.User, path, password string, res *DirSizeResult, limit int) error {
+	return WalkAccessible(ctx, user, path, password, func(reqPath string, objs []model.Obj, err error) ([]model.Obj, error) {
+		if err != nil {
+			res.Skipped = append(res.Skipped, reqPath)
+			return nil, nil
+		}
+		var dirs []model.Obj
+		for _, obj := range objs {
+			if limit > 0 && res.Files+res.Folders >= limit {
+				res.Truncated = true
+				return nil, nil
+			}
+			if obj.IsDir() {
+				res.Folders++
+				dirs = append(dirs, obj)
+				continue
+			}
+			res.Size += obj.GetSize()
+			res.Files++
+		}
+		return dirs, nil
+	})
+}
@@ -0,0 +1,41 @@
+package fs
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// the file events of the audited mutations
+var auditEvents = map[string]string{
+	model.AuditMakeDir: model.FileEventCreate,
+	model.AuditPut:     model.FileEventUpload,
+	model.AuditMove:    model.FileEventMove,
+	model.AuditCopy:    model.FileEventCopy,
+	model.AuditRename:  model.FileEventRename,
+	model.AuditRemove:  model.FileEventDelete,
+}
+
+// recordChange audits the mutation and, if it succeeded, publishes its event
+func recordChange(ctx context.Context, action, path, srcPath string, err error) {
+	recordAudit(ctx, action, path, srcPath, err)
+	typ, ok := auditEvents[action]
+	if !ok || err != nil {
+		return
+	}
+	e := model.FileEvent{
+		Type: typ,
+		Path: utils.FixAndCleanPath(path),
+		Time: time.Now(),
+	}
+	if srcPath != "" {
+		e.SrcPath = utils.FixAndCleanPath(srcPath)
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		e.Username = user.Username
+	}
+	event.Publish(e)
+}
@@ -0,0 +1,33 @@
+package fs
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// IsExcluded reports whether the recursive operations skip an obj named name,
+// such as the .DS_Store files, by the recursive_exclude setting
+func IsExcluded(name string) bool {
+	for _, p := range conf.SlicesMap[conf.RecursiveExclude] {
+		if ok, _ := stdpath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterExcluded drops the excluded objs unless includeAll, and returns how many were dropped
+func filterExcluded(objs []model.Obj, includeAll bool) ([]model.Obj, int) {
+	if includeAll {
+		return objs, 0
+	}
+	kept := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if !IsExcluded(obj.GetName()) {
+			kept = append(kept, obj)
+		}
+	}
+	return kept, len(objs) - len(kept)
+}
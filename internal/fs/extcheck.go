@@ -0,0 +1,184 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// the bytes read from the head of each file, enough for mimetype to detect most formats
+const extSniffSize = 3072
+
+type ExtMismatch struct {
+	Path     string `json:"path"`
+	Declared string `json:"declared"`
+	Detected string `json:"detected"`
+	// Suggested is the name with the extension of the detected type
+	Suggested string `json:"suggested,omitempty"`
+}
+
+// ExtCheckTask walks a path and reports the files whose content doesn't match
+// their extension, or which have no extension while their content is recognized
+type ExtCheckTask struct {
+	task.TaskExtension
+	Status     string        `json:"-"`
+	Path       string        `json:"path"`
+	Password   string        `json:"-"`
+	Checked    int           `json:"checked"`
+	Mismatches []ExtMismatch `json:"mismatches"`
+	// Skipped are the dirs the creator can't read and the files which can't be read
+	Skipped []string `json:"skipped"`
+}
+
+var ExtCheckTaskManager *tache.Manager[*ExtCheckTask]
+
+var _ task.TaskExtensionInfo = (*ExtCheckTask)(nil)
+
+func (t *ExtCheckTask) GetName() string {
+	return fmt.Sprintf("check extensions of [%s]", t.Path)
+}
+
+func (t *ExtCheckTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ExtCheckTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Checked, t.Mismatches, t.Skipped = 0, nil, nil
+	ctx := context.WithValue(t.Ctx(), "user", t.GetCreator())
+	obj, err := get(ctx, t.Path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", t.Path)
+	}
+	if err = t.walk(ctx, t.Path, obj, t.Password); err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("done, %d of %d mismatched", len(t.Mismatches), t.Checked)
+	return nil
+}
+
+func (t *ExtCheckTask) walk(ctx context.Context, path string, obj model.Obj, password string) error {
+	if !obj.IsDir() {
+		t.Status = "checking " + path
+		t.check(ctx, path, obj)
+		return nil
+	}
+	return WalkAccessible(ctx, t.GetCreator(), path, password, func(reqPath string, objs []model.Obj, err error) ([]model.Obj, error) {
+		if errors.Is(err, errs.PermissionDenied) {
+			t.Skipped = append(t.Skipped, reqPath)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed list [%s]", reqPath)
+		}
+		var dirs []model.Obj
+		for _, o := range objs {
+			if o.IsDir() {
+				dirs = append(dirs, o)
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			objPath := stdpath.Join(reqPath, o.GetName())
+			t.Status = "checking " + objPath
+			t.check(ctx, objPath, o)
+		}
+		return dirs, nil
+	})
+}
+
+func (t *ExtCheckTask) check(ctx context.Context, path string, obj model.Obj) {
+	if obj.GetSize() == 0 {
+		return
+	}
+	head, err := readHead(ctx, path, obj, extSniffSize)
+	if err != nil {
+		t.Skipped = append(t.Skipped, path)
+		return
+	}
+	t.Checked++
+	if m, ok := extMismatch(obj.GetName(), head); ok {
+		m.Path = path
+		t.Mismatches = append(t.Mismatches, m)
+	}
+}
+
+// extMismatch compares the type detected from the head of a file with its
+// extension. Generic types such as text/plain are never reported, since many
+// extensions legitimately hold plain text.
+func extMismatch(name string, head []byte) (ExtMismatch, bool) {
+	detected := mimetype.Detect(head)
+	if detected.Is("application/octet-stream") || detected.Is("text/plain") {
+		return ExtMismatch{}, false
+	}
+	ext := utils.Ext(name)
+	if ext != "" {
+		declared := utils.GetMimeType(name)
+		for m := detected; m != nil; m = m.Parent() {
+			if m.Is(declared) || strings.TrimPrefix(m.Extension(), ".") == ext {
+				return ExtMismatch{}, false
+			}
+		}
+	}
+	res := ExtMismatch{Declared: ext, Detected: detected.String()}
+	if detected.Extension() != "" {
+		res.Suggested = strings.TrimSuffix(name, "."+ext) + detected.Extension()
+		if ext == "" {
+			res.Suggested = name + detected.Extension()
+		}
+	}
+	return res, true
+}
+
+// readHead reads at most n bytes from the head of the file through its link
+func readHead(ctx context.Context, path string, obj model.Obj, n int64) ([]byte, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	l, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: obj}, l)
+	if err != nil {
+		if l.MFile != nil {
+			_ = l.MFile.Close()
+		}
+		if l.RangeReadCloser != nil {
+			_ = l.RangeReadCloser.Close()
+		}
+		return nil, err
+	}
+	defer ss.Close()
+	n = min(n, obj.GetSize())
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: n})
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	read, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // the param named path of functions in this package is a mount path
@@ -24,10 +27,14 @@ type ListArgs struct {
 	// Wrapper drivers set it when listing their underlying storage so the real
 	// path is not auto-indexed alongside the wrapper's mount path.
 	NoUpdateIndex bool
+	// ArchivePass is the password of the archive when listing inside an archive
+	ArchivePass string
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.List", attribute.String("path", path), attribute.Bool("refresh", args.Refresh))
 	res, err := list(ctx, path, args)
+	tracing.End(span, err)
 	if err != nil {
 		if !args.NoLog {
 			log.Errorf("failed list %s: %+v", path, err)
@@ -39,10 +46,32 @@ func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 
 type GetArgs struct {
 	NoLog bool
+	// Collision also returns an obj whose name collides with the base of path
+	// according to the name_match of the storage, for existence checks
+	Collision bool
+	// ArchivePass is the password of the archive when getting an entry of an archive
+	ArchivePass string
 }
 
 func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.Get", attribute.String("path", path))
+	if managedDirDenied(ctx, path) {
+		tracing.End(span, errs.PermissionDenied)
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
 	res, err := get(ctx, path)
+	if args.Collision && errs.IsObjectNotFound(err) {
+		res, err = getCollision(ctx, path, err)
+	}
+	// the entries of archives are browsed as if they were plain objs
+	if err != nil && !args.Collision {
+		if archivePath, innerPath, ok := SplitArchivePath(ctx, path); ok && innerPath != "/" {
+			if obj, e := archiveGet(ctx, archivePath, innerPath, args.ArchivePass); e == nil {
+				res, err = obj, nil
+			}
+		}
+	}
+	tracing.End(span, err)
 	if err != nil {
 		if !args.NoLog {
 			log.Warnf("failed get %s: %s", path, err)
@@ -53,7 +82,9 @@ func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 }
 
 func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.Link", attribute.String("path", path))
 	res, file, err := link(ctx, path, args)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
 		return nil, nil, err
@@ -62,50 +93,146 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 }
 
 func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.MakeDir", attribute.String("path", path))
 	err := makeDir(ctx, path, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	recordChange(ctx, model.AuditMakeDir, path, "", err)
 	return err
 }
 
 func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.Move", attribute.String("path", srcPath), attribute.String("dst_dir", dstDirPath))
 	err := move(ctx, srcPath, dstDirPath, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	recordChange(ctx, model.AuditMove, stdpath.Join(dstDirPath, stdpath.Base(srcPath)), srcPath, err)
 	return err
 }
 
+// MoveAsTask moves like Move, but adds a task to move between two storages
+func MoveAsTask(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	res, err := moveAsTask(ctx, srcPath, dstDirPath, lazyCache...)
+	if err != nil {
+		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	}
+	// a queued move is recorded by its task once it ends
+	if res == nil {
+		recordChange(ctx, model.AuditMove, stdpath.Join(dstDirPath, stdpath.Base(srcPath)), srcPath, err)
+	}
+	return res, err
+}
+
+// CompressAsTask adds a task archiving the objs of srcDirPath named in names as
+// dstName in dstDirPath, the format is zip or tar.gz
+func CompressAsTask(ctx context.Context, srcDirPath, password string, names []string, dstDirPath, dstName, format string) (task.TaskExtensionInfo, error) {
+	res, err := compressAsTask(ctx, srcDirPath, password, names, dstDirPath, dstName, format)
+	if err != nil {
+		log.Errorf("failed compress %s to %s: %+v", srcDirPath, dstDirPath, err)
+	}
+	return res, err
+}
+
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	res, err := _copy(ctx, srcObjPath, dstDirPath, lazyCache...)
+	res, err := _copy(ctx, srcObjPath, dstDirPath, "", lazyCache...)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	recordChange(ctx, model.AuditCopy, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)), srcObjPath, err)
 	return res, err
 }
 
+// CopyAs copies the obj into dstDirPath under dstName, the copy isn't renamed
+// afterwards, it's written with the new name
+func CopyAs(ctx context.Context, srcObjPath, dstDirPath, dstName string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	res, err := _copy(ctx, srcObjPath, dstDirPath, dstName, lazyCache...)
+	if err != nil {
+		log.Errorf("failed copy %s to %s as %s: %+v", srcObjPath, dstDirPath, dstName, err)
+	}
+	recordChange(ctx, model.AuditCopy, stdpath.Join(dstDirPath, dstName), srcObjPath, err)
+	return res, err
+}
+
+// SyncAsTask adds a task mirroring the content of the srcPath dir into the
+// dstPath dir, compare is size, mtime or hash, with del the objs of dstPath
+// missing in srcPath are removed
+func SyncAsTask(ctx context.Context, srcPath, dstPath, compare string, del bool) (task.TaskExtensionInfo, error) {
+	res, err := syncAsTask(ctx, srcPath, dstPath, compare, del)
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcPath, dstPath, err)
+	}
+	return res, err
+}
+
+// DedupAsTask adds a task finding the duplicate files under paths, the files
+// smaller than minSize are skipped
+func DedupAsTask(ctx context.Context, paths []string, minSize int64) (task.TaskExtensionInfo, error) {
+	res, err := dedupAsTask(ctx, paths, minSize)
+	if err != nil {
+		log.Errorf("failed find duplicates in %v: %+v", paths, err)
+	}
+	return res, err
+}
+
+// DedupSet removes or hard links the files of a duplicate set except keep,
+// and returns the paths done
+func DedupSet(ctx context.Context, set DupSet, keep, action string) ([]string, error) {
+	res, err := dedupSet(ctx, set, keep, action)
+	if err != nil {
+		log.Errorf("failed %s duplicates of %s: %+v", action, keep, err)
+	}
+	return res, err
+}
+
+// SyncPairAsTask adds a task syncing the dirs of the pair both ways
+func SyncPairAsTask(ctx context.Context, pair *model.SyncPair) (task.TaskExtensionInfo, error) {
+	return syncPairAsTask(ctx, pair)
+}
+
+// ResolveSyncConflict resolves a queued conflict by the choice, which is a,
+// b or both
+func ResolveSyncConflict(ctx context.Context, conflict *model.SyncConflict, choice string) error {
+	err := resolveSyncConflict(ctx, conflict, choice)
+	if err != nil {
+		log.Errorf("failed resolve sync conflict %s: %+v", conflict.Path, err)
+	}
+	return err
+}
+
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.Rename", attribute.String("path", srcPath), attribute.String("dst_name", dstName))
 	err := rename(ctx, srcPath, dstName, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	recordChange(ctx, model.AuditRename, stdpath.Join(stdpath.Dir(srcPath), dstName), srcPath, err)
 	return err
 }
 
 func Remove(ctx context.Context, path string) error {
+	ctx, span := tracing.Start(ctx, "fs.Remove", attribute.String("path", path))
 	err := remove(ctx, path)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
+	recordChange(ctx, model.AuditRemove, path, "", err)
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.Put", attribute.String("dst_dir", dstDirPath), attribute.String("name", file.GetName()), attribute.Int64("size", file.GetSize()))
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	recordChange(ctx, model.AuditPut, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return err
 }
 
@@ -114,6 +241,7 @@ func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	recordChange(ctx, model.AuditPut, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return t, err
 }
 
@@ -37,3 +37,21 @@ func get(ctx context.Context, path string) (model.Obj, error) {
 	}
 	return op.Get(ctx, storage, actualPath)
 }
+
+// DirModTime returns the mtime of the dir computed from its children
+func DirModTime(ctx context.Context, path string) (time.Time, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return time.Time{}, errors.WithMessage(err, "failed get storage")
+	}
+	return op.DirModTime(ctx, storage, actualPath)
+}
+
+// getCollision looks for an obj colliding with path once it is known not to exist
+func getCollision(ctx context.Context, path string, notFound error) (model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(utils.FixAndCleanPath(path))
+	if err != nil {
+		return nil, notFound
+	}
+	return op.FindCollision(ctx, storage, actualPath)
+}
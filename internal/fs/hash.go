@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ComputableHashes are the hash types which can be computed from the content alone,
+// driver specific ones may need extra params such as the size
+var ComputableHashes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// ComputeHashes reads the file at path once and computes all the hash types
+func ComputeHashes(ctx context.Context, path string, obj model.Obj, types []*utils.HashType) (utils.HashInfo, error) {
+	if obj.IsDir() {
+		return utils.HashInfo{}, errors.New("can't compute hash of a folder")
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessage(err, "failed get storage")
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed get [%s] stream", path)
+	}
+	defer ss.Close()
+	hasher := utils.NewMultiHasher(types)
+	if _, err = utils.CopyWithBuffer(hasher, ss); err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed read [%s]", path)
+	}
+	return *hasher.GetHashInfo(), nil
+}
+
+type FileHashResult struct {
+	// Hashes are the hex digests by hash name
+	Hashes map[string]string `json:"hashes"`
+	// Sources tell where each hash comes from: driver, cache or computed
+	Sources map[string]string `json:"sources"`
+}
+
+// FileHashes returns the hashes of the file at path, taken from the driver when
+// it provides them, else from the recorded ones if the file is unchanged, and
+// the missing ones are computed by reading the file once then recorded
+func FileHashes(ctx context.Context, path string, types []*utils.HashType, refresh bool) (*FileHashResult, error) {
+	obj, err := get(ctx, path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	if obj.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	res := &FileHashResult{Hashes: map[string]string{}, Sources: map[string]string{}}
+	found := func(hi utils.HashInfo, source string) []*utils.HashType {
+		var missing []*utils.HashType
+		for _, t := range types {
+			if _, ok := res.Hashes[t.Name]; ok {
+				continue
+			}
+			if v := hi.GetHash(t); v != "" {
+				res.Hashes[t.Name], res.Sources[t.Name] = v, source
+			} else {
+				missing = append(missing, t)
+			}
+		}
+		return missing
+	}
+	missing := found(obj.GetHash(), "driver")
+	if len(missing) == 0 {
+		return res, nil
+	}
+	recorded := utils.NewHashInfo(nil, "")
+	if record, err := db.GetFileHash(path); err == nil &&
+		record.Size == obj.GetSize() && record.Modified.Equal(obj.ModTime()) {
+		recorded = utils.FromString(record.HashInfoStr)
+		if !refresh {
+			missing = found(recorded, "cache")
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	computed, err := ComputeHashes(ctx, path, obj, missing)
+	if err != nil {
+		return nil, err
+	}
+	found(computed, "computed")
+	// the recorded hashes of other types are kept
+	all := recorded.Export()
+	for t, v := range computed.All() {
+		all[t] = v
+	}
+	err = db.SaveFileHash(&model.FileHash{
+		Path:        path,
+		Size:        obj.GetSize(),
+		Modified:    obj.ModTime(),
+		HashInfoStr: utils.NewHashInfoByMap(all).String(),
+	})
+	if err != nil {
+		log.Warnf("failed record hashes of %s: %+v", path, err)
+	}
+	return res, nil
+}
+
+// VerifyHashes checks the file at path against the expected hex digests, the
+// hashes are taken from the driver when it provides them, else computed
+func VerifyHashes(ctx context.Context, path string, expected map[*utils.HashType]string) (map[string]string, error) {
+	types := make([]*utils.HashType, 0, len(expected))
+	for t := range expected {
+		types = append(types, t)
+	}
+	res, err := FileHashes(ctx, path, types, true)
+	if err != nil {
+		return nil, err
+	}
+	for t, v := range expected {
+		if !strings.EqualFold(res.Hashes[t.Name], v) {
+			return res.Hashes, errors.Errorf("%s mismatch: expected %s, got %s", t.Name, v, res.Hashes[t.Name])
+		}
+	}
+	return res.Hashes, nil
+}
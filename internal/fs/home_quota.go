@@ -0,0 +1,44 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// homeObjSize returns the size of the obj at path, the content of a dir is
+// walked. It's 0 when no home quota holds path or one of others, the size
+// isn't needed then.
+func homeObjSize(ctx context.Context, path string, others ...string) int64 {
+	held := false
+	for _, p := range append([]string{path}, others...) {
+		if users, err := op.HomeQuotaUsers(p); err == nil && len(users) > 0 {
+			held = true
+			break
+		}
+	}
+	if !held {
+		return 0
+	}
+	obj, err := get(ctx, path)
+	if err != nil {
+		return 0
+	}
+	if !obj.IsDir() {
+		return obj.GetSize()
+	}
+	res := &DirSizeResult{}
+	if err = walkDirSize(ctx, nil, path, "", res, 0); err != nil {
+		log.Warnf("failed walk [%s] for home usage: %+v", path, err)
+	}
+	return res.Size
+}
+
+// countHomeUsed counts the bytes written at path for the home quotas, a
+// failure is logged but doesn't fail the write which is done
+func countHomeUsed(path string, delta int64) {
+	if err := op.AddHomeUsed(path, delta); err != nil {
+		log.Errorf("failed count home usage of [%s]: %+v", path, err)
+	}
+}
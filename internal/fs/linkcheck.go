@@ -0,0 +1,197 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// a chain of links longer than this is reported as broken, like ELOOP
+const maxLinkHops = 40
+
+type BrokenLink struct {
+	Path string `json:"path"`
+	// Target is the target as stored in the link
+	Target string `json:"target"`
+	// Resolved is the target as a path in alist, empty if it's out of the storage
+	Resolved string `json:"resolved,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type LinkCheckReport struct {
+	Scanned int `json:"scanned"`
+	Links   int `json:"links"`
+	// Cycles are the chains of links leading back to one of them or to a dir
+	// containing them, each chain ends with the path it loops to
+	Cycles   [][]string   `json:"cycles"`
+	Dangling []BrokenLink `json:"dangling"`
+	// Outside are the links whose target is out of their storage, they aren't followed
+	Outside []BrokenLink `json:"outside"`
+	// Skipped are the dirs which can't be listed
+	Skipped []string `json:"skipped"`
+}
+
+// CheckLinks walks path and follows the link objs found, reporting the cycles and
+// the targets which don't exist
+func CheckLinks(ctx context.Context, path string) (*LinkCheckReport, error) {
+	obj, err := get(ctx, path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	c := &linkChecker{
+		report:   &LinkCheckReport{},
+		links:    map[string]string{},
+		cycles:   map[string]struct{}{},
+		dangling: map[string]struct{}{},
+	}
+	var paths []string
+	c.visit(path, obj, &paths)
+	if obj.IsDir() {
+		if err = c.walk(ctx, path, &paths); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range paths {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		c.follow(ctx, p)
+	}
+	return c.report, nil
+}
+
+type linkChecker struct {
+	report *LinkCheckReport
+	// links are the targets of the link objs found, by path
+	links    map[string]string
+	cycles   map[string]struct{}
+	dangling map[string]struct{}
+}
+
+func (c *linkChecker) visit(path string, obj model.Obj, paths *[]string) {
+	c.report.Scanned++
+	if target, ok := model.GetLinkTarget(obj); ok {
+		c.report.Links++
+		c.links[path] = target
+		*paths = append(*paths, path)
+	}
+}
+
+func (c *linkChecker) walk(ctx context.Context, path string, paths *[]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	meta, _ := op.GetNearestMeta(path)
+	objs, err := List(context.WithValue(ctx, "meta", meta), path, &ListArgs{NoLog: true})
+	if err != nil {
+		c.report.Skipped = append(c.report.Skipped, path)
+		return nil
+	}
+	for _, o := range objs {
+		objPath := stdpath.Join(path, o.GetName())
+		c.visit(objPath, o, paths)
+		// the links aren't walked into, they are followed afterwards
+		if _, isLink := c.links[objPath]; o.IsDir() && !isLink {
+			if err := c.walk(ctx, objPath, paths); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// follow resolves the chain of links starting at path until it reaches an obj
+// which isn't a link, a missing target or a path already in the chain
+func (c *linkChecker) follow(ctx context.Context, path string) {
+	chain := []string{path}
+	seen := map[string]int{path: 0}
+	cur, target := path, c.links[path]
+	for {
+		resolved, ok := resolveLinkTarget(cur, target)
+		if !ok {
+			if cur == path {
+				c.report.Outside = append(c.report.Outside, BrokenLink{Path: cur, Target: target})
+			}
+			return
+		}
+		// a link to its own dir or an ancestor loops when walked
+		if utils.IsSubPath(resolved, cur) {
+			c.addCycle(append(chain, resolved))
+			return
+		}
+		if i, ok := seen[resolved]; ok {
+			c.addCycle(append(chain[i:], resolved))
+			return
+		}
+		if len(chain) > maxLinkHops {
+			c.addDangling(BrokenLink{Path: path, Target: c.links[path], Resolved: resolved, Error: "too many levels of links"})
+			return
+		}
+		next, ok := c.links[resolved]
+		if !ok {
+			obj, err := Get(ctx, resolved, &GetArgs{NoLog: true})
+			if err != nil {
+				c.addDangling(BrokenLink{Path: cur, Target: target, Resolved: resolved, Error: err.Error()})
+				return
+			}
+			if next, ok = model.GetLinkTarget(obj); !ok {
+				return
+			}
+			c.links[resolved] = next
+		}
+		seen[resolved] = len(chain)
+		chain = append(chain, resolved)
+		cur, target = resolved, next
+	}
+}
+
+func (c *linkChecker) addCycle(chain []string) {
+	// the same cycle is found from each of its links, the key ignores where it starts
+	nodes := append([]string(nil), chain[:len(chain)-1]...)
+	sort.Strings(nodes)
+	key := strings.Join(nodes, "\x00") + "\x00" + chain[len(chain)-1]
+	if _, ok := c.cycles[key]; ok {
+		return
+	}
+	c.cycles[key] = struct{}{}
+	c.report.Cycles = append(c.report.Cycles, append([]string(nil), chain...))
+}
+
+func (c *linkChecker) addDangling(b BrokenLink) {
+	if _, ok := c.dangling[b.Path]; ok {
+		return
+	}
+	c.dangling[b.Path] = struct{}{}
+	c.report.Dangling = append(c.report.Dangling, b)
+}
+
+// resolveLinkTarget maps the target of the link at path to a path in alist. Relative
+// targets are relative to the dir of the link in the backend, absolute ones are
+// backend paths and must be under the root folder of the storage.
+func resolveLinkTarget(path, target string) (string, bool) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return "", false
+	}
+	root := "/"
+	if r, ok := storage.GetAddition().(driver.IRootPath); ok {
+		root = stdpath.Join("/", filepath.ToSlash(r.GetRootPath()))
+	}
+	target = filepath.ToSlash(target)
+	if !stdpath.IsAbs(target) {
+		target = stdpath.Join(stdpath.Dir(stdpath.Join(root, actualPath)), target)
+	}
+	target = stdpath.Clean(target)
+	if !utils.IsSubPath(root, target) {
+		return "", false
+	}
+	return stdpath.Join(storage.GetStorage().MountPath, strings.TrimPrefix(target, root)), true
+}
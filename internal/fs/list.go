@@ -2,7 +2,10 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
+	"slices"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -11,10 +14,28 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// inDropFolder reports whether path is in a file drop for the user in ctx, its
+// content is neither listed nor downloaded whichever protocol is used
+func inDropFolder(ctx context.Context, path string) bool {
+	user, _ := ctx.Value("user").(*model.User)
+	if user == nil {
+		return false
+	}
+	meta, _ := op.GetNearestMeta(path)
+	return common.InDropFolder(user, meta, path)
+}
+
 // List files
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
 	meta, _ := ctx.Value("meta").(*model.Meta)
 	user, _ := ctx.Value("user").(*model.User)
+	// a file drop is listed empty, only to be uploaded to, whichever way it's listed
+	if inDropFolder(ctx, path) {
+		return []model.Obj{}, nil
+	}
+	if managedDirDenied(ctx, path) {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
 	virtualFiles := op.GetStorageVirtualFilesByPath(path)
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil && len(virtualFiles) == 0 {
@@ -33,11 +54,23 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				log.Errorf("fs/list: %+v", err)
 			}
 			if len(virtualFiles) == 0 {
+				// the archives are browsed as if they were dirs
+				if archivePath, innerPath, ok := SplitArchivePath(ctx, path); ok {
+					return archiveList(ctx, archivePath, archiveInnerListArgs(innerPath, args.ArchivePass, args.Refresh))
+				}
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
 	}
 
+	// the trash and the previous versions are managed through their own api
+	if storage != nil && user != nil && !user.IsAdmin() {
+		vDir := versionDir(storage)
+		_objs = slices.DeleteFunc(_objs, func(obj model.Obj) bool {
+			p := stdpath.Join(actualPath, obj.GetName())
+			return p == "/"+TrashDirName || p == vDir
+		})
+	}
 	om := model.NewObjMerge()
 	if whetherHide(user, meta, path) {
 		om.InitHideReg(meta.Hide)
@@ -0,0 +1,257 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+const (
+	// the number of the longest paths reported
+	longestPathsCount = 10
+	// a shortened name keeps at least this many units of its stem
+	minTrimmedStem = 1
+)
+
+type LongPath struct {
+	Path string `json:"path"`
+	// BackendPath is the path in the backend, including the root folder of the storage
+	BackendPath string `json:"backend_path"`
+	Length      int    `json:"length"`
+	// Suggested is the shortened name, empty if the path can't be fixed by renaming
+	// the obj itself, for example when its parent path alone is too long
+	Suggested string `json:"suggested,omitempty"`
+}
+
+type LongPathReport struct {
+	Scanned int        `json:"scanned"`
+	Over    []LongPath `json:"over"`
+	Longest []LongPath `json:"longest"`
+	// Skipped are the dirs which can't be listed or accessed
+	Skipped []string `json:"skipped"`
+}
+
+type LongPathArgs struct {
+	Limit int
+	// CountBytes measures the paths in bytes instead of characters
+	CountBytes bool
+	Password   string
+}
+
+// ScanLongPaths walks path and reports the objs whose backend path is longer than
+// the limit. The suggestions are computed top-down, so the paths of the children
+// of a shortened dir are measured as if the dir was already renamed.
+func ScanLongPaths(ctx context.Context, path string, args LongPathArgs) (*LongPathReport, error) {
+	if args.Limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	obj, err := get(ctx, path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	s := &longPathScanner{args: args, report: &LongPathReport{}}
+	if s.args.CountBytes {
+		s.measure = func(str string) int { return len(str) }
+	} else {
+		s.measure = utf8.RuneCountInString
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	s.user = user
+	backend, err := backendPath(path)
+	if err != nil {
+		return nil, err
+	}
+	s.visit(path, backend, obj, nil)
+	if obj.IsDir() {
+		if err = s.walk(ctx, path, backend, args.Password); err != nil {
+			return nil, err
+		}
+	}
+	return s.report, nil
+}
+
+type longPathScanner struct {
+	args    LongPathArgs
+	user    *model.User
+	measure func(string) int
+	report  *LongPathReport
+}
+
+// backendPath returns the path of the obj in the backend, where the storage is mounted
+func backendPath(path string) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get storage")
+	}
+	if r, ok := storage.GetAddition().(driver.IRootPath); ok {
+		return stdpath.Join("/", r.GetRootPath(), actualPath), nil
+	}
+	return actualPath, nil
+}
+
+func (s *longPathScanner) walk(ctx context.Context, path, backend, password string) error {
+	// the backend paths of the dirs to walk, which take the suggested names
+	backends := map[string]string{path: backend}
+	return WalkAccessible(ctx, s.user, path, password, func(reqPath string, objs []model.Obj, err error) ([]model.Obj, error) {
+		backend := backends[reqPath]
+		delete(backends, reqPath)
+		if err != nil {
+			s.report.Skipped = append(s.report.Skipped, reqPath)
+			return nil, nil
+		}
+		storage, _, err := op.GetStorageAndActualPath(reqPath)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get storage")
+		}
+		// the names a suggestion must not collide with
+		nameKey := storage.GetStorage().NameKey
+		taken := make(map[string]struct{}, len(objs))
+		for _, o := range objs {
+			taken[nameKey(o.GetName())] = struct{}{}
+		}
+		isTaken := func(name string) bool {
+			_, ok := taken[nameKey(name)]
+			return ok
+		}
+		var dirs []model.Obj
+		for _, o := range objs {
+			objPath := stdpath.Join(reqPath, o.GetName())
+			objBackend := stdpath.Join(backend, o.GetName())
+			if suggested := s.visit(objPath, objBackend, o, isTaken); suggested != "" {
+				taken[nameKey(suggested)] = struct{}{}
+				objBackend = stdpath.Join(backend, suggested)
+			}
+			if o.IsDir() {
+				backends[objPath] = objBackend
+				dirs = append(dirs, o)
+			}
+		}
+		return dirs, nil
+	})
+}
+
+// visit records the obj and returns its shortened name if it's over the limit
+func (s *longPathScanner) visit(path, backend string, obj model.Obj, isTaken func(string) bool) string {
+	s.report.Scanned++
+	lp := LongPath{Path: path, BackendPath: backend, Length: s.measure(backend)}
+	s.addLongest(lp)
+	if lp.Length <= s.args.Limit {
+		return ""
+	}
+	lp.Suggested = s.shorten(obj.GetName(), obj.IsDir(), lp.Length-s.args.Limit, isTaken)
+	s.report.Over = append(s.report.Over, lp)
+	return lp.Suggested
+}
+
+func (s *longPathScanner) addLongest(lp LongPath) {
+	longest := s.report.Longest
+	i := sort.Search(len(longest), func(i int) bool { return longest[i].Length < lp.Length })
+	if i >= longestPathsCount {
+		return
+	}
+	longest = append(longest, LongPath{})
+	copy(longest[i+1:], longest[i:])
+	longest[i] = lp
+	if len(longest) > longestPathsCount {
+		longest = longest[:longestPathsCount]
+	}
+	s.report.Longest = longest
+}
+
+// shorten trims the stem of name by excess, keeping the extension of files and
+// appending a hash of the original name so trimmed names sharing a prefix differ.
+// The hash is changed until the name isn't taken.
+func (s *longPathScanner) shorten(name string, isDir bool, excess int, isTaken func(string) bool) string {
+	stem, ext := name, ""
+	if !isDir {
+		ext = stdpath.Ext(name)
+		stem = name[:len(name)-len(ext)]
+	}
+	// every hash has the same length
+	target := s.measure(stem) - excess - s.measure("~"+nameHash(name, 0))
+	if target < minTrimmedStem {
+		return ""
+	}
+	for s.measure(stem) > target {
+		_, size := utf8.DecodeLastRuneInString(stem)
+		stem = stem[:len(stem)-size]
+	}
+	for i := 0; ; i++ {
+		suggested := stem + "~" + nameHash(name, i) + ext
+		if isTaken == nil || !isTaken(suggested) {
+			return suggested
+		}
+	}
+}
+
+func nameHash(name string, attempt int) string {
+	sum := sha1.Sum([]byte(name + "\x00" + strconv.Itoa(attempt)))
+	return hex.EncodeToString(sum[:])[:6]
+}
+
+// LongPathTrimTask applies the renames suggested by ScanLongPaths
+type LongPathTrimTask struct {
+	task.TaskExtension
+	Status  string     `json:"-"`
+	Path    string     `json:"path"`
+	Renames []LongPath `json:"renames"`
+	Renamed int        `json:"renamed"`
+	// Failed are the paths which weren't renamed and why
+	Failed map[string]string `json:"failed"`
+}
+
+var LongPathTrimTaskManager *tache.Manager[*LongPathTrimTask]
+
+var _ task.TaskExtensionInfo = (*LongPathTrimTask)(nil)
+
+func (t *LongPathTrimTask) GetName() string {
+	return fmt.Sprintf("trim long paths under [%s]", t.Path)
+}
+
+func (t *LongPathTrimTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *LongPathTrimTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Renamed, t.Failed = 0, map[string]string{}
+	ctx := context.WithValue(t.Ctx(), "user", t.GetCreator())
+	// the renames were collected top-down, children are renamed before their
+	// parents so their paths are still valid
+	for i := len(t.Renames) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r := t.Renames[i]
+		t.Status = "renaming " + r.Path
+		dstPath := stdpath.Join(stdpath.Dir(r.Path), r.Suggested)
+		if res, _ := Get(ctx, dstPath, &GetArgs{NoLog: true, Collision: true}); res != nil {
+			t.Failed[r.Path] = fmt.Sprintf("[%s] exists", res.GetName())
+			continue
+		}
+		if err := Rename(ctx, r.Path, r.Suggested); err != nil {
+			t.Failed[r.Path] = err.Error()
+			continue
+		}
+		t.Renamed++
+		t.SetProgress(float64(len(t.Renames)-i) / float64(len(t.Renames)) * 100)
+	}
+	t.Status = fmt.Sprintf("done, %d of %d renamed", t.Renamed, len(t.Renames))
+	return nil
+}
@@ -0,0 +1,240 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// MoveTask moves an obj to another storage by copying each file then removing it
+// from the src. A file which fails is kept in the src and the task goes on, the
+// dirs still holding such files aren't removed.
+type MoveTask struct {
+	task.TaskExtension
+	Status       string        `json:"-"`
+	SrcObjPath   string        `json:"src_path"`
+	DstDirPath   string        `json:"dst_path"`
+	srcStorage   driver.Driver `json:"-"`
+	dstStorage   driver.Driver `json:"-"`
+	SrcStorageMp string        `json:"src_storage_mp"`
+	DstStorageMp string        `json:"dst_storage_mp"`
+	// Moved are the paths of the moved files, relative to the src storage
+	Moved []string `json:"moved"`
+	// Failed are the files kept in the src and why
+	Failed map[string]string `json:"failed"`
+	// MtimeLost are the moved files whose modification time the dst storage
+	// didn't keep, only checked with move_preserve_mtime
+	MtimeLost []string `json:"mtime_lost"`
+	// IncludeAll moves the objs matching recursive_exclude too
+	IncludeAll bool `json:"include_all,omitempty"`
+	// Excluded is the number of children left in the src by recursive_exclude
+	Excluded int `json:"excluded,omitempty"`
+}
+
+var MoveTaskManager *tache.Manager[*MoveTask]
+
+var _ task.TaskExtensionInfo = (*MoveTask)(nil)
+
+func (t *MoveTask) GetName() string {
+	return fmt.Sprintf("move [%s](%s) to [%s](%s)", t.SrcStorageMp, t.SrcObjPath, t.DstStorageMp, t.DstDirPath)
+}
+
+func (t *MoveTask) GetStatus() string {
+	if t.Excluded > 0 {
+		return fmt.Sprintf("%s (%d excluded)", t.Status, t.Excluded)
+	}
+	return t.Status
+}
+
+func (t *MoveTask) Retryable() bool {
+	return t.RetryableAs("move")
+}
+
+func (t *MoveTask) OnBeforeRetry() {
+	t.WaitBackoffAs("move")
+}
+
+func (t *MoveTask) OnSucceeded() {
+	t.fireWebhook()
+	t.recordMove(nil)
+}
+
+func (t *MoveTask) OnFailed() {
+	t.fireWebhook()
+	t.recordMove(t.GetErr())
+}
+
+func (t *MoveTask) recordMove(err error) {
+	srcPath := stdpath.Join(t.SrcStorageMp, t.SrcObjPath)
+	recordChange(t.Ctx(), model.AuditMove, stdpath.Join(t.DstStorageMp, t.DstDirPath, stdpath.Base(t.SrcObjPath)), srcPath, err)
+}
+
+func (t *MoveTask) fireWebhook() {
+	task.FireWebhook(t, "move", stdpath.Join(t.SrcStorageMp, t.SrcObjPath), stdpath.Join(t.DstStorageMp, t.DstDirPath))
+}
+
+func (t *MoveTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	var err error
+	if t.srcStorage == nil {
+		if t.srcStorage, err = op.GetStorageByMountPath(t.SrcStorageMp); err != nil {
+			return errors.WithMessage(err, "failed get src storage")
+		}
+	}
+	if t.dstStorage == nil {
+		if t.dstStorage, err = op.GetStorageByMountPath(t.DstStorageMp); err != nil {
+			return errors.WithMessage(err, "failed get dst storage")
+		}
+	}
+	// a retry only moves what is left in the src
+	t.Moved, t.Failed, t.MtimeLost, t.Excluded = nil, map[string]string{}, nil, 0
+	if err = t.moveTree(t.SrcObjPath, t.DstDirPath); err != nil {
+		return err
+	}
+	status := fmt.Sprintf("%d moved", len(t.Moved))
+	if setting.GetBool(conf.MovePreserveMtime) {
+		status += fmt.Sprintf(", %d with mtime kept", len(t.Moved)-len(t.MtimeLost))
+	}
+	if len(t.Failed) > 0 {
+		t.Status = fmt.Sprintf("%s, %d failed", status, len(t.Failed))
+		return errors.Errorf("failed to move %d files", len(t.Failed))
+	}
+	t.Status = status
+	return nil
+}
+
+// moveTree moves srcObjPath into dstDirPath, it only fails when the task can't
+// go on, the failures of single files are recorded in Failed
+func (t *MoveTask) moveTree(srcObjPath, dstDirPath string) error {
+	if err := t.Ctx().Err(); err != nil {
+		return err
+	}
+	srcObj, err := op.Get(t.Ctx(), t.srcStorage, srcObjPath)
+	if err != nil {
+		t.Failed[srcObjPath] = errors.WithMessage(err, "failed get src").Error()
+		return nil
+	}
+	if !srcObj.IsDir() {
+		t.Status = "moving " + srcObjPath
+		if err := t.moveFile(srcObjPath, srcObj, dstDirPath); err != nil {
+			if t.Ctx().Err() != nil {
+				return t.Ctx().Err()
+			}
+			t.Failed[srcObjPath] = err.Error()
+		} else {
+			t.Moved = append(t.Moved, srcObjPath)
+		}
+		return nil
+	}
+	dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
+	if err := op.MakeDir(t.Ctx(), t.dstStorage, dstObjPath); err != nil {
+		t.Failed[srcObjPath] = errors.WithMessage(err, "failed make dst dir").Error()
+		return nil
+	}
+	objs, err := op.List(t.Ctx(), t.srcStorage, srcObjPath, model.ListArgs{Refresh: true})
+	if err != nil {
+		t.Failed[srcObjPath] = errors.WithMessage(err, "failed list src").Error()
+		return nil
+	}
+	// the excluded objs are left in the src, with the dir holding them
+	objs, excluded := filterExcluded(objs, t.IncludeAll)
+	t.Excluded += excluded
+	failed := len(t.Failed)
+	for _, obj := range objs {
+		if err := t.moveTree(stdpath.Join(srcObjPath, obj.GetName()), dstObjPath); err != nil {
+			return err
+		}
+	}
+	// the dir is only removed once everything under it has been moved
+	if len(t.Failed) == failed && excluded == 0 {
+		if err := op.Remove(t.Ctx(), t.srcStorage, srcObjPath); err != nil {
+			t.Failed[srcObjPath] = errors.WithMessage(err, "moved the content but failed remove the src dir").Error()
+		}
+	}
+	return nil
+}
+
+func (t *MoveTask) moveFile(srcFilePath string, srcFile model.Obj, dstDirPath string) error {
+	defer op.KeepStorage(t.srcStorage)()
+	link, _, err := op.Link(t.Ctx(), t.srcStorage, srcFilePath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
+	}
+	// the storages which can set the mtime take the one of the stream
+	preserve := setting.GetBool(conf.MovePreserveMtime)
+	obj := srcFile
+	if !preserve {
+		obj = &model.Object{Name: srcFile.GetName(), Size: srcFile.GetSize(), Modified: time.Now(), HashInfo: srcFile.GetHash()}
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: t.Ctx()}, link)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
+	}
+	t.SetTotalBytes(srcFile.GetSize())
+	if err = op.Put(t.Ctx(), t.dstStorage, dstDirPath, ss, t.SetProgress, true); err != nil {
+		return err
+	}
+	if preserve && !t.mtimeKept(stdpath.Join(dstDirPath, srcFile.GetName()), srcFile.ModTime()) {
+		t.MtimeLost = append(t.MtimeLost, srcFilePath)
+	}
+	return errors.WithMessage(op.Remove(t.Ctx(), t.srcStorage, srcFilePath), "copied but failed remove the src")
+}
+
+// mtimeKept reports whether the dst file has the mtime of the src, to the
+// second as many storages don't keep less
+func (t *MoveTask) mtimeKept(dstFilePath string, mtime time.Time) bool {
+	dst, err := op.Get(t.Ctx(), t.dstStorage, dstFilePath)
+	if err != nil {
+		return false
+	}
+	d := dst.ModTime().Sub(mtime)
+	return d > -time.Second && d < time.Second
+}
+
+// moveAsTask moves within a storage directly, and adds a MoveTask to move
+// between storages
+func moveAsTask(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+		return nil, op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &MoveTask{
+		TaskExtension: task.TaskExtension{
+			Creator: taskCreator,
+		},
+		srcStorage:   srcStorage,
+		dstStorage:   dstStorage,
+		SrcObjPath:   srcActualPath,
+		DstDirPath:   dstDirActualPath,
+		SrcStorageMp: srcStorage.GetStorage().MountPath,
+		DstStorageMp: dstStorage.GetStorage().MountPath,
+		IncludeAll:   ctx.Value(conf.IncludeAllKey) != nil,
+	}
+	MoveTaskManager.Add(t)
+	return t, nil
+}
@@ -34,7 +34,14 @@ func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	if srcStorage.GetStorage() != dstStorage.GetStorage() {
 		return errors.WithStack(errs.MoveBetweenTwoStorages)
 	}
-	return op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
+	dstPath := stdpath.Join(dstDirPath, stdpath.Base(srcPath))
+	size := homeObjSize(ctx, srcPath, dstPath)
+	if err = op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...); err != nil {
+		return err
+	}
+	countHomeUsed(srcPath, -size)
+	countHomeUsed(dstPath, size)
+	return nil
 }
 
 func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
@@ -42,6 +49,12 @@ func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	// an overwritten file is kept as a version of its path
+	if dstPath := stdpath.Join(stdpath.Dir(srcPath), dstName); dstPath != srcPath {
+		if err = keepVersion(ctx, dstPath); err != nil {
+			return errors.WithMessage(err, "failed keep the previous version")
+		}
+	}
 	return op.Rename(ctx, storage, srcActualPath, dstName, lazyCache...)
 }
 
@@ -50,7 +63,12 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Remove(ctx, storage, actualPath)
+	size := homeObjSize(ctx, path)
+	if err = op.Remove(ctx, storage, actualPath); err != nil {
+		return err
+	}
+	countHomeUsed(path, -size)
+	return nil
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
@@ -3,36 +3,211 @@ package fs
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/xhofe/tache"
-	"time"
 )
 
+// UploadTaskDirName is the dir of the temp dir keeping the content of the
+// persisted upload tasks, it survives the cleaning of the temp dir
+const UploadTaskDirName = "upload_tasks"
+
 type UploadTask struct {
 	task.TaskExtension
 	storage          driver.Driver
-	dstDirActualPath string
 	file             model.FileStreamer
+	StorageMp        string    `json:"storage_mp"`
+	DstDirActualPath string    `json:"dst_dir_path"`
+	Name             string    `json:"name"`
+	Size             int64     `json:"size"`
+	Mimetype         string    `json:"mimetype"`
+	Modified         time.Time `json:"modified"`
+	// KeptPath is a copy of the content for the task to be resumed after a
+	// restart, only the tasks having one are persisted
+	KeptPath string `json:"kept_path,omitempty"`
+	// ReplacedSize is the size of the file overwritten, for the home usage
+	ReplacedSize int64 `json:"replaced_size"`
+	// Put tells the content was put by a run, the dst may have been replaced by
+	// another content of the same size since, so it can't be told by the obj
+	Put    bool `json:"put,omitempty"`
+	status string
 }
 
 func (t *UploadTask) GetName() string {
-	return fmt.Sprintf("upload %s to [%s](%s)", t.file.GetName(), t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	return fmt.Sprintf("upload %s to [%s](%s)", t.Name, t.StorageMp, t.DstDirActualPath)
 }
 
 func (t *UploadTask) GetStatus() string {
-	return "uploading"
+	return t.status
+}
+
+func (t *UploadTask) Persistable() bool {
+	return t.KeptPath != ""
+}
+
+func (t *UploadTask) Recoverable() bool {
+	_, err := os.Stat(t.KeptPath)
+	return err == nil
 }
 
 func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	var err error
+	if t.storage == nil {
+		if t.storage, err = op.GetStorageByMountPath(t.StorageMp); err != nil {
+			return errors.WithMessage(err, "failed get storage")
+		}
+	}
+	dstPath := stdpath.Join(t.StorageMp, t.DstDirActualPath, t.Name)
+	if t.Put {
+		// a run interrupted by a restart after the put, only the usage is left
+		t.status = "done by a previous run"
+		return t.countUsed(dstPath)
+	}
+	if t.file == nil {
+		if t.file, err = t.keptStream(); err != nil {
+			return err
+		}
+	}
+	t.status = "waiting for a task slot"
+	release, err := op.AcquireTask(t.Ctx(), t.storage)
+	if err != nil {
+		return err
+	}
+	defer release()
+	t.status = "uploading"
+	err = op.Put(t.Ctx(), t.storage, t.DstDirActualPath, t.file, t.SetProgress, true)
+	if t.KeptPath != "" {
+		// the stream is closed by the put, a retry reads the kept content again
+		t.file = nil
+	}
+	if err != nil {
+		return err
+	}
+	t.Put = true
+	t.Persist()
+	return t.countUsed(dstPath)
+}
+
+func (t *UploadTask) countUsed(dstPath string) error {
+	countHomeUsed(dstPath, t.Size-t.ReplacedSize)
+	return op.AddUploadUsed(t.GetCreator(), t.Size)
+}
+
+func (t *UploadTask) keptStream() (model.FileStreamer, error) {
+	if t.KeptPath == "" {
+		return nil, errors.New("the content of the upload is lost")
+	}
+	f, err := os.Open(t.KeptPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &stream.FileStream{
+		Ctx: t.Ctx(),
+		Obj: &model.Object{
+			Name:     t.Name,
+			Size:     t.Size,
+			Modified: t.Modified,
+		},
+		Reader:   f,
+		Mimetype: t.Mimetype,
+	}
+	s.Closers.Add(f)
+	return s, nil
+}
+
+func (t *UploadTask) Retryable() bool {
+	return t.RetryableAs("upload")
+}
+
+func (t *UploadTask) OnBeforeRetry() {
+	t.WaitBackoffAs("upload")
+}
+
+func (t *UploadTask) OnSucceeded() {
+	t.dropKept()
+	t.fireWebhook()
+}
+
+func (t *UploadTask) OnFailed() {
+	t.dropKept()
+	t.fireWebhook()
+}
+
+func (t *UploadTask) fireWebhook() {
+	task.FireWebhook(t, "upload", "", stdpath.Join(t.StorageMp, t.DstDirActualPath, t.Name))
+}
+
+func (t *UploadTask) dropKept() {
+	if t.KeptPath == "" {
+		return
+	}
+	if err := os.Remove(t.KeptPath); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove kept upload %s: %+v", t.KeptPath, err)
+	}
+	t.KeptPath = ""
+}
+
+// keepUpload copies the cached content of an upload to the upload task dir
+// under name, a hard link is tried first
+func keepUpload(name string, cached model.File) (string, error) {
+	dir := filepath.Join(conf.Conf.TempDir, UploadTaskDirName)
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return "", errors.WithStack(err)
+	}
+	path := filepath.Join(dir, name)
+	if f, ok := cached.(*os.File); ok && os.Link(f.Name(), path) == nil {
+		return path, nil
+	}
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	_, err = utils.CopyWithBuffer(dst, io.NewSectionReader(cached, 0, 1<<62))
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", errors.WithStack(err)
+	}
+	return path, nil
+}
+
+// CleanUploadTaskDir removes the kept contents of no upload task
+func CleanUploadTaskDir() {
+	dir := filepath.Join(conf.Conf.TempDir, UploadTaskDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	kept := make(map[string]struct{})
+	for _, t := range UploadTaskManager.GetAll() {
+		kept[t.KeptPath] = struct{}{}
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if _, ok := kept[path]; !ok {
+			_ = os.RemoveAll(path)
+		}
+	}
 }
 
 var UploadTaskManager *tache.Manager[*UploadTask]
@@ -46,22 +221,49 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	taskCreator, _ := ctx.Value("user").(*model.User) // taskCreator is nil when convert failed
+	if err = op.CheckUploadQuota(taskCreator, file.GetSize()); err != nil {
+		return nil, err
+	}
+	dstPath := stdpath.Join(dstDirPath, file.GetName())
+	replacedSize := homeObjSize(ctx, dstPath)
+	if err = op.CheckHomeQuota(dstPath, file.GetSize()-replacedSize); err != nil {
+		return nil, err
+	}
+	if err = keepVersion(ctx, dstPath); err != nil {
+		return nil, errors.WithMessage(err, "failed keep the previous version")
+	}
+	var cached model.File
 	if file.NeedStore() {
-		_, err := file.CacheFullInTempFile()
+		cached, err = file.CacheFullInTempFile()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create temp file")
 		}
 		//file.SetReader(tempFile)
 		//file.SetTmpFile(tempFile)
+	} else {
+		cached = file.GetFile()
 	}
-	taskCreator, _ := ctx.Value("user").(*model.User) // taskCreator is nil when convert failed
 	t := &UploadTask{
 		TaskExtension: task.TaskExtension{
 			Creator: taskCreator,
 		},
 		storage:          storage,
-		dstDirActualPath: dstDirActualPath,
 		file:             file,
+		StorageMp:        storage.GetStorage().MountPath,
+		DstDirActualPath: dstDirActualPath,
+		Name:             file.GetName(),
+		Size:             file.GetSize(),
+		Mimetype:         file.GetMimetype(),
+		Modified:         file.ModTime(),
+		ReplacedSize:     replacedSize,
+		status:           "uploading",
+	}
+	if conf.Conf.Tasks.Upload.TaskPersistant && cached != nil {
+		// the content must outlive the request for the task to be resumed
+		if t.KeptPath, err = keepUpload(random.String(32), cached); err != nil {
+			log.Warnf("failed keep upload of %s, it can't be resumed after a restart: %+v", dstPath, err)
+		}
 	}
 	t.SetTotalBytes(file.GetSize())
 	UploadTaskManager.Add(t)
@@ -77,5 +279,21 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	if storage.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
+	user, _ := ctx.Value("user").(*model.User)
+	if err = op.CheckUploadQuota(user, file.GetSize()); err != nil {
+		return err
+	}
+	dstPath := stdpath.Join(dstDirPath, file.GetName())
+	replacedSize := homeObjSize(ctx, dstPath)
+	if err = op.CheckHomeQuota(dstPath, file.GetSize()-replacedSize); err != nil {
+		return err
+	}
+	if err = keepVersion(ctx, dstPath); err != nil {
+		return errors.WithMessage(err, "failed keep the previous version")
+	}
+	if err = op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...); err != nil {
+		return err
+	}
+	countHomeUsed(dstPath, file.GetSize()-replacedSize)
+	return op.AddUploadUsed(user, file.GetSize())
 }
@@ -0,0 +1,135 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"slices"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// RehashTask walks a path and refreshes the hashes recorded for its files
+// when their size or modification time changed since they were recorded.
+type RehashTask struct {
+	task.TaskExtension
+	Status  string   `json:"-"`
+	Path    string   `json:"path"`
+	Updated []string `json:"updated"`
+}
+
+var RehashTaskManager *tache.Manager[*RehashTask]
+
+var _ task.TaskExtensionInfo = (*RehashTask)(nil)
+
+func (t *RehashTask) GetName() string {
+	return fmt.Sprintf("rehash [%s]", t.Path)
+}
+
+func (t *RehashTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *RehashTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Updated = nil
+	obj, err := get(t.Ctx(), t.Path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", t.Path)
+	}
+	if err = t.walk(t.Path, obj); err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("done, %d updated", len(t.Updated))
+	return nil
+}
+
+func (t *RehashTask) walk(path string, obj model.Obj) error {
+	if err := t.Ctx().Err(); err != nil {
+		return err
+	}
+	if !obj.IsDir() {
+		return t.rehash(path, obj)
+	}
+	t.Status = "listing " + path
+	meta, _ := op.GetNearestMeta(path)
+	// objects changed out-of-band, so the cached listing can't be trusted
+	objs, err := List(context.WithValue(t.Ctx(), "meta", meta), path, &ListArgs{Refresh: true, NoLog: true})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list [%s]", path)
+	}
+	for _, o := range objs {
+		if err := t.walk(stdpath.Join(path, o.GetName()), o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rehash refreshes the records of the file, the ones of the labels are of
+// the path of the obj in its storage, the hashes computed of its path in alist
+func (t *RehashTask) rehash(path string, obj model.Obj) error {
+	changed := func(size int64, modified time.Time) bool {
+		return size != obj.GetSize() || !modified.Equal(obj.ModTime())
+	}
+	records, err := db.GetObjFilesByPath(obj.GetPath(), obj.GetName())
+	if err != nil {
+		return err
+	}
+	records = slices.DeleteFunc(records, func(r model.ObjFile) bool {
+		return !changed(r.Size, r.Modified)
+	})
+	fileHash, err := db.GetFileHash(path)
+	if err != nil || !changed(fileHash.Size, fileHash.Modified) {
+		fileHash = nil
+	}
+	if len(records) == 0 && fileHash == nil {
+		return nil
+	}
+	t.Status = "hashing " + path
+	hashInfo, err := t.hashInfo(path, obj)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		record.Size = obj.GetSize()
+		record.Modified = obj.ModTime()
+		record.HashInfoStr = hashInfo
+		if err = db.UpdateObjFileHash(record); err != nil {
+			return errors.WithMessagef(err, "failed update hash of [%s]", path)
+		}
+	}
+	if fileHash != nil {
+		fileHash.Size = obj.GetSize()
+		fileHash.Modified = obj.ModTime()
+		fileHash.HashInfoStr = hashInfo
+		if err = db.SaveFileHash(fileHash); err != nil {
+			return errors.WithMessagef(err, "failed update hash of [%s]", path)
+		}
+	}
+	t.Updated = append(t.Updated, path)
+	return nil
+}
+
+// hashInfo returns the hashes provided by the storage, or computes sha1 by
+// reading the object when the storage provides none
+func (t *RehashTask) hashInfo(path string, obj model.Obj) (string, error) {
+	if len(obj.GetHash().Export()) > 0 {
+		return obj.GetHash().String(), nil
+	}
+	hi, err := ComputeHashes(t.Ctx(), path, obj, []*utils.HashType{utils.SHA1})
+	if err != nil {
+		return "", err
+	}
+	return hi.String(), nil
+}
@@ -0,0 +1,217 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// the ways of telling a changed file, the size is always compared
+const (
+	SyncCompareSize  = "size"
+	SyncCompareMtime = "mtime"
+	// SyncCompareHash compares a hash known for both files, the mtime if none
+	SyncCompareHash = "hash"
+)
+
+// SyncTask mirrors the content of the SrcPath dir into the DstPath dir: the
+// new and changed files are copied by copy tasks, and with Delete the objs of
+// the dst missing in the src are removed. The paths are mount paths.
+type SyncTask struct {
+	task.TaskExtension
+	Status  string `json:"-"`
+	SrcPath string `json:"src_path"`
+	DstPath string `json:"dst_path"`
+	Compare string `json:"compare"`
+	Delete  bool   `json:"delete"`
+	// the counts of the last run, Copied counts the copy tasks added
+	Copied    int `json:"copied"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+var SyncTaskManager *tache.Manager[*SyncTask]
+
+var _ task.TaskExtensionInfo = (*SyncTask)(nil)
+
+func (t *SyncTask) GetName() string {
+	return fmt.Sprintf("sync [%s] to [%s]", t.SrcPath, t.DstPath)
+}
+
+func (t *SyncTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SyncTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Copied, t.Deleted, t.Unchanged = 0, 0, 0
+	if err := t.syncDir(t.SrcPath, t.DstPath); err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("done, %d copied, %d deleted, %d unchanged", t.Copied, t.Deleted, t.Unchanged)
+	return nil
+}
+
+func (t *SyncTask) syncDir(srcDirPath, dstDirPath string) error {
+	if err := t.Ctx().Err(); err != nil {
+		return err
+	}
+	t.Status = "comparing " + srcDirPath
+	srcObjs, err := List(t.Ctx(), srcDirPath, &ListArgs{NoLog: true})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s]", srcDirPath)
+	}
+	dstObjs, err := List(t.Ctx(), dstDirPath, &ListArgs{NoLog: true})
+	if errs.IsObjectNotFound(err) {
+		if err = MakeDir(t.Ctx(), dstDirPath); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return errors.WithMessagef(err, "failed list dst [%s]", dstDirPath)
+	}
+	dstByName := make(map[string]model.Obj, len(dstObjs))
+	for _, obj := range dstObjs {
+		dstByName[obj.GetName()] = obj
+	}
+	for _, srcObj := range srcObjs {
+		name := srcObj.GetName()
+		srcObjPath, dstObjPath := stdpath.Join(srcDirPath, name), stdpath.Join(dstDirPath, name)
+		dstObj, ok := dstByName[name]
+		delete(dstByName, name)
+		if ok && dstObj.IsDir() != srcObj.IsDir() {
+			// a file replaced by a dir or the other way round
+			if !t.Delete {
+				return errors.Errorf("[%s] and [%s] are not both dirs or files", srcObjPath, dstObjPath)
+			}
+			if err = t.remove(dstObjPath); err != nil {
+				return err
+			}
+			ok = false
+		}
+		if srcObj.IsDir() {
+			if err = t.syncDir(srcObjPath, dstObjPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if ok && !syncChanged(t.Compare, srcObj, dstObj) {
+			t.Unchanged++
+			continue
+		}
+		if err = t.copyFile(srcObjPath, dstDirPath); err != nil {
+			return err
+		}
+	}
+	if t.Delete {
+		for _, dstObj := range dstByName {
+			if err = t.remove(stdpath.Join(dstDirPath, dstObj.GetName())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *SyncTask) remove(path string) error {
+	if err := Remove(t.Ctx(), path); err != nil {
+		return errors.WithMessagef(err, "failed remove dst [%s]", path)
+	}
+	t.Deleted++
+	return nil
+}
+
+func (t *SyncTask) copyFile(srcFilePath, dstDirPath string) error {
+	if err := addCopyTask(&t.TaskExtension, srcFilePath, dstDirPath, ""); err != nil {
+		return err
+	}
+	t.Copied++
+	return nil
+}
+
+// addCopyTask adds a copy task of the file as dstName, or its own name if
+// empty, for the creator of parent. The task streams the file even within a
+// storage as the dst may have to be overwritten.
+func addCopyTask(parent *task.TaskExtension, srcFilePath, dstDirPath, dstName string) error {
+	srcStorage, srcFileActualPath, err := op.GetStorageAndActualPath(srcFilePath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	CopyTaskManager.Add(&CopyTask{
+		TaskExtension: task.TaskExtension{
+			Creator:  parent.GetCreator(),
+			Priority: parent.Priority,
+		},
+		srcStorage:   srcStorage,
+		dstStorage:   dstStorage,
+		SrcObjPath:   srcFileActualPath,
+		DstDirPath:   dstDirActualPath,
+		SrcStorageMp: srcStorage.GetStorage().MountPath,
+		DstStorageMp: dstStorage.GetStorage().MountPath,
+		DstName:      dstName,
+	})
+	return nil
+}
+
+// syncChanged reports whether src has to be copied over dst
+func syncChanged(compare string, src, dst model.Obj) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	switch compare {
+	case SyncCompareSize:
+		return false
+	case SyncCompareHash:
+		for ht, h := range src.GetHash().All() {
+			if dh := dst.GetHash().GetHash(ht); dh != "" {
+				return !strings.EqualFold(h, dh)
+			}
+		}
+	}
+	return src.ModTime().After(dst.ModTime())
+}
+
+// syncAsTask adds a SyncTask mirroring srcPath into dstPath
+func syncAsTask(ctx context.Context, srcPath, dstPath, compare string, del bool) (task.TaskExtensionInfo, error) {
+	if compare == "" {
+		compare = SyncCompareMtime
+	}
+	if !utils.SliceContains([]string{SyncCompareSize, SyncCompareMtime, SyncCompareHash}, compare) {
+		return nil, errors.Errorf("invalid compare: %s", compare)
+	}
+	if utils.IsSubPath(srcPath, dstPath) || utils.IsSubPath(dstPath, srcPath) {
+		return nil, errors.New("the src and dst of a sync can't contain each other")
+	}
+	if _, _, err := op.GetStorageAndActualPath(dstPath); err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &SyncTask{
+		TaskExtension: task.TaskExtension{
+			Creator:  taskCreator,
+			Priority: task.PriorityOf(ctx),
+		},
+		SrcPath: srcPath,
+		DstPath: dstPath,
+		Compare: compare,
+		Delete:  del,
+	}
+	SyncTaskManager.Add(t)
+	return t, nil
+}
@@ -0,0 +1,423 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// the choices resolving a queued SyncConflict
+const (
+	SyncKeepA    = "a"
+	SyncKeepB    = "b"
+	SyncKeepBoth = "both"
+)
+
+// SyncPairTask runs a SyncPair once: a file changed or added on one side only
+// is copied to the other, a file deleted on one side and unchanged on the
+// other is deleted, and a file changed on both sides is a conflict resolved
+// by the policy of the pair. The empty dirs are left as they are.
+type SyncPairTask struct {
+	task.TaskExtension
+	Status string `json:"-"`
+	PairID uint   `json:"pair_id"`
+	PathA  string `json:"path_a"`
+	PathB  string `json:"path_b"`
+	// the counts of the last run, Copied counts the copy tasks added
+	Copied    int `json:"copied"`
+	Deleted   int `json:"deleted"`
+	Conflicts int `json:"conflicts"`
+}
+
+var SyncPairTaskManager *tache.Manager[*SyncPairTask]
+
+var _ task.TaskExtensionInfo = (*SyncPairTask)(nil)
+
+func (t *SyncPairTask) GetName() string {
+	return fmt.Sprintf("sync [%s] with [%s]", t.PathA, t.PathB)
+}
+
+func (t *SyncPairTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SyncPairTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Copied, t.Deleted, t.Conflicts = 0, 0, 0
+	pair, err := db.GetSyncPairById(t.PairID)
+	if err != nil {
+		return err
+	}
+	// the sides are compared once the copies of the last run are done, a
+	// file still on the way would be seen as deleted or changed
+	if syncPairCopying(pair) {
+		t.Status = "skipped, the copies of the last run are still pending"
+		return nil
+	}
+	oldStates, err := db.GetSyncPairStates(pair.ID)
+	if err != nil {
+		return err
+	}
+	t.Status = "listing " + pair.PathA
+	a, err := listSyncTree(t.Ctx(), pair.PathA, len(oldStates) > 0)
+	if err != nil {
+		return err
+	}
+	t.Status = "listing " + pair.PathB
+	b, err := listSyncTree(t.Ctx(), pair.PathB, len(oldStates) > 0)
+	if err != nil {
+		return err
+	}
+	conflicts, err := db.GetSyncConflicts(pair.ID)
+	if err != nil {
+		return err
+	}
+	queued := make(map[string]bool, len(conflicts))
+	for _, c := range conflicts {
+		queued[c.Path] = true
+	}
+	states := make(map[string]model.SyncPairState, len(oldStates))
+	for _, st := range oldStates {
+		states[st.Path] = st
+	}
+	var paths []string
+	for rel := range a {
+		paths = append(paths, rel)
+	}
+	for rel := range b {
+		if _, ok := a[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+	s := &pairSyncer{ctx: t.Ctx(), parent: &t.TaskExtension, pair: pair}
+	var newStates []model.SyncPairState
+	for _, rel := range paths {
+		if err = t.Ctx().Err(); err != nil {
+			return err
+		}
+		t.Status = "comparing " + rel
+		st, inS := states[rel]
+		var stp *model.SyncPairState
+		if inS {
+			stp = &st
+		}
+		oa, ob := a[rel], b[rel]
+		var res []model.SyncPairState
+		switch decideSyncPair(oa, ob, stp, queued[rel]) {
+		case syncPairSynced:
+			res = append(res, syncedState(rel, oa, ob))
+		case syncPairQueued:
+			if inS {
+				res = append(res, st)
+			}
+		case syncPairConflict:
+			t.Conflicts++
+			res, err = s.conflict(rel, oa, ob)
+			if pair.Conflict == model.SyncConflictManual && inS {
+				res = append(res, st)
+			}
+		case syncPairCopyA:
+			res, err = s.copy(rel, oa, true)
+			t.Copied++
+		case syncPairCopyB:
+			res, err = s.copy(rel, ob, false)
+			t.Copied++
+		case syncPairRemoveA:
+			err = s.remove(rel, true)
+			t.Deleted++
+		case syncPairRemoveB:
+			err = s.remove(rel, false)
+			t.Deleted++
+		}
+		if err != nil {
+			return err
+		}
+		newStates = append(newStates, res...)
+	}
+	for i := range newStates {
+		newStates[i].ID, newStates[i].PairID = 0, pair.ID
+	}
+	if err = db.ReplaceSyncPairStates(pair.ID, newStates); err != nil {
+		return err
+	}
+	if err = db.SetSyncPairLastRun(pair.ID, *t.GetStartTime()); err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("done, %d copied, %d deleted, %d conflicts", t.Copied, t.Deleted, t.Conflicts)
+	return nil
+}
+
+// listSyncTree returns the files under root by their path relative to it. A
+// missing root is empty unless the pair ran before, lest a storage which is
+// down gets everything deleted on the other side.
+func listSyncTree(ctx context.Context, root string, ranBefore bool) (map[string]model.Obj, error) {
+	files := make(map[string]model.Obj)
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		objs, err := List(ctx, dir, &ListArgs{NoLog: true})
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if obj.IsDir() {
+				if err = walk(stdpath.Join(dir, obj.GetName()), stdpath.Join(rel, obj.GetName())); err != nil {
+					return err
+				}
+				continue
+			}
+			files[stdpath.Join(rel, obj.GetName())] = obj
+		}
+		return nil
+	}
+	err := walk(root, "")
+	if errs.IsObjectNotFound(err) && len(files) == 0 && !ranBefore {
+		return files, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list [%s]", root)
+	}
+	return files, nil
+}
+
+// the actions of a run on a path
+const (
+	syncPairSynced = iota
+	// syncPairQueued leaves a conflict queued for a choice as it is
+	syncPairQueued
+	syncPairConflict
+	syncPairCopyA
+	syncPairCopyB
+	syncPairRemoveA
+	syncPairRemoveB
+)
+
+// decideSyncPair tells what a run does with the files a and b at a path of
+// the sides, nil where missing, given the state the last run left, nil if none
+func decideSyncPair(a, b model.Obj, st *model.SyncPairState, queued bool) int {
+	inA, inB, inS := a != nil, b != nil, st != nil
+	changedA := inA && (!inS || syncSideChanged(a, st.ASize, st.AModified))
+	changedB := inB && (!inS || syncSideChanged(b, st.BSize, st.BModified))
+	switch {
+	case inA && inB && !changedA && !changedB:
+		return syncPairSynced
+	case inA && inB && !inS && sameSyncFile(a, b):
+		// added on both sides alike
+		return syncPairSynced
+	case inA && inB && changedA && changedB:
+		if queued {
+			return syncPairQueued
+		}
+		return syncPairConflict
+	// a change wins over a deletion, and a file copied by the last run
+	// may still be on the way
+	case inA && (changedA || !inB && st.BModified.IsZero()):
+		return syncPairCopyA
+	case inB && (changedB || !inA && st.AModified.IsZero()):
+		return syncPairCopyB
+	case inA:
+		return syncPairRemoveA
+	default:
+		return syncPairRemoveB
+	}
+}
+
+// sameSyncFile reports whether the files added on both sides are the same,
+// by their hashes if they have one in common, or else by their mtimes
+func sameSyncFile(a, b model.Obj) bool {
+	if a.GetSize() != b.GetSize() {
+		return false
+	}
+	for ht, h := range a.GetHash().All() {
+		if bh := b.GetHash().GetHash(ht); h != "" && bh != "" {
+			return strings.EqualFold(h, bh)
+		}
+	}
+	return a.ModTime().Unix() == b.ModTime().Unix()
+}
+
+// syncPairCopying reports whether a copy task between the sides of pair
+// hasn't ended
+func syncPairCopying(pair *model.SyncPair) bool {
+	inPair := func(path string) bool {
+		return utils.IsSubPath(pair.PathA, path) || utils.IsSubPath(pair.PathB, path)
+	}
+	return len(CopyTaskManager.GetByCondition(func(t *CopyTask) bool {
+		switch t.GetState() {
+		case tache.StateSucceeded, tache.StateFailed, tache.StateCanceled:
+			return false
+		}
+		return inPair(stdpath.Join(t.SrcStorageMp, t.SrcObjPath)) &&
+			inPair(stdpath.Join(t.DstStorageMp, t.DstDirPath))
+	})) > 0
+}
+
+// syncSideChanged compares a file with its state, in seconds as some dbs
+// don't keep less
+func syncSideChanged(obj model.Obj, size int64, modified time.Time) bool {
+	return obj.GetSize() != size || (!modified.IsZero() && obj.ModTime().Unix() != modified.Unix())
+}
+
+func syncedState(rel string, a, b model.Obj) model.SyncPairState {
+	return model.SyncPairState{
+		Path:      rel,
+		ASize:     a.GetSize(),
+		AModified: a.ModTime(),
+		BSize:     b.GetSize(),
+		BModified: b.ModTime(),
+	}
+}
+
+// conflictName is the name under which the version of B is kept
+func conflictName(name string, t time.Time) string {
+	ext := stdpath.Ext(name)
+	return fmt.Sprintf("%s (conflict %s)%s", strings.TrimSuffix(name, ext), t.Format("20060102-150405"), ext)
+}
+
+// pairSyncer applies the decisions of a run, or of a resolved conflict, and
+// returns the states they leave
+type pairSyncer struct {
+	ctx    context.Context
+	parent *task.TaskExtension
+	pair   *model.SyncPair
+}
+
+func (s *pairSyncer) sidePath(rel string, a bool) string {
+	if a {
+		return stdpath.Join(s.pair.PathA, rel)
+	}
+	return stdpath.Join(s.pair.PathB, rel)
+}
+
+// copy copies obj from the side a tells to the other one
+func (s *pairSyncer) copy(rel string, obj model.Obj, a bool) ([]model.SyncPairState, error) {
+	dstPath := s.sidePath(rel, !a)
+	if err := addCopyTask(s.parent, s.sidePath(rel, a), stdpath.Dir(dstPath), ""); err != nil {
+		return nil, err
+	}
+	st := model.SyncPairState{Path: rel, ASize: obj.GetSize(), BSize: obj.GetSize()}
+	if a {
+		st.AModified = obj.ModTime()
+	} else {
+		st.BModified = obj.ModTime()
+	}
+	return []model.SyncPairState{st}, nil
+}
+
+func (s *pairSyncer) remove(rel string, a bool) error {
+	path := s.sidePath(rel, a)
+	if err := Remove(s.ctx, path); err != nil {
+		return errors.WithMessagef(err, "failed remove [%s]", path)
+	}
+	return nil
+}
+
+// conflict resolves a file changed on both sides by the policy of the pair
+func (s *pairSyncer) conflict(rel string, a, b model.Obj) ([]model.SyncPairState, error) {
+	switch s.pair.Conflict {
+	case model.SyncConflictNewest:
+		if b.ModTime().After(a.ModTime()) {
+			return s.copy(rel, b, false)
+		}
+		return s.copy(rel, a, true)
+	case model.SyncConflictKeepBoth:
+		return s.keepBoth(rel, a, b)
+	}
+	return nil, db.CreateSyncConflict(&model.SyncConflict{
+		PairID:    s.pair.ID,
+		Path:      rel,
+		ASize:     a.GetSize(),
+		AModified: a.ModTime(),
+		BSize:     b.GetSize(),
+		BModified: b.ModTime(),
+	})
+}
+
+// keepBoth renames the version of B with a suffix and copies it to A, then
+// copies the version of A to B
+func (s *pairSyncer) keepBoth(rel string, a, b model.Obj) ([]model.SyncPairState, error) {
+	name := conflictName(b.GetName(), time.Now())
+	bPath := s.sidePath(rel, false)
+	if err := Rename(s.ctx, bPath, name); err != nil {
+		return nil, err
+	}
+	renamedRel := stdpath.Join(stdpath.Dir(rel), name)
+	if err := addCopyTask(s.parent, stdpath.Join(stdpath.Dir(bPath), name), stdpath.Dir(s.sidePath(rel, true)), ""); err != nil {
+		return nil, err
+	}
+	res, err := s.copy(rel, a, true)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, model.SyncPairState{Path: renamedRel, ASize: b.GetSize(), BSize: b.GetSize()}), nil
+}
+
+// syncPairAsTask adds a task running the pair once
+func syncPairAsTask(ctx context.Context, pair *model.SyncPair) (task.TaskExtensionInfo, error) {
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &SyncPairTask{
+		TaskExtension: task.TaskExtension{
+			Creator:  taskCreator,
+			Priority: task.PriorityOf(ctx),
+		},
+		PairID: pair.ID,
+		PathA:  pair.PathA,
+		PathB:  pair.PathB,
+	}
+	SyncPairTaskManager.Add(t)
+	return t, nil
+}
+
+// resolveSyncConflict applies the choice to a queued conflict and records the
+// state it leaves, the next runs see the file as synced
+func resolveSyncConflict(ctx context.Context, conflict *model.SyncConflict, choice string) error {
+	pair, err := db.GetSyncPairById(conflict.PairID)
+	if err != nil {
+		return err
+	}
+	creator, _ := ctx.Value("user").(*model.User)
+	s := &pairSyncer{ctx: ctx, parent: &task.TaskExtension{Creator: creator}, pair: pair}
+	a, err := Get(ctx, s.sidePath(conflict.Path, true), &GetArgs{NoLog: true})
+	if err != nil {
+		return err
+	}
+	b, err := Get(ctx, s.sidePath(conflict.Path, false), &GetArgs{NoLog: true})
+	if err != nil {
+		return err
+	}
+	var res []model.SyncPairState
+	switch choice {
+	case SyncKeepA:
+		res, err = s.copy(conflict.Path, a, true)
+	case SyncKeepB:
+		res, err = s.copy(conflict.Path, b, false)
+	case SyncKeepBoth:
+		res, err = s.keepBoth(conflict.Path, a, b)
+	default:
+		return errors.Errorf("invalid choice: %s", choice)
+	}
+	if err != nil {
+		return err
+	}
+	for i := range res {
+		res[i].ID, res[i].PairID = 0, pair.ID
+	}
+	if err = db.SetSyncPairStates(pair.ID, res); err != nil {
+		return err
+	}
+	return db.DeleteSyncConflictById(conflict.ID)
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestDecideSyncPair(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	t1 := t0.Add(time.Hour)
+	obj := func(size int64, modified time.Time, md5 string) model.Obj {
+		o := &model.Object{Name: "f", Size: size, Modified: modified}
+		if md5 != "" {
+			o.HashInfo = utils.NewHashInfo(utils.MD5, md5)
+		}
+		return o
+	}
+	synced := &model.SyncPairState{ASize: 1, AModified: t0, BSize: 1, BModified: t0}
+	// the state left by a copy from A which the last run added
+	copiedFromA := &model.SyncPairState{ASize: 1, AModified: t0, BSize: 1}
+	datas := []struct {
+		a, b   model.Obj
+		st     *model.SyncPairState
+		queued bool
+		expect int
+	}{
+		{a: obj(1, t0, ""), b: obj(1, t0, ""), st: synced, expect: syncPairSynced},
+		// added on both sides
+		{a: obj(1, t0, ""), b: obj(1, t0, ""), expect: syncPairSynced},
+		{a: obj(1, t0, "a"), b: obj(1, t1, "a"), expect: syncPairSynced},
+		{a: obj(1, t0, ""), b: obj(1, t1, ""), expect: syncPairConflict},
+		{a: obj(1, t0, "a"), b: obj(1, t0, "b"), expect: syncPairConflict},
+		{a: obj(1, t0, ""), b: obj(2, t0, ""), expect: syncPairConflict},
+		// changed on both sides
+		{a: obj(2, t0, ""), b: obj(3, t0, ""), st: synced, expect: syncPairConflict},
+		{a: obj(2, t0, ""), b: obj(3, t0, ""), st: synced, queued: true, expect: syncPairQueued},
+		// changed on one side
+		{a: obj(2, t0, ""), b: obj(1, t0, ""), st: synced, expect: syncPairCopyA},
+		{a: obj(1, t0, ""), b: obj(1, t1, ""), st: synced, expect: syncPairCopyB},
+		// a change wins over a deletion
+		{a: obj(2, t0, ""), st: synced, expect: syncPairCopyA},
+		{b: obj(1, t1, ""), st: synced, expect: syncPairCopyB},
+		// added on one side
+		{a: obj(1, t0, ""), expect: syncPairCopyA},
+		{b: obj(1, t0, ""), expect: syncPairCopyB},
+		// deleted on one side
+		{a: obj(1, t0, ""), st: synced, expect: syncPairRemoveA},
+		{b: obj(1, t0, ""), st: synced, expect: syncPairRemoveB},
+		// the copy of the last run isn't there yet
+		{a: obj(1, t0, ""), st: copiedFromA, expect: syncPairCopyA},
+	}
+	for i, data := range datas {
+		if res := decideSyncPair(data.a, data.b, data.st, data.queued); res != data.expect {
+			t.Errorf("TestDecideSyncPair %d failed, expect %d, got %d", i, data.expect, res)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// the data is written under this suffix until the transfer completes
+const transferPartSuffix = ".part"
+
+var errTransferPaused = errors.New("transfer paused")
+
+// TransferTask copies a single file between storages in a way that can be paused
+// and resumed. The destination must implement driver.Append, the offset to resume
+// from is the size of the partial file, so it survives restarts when persisted.
+type TransferTask struct {
+	task.TaskExtension
+	Status     string `json:"-"`
+	SrcPath    string `json:"src_path"`
+	DstDirPath string `json:"dst_dir_path"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+	// Speed is the throughput in bytes per second of the running segment
+	Speed  int64 `json:"speed"`
+	Paused bool  `json:"paused"`
+	mu     sync.Mutex
+	resume chan struct{}
+}
+
+var TransferTaskManager *tache.Manager[*TransferTask]
+
+var _ task.TaskExtensionInfo = (*TransferTask)(nil)
+
+func (t *TransferTask) GetName() string {
+	return fmt.Sprintf("transfer [%s] to [%s]", t.SrcPath, t.DstDirPath)
+}
+
+func (t *TransferTask) GetStatus() string {
+	return t.Status
+}
+
+// Pause stops the transfer after the data being written, the source is
+// released while paused
+func (t *TransferTask) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Paused = true
+	t.Persist()
+}
+
+func (t *TransferTask) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Paused = false
+	t.Persist()
+	if t.resume != nil {
+		select {
+		case t.resume <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *TransferTask) isPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Paused
+}
+
+func (t *TransferTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.mu.Lock()
+	t.resume = make(chan struct{}, 1)
+	t.mu.Unlock()
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(t.SrcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	if _, ok := dstStorage.(driver.Append); !ok {
+		return errors.Errorf("storage [%s] doesn't support resumable writes", dstStorage.GetStorage().MountPath)
+	}
+	srcObj, err := op.Get(t.Ctx(), srcStorage, srcActualPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get src [%s] file", t.SrcPath)
+	}
+	if srcObj.IsDir() {
+		return errors.Errorf("[%s] is a folder", t.SrcPath)
+	}
+	t.Size = srcObj.GetSize()
+	t.SetTotalBytes(t.Size)
+	if dstObj, err := op.GetCollision(t.Ctx(), dstStorage, stdpath.Join(dstDirActualPath, srcObj.GetName())); err == nil {
+		return errors.Errorf("[%s] already exists in [%s]", dstObj.GetName(), t.DstDirPath)
+	}
+	if err = op.MakeDir(t.Ctx(), dstStorage, dstDirActualPath); err != nil {
+		return err
+	}
+	partName := srcObj.GetName() + transferPartSuffix
+	partPath := stdpath.Join(dstDirActualPath, partName)
+	for {
+		if err = t.Ctx().Err(); err != nil {
+			return err
+		}
+		t.Offset = 0
+		part, partErr := op.Get(t.Ctx(), dstStorage, partPath)
+		if partErr == nil {
+			t.Offset = part.GetSize()
+		}
+		t.Persist()
+		if t.Size > 0 {
+			t.SetProgress(float64(t.Offset) / float64(t.Size) * 100)
+		}
+		if t.Offset > t.Size {
+			return errors.Errorf("partial file [%s] is larger than the source", partName)
+		}
+		// an empty source still needs its partial file created
+		if t.Offset == t.Size && partErr == nil {
+			break
+		}
+		if t.isPaused() {
+			t.Speed = 0
+			t.Status = fmt.Sprintf("paused at %d/%d", t.Offset, t.Size)
+			select {
+			case <-t.resume:
+			case <-t.Ctx().Done():
+				return t.Ctx().Err()
+			}
+			continue
+		}
+		err = t.transfer(srcStorage, srcActualPath, srcObj, dstStorage, dstDirActualPath, partName)
+		if err != nil && !errors.Is(err, errTransferPaused) {
+			return err
+		}
+	}
+	t.Status = "renaming"
+	if err = op.Rename(t.Ctx(), dstStorage, partPath, srcObj.GetName()); err != nil {
+		return err
+	}
+	t.Speed = 0
+	t.Status = "done"
+	return nil
+}
+
+// transfer appends the source from the current offset until it ends or the task is paused
+func (t *TransferTask) transfer(srcStorage driver.Driver, srcActualPath string, srcObj model.Obj, dstStorage driver.Driver, dstDirActualPath, partName string) error {
+	defer op.KeepStorage(srcStorage)()
+	link, _, err := op.Link(t.Ctx(), srcStorage, srcActualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", t.SrcPath)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: t.Ctx(), Obj: srcObj}, link)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] stream", t.SrcPath)
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: t.Offset, Length: t.Size - t.Offset})
+	if err != nil {
+		return err
+	}
+	t.Status = "transferring"
+	return op.Append(t.Ctx(), dstStorage, dstDirActualPath, partName, &transferReader{Reader: r, t: t, start: time.Now()})
+}
+
+// transferReader tracks the offset and the throughput, and stops at a pause
+type transferReader struct {
+	io.Reader
+	t     *TransferTask
+	start time.Time
+	read  int64
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	if r.t.isPaused() {
+		return 0, errTransferPaused
+	}
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	t := r.t
+	t.Offset += int64(n)
+	if elapsed := time.Since(r.start).Seconds(); elapsed > 0 {
+		t.Speed = int64(float64(r.read) / elapsed)
+	}
+	if t.Size > 0 {
+		t.SetProgress(float64(t.Offset) / float64(t.Size) * 100)
+	}
+	return n, err
+}
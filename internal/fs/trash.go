@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashDirName is the dir at the root of each storage holding its trashed objs,
+// each obj is moved into a dir of its own so the names never collide
+const TrashDirName = ".trash"
+
+// inTrash reports whether the actual path of a storage is in its trash
+func inTrash(actualPath string) bool {
+	return utils.IsSubPath("/"+TrashDirName, actualPath)
+}
+
+// Trash moves the obj at path to the trash of its storage and records it. An
+// obj already in the trash is removed.
+func Trash(ctx context.Context, path string) error {
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if actualPath == "/" {
+		return errors.New("the root of a storage can't be trashed")
+	}
+	if inTrash(actualPath) {
+		return Remove(ctx, path)
+	}
+	obj, err := get(ctx, path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	now := time.Now()
+	holder := stdpath.Join(storage.GetStorage().MountPath, TrashDirName, strconv.FormatInt(now.UnixNano(), 36))
+	if err = makeDir(ctx, holder); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	if err = move(ctx, path, holder); err != nil {
+		_ = remove(ctx, holder)
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	item := &model.TrashItem{
+		OriginalPath: path,
+		TrashPath:    holder,
+		Name:         obj.GetName(),
+		IsDir:        obj.IsDir(),
+		Size:         obj.GetSize(),
+		DeletedAt:    now,
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		item.UserID, item.Username = user.ID, user.Username
+	}
+	if err = db.CreateTrashItem(item); err != nil {
+		return errors.WithMessagef(err, "moved [%s] to [%s] but failed record it", path, holder)
+	}
+	recordChange(ctx, model.AuditRemove, path, "", nil)
+	return nil
+}
+
+// RestoreTrash moves a trashed obj back to its original path, which must be free
+func RestoreTrash(ctx context.Context, item *model.TrashItem) error {
+	if res, _ := Get(ctx, item.OriginalPath, &GetArgs{NoLog: true, Collision: true}); res != nil {
+		return errors.Errorf("[%s] exists", res.GetName())
+	}
+	dstDir := stdpath.Dir(item.OriginalPath)
+	if err := makeDir(ctx, dstDir); err != nil {
+		return errors.WithMessagef(err, "failed make [%s]", dstDir)
+	}
+	if err := move(ctx, stdpath.Join(item.TrashPath, item.Name), dstDir); err != nil {
+		return errors.WithMessage(err, "failed move out of trash")
+	}
+	if err := remove(ctx, item.TrashPath); err != nil {
+		log.Warnf("failed remove trash dir %s: %+v", item.TrashPath, err)
+	}
+	recordChange(ctx, model.AuditMove, item.OriginalPath, stdpath.Join(item.TrashPath, item.Name), nil)
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrash removes a trashed obj for good
+func PurgeTrash(ctx context.Context, item *model.TrashItem) error {
+	if err := remove(ctx, item.TrashPath); err != nil && !errors.Is(err, errs.ObjectNotFound) {
+		return errors.WithMessagef(err, "failed remove [%s]", item.TrashPath)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+var trashExpiryOnce sync.Once
+
+// StartTrashExpiry purges the objs trashed for longer than trash_retention days
+func StartTrashExpiry() {
+	trashExpiryOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for ; true; <-ticker.C {
+				expireTrash()
+			}
+		}()
+	})
+}
+
+func expireTrash() {
+	days := setting.GetInt(conf.TrashRetention, 30)
+	if days <= 0 {
+		return
+	}
+	items, err := db.GetTrashItemsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed get expired trash items: %+v", err)
+		return
+	}
+	for i := range items {
+		if err = PurgeTrash(context.Background(), &items[i]); err != nil {
+			log.Errorf("failed purge trashed %s: %+v", items[i].OriginalPath, err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TusExpiration is how long an upload receiving nothing is kept
+const TusExpiration = 24 * time.Hour
+
+var (
+	ErrTusOffsetMismatch = errors.New("upload offset mismatch")
+	ErrTusBusy           = errors.New("upload is being written by another request")
+)
+
+var (
+	tusBusyMu sync.Mutex
+	tusBusy   = map[string]struct{}{}
+)
+
+func tusFilePath(id string) string {
+	return filepath.Join(conf.Conf.TempDir, "tus", id)
+}
+
+// CreateTusUpload records a resumable upload of size bytes to path, an empty
+// upload is complete at once
+func CreateTusUpload(ctx context.Context, u *model.TusUpload) error {
+	u.ID = random.String(32)
+	u.Path = utils.FixAndCleanPath(u.Path)
+	if err := os.MkdirAll(filepath.Dir(tusFilePath(u.ID)), 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(tusFilePath(u.ID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_ = f.Close()
+	if err = db.CreateTusUpload(u); err != nil {
+		_ = os.Remove(tusFilePath(u.ID))
+		return err
+	}
+	if u.Size == 0 {
+		return finishTusUpload(ctx, u)
+	}
+	return nil
+}
+
+// WriteTusUpload appends the bytes of r to the upload at offset, which must be
+// the offset reached so far. The bytes received are kept even if r fails, and
+// the file is put to its storage once complete.
+func WriteTusUpload(ctx context.Context, u *model.TusUpload, offset int64, r io.Reader) error {
+	tusBusyMu.Lock()
+	if _, ok := tusBusy[u.ID]; ok {
+		tusBusyMu.Unlock()
+		return ErrTusBusy
+	}
+	tusBusy[u.ID] = struct{}{}
+	tusBusyMu.Unlock()
+	defer func() {
+		tusBusyMu.Lock()
+		delete(tusBusy, u.ID)
+		tusBusyMu.Unlock()
+	}()
+	// another request may have written meanwhile
+	latest, err := db.GetTusUploadById(u.ID)
+	if err != nil {
+		return err
+	}
+	*u = *latest
+	if offset != u.Offset {
+		return ErrTusOffsetMismatch
+	}
+	f, err := os.OpenFile(tusFilePath(u.ID), os.O_WRONLY, 0o666)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return errors.WithStack(err)
+	}
+	n, copyErr := utils.CopyWithBuffer(f, io.LimitReader(r, u.Size-offset))
+	if err = f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+		n = 0
+	}
+	if n > 0 {
+		u.Offset += n
+		if err = db.UpdateTusUploadOffset(u); err != nil {
+			return err
+		}
+	}
+	if copyErr != nil {
+		return errors.WithStack(copyErr)
+	}
+	if u.Offset == u.Size {
+		return finishTusUpload(ctx, u)
+	}
+	return nil
+}
+
+// finishTusUpload streams the assembled file to its storage
+func finishTusUpload(ctx context.Context, u *model.TusUpload) error {
+	if !u.Overwrite {
+		if res, _ := Get(ctx, u.Path, &GetArgs{NoLog: true, Collision: true}); res != nil {
+			return errors.Errorf("file [%s] exists", res.GetName())
+		}
+	}
+	f, err := os.Open(tusFilePath(u.ID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dir, name := stdpath.Split(u.Path)
+	s := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     u.Size,
+			Modified: u.Modified,
+		},
+		Reader:   f,
+		Mimetype: u.Mimetype,
+	}
+	s.Closers.Add(f)
+	if err = PutDirectly(ctx, dir, s, true); err != nil {
+		return errors.WithMessage(err, "failed put the complete upload")
+	}
+	return DeleteTusUpload(u)
+}
+
+// DeleteTusUpload drops an upload and the bytes received
+func DeleteTusUpload(u *model.TusUpload) error {
+	if err := os.Remove(tusFilePath(u.ID)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove tus file of %s: %+v", u.Path, err)
+	}
+	return db.DeleteTusUploadById(u.ID)
+}
+
+var tusExpiryOnce sync.Once
+
+// StartTusExpiry drops the uploads which received nothing for TusExpiration
+func StartTusExpiry() {
+	tusExpiryOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for ; true; <-ticker.C {
+				uploads, err := db.GetTusUploadsBefore(time.Now().Add(-TusExpiration))
+				if err != nil {
+					log.Errorf("failed get expired tus uploads: %+v", err)
+					continue
+				}
+				for i := range uploads {
+					if err = DeleteTusUpload(&uploads[i]); err != nil {
+						log.Errorf("failed drop expired tus upload of %s: %+v", uploads[i].Path, err)
+					}
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,56 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestWriteTusUpload(t *testing.T) {
+	conf.Conf.TempDir = t.TempDir()
+	u := &model.TusUpload{Path: "/tus/a.txt", Size: 10}
+	if err := fs.CreateTusUpload(context.Background(), u); err != nil {
+		t.Fatalf("failed to create tus upload: %+v", err)
+	}
+	// the upload isn't completed, it would be put to a storage
+	var writes = []struct {
+		offset int64
+		data   string
+		result int64
+		err    error
+	}{
+		{offset: 0, data: "abcd", result: 4},
+		{offset: 0, data: "abcd", result: 4, err: fs.ErrTusOffsetMismatch},
+		{offset: 6, data: "ef", result: 4, err: fs.ErrTusOffsetMismatch},
+		{offset: 4, data: "efgh", result: 8},
+		{offset: 8, data: "", result: 8},
+	}
+	for i, data := range writes {
+		err := fs.WriteTusUpload(context.Background(), u, data.offset, strings.NewReader(data.data))
+		if !errors.Is(err, data.err) {
+			t.Errorf("TestWriteTusUpload %d failed: %+v", i, err)
+		}
+		latest, err := db.GetTusUploadById(u.ID)
+		if err != nil {
+			t.Fatalf("failed to get tus upload: %+v", err)
+		}
+		if latest.Offset != data.result {
+			t.Errorf("TestWriteTusUpload %d failed, got offset %d", i, latest.Offset)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(conf.Conf.TempDir, "tus", u.ID))
+	if err != nil {
+		t.Fatalf("failed to read the upload: %+v", err)
+	}
+	if string(b) != "abcdefgh" {
+		t.Errorf("TestWriteTusUpload failed, got %s", b)
+	}
+}
@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultVersionDir = "/.versions"
+
+// versionDir is the actual path of the dir holding the previous versions of
+// the files of a storage
+func versionDir(storage driver.Driver) string {
+	if dir := storage.GetStorage().VersionDir; dir != "" {
+		return utils.FixAndCleanPath(dir)
+	}
+	return defaultVersionDir
+}
+
+// InManagedDir reports whether path is in the trash or the version dir of its
+// storage, which are only reached through their own api
+func InManagedDir(path string) bool {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	return err == nil && (inTrash(actualPath) || utils.IsSubPath(versionDir(storage), actualPath))
+}
+
+// managedDirDenied reports whether path is in a trash or a version dir the user
+// in ctx can't read, they hold the objs of every user and folder whatever
+// their metas, so only the admins can
+func managedDirDenied(ctx context.Context, path string) bool {
+	user, _ := ctx.Value("user").(*model.User)
+	return user != nil && !user.IsAdmin() && InManagedDir(path)
+}
+
+// keepVersion moves the file at path to the version dir of its storage before
+// it is overwritten, then drops the versions over the number kept. It does
+// nothing if the storage doesn't keep versions or there is no file at path.
+func keepVersion(ctx context.Context, path string) error {
+	keep, err := saveVersion(ctx, path)
+	if err != nil || keep == 0 {
+		return err
+	}
+	pruneVersions(ctx, path, keep)
+	return nil
+}
+
+// saveVersion moves the file at path to the version dir and returns the number
+// of versions kept by the storage, 0 if nothing was saved
+func saveVersion(ctx context.Context, path string) (int, error) {
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed get storage")
+	}
+	keep := storage.GetStorage().KeepVersions
+	dir := versionDir(storage)
+	if keep <= 0 || utils.IsSubPath(dir, actualPath) {
+		return 0, nil
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return 0, nil
+		}
+		return 0, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	if obj.IsDir() || obj.GetName() != stdpath.Base(actualPath) {
+		return 0, nil
+	}
+	now := time.Now()
+	holder := stdpath.Join(storage.GetStorage().MountPath, dir, strconv.FormatInt(now.UnixNano(), 36))
+	if err = makeDir(ctx, holder); err != nil {
+		return 0, errors.WithMessage(err, "failed make version dir")
+	}
+	if err = move(ctx, path, holder); err != nil {
+		_ = remove(ctx, holder)
+		return 0, errors.WithMessage(err, "failed move to version dir")
+	}
+	v := &model.FileVersion{
+		Path:      path,
+		BlobPath:  holder,
+		Size:      obj.GetSize(),
+		Modified:  obj.ModTime(),
+		CreatedAt: now,
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		v.UserID, v.Username = user.ID, user.Username
+	}
+	if err = db.CreateFileVersion(v); err != nil {
+		return 0, errors.WithMessagef(err, "moved [%s] to [%s] but failed record it", path, holder)
+	}
+	return keep, nil
+}
+
+func pruneVersions(ctx context.Context, path string, keep int) {
+	versions, err := db.GetFileVersions(path)
+	if err != nil {
+		log.Errorf("failed get versions of %s: %+v", path, err)
+		return
+	}
+	for i := keep; i < len(versions); i++ {
+		if err = DeleteVersion(ctx, &versions[i]); err != nil {
+			log.Warnf("failed drop version %d of %s: %+v", versions[i].ID, path, err)
+		}
+	}
+}
+
+// GetVersions returns the previous versions kept of the file at path, newest first
+func GetVersions(path string) ([]model.FileVersion, error) {
+	return db.GetFileVersions(utils.FixAndCleanPath(path))
+}
+
+// RestoreVersion replaces a file with one of its previous versions, the current
+// file is kept as a version in turn if the storage still keeps versions
+func RestoreVersion(ctx context.Context, v *model.FileVersion) error {
+	keep, err := saveVersion(ctx, v.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed keep the current file")
+	}
+	if keep == 0 {
+		if err = remove(ctx, v.Path); err != nil && !errs.IsObjectNotFound(err) {
+			return errors.WithMessage(err, "failed remove the current file")
+		}
+	}
+	dstDir := stdpath.Dir(v.Path)
+	if err = makeDir(ctx, dstDir); err != nil {
+		return errors.WithMessagef(err, "failed make [%s]", dstDir)
+	}
+	if err = move(ctx, stdpath.Join(v.BlobPath, stdpath.Base(v.Path)), dstDir); err != nil {
+		return errors.WithMessage(err, "failed move out of version dir")
+	}
+	if err = remove(ctx, v.BlobPath); err != nil {
+		log.Warnf("failed remove version dir %s: %+v", v.BlobPath, err)
+	}
+	if err = db.DeleteFileVersionById(v.ID); err != nil {
+		return err
+	}
+	recordChange(ctx, model.AuditPut, v.Path, "", nil)
+	if keep > 0 {
+		pruneVersions(ctx, v.Path, keep)
+	}
+	return nil
+}
+
+// DeleteVersion removes a previous version of a file for good
+func DeleteVersion(ctx context.Context, v *model.FileVersion) error {
+	if err := remove(ctx, v.BlobPath); err != nil && !errs.IsObjectNotFound(err) {
+		return errors.WithMessagef(err, "failed remove [%s]", v.BlobPath)
+	}
+	return db.DeleteFileVersionById(v.ID)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"path"
 	"path/filepath"
+	"slices"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
 )
 
 // WalkFS traverses filesystem fs starting at name up to depth levels.
@@ -43,3 +46,52 @@ func WalkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	}
 	return nil
 }
+
+// CanAccessDir reports whether user can access the dir at path with password,
+// a nil user can access every dir
+func CanAccessDir(user *model.User, path, password string) bool {
+	_, ok := accessibleMeta(user, path, password)
+	return ok
+}
+
+func accessibleMeta(user *model.User, path, password string) (*model.Meta, bool) {
+	meta, _ := op.GetNearestMeta(path)
+	return meta, user == nil || common.CanAccessWithRoles(user, meta, path, password)
+}
+
+// WalkAccessibleFunc is called by WalkAccessible with the children of each dir
+// it lists, or with err if the dir can't be listed, errs.PermissionDenied if
+// the user can't access it. It returns the child dirs to walk into next.
+type WalkAccessibleFunc func(reqPath string, objs []model.Obj, err error) ([]model.Obj, error)
+
+// WalkAccessible walks the dir at name as user like it would list each dir,
+// the dirs it can't access aren't listed and the children its roles can't read
+// are left out. The password applies to the dirs under name too, as the meta
+// which asks it may. The walk stops at the first error returned by walkFn or
+// once ctx is done.
+func WalkAccessible(ctx context.Context, user *model.User, name, password string, walkFn WalkAccessibleFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var objs []model.Obj
+	meta, ok := accessibleMeta(user, name, password)
+	err := errs.PermissionDenied
+	if ok {
+		objs, err = List(context.WithValue(ctx, "meta", meta), name, &ListArgs{NoLog: true})
+	}
+	if err == nil && user != nil {
+		objs = slices.DeleteFunc(slices.Clone(objs), func(obj model.Obj) bool {
+			return !common.CanReadPathByRole(user, path.Join(name, obj.GetName()))
+		})
+	}
+	dirs, err := walkFn(name, objs, err)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if err := WalkAccessible(ctx, user, path.Join(name, dir.GetName()), password, walkFn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
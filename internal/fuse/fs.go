@@ -1,170 +1,380 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Handle is an opened file, read at any offset or written from the start
+type Handle interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+}
+
+// Backend is the file system to mount, the paths are the ones of the user
+// it acts as, so are its permissions
+type Backend interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Mkdir(name string, perm os.FileMode) error
+	Remove(name string) error
+	Rename(oldName, newName string) error
+	// OpenFile opens name to read from offset, or with os.O_WRONLY to write
+	// the whole file which is put on Close
+	OpenFile(name string, flags int, offset int64) (Handle, error)
+}
+
+// the kernel asks for the attributes of every entry listed, they are kept a
+// while not to get them one by one from the storages
+const attrCacheExpiration = 10 * time.Second
+
+type handle struct {
+	sync.Mutex
+	path   string
+	write  bool
+	file   Handle
+	offset int64
+}
 
 type Fs struct {
-	RootFolder string
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
+	RootFolder string
+	Backend    Backend
+
+	attrs   *cache.MemCache[os.FileInfo]
+	mu      sync.Mutex
+	handles map[uint64]*handle
+	nextFh  uint64
+}
+
+func NewFs(backend Backend, rootFolder string) *Fs {
+	return &Fs{
+		RootFolder: utils.FixAndCleanPath(rootFolder),
+		Backend:    backend,
+		attrs:      cache.NewMemCache(cache.WithShards[os.FileInfo](64)),
+		handles:    make(map[uint64]*handle),
+	}
+}
+
+func (fs *Fs) join(path string) string {
+	return stdpath.Join(fs.RootFolder, path)
+}
+
+// errno maps the errors of the backend to the ones of the file systems
+func errno(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errs.IsObjectNotFound(err) || errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errs.IsNotSupportError(err) || errs.IsNotImplement(err):
+		return -fuse.ENOSYS
+	default:
+		return -fuse.EIO
+	}
+}
+
+func fillStat(stat *fuse.Stat_t, info os.FileInfo) {
+	*stat = fuse.Stat_t{}
+	if info.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		stat.Size = info.Size()
+		stat.Blocks = (info.Size() + 511) / 512
+	}
+	stat.Blksize = 4096
+	t := fuse.NewTimespec(info.ModTime())
+	stat.Atim, stat.Mtim, stat.Ctim, stat.Birthtim = t, t, t, t
+}
+
+func (fs *Fs) stat(path string) (os.FileInfo, error) {
+	if info, ok := fs.attrs.Get(path); ok {
+		return info, nil
+	}
+	info, err := fs.Backend.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	fs.attrs.Set(path, info, cache.WithEx[os.FileInfo](attrCacheExpiration))
+	return info, nil
+}
+
+// changed drops the cached attributes of path and its dir after a write
+func (fs *Fs) changed(path string) {
+	fs.attrs.Del(path)
+	fs.attrs.Del(stdpath.Dir(path))
+}
+
+func (fs *Fs) newHandle(h *handle) uint64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.nextFh++
+	fs.handles[fs.nextFh] = h
+	return fs.nextFh
+}
+
+func (fs *Fs) getHandle(fh uint64) *handle {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.handles[fh]
+}
+
+// writing returns the handle path is being written by, if any
+func (fs *Fs) writing(path string) *handle {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, h := range fs.handles {
+		if h.write && h.path == path {
+			return h
+		}
+	}
+	return nil
 }
 
 func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
+	*stat = fuse.Statfs_t{
+		Bsize:   4096,
+		Frsize:  4096,
+		Namemax: 255,
+	}
+	return 0
 }
 
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	p := fs.join(path)
+	// the file being written is not in the storage until released
+	if h := fs.writing(p); h != nil {
+		h.Lock()
+		defer h.Unlock()
+		fillStat(stat, &writingInfo{name: stdpath.Base(p), size: h.offset})
+		return 0
+	}
+	info, err := fs.stat(p)
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(stat, info)
+	return 0
 }
 
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Opendir(path string) (int, uint64) {
+	info, err := fs.stat(fs.join(path))
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !info.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
 }
 
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	p := fs.join(path)
+	infos, err := fs.Backend.ReadDir(p)
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, info := range infos {
+		fs.attrs.Set(stdpath.Join(p, info.Name()), info, cache.WithEx[os.FileInfo](attrCacheExpiration))
+		var stat fuse.Stat_t
+		fillStat(&stat, info)
+		if !fill(info.Name(), &stat, 0) {
+			break
+		}
+	}
+	return 0
 }
 
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Releasedir(path string, fh uint64) int {
+	return 0
 }
 
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Mkdir(path string, mode uint32) int {
+	p := fs.join(path)
+	defer fs.changed(p)
+	return errno(fs.Backend.Mkdir(p, os.FileMode(mode)))
 }
 
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Unlink(path string) int {
+	p := fs.join(path)
+	defer fs.changed(p)
+	return errno(fs.Backend.Remove(p))
 }
 
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
+func (fs *Fs) Rmdir(path string) int {
+	p := fs.join(path)
+	infos, err := fs.Backend.ReadDir(p)
+	if err != nil {
+		return errno(err)
+	}
+	if len(infos) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	defer fs.changed(p)
+	return errno(fs.Backend.Remove(p))
 }
 
 func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	oldP, newP := fs.join(oldpath), fs.join(newpath)
+	defer fs.changed(oldP)
+	defer fs.changed(newP)
+	return errno(fs.Backend.Rename(oldP, newP))
 }
 
+// the storages keep neither the modes nor the times, they are accepted and
+// ignored not to fail the tools copying them
 func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	p := fs.join(path)
+	file, err := fs.Backend.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, fs.newHandle(&handle{path: p, write: true, file: file})
 }
 
 func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	p := fs.join(path)
+	info, err := fs.stat(p)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if info.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		// the file is opened at the first read, to start at its offset
+		return 0, fs.newHandle(&handle{path: p})
+	}
+	// a file can only be written as a whole
+	if flags&fuse.O_APPEND != 0 {
+		return -fuse.ENOTSUP, ^uint64(0)
+	}
+	file, err := fs.Backend.OpenFile(p, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	return 0, fs.newHandle(&handle{path: p, write: true, file: file})
 }
 
 func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	// the files are written as a whole from the start, so they can only be
+	// truncated to nothing before being written
+	if size != 0 {
+		return -fuse.ENOTSUP
+	}
+	return 0
 }
 
 func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	h.Lock()
+	defer h.Unlock()
+	if h.write {
+		return -fuse.EBADF
+	}
+	if h.file == nil {
+		file, err := fs.Backend.OpenFile(h.path, os.O_RDONLY, ofst)
+		if err != nil {
+			return errno(err)
+		}
+		h.file, h.offset = file, ofst
+	}
+	if ofst != h.offset {
+		if _, err := h.file.Seek(ofst, io.SeekStart); err != nil {
+			return errno(err)
+		}
+		h.offset = ofst
+	}
+	n, err := io.ReadFull(h.file, buff)
+	h.offset += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return errno(err)
+	}
+	return n
 }
 
 func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h := fs.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	h.Lock()
+	defer h.Unlock()
+	if !h.write {
+		return -fuse.EBADF
+	}
+	// the file is streamed to the storage, it can't be written at random
+	if ofst != h.offset {
+		return -fuse.ENOTSUP
+	}
+	n, err := h.file.Write(buff)
+	h.offset += int64(n)
+	if err != nil {
+		return errno(err)
+	}
+	return n
 }
 
 func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
+	fs.mu.Lock()
+	h := fs.handles[fh]
+	delete(fs.handles, fh)
+	fs.mu.Unlock()
+	if h == nil || h.file == nil {
+		return 0
+	}
+	h.Lock()
+	defer h.Unlock()
+	if h.write {
+		defer fs.changed(h.path)
+	}
+	return errno(h.file.Close())
 }
 
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
+var _ fuse.FileSystemInterface = (*Fs)(nil)
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+// writingInfo is the info of a file being written
+type writingInfo struct {
+	name string
+	size int64
 }
 
-var _ fuse.FileSystemInterface = (*Fs)(nil)
+func (w *writingInfo) Name() string       { return w.name }
+func (w *writingInfo) Size() int64        { return w.size }
+func (w *writingInfo) Mode() os.FileMode  { return 0644 }
+func (w *writingInfo) ModTime() time.Time { return time.Now() }
+func (w *writingInfo) IsDir() bool        { return false }
+func (w *writingInfo) Sys() any           { return nil }
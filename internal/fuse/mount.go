@@ -1,9 +1,18 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
-	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Mount mounts mountSrc of backend at mountDst until ctx is done, it returns
+// false if the mount failed
+func Mount(ctx context.Context, backend Backend, mountSrc, mountDst string, opts []string) bool {
+	host := fuse.NewFileSystemHost(NewFs(backend, mountSrc))
+	go func() {
+		<-ctx.Done()
+		host.Unmount()
+	}()
+	return host.Mount(mountDst, opts)
 }
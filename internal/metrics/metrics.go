@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry holds the metrics served by /metrics, apart from the ones of alist
+// it has the go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "http_requests_total",
+		Help:      "Count of the HTTP requests by handler, method and status code.",
+	}, []string{"handler", "method", "code"})
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "alist",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by handler and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "method"})
+	ProxiedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "proxied_bytes_total",
+		Help:      "Bytes sent to the clients by the proxied downloads.",
+	})
+	DriverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "driver_errors_total",
+		Help:      "Count of the failed driver operations by driver and operation.",
+	}, []string{"driver", "op"})
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "cache_requests_total",
+		Help:      "Count of the cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, ProxiedBytes, DriverErrors, CacheRequests,
+	)
+}
+
+// CacheLookup counts a lookup of the cache name, the hit ratio is
+// hit / (hit + miss)
+func CacheLookup(name string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(name, result).Inc()
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	ACLSubjectUser = "user"
+	ACLSubjectRole = "role"
+)
+
+// ACLEntry grants a user, or the users of a role, the permissions on a file
+// or folder and what is under it, on top of the ones of their roles
+type ACLEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Path        string    `json:"path" gorm:"size:4096;not null"`
+	SubjectType string    `json:"subject_type" gorm:"size:8;not null"`
+	SubjectID   uint      `json:"subject_id" gorm:"not null"`
+	Permission  int32     `json:"permission"`
+	CreatorID   uint      `json:"creator_id" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AppliesTo reports whether the entry grants u
+func (e ACLEntry) AppliesTo(u *User) bool {
+	switch e.SubjectType {
+	case ACLSubjectUser:
+		return e.SubjectID == u.ID
+	case ACLSubjectRole:
+		return u.Role.Contains(int(e.SubjectID))
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+const (
+	APITokenScopeAll    = "all"
+	APITokenScopeRead   = "read"
+	APITokenScopeUpload = "upload"
+)
+
+// APIToken lets a script act as its user without the password, limited to
+// the apis of its scope and to the files under its path
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"size:255;not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Hint       string     `json:"hint" gorm:"size:16"`
+	Scope      string     `json:"scope" gorm:"size:16;not null"`
+	Path       string     `json:"path" gorm:"size:4096"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+func ValidAPITokenScope(scope string) bool {
+	switch scope {
+	case APITokenScopeAll, APITokenScopeRead, APITokenScopeUpload:
+		return true
+	}
+	return false
+}
@@ -80,6 +80,8 @@ type ArchiveDecompressArgs struct {
 	ArchiveInnerArgs
 	CacheFull     bool
 	PutIntoNewDir bool
+	// SkipExisting keeps the files already in the dst dir instead of overwriting them
+	SkipExisting bool
 }
 
 type RangeReadCloserIF interface {
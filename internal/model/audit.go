@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+const (
+	AuditMakeDir = "mkdir"
+	AuditMove    = "move"
+	AuditCopy    = "copy"
+	AuditRename  = "rename"
+	AuditRemove  = "remove"
+	AuditPut     = "put"
+
+	AuditLogin         = "login"
+	AuditUserCreate    = "user_create"
+	AuditUserUpdate    = "user_update"
+	AuditUserDelete    = "user_delete"
+	AuditRoleCreate    = "role_create"
+	AuditRoleUpdate    = "role_update"
+	AuditRoleDelete    = "role_delete"
+	AuditACLGrant      = "acl_grant"
+	AuditACLRevoke     = "acl_revoke"
+	AuditStorageCreate = "storage_create"
+	AuditStorageUpdate = "storage_update"
+	AuditStorageDelete = "storage_delete"
+)
+
+// AuditLog records a mutation of an object or a security relevant operation,
+// and the user who made it
+type AuditLog struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Username string `json:"username"`
+	Action   string `json:"action" gorm:"index"`
+	// Path is the mount path of the object after the mutation
+	Path string `json:"path" gorm:"size:4096"`
+	// SrcPath is the source of move, copy and rename
+	SrcPath string `json:"src_path,omitempty" gorm:"size:4096"`
+	// Target is the user, role or storage of the operations not on objects
+	Target    string    `json:"target,omitempty" gorm:"size:255"`
+	IP        string    `json:"ip,omitempty" gorm:"size:64"`
+	Failed    bool      `json:"failed" gorm:"default:false;index"`
+	Error     string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+type AuditLogQuery struct {
+	UserID  uint
+	Parent  string
+	Actions []string
+	From    *time.Time
+	To      *time.Time
+	// Username matches the user by name, the failed logins have no UserID
+	Username string
+	// WithFailed includes the failed operations, FailedOnly only them
+	WithFailed bool
+	FailedOnly bool
+	PageReq
+}
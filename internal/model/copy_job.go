@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+const (
+	CopyJobOverwrite = "overwrite"
+	CopyJobSkip      = "skip"
+
+	CopyJobModeCopy = "copy"
+	CopyJobModeSync = "sync"
+)
+
+// CopyJob copies SrcPath into the DstPath dir at the minutes of Cron, or in
+// the sync mode mirrors the content of SrcPath into DstPath
+type CopyJob struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name"`
+	SrcPath string `json:"src_path" gorm:"size:4096"`
+	DstPath string `json:"dst_path" gorm:"size:4096"`
+	// Cron has 5 fields: minute, hour, day of month, month and day of week
+	Cron string `json:"cron"`
+	// ConflictPolicy is overwrite or skip for the files existing in DstPath
+	ConflictPolicy string `json:"conflict_policy"`
+	// Mode is copy or sync, copy by default
+	Mode string `json:"mode"`
+	// SyncCompare and SyncDelete are the compare and delete of a sync
+	SyncCompare string     `json:"sync_compare"`
+	SyncDelete  bool       `json:"sync_delete"`
+	Disabled    bool       `json:"disabled"`
+	LastRunAt   *time.Time `json:"last_run_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CopyJobRun records a run of a CopyJob
+type CopyJobRun struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     uint      `json:"job_id" gorm:"index"`
+	Manual    bool      `json:"manual"`
+	StartedAt time.Time `json:"started_at"`
+	// TaskID is the copy task added, empty if the copy was done at once
+	TaskID string `json:"task_id"`
+	Error  string `json:"error"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// DavProp is a dead property set by a webdav client with PROPPATCH, it's kept
+// by the storage and the path in it, so it follows the storage when remounted
+type DavProp struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	StorageID uint `json:"storage_id" gorm:"index:idx_dav_prop_path"`
+	// PathHash is the sha1 of Path, the paths are too long to be indexed
+	PathHash string `json:"-" gorm:"index:idx_dav_prop_path;size:40"`
+	// Path is the actual path in the storage
+	Path     string `json:"path" gorm:"size:4096"`
+	Space    string `json:"space" gorm:"size:255"`
+	Local    string `json:"local" gorm:"size:255"`
+	Lang     string `json:"lang" gorm:"size:64"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
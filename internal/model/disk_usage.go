@@ -0,0 +1,11 @@
+package model
+
+// DiskUsage is the space of a storage in bytes
+type DiskUsage struct {
+	TotalSpace int64 `json:"total_space"`
+	FreeSpace  int64 `json:"free_space"`
+}
+
+func (d *DiskUsage) UsedSpace() int64 {
+	return d.TotalSpace - d.FreeSpace
+}
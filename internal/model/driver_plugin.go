@@ -0,0 +1,14 @@
+package model
+
+// DriverPlugin is an external storage driver, served over gRPC by Command run
+// by alist, or by a process already listening on Address
+type DriverPlugin struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:255"`
+	// Command is the name of the plugin binary in the plugins dir of the data
+	// dir, run without arguments
+	Command string `json:"command" gorm:"type:text"`
+	// Address is a unix:///path or host:port address, used if Command is empty
+	Address  string `json:"address" gorm:"size:1024"`
+	Disabled bool   `json:"disabled"`
+}
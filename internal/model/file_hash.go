@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// FileHash records the hashes computed by reading a file, they are valid while
+// the size and the modification time of the file are unchanged
+type FileHash struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// PathHash is the sha1 of Path, the paths are too long to be indexed
+	PathHash    string    `json:"-" gorm:"uniqueIndex;size:40"`
+	Path        string    `json:"path" gorm:"size:4096"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	HashInfoStr string    `json:"hashinfo" gorm:"size:1024"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// FileVersion records a previous version of a file, kept in the version dir of
+// its storage when the file was overwritten
+type FileVersion struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// PathHash is the sha1 of Path, the paths are too long to be indexed
+	PathHash string `json:"-" gorm:"index;size:40"`
+	// Path is the mount path of the file the version belongs to
+	Path string `json:"path" gorm:"size:4096"`
+	// BlobPath is the mount path of the dir holding the version, under the
+	// name of the file
+	BlobPath  string    `json:"blob_path" gorm:"size:4096"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -13,4 +13,7 @@ type Meta struct {
 	RSub      bool   `json:"r_sub"`
 	Header    string `json:"header"`
 	HeaderSub bool   `json:"header_sub"`
+	// Drop makes the folder a file drop, the users who can't write it can
+	// upload into it but neither see nor overwrite what is in it
+	Drop bool `json:"drop"`
 }
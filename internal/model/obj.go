@@ -82,6 +82,12 @@ type Thumb interface {
 	Thumb() string
 }
 
+// Symlink is implemented by objs that represent a link which is presented as-is
+// instead of being followed
+type Symlink interface {
+	LinkTarget() string
+}
+
 type SetPath interface {
 	SetPath(path string)
 }
@@ -179,6 +185,16 @@ func GetUrl(obj Obj) (url string, ok bool) {
 	return url, false
 }
 
+func GetLinkTarget(obj Obj) (target string, ok bool) {
+	if obj, ok := obj.(Symlink); ok {
+		return obj.LinkTarget(), true
+	}
+	if unwrap, ok := obj.(ObjUnwrap); ok {
+		return GetLinkTarget(unwrap.Unwrap())
+	}
+	return target, false
+}
+
 func GetStorageClass(obj Obj) (string, bool) {
 	if provider, ok := obj.(StorageClassProvider); ok {
 		value := provider.StorageClass()
@@ -201,6 +217,8 @@ func GetRawObject(obj Obj) *Object {
 		return &v.Object
 	case *ObjectURL:
 		return &v.Object
+	case *ObjLink:
+		return &v.Object
 	case *Object:
 		return v
 	}
@@ -113,6 +113,15 @@ type ObjectURL struct {
 	Url
 }
 
+type ObjLink struct {
+	Object
+	Target string
+}
+
+func (o *ObjLink) LinkTarget() string {
+	return o.Target
+}
+
 type ObjThumbURL struct {
 	Object
 	Thumbnail
@@ -22,6 +22,13 @@ type Role struct {
 	PermissionScopes []PermissionEntry `json:"permission_scopes" gorm:"-"`
 	// RawPermission is the JSON representation of PermissionScopes stored in DB.
 	RawPermission string `json:"-" gorm:"type:text"`
+	// DownloadSpeed limits the downloads of the users in KB/s, 0 for no limit
+	DownloadSpeed int `json:"download_speed"`
+	// Require2FA makes the users enroll TOTP before using the api
+	Require2FA bool `json:"require_2fa" gorm:"default:false"`
+	// AllowedIPs are the ranges the users can be used from, separated by
+	// commas or lines, empty for anywhere
+	AllowedIPs string `json:"allowed_ips" gorm:"type:text"`
 }
 
 // BeforeSave GORM hook serializes PermissionScopes into RawPermission.
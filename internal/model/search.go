@@ -1,7 +1,11 @@
 package model
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -17,14 +21,101 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// the size filters only match files, 0 for no limit
+	MinSize        int64      `json:"min_size"`
+	MaxSize        int64      `json:"max_size"`
+	ModifiedAfter  *time.Time `json:"modified_after"`
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// Exts only match the files with these extensions, such as mp4
+	Exts []string `json:"exts"`
+	// OrderBy is name, size, modified or relevance, the order of the searcher if empty
+	OrderBy string `json:"order_by"`
+	// OrderDirection is asc or desc
+	OrderDirection string `json:"order_direction"`
+	// Cursor continues after the page which returned it, the page is ignored then
+	Cursor string `json:"cursor"`
+	// After is the decoded Cursor
+	After *SearchCursor `json:"-"`
+	// Restrict leaves out what the user can't see, nil for all
+	Restrict *SearchRestriction `json:"-"`
 	PageReq
 }
 
+// the orders of the search results
+const (
+	SearchOrderName      = "name"
+	SearchOrderSize      = "size"
+	SearchOrderModified  = "modified"
+	SearchOrderRelevance = "relevance"
+)
+
+// SearchCursor is where a page of search results ends: the searchers which
+// can seek continue after the last node in the order, which ties on parent
+// then name, and the others skip Offset results
+type SearchCursor struct {
+	Offset   int       `json:"o"`
+	Parent   string    `json:"p,omitempty"`
+	Name     string    `json:"n,omitempty"`
+	Size     int64     `json:"s,omitempty"`
+	Modified time.Time `json:"m,omitempty"`
+}
+
+func (c *SearchCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeSearchCursor(s string) (*SearchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c SearchCursor
+	if err = json.Unmarshal(b, &c); err != nil || c.Offset < 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// NextCursor returns the cursor continuing after last, consumed results
+// after the previous cursor or page
+func (p *SearchReq) NextCursor(last SearchNode, consumed int) *SearchCursor {
+	return &SearchCursor{
+		Offset:   p.Offset() + consumed,
+		Parent:   last.Parent,
+		Name:     last.Name,
+		Size:     last.Size,
+		Modified: last.Modified,
+	}
+}
+
+// Offset is the count of results to skip for the searchers which can't seek
+func (p *SearchReq) Offset() int {
+	if p.After != nil {
+		return p.After.Offset
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// Seek reports whether the searchers which can seek continue after the node
+// of the cursor, the relevance can't be sought
+func (p *SearchReq) Seek() bool {
+	return p.After != nil && p.OrderBy != "" && p.OrderBy != SearchOrderRelevance
+}
+
+// Desc reports whether the results are in descending order
+func (p *SearchReq) Desc() bool {
+	return p.OrderDirection == "desc"
+}
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// Content is the text of the document when the content is indexed
+	Content string `json:"content,omitempty" gorm:"type:text"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +125,72 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if p.MinSize < 0 || p.MaxSize < 0 {
+		return fmt.Errorf("size can't < 0")
+	}
+	if p.MaxSize > 0 && p.MinSize > p.MaxSize {
+		return fmt.Errorf("min_size can't > max_size")
+	}
+	exts := p.Exts[:0]
+	for _, ext := range p.Exts {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	p.Exts = exts
+	switch p.OrderBy {
+	case "", SearchOrderName, SearchOrderSize, SearchOrderModified, SearchOrderRelevance:
+	default:
+		return fmt.Errorf("invalid order_by: %s", p.OrderBy)
+	}
+	if p.OrderDirection != "" && p.OrderDirection != "asc" && p.OrderDirection != "desc" {
+		return fmt.Errorf("invalid order_direction: %s", p.OrderDirection)
+	}
+	if p.Cursor != "" {
+		after, err := DecodeSearchCursor(p.Cursor)
+		if err != nil {
+			return err
+		}
+		p.After = after
+	}
 	return nil
 }
 
+// FilesOnly reports whether the filters exclude the dirs
+func (p *SearchReq) FilesOnly() bool {
+	return p.Scope == 2 || p.MinSize > 0 || p.MaxSize > 0 || len(p.Exts) > 0
+}
+
+// Filter reports whether node passes the scope and the filters, it's for the
+// searchers which can't filter by themselves
+func (p *SearchReq) Filter(node *SearchNode) bool {
+	if p.ModifiedAfter != nil && node.Modified.Before(*p.ModifiedAfter) {
+		return false
+	}
+	if p.ModifiedBefore != nil && node.Modified.After(*p.ModifiedBefore) {
+		return false
+	}
+	if node.IsDir {
+		return !p.FilesOnly()
+	}
+	if p.Scope == 1 {
+		return false
+	}
+	if node.Size < p.MinSize || (p.MaxSize > 0 && node.Size > p.MaxSize) {
+		return false
+	}
+	if len(p.Exts) == 0 {
+		return true
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(node.Name), "."))
+	for _, e := range p.Exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
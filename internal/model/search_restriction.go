@@ -0,0 +1,147 @@
+package model
+
+import (
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/dlclark/regexp2"
+)
+
+// SearchRestriction is what a user can't see, the searchers leave it out in
+// the index so it's not in the counts either
+type SearchRestriction struct {
+	// Roots are the paths the results must be in, nil for all
+	Roots []string
+	Hides []SearchHide
+}
+
+// SearchHide is a hide rule of a meta, it hides the names matching Rule in
+// Dir, or under it if Sub, but in the Except dirs where another meta applies
+type SearchHide struct {
+	Dir    string
+	Sub    bool
+	Except []string
+	Rule   string
+	// Glob is Rule as a pattern of literals and *, empty if it can't be
+	Glob string
+	re   *regexp2.Regexp
+}
+
+func NewSearchHide(dir string, sub bool, except []string, rule string) SearchHide {
+	re, _ := regexp2.Compile(rule, regexp2.None)
+	return SearchHide{
+		Dir:    dir,
+		Sub:    sub,
+		Except: except,
+		Rule:   rule,
+		Glob:   ruleGlob(rule),
+		re:     re,
+	}
+}
+
+// Applies reports whether the rule applies to the nodes in parent
+func (h *SearchHide) Applies(parent string) bool {
+	if parent != h.Dir && !(h.Sub && utils.IsSubPath(h.Dir, parent)) {
+		return false
+	}
+	for _, except := range h.Except {
+		if utils.IsSubPath(except, parent) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hides reports whether the rule hides node
+func (h *SearchHide) Hides(node *SearchNode) bool {
+	if h.re == nil || !h.Applies(node.Parent) {
+		return false
+	}
+	isMatch, _ := h.re.MatchString(node.Name)
+	return isMatch
+}
+
+// InRoots reports whether p is in one of the roots
+func (r *SearchRestriction) InRoots(p string) bool {
+	if r == nil || r.Roots == nil {
+		return true
+	}
+	for _, root := range r.Roots {
+		if utils.IsSubPath(root, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reachable reports whether the dir p may have nodes in the roots
+func (r *SearchRestriction) Reachable(p string) bool {
+	if r.InRoots(p) {
+		return true
+	}
+	for _, root := range r.Roots {
+		if utils.IsSubPath(p, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow reports whether node can be seen, it's for the searchers which can't
+// filter by themselves
+func (r *SearchRestriction) Allow(node *SearchNode) bool {
+	if r == nil {
+		return true
+	}
+	if !r.InRoots(path.Join(node.Parent, node.Name)) {
+		return false
+	}
+	for i := range r.Hides {
+		if r.Hides[i].Hides(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleGlob turns the rules made of literals, .* and anchors into globs, as
+// most of the indexes match globs but not regexps
+func ruleGlob(rule string) string {
+	var b strings.Builder
+	s := rule
+	if strings.HasPrefix(s, "^") {
+		s = s[1:]
+	} else {
+		b.WriteByte('*')
+	}
+	end := "*"
+	if strings.HasSuffix(s, "$") && !strings.HasSuffix(s, `\$`) {
+		s, end = s[:len(s)-1], ""
+	}
+	const meta = `.^$*+?()[]{}|\`
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(s) || !strings.ContainsRune(meta+"/-", rune(s[i+1])) {
+				return ""
+			}
+			i++
+			// the globs have no escape
+			if s[i] == '*' || s[i] == '?' {
+				return ""
+			}
+			b.WriteByte(s[i])
+		case c == '.' && i+1 < len(s) && s[i+1] == '*':
+			b.WriteByte('*')
+			i++
+		case strings.IndexByte(meta, c) >= 0:
+			return ""
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteString(end)
+	return b.String()
+}
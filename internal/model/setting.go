@@ -14,6 +14,7 @@ const (
 	FTP
 	TRAFFIC
 	FRP
+	NOTIFY
 )
 
 const (
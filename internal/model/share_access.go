@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	ShareAccessView     = "view"
+	ShareAccessDownload = "download"
+	ShareAccessPreview  = "preview"
+)
+
+// ShareAccess records a visit or a download of a share
+type ShareAccess struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	ShareID string `json:"share_id" gorm:"index;size:32;not null"`
+	Kind    string `json:"kind" gorm:"size:16"`
+	// Path is the path in the share of the file downloaded or previewed
+	Path      string `json:"path" gorm:"size:4096"`
+	IP        string `json:"ip" gorm:"size:64"`
+	UserAgent string `json:"user_agent" gorm:"size:255"`
+	// Bytes are the ones served by alist, nothing for a redirect
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+type ShareStats struct {
+	Views        int64      `json:"views"`
+	Downloads    int64      `json:"downloads"`
+	Previews     int64      `json:"previews"`
+	BytesServed  int64      `json:"bytes_served"`
+	UniqueIPs    int64      `json:"unique_ips"`
+	LastAccessAt *time.Time `json:"last_access_at"`
+}
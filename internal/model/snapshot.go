@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Snapshot records the structure of a directory at a point in time
+type Snapshot struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Path      string    `json:"path" gorm:"size:4096;not null"`
+	Auto      bool      `json:"auto"`
+	DirCount  int       `json:"dir_count"`
+	FileCount int       `json:"file_count"`
+	TotalSize int64     `json:"total_size"`
+	Entries   string    `json:"-" gorm:"type:text"` // json of []SnapshotEntry
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SnapshotEntry struct {
+	// Path is relative to the snapshotted directory
+	Path     string    `json:"path"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	HashInfo string    `json:"hash_info,omitempty"`
+}
+
+type SnapshotRestoreFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+type SnapshotRestoreResult struct {
+	Restored []string                 `json:"restored"`
+	Tasks    []string                 `json:"tasks"`
+	Failed   []SnapshotRestoreFailure `json:"failed"`
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Storage struct {
@@ -17,8 +19,26 @@ type Storage struct {
 	Disabled        bool      `json:"disabled"` // if disabled
 	DisableIndex    bool      `json:"disable_index"`
 	EnableSign      bool      `json:"enable_sign"`
+	// NameMatch is how names are compared when checking whether a name is taken:
+	// exact, case, unicode or case_unicode
+	NameMatch string `json:"name_match"`
+	// MaxConcurrency is the number of driver operations run at once, 0 for no limit
+	MaxConcurrency int `json:"max_concurrency"`
+	// MaxTasks is the number of copy and upload tasks writing to the storage at
+	// once, the others wait for their turn, 0 for no limit
+	MaxTasks int `json:"max_tasks"`
+	// UploadConcurrency is the number of parts uploaded at once by the drivers
+	// uploading in parts, the default of the driver if 0
+	UploadConcurrency int `json:"upload_concurrency"`
+	// KeepVersions is the number of previous versions kept of each overwritten
+	// file, versioning is off if 0
+	KeepVersions int `json:"keep_versions"`
+	// VersionDir is the dir of the storage holding the previous versions,
+	// /.versions if empty
+	VersionDir string `json:"version_dir"`
 	Sort
 	Proxy
+	Timeout
 }
 
 type Sort struct {
@@ -33,6 +53,19 @@ type Proxy struct {
 	ProxyRange    bool   `json:"proxy_range"`
 	DownProxyUrl  string `json:"down_proxy_url"`
 	DownProxySign bool   `json:"down_proxy_sign" gorm:"default:true"`
+	// ReadAheadSize is the chunk size in KB prefetched by the proxy,
+	// ReadAheadDepth is the number of chunks, 0 disables read-ahead
+	ReadAheadSize  int `json:"read_ahead_size"`
+	ReadAheadDepth int `json:"read_ahead_depth"`
+}
+
+// Timeout holds the operation timeouts in seconds of the storage,
+// zero means using the default of the driver
+type Timeout struct {
+	ListTimeout int `json:"list_timeout"`
+	GetTimeout  int `json:"get_timeout"`
+	LinkTimeout int `json:"link_timeout"`
+	PutTimeout  int `json:"put_timeout"`
 }
 
 func (s *Storage) GetStorage() *Storage {
@@ -47,6 +80,29 @@ func (s *Storage) SetStatus(status string) {
 	s.Status = status
 }
 
+// UploadPartConcurrency returns the number of parts uploaded at once, def if
+// the storage doesn't set it
+func (s *Storage) UploadPartConcurrency(def int) int {
+	if s.UploadConcurrency > 0 {
+		return s.UploadConcurrency
+	}
+	return def
+}
+
+// NameKey returns the key the name collides on in the storage
+func (s *Storage) NameKey(name string) string {
+	switch s.NameMatch {
+	case "case":
+		return utils.NormalizeName(name, true, false)
+	case "unicode":
+		return utils.NormalizeName(name, false, true)
+	case "case_unicode":
+		return utils.NormalizeName(name, true, true)
+	default:
+		return name
+	}
+}
+
 func (p Proxy) Webdav302() bool {
 	return p.WebdavPolicy == "302_redirect"
 }
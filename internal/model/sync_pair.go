@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// the ways of resolving a file changed on both sides of a SyncPair
+const (
+	SyncConflictNewest = "newest"
+	// SyncConflictKeepBoth keeps the version of B under a suffixed name
+	SyncConflictKeepBoth = "keep_both"
+	// SyncConflictManual queues the conflict to be resolved by the api
+	SyncConflictManual = "manual"
+)
+
+// SyncPair syncs the files of two dirs both ways, at the minutes of Cron if
+// set. The deletions and changes are told from the SyncPairStates of the
+// last run.
+type SyncPair struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	PathA    string `json:"path_a" gorm:"size:4096"`
+	PathB    string `json:"path_b" gorm:"size:4096"`
+	Conflict string `json:"conflict"`
+	// Cron has 5 fields as the one of a CopyJob, the pair is only run by the
+	// api if empty
+	Cron      string     `json:"cron"`
+	Disabled  bool       `json:"disabled"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SyncPairState is a file of a SyncPair as it was on both sides after the
+// last run. The modified time of the side written by the run isn't known
+// until the copy ends, it's zero and the size alone is compared.
+type SyncPairState struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PairID    uint      `json:"pair_id" gorm:"index"`
+	Path      string    `json:"path" gorm:"size:4096"`
+	ASize     int64     `json:"a_size"`
+	AModified time.Time `json:"a_modified"`
+	BSize     int64     `json:"b_size"`
+	BModified time.Time `json:"b_modified"`
+}
+
+// SyncConflict is a file changed on both sides of a SyncPair waiting to be
+// resolved, Path is relative to the dirs of the pair
+type SyncConflict struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PairID    uint      `json:"pair_id" gorm:"index"`
+	Path      string    `json:"path" gorm:"size:4096"`
+	ASize     int64     `json:"a_size"`
+	AModified time.Time `json:"a_modified"`
+	BSize     int64     `json:"b_size"`
+	BModified time.Time `json:"b_modified"`
+	CreatedAt time.Time `json:"created_at"`
+}
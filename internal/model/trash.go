@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TrashItem records an obj moved to the .trash dir of its storage instead of
+// being removed
+type TrashItem struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// OriginalPath is the mount path of the obj before it was trashed
+	OriginalPath string `json:"original_path" gorm:"size:4096"`
+	// TrashPath is the mount path of the dir holding the obj in the trash
+	TrashPath string    `json:"trash_path" gorm:"size:4096"`
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Username  string    `json:"username"`
+	DeletedAt time.Time `json:"deleted_at" gorm:"index"`
+}
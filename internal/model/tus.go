@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// TusUpload is the state of a resumable upload, the bytes received so far are
+// kept in a temp file until the upload is complete
+type TusUpload struct {
+	ID string `json:"id" gorm:"primaryKey;size:32"`
+	// Path is the mount path of the uploaded file
+	Path     string    `json:"path" gorm:"size:4096"`
+	Size     int64     `json:"size"`
+	Offset   int64     `json:"offset" gorm:"column:upload_offset"`
+	Mimetype string    `json:"mimetype"`
+	Modified time.Time `json:"modified"`
+	UserID   uint      `json:"user_id"`
+	// Overwrite lets the upload replace an existing file once complete
+	Overwrite bool      `json:"overwrite"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+}
@@ -55,6 +55,20 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// UploadQuota is the bytes the user can upload, 0 for no limit
+	UploadQuota int64 `json:"upload_quota"`
+	// UploadUsed is the bytes uploaded so far, counted against UploadQuota
+	UploadUsed int64 `json:"upload_used"`
+	// HomeQuota is the bytes which can be stored under BasePath, 0 for no limit
+	HomeQuota int64 `json:"home_quota"`
+	// HomeUsed is the bytes stored under BasePath as counted by the writes
+	HomeUsed int64 `json:"home_used"`
+	// DownloadSpeed limits the downloads of the user in KB/s, the speed of its
+	// roles applies if 0
+	DownloadSpeed int `json:"download_speed"`
+	// AllowedIPs are the ranges the user can be used from, separated by
+	// commas or lines, the ones of its roles apply if empty
+	AllowedIPs string `json:"allowed_ips" gorm:"type:text"`
 }
 
 func (u *User) IsGuest() bool {
@@ -65,6 +79,11 @@ func (u *User) IsAdmin() bool {
 	return u.Role.Contains(ADMIN)
 }
 
+// CanUpload reports whether size more bytes fit in the upload quota
+func (u *User) CanUpload(size int64) bool {
+	return u.UploadQuota <= 0 || u.UploadUsed+size <= u.UploadQuota
+}
+
 func (u *User) ValidateRawPassword(password string) error {
 	return u.ValidatePwdStaticHash(StaticHash(password))
 }
@@ -223,6 +242,10 @@ func (u *User) WebAuthnIcon() string {
 // to avoid an import cycle between model and op.
 var FetchRole func(uint) (*Role, error)
 
+// FetchACLPaths is used to load the paths granted to a user by the acl. It
+// should be set by the op package
+var FetchACLPaths func(*User) []string
+
 // GetAllBasePathsFromRoles returns all permission paths from user's roles
 func GetAllBasePathsFromRoles(u *User) []string {
 	basePaths := make([]string, 0)
@@ -246,5 +269,13 @@ func GetAllBasePathsFromRoles(u *User) []string {
 			}
 		}
 	}
+	if FetchACLPaths != nil {
+		for _, p := range FetchACLPaths(u) {
+			if _, ok := seen[p]; !ok {
+				basePaths = append(basePaths, p)
+				seen[p] = struct{}{}
+			}
+		}
+	}
 	return basePaths
 }
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// Webhook is a subscriber of the file change events, they are posted to URL
+// if their type is in Events and their path is under one of PathFilters
+type Webhook struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:255"`
+	URL  string `json:"url" gorm:"size:2048;not null" binding:"required"`
+	// Secret signs the body with HMAC-SHA256 in the X-Alist-Signature header
+	Secret string `json:"secret" gorm:"size:255"`
+	// Events are comma separated among create, upload, move, copy, rename and
+	// delete, empty for all of them
+	Events string `json:"events"`
+	// PathFilters are the paths or path globs the events are sent for, one per
+	// line, empty for all the paths
+	PathFilters string    `json:"path_filters" gorm:"type:text"`
+	Disabled    bool      `json:"disabled"`
+	LastStatus  int       `json:"last_status"`
+	LastError   string    `json:"last_error" gorm:"type:text"`
+	LastSentAt  time.Time `json:"last_sent_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const (
+	FileEventCreate = "create"
+	FileEventUpload = "upload"
+	FileEventMove   = "move"
+	FileEventCopy   = "copy"
+	FileEventRename = "rename"
+	FileEventDelete = "delete"
+)
+
+func ValidFileEvent(typ string) bool {
+	switch typ {
+	case FileEventCreate, FileEventUpload, FileEventMove, FileEventCopy, FileEventRename, FileEventDelete:
+		return true
+	}
+	return false
+}
+
+// FileEvent is a change of a file or folder made by a user, the paths are
+// mount paths, SrcPath is the former path of a move, copy or rename
+type FileEvent struct {
+	Type     string    `json:"type"`
+	Path     string    `json:"path"`
+	SrcPath  string    `json:"src_path,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Time     time.Time `json:"time"`
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register(telegram{})
+	Register(smtpNotifier{})
+	Register(webhook{})
+	Register(gotify{})
+}
+
+func text(n Notification) string {
+	return "[alist] " + n.Title + "\n" + n.Message
+}
+
+func checkResp(res *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return errors.Errorf("responded %s: %s", res.Status(), res.String())
+	}
+	return nil
+}
+
+type telegram struct{}
+
+func (telegram) Name() string {
+	return "telegram"
+}
+
+func (telegram) Enabled() bool {
+	return setting.GetStr(conf.NotifyTelegramBotToken) != "" && setting.GetStr(conf.NotifyTelegramChatID) != ""
+}
+
+func (telegram) Send(ctx context.Context, n Notification) error {
+	url := "https://api.telegram.org/bot" + setting.GetStr(conf.NotifyTelegramBotToken) + "/sendMessage"
+	return checkResp(base.RestyClient.R().SetContext(ctx).
+		SetBody(map[string]any{
+			"chat_id": setting.GetStr(conf.NotifyTelegramChatID),
+			"text":    text(n),
+		}).Post(url))
+}
+
+type webhook struct{}
+
+func (webhook) Name() string {
+	return "webhook"
+}
+
+func (webhook) Enabled() bool {
+	return setting.GetStr(conf.NotifyWebhookURL) != ""
+}
+
+func (webhook) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req := base.RestyClient.R().SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body)
+	if secret := setting.GetStr(conf.NotifyWebhookSecret); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.SetHeader("X-Alist-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return checkResp(req.Post(setting.GetStr(conf.NotifyWebhookURL)))
+}
+
+type gotify struct{}
+
+func (gotify) Name() string {
+	return "gotify"
+}
+
+func (gotify) Enabled() bool {
+	return setting.GetStr(conf.NotifyGotifyURL) != "" && setting.GetStr(conf.NotifyGotifyToken) != ""
+}
+
+func (gotify) Send(ctx context.Context, n Notification) error {
+	url := strings.TrimSuffix(setting.GetStr(conf.NotifyGotifyURL), "/") + "/message"
+	return checkResp(base.RestyClient.R().SetContext(ctx).
+		SetHeader("X-Gotify-Key", setting.GetStr(conf.NotifyGotifyToken)).
+		SetBody(map[string]any{
+			"title":    "[alist] " + n.Title,
+			"message":  n.Message,
+			"priority": 5,
+		}).Post(url))
+}
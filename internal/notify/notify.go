@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	TaskFailed     = "task_failed"
+	StorageOffline = "storage_offline"
+	LoginAnomaly   = "login_anomaly"
+)
+
+// the same notification isn't sent again within this time, so a storage
+// failing every probe doesn't flood the channels
+const dedupWindow = 10 * time.Minute
+
+const sendTimeout = 15 * time.Second
+
+type Notification struct {
+	Event   string    `json:"event"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier is a channel the notifications are sent through
+type Notifier interface {
+	Name() string
+	// Enabled reports whether the channel is configured in the settings
+	Enabled() bool
+	Send(ctx context.Context, n Notification) error
+}
+
+var (
+	notifiers []Notifier
+	sent      = cache.NewMemCache[struct{}]()
+)
+
+// Register adds a channel, it's meant to be called on init
+func Register(n Notifier) {
+	notifiers = append(notifiers, n)
+}
+
+// Notify sends a notification of event through the enabled channels in the
+// background, if the event is enabled in the settings
+func Notify(event, title, message string) {
+	events := strings.Split(setting.GetStr(conf.NotifyEvents), ",")
+	if !utils.SliceContains(utils.MustSliceConvert(events, strings.TrimSpace), event) {
+		return
+	}
+	key := event + "\n" + title + "\n" + message
+	if _, ok := sent.Get(key); ok {
+		return
+	}
+	sent.Set(key, struct{}{}, cache.WithEx[struct{}](dedupWindow))
+	n := Notification{Event: event, Title: title, Message: message, Time: time.Now()}
+	for _, notifier := range notifiers {
+		if !notifier.Enabled() {
+			continue
+		}
+		go func(notifier Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := notifier.Send(ctx, n); err != nil {
+				log.Warnf("failed send notification through %s: %+v", notifier.Name(), err)
+			}
+		}(notifier)
+	}
+}
+
+// Test sends a test notification through the channel name right away, even if
+// the event isn't enabled
+func Test(name string) error {
+	for _, notifier := range notifiers {
+		if notifier.Name() != name {
+			continue
+		}
+		if !notifier.Enabled() {
+			return errors.Errorf("%s isn't configured", name)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+		return notifier.Send(ctx, Notification{
+			Event:   "test",
+			Title:   "Test notification",
+			Message: "The notifications of alist are sent through " + name + ".",
+			Time:    time.Now(),
+		})
+	}
+	return errors.Errorf("unknown notifier: %s", name)
+}
+
+// Names returns the names of the channels and whether they are configured
+func Names() map[string]bool {
+	res := make(map[string]bool, len(notifiers))
+	for _, notifier := range notifiers {
+		res[notifier.Name()] = notifier.Enabled()
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+)
+
+type smtpNotifier struct{}
+
+func (smtpNotifier) Name() string {
+	return "smtp"
+}
+
+func (smtpNotifier) Enabled() bool {
+	return setting.GetStr(conf.NotifySMTPHost) != "" && recipients() != nil
+}
+
+func recipients() []string {
+	var res []string
+	for _, to := range strings.Split(setting.GetStr(conf.NotifySMTPTo), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			res = append(res, to)
+		}
+	}
+	return res
+}
+
+func (smtpNotifier) Send(ctx context.Context, n Notification) error {
+	host := setting.GetStr(conf.NotifySMTPHost)
+	port := setting.GetInt(conf.NotifySMTPPort, 587)
+	username := setting.GetStr(conf.NotifySMTPUsername)
+	from := setting.GetStr(conf.NotifySMTPFrom)
+	if from == "" {
+		from = username
+	}
+	to := recipients()
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, strings.Join(to, ", "), mime.QEncoding.Encode("UTF-8", "[alist] "+n.Title),
+		n.Time.Format(time.RFC1123Z), strings.ReplaceAll(n.Message, "\n", "\r\n"))
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	// 465 is the port of the implicit TLS, the others upgrade by STARTTLS
+	if port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return errors.WithStack(err)
+	}
+	defer c.Close()
+	if port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if username != "" {
+		if err := c.Auth(smtp.PlainAuth("", username, setting.GetStr(conf.NotifySMTPPassword), host)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return c.Quit()
+}
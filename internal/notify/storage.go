@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func init() {
+	op.RegisterStorageHook(func(typ string, storage driver.Driver) {
+		if typ == "del" {
+			return
+		}
+		if s := storage.GetStorage(); s.Status != op.WORK && s.Status != op.DISABLED {
+			StorageFailed(s.MountPath, s.Status)
+		}
+	})
+}
+
+// StorageFailed notifies that the storage at mountPath is offline for reason
+func StorageFailed(mountPath, reason string) {
+	Notify(StorageOffline, fmt.Sprintf("The storage %s is offline", mountPath), reason)
+}
@@ -0,0 +1,130 @@
+package tool
+
+import (
+	"context"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// how long to wait for the canceled transfers to stop writing
+const cancelWaitTimeout = 10 * time.Second
+
+type CancelResult struct {
+	// TempDir is the removed temp dir of the download, empty if there was none
+	TempDir string `json:"temp_dir,omitempty"`
+	// Transfers are the ids of the canceled transfer tasks
+	Transfers []string `json:"transfers"`
+	// PartialRemoved and PartialKept are the partially transferred destination objects
+	PartialRemoved []string `json:"partial_removed"`
+	PartialKept    []string `json:"partial_kept"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// Cancel stops the download and the transfers of what it downloaded, then removes
+// its temp files. Partially transferred destination objects the transfers created
+// are removed unless keepPartial. The temp dir is not removed when it is the
+// destination itself.
+func Cancel(ctx context.Context, t *DownloadTask, keepPartial bool) *CancelResult {
+	res := &CancelResult{}
+	DownloadTaskManager.Cancel(t.GetID())
+	transfers := TransferTaskManager.GetByCondition(func(tt *TransferTask) bool {
+		return utils.IsSubPath(t.TempDir, transferSrcPath(tt)) &&
+			argsContains(tt.GetState(), tache.StatePending, tache.StateRunning, tache.StateWaitingRetry, tache.StateBeforeRetry)
+	})
+	var running []*TransferTask
+	for _, tt := range transfers {
+		// pending transfers haven't written anything
+		if tt.GetState() == tache.StateRunning && tt.DstStorage != nil {
+			running = append(running, tt)
+		}
+		TransferTaskManager.Cancel(tt.GetID())
+		res.Transfers = append(res.Transfers, tt.GetID())
+	}
+	waitStopped(running)
+	for _, tt := range running {
+		dstPath := stdpath.Join(tt.DstDirPath, stdpath.Base(tt.SrcObjPath))
+		dstRawPath := stdpath.Join(tt.DstStorageMp, dstPath)
+		// the objects existing before the transfer are the user's own
+		if tt.DstExisted == nil || *tt.DstExisted {
+			continue
+		}
+		if keepPartial {
+			res.PartialKept = append(res.PartialKept, dstRawPath)
+			continue
+		}
+		if _, err := op.Get(ctx, tt.DstStorage, dstPath); err != nil {
+			continue
+		}
+		if err := op.Remove(ctx, tt.DstStorage, dstPath); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		} else {
+			res.PartialRemoved = append(res.PartialRemoved, dstRawPath)
+		}
+	}
+	if t.TempDir == t.DstDirPath {
+		return res
+	}
+	if utils.IsSubPath(filepath.Join(conf.Conf.TempDir, t.Toolname), t.TempDir) {
+		if err := os.RemoveAll(t.TempDir); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		} else {
+			res.TempDir = t.TempDir
+		}
+		return res
+	}
+	// the temp dir of cloud tools is a dir on the storage
+	storage, actualPath, err := op.GetStorageAndActualPath(t.TempDir)
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+		return res
+	}
+	if _, err = op.Get(ctx, storage, actualPath); err != nil {
+		return res
+	}
+	if err = op.Remove(ctx, storage, actualPath); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	} else {
+		res.TempDir = t.TempDir
+	}
+	return res
+}
+
+// transferSrcPath returns the local path or the mount path of the source
+func transferSrcPath(t *TransferTask) string {
+	if t.SrcStorage == nil {
+		return t.SrcObjPath
+	}
+	return stdpath.Join(t.SrcStorageMp, t.SrcObjPath)
+}
+
+// waitStopped waits for the canceled transfers to stop, all of them sharing
+// the one timeout
+func waitStopped(tasks []*TransferTask) {
+	deadline := time.Now().Add(cancelWaitTimeout)
+	for _, t := range tasks {
+		for argsContains(t.GetState(), tache.StateRunning, tache.StateCanceling) {
+			if time.Now().After(deadline) {
+				log.Warnf("transfer task %s is still running after canceled", t.GetID())
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+func argsContains[T comparable](v T, slice ...T) bool {
+	for _, s := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -32,6 +32,12 @@ func (t *DownloadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
+	t.Status = "waiting in the queue"
+	release, err := t.WaitTurn(DownloadTaskPool)
+	if err != nil {
+		return err
+	}
+	defer release()
 	if t.tool == nil {
 		tool, err := Tools.Get(t.Toolname)
 		if err != nil {
@@ -172,6 +178,22 @@ func (t *DownloadTask) Transfer() error {
 	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
 }
 
+func (t *DownloadTask) Retryable() bool {
+	return t.RetryableAs("offline_download")
+}
+
+func (t *DownloadTask) OnBeforeRetry() {
+	t.WaitBackoffAs("offline_download")
+}
+
+func (t *DownloadTask) OnSucceeded() {
+	task.FireWebhook(t, "offline_download", t.Url, t.DstDirPath)
+}
+
+func (t *DownloadTask) OnFailed() {
+	task.FireWebhook(t, "offline_download", t.Url, t.DstDirPath)
+}
+
 func (t *DownloadTask) GetName() string {
 	return fmt.Sprintf("download %s to (%s)", t.Url, t.DstDirPath)
 }
@@ -181,3 +203,6 @@ func (t *DownloadTask) GetStatus() string {
 }
 
 var DownloadTaskManager *tache.Manager[*DownloadTask]
+
+// DownloadTaskPool limits the running download tasks, taking the urgent ones first
+var DownloadTaskPool *task.PriorityPool
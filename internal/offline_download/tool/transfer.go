@@ -29,6 +29,9 @@ type TransferTask struct {
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
 	DeletePolicy DeletePolicy  `json:"delete_policy"`
+	// DstExisted records whether the destination object existed before the
+	// first run, so canceling never removes what the transfer didn't create
+	DstExisted *bool `json:"dst_existed,omitempty"`
 }
 
 func (t *TransferTask) Run() error {
@@ -36,6 +39,17 @@ func (t *TransferTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
+	if t.DstExisted == nil && t.DstStorage != nil {
+		_, err := op.Get(t.Ctx(), t.DstStorage, stdpath.Join(t.DstDirPath, stdpath.Base(t.SrcObjPath)))
+		existed := err == nil
+		t.DstExisted = &existed
+	}
+	t.Status = "waiting in the queue"
+	release, err := t.WaitTurn(TransferTaskPool)
+	if err != nil {
+		return err
+	}
+	defer release()
 	if t.SrcStorage == nil {
 		return transferStdPath(t)
 	} else {
@@ -51,7 +65,16 @@ func (t *TransferTask) GetStatus() string {
 	return t.Status
 }
 
+func (t *TransferTask) Retryable() bool {
+	return t.RetryableAs("offline_download_transfer")
+}
+
+func (t *TransferTask) OnBeforeRetry() {
+	t.WaitBackoffAs("offline_download_transfer")
+}
+
 func (t *TransferTask) OnSucceeded() {
+	t.fireWebhook()
 	if t.DeletePolicy == DeleteOnUploadSucceed || t.DeletePolicy == DeleteAlways {
 		if t.SrcStorage == nil {
 			removeStdTemp(t)
@@ -61,7 +84,16 @@ func (t *TransferTask) OnSucceeded() {
 	}
 }
 
+func (t *TransferTask) fireWebhook() {
+	srcPath := t.SrcObjPath
+	if t.SrcStorage != nil {
+		srcPath = stdpath.Join(t.SrcStorageMp, t.SrcObjPath)
+	}
+	task.FireWebhook(t, "offline_download_transfer", srcPath, stdpath.Join(t.DstStorageMp, t.DstDirPath))
+}
+
 func (t *TransferTask) OnFailed() {
+	t.fireWebhook()
 	if t.DeletePolicy == DeleteOnUploadFailed || t.DeletePolicy == DeleteAlways {
 		if t.SrcStorage == nil {
 			removeStdTemp(t)
@@ -73,6 +105,8 @@ func (t *TransferTask) OnFailed() {
 
 var (
 	TransferTaskManager *tache.Manager[*TransferTask]
+	// TransferTaskPool limits the running transfer tasks, taking the urgent ones first
+	TransferTaskPool *task.PriorityPool
 )
 
 func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
@@ -88,7 +122,8 @@ func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 	for _, entry := range entries {
 		t := &TransferTask{
 			TaskExtension: task.TaskExtension{
-				Creator: taskCreator,
+				Creator:  taskCreator,
+				Priority: task.PriorityOf(ctx),
 			},
 			SrcObjPath:   stdpath.Join(tempDir, entry.Name()),
 			DstDirPath:   dstDirActualPath,
@@ -118,7 +153,8 @@ func transferStdPath(t *TransferTask) error {
 			dstObjPath := stdpath.Join(t.DstDirPath, info.Name())
 			t := &TransferTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.Creator,
+					Creator:  t.Creator,
+					Priority: t.Priority,
 				},
 				SrcObjPath:   srcRawPath,
 				DstDirPath:   dstObjPath,
@@ -188,7 +224,8 @@ func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 	for _, obj := range objs {
 		t := &TransferTask{
 			TaskExtension: task.TaskExtension{
-				Creator: taskCreator,
+				Creator:  taskCreator,
+				Priority: task.PriorityOf(ctx),
 			},
 			SrcObjPath:   stdpath.Join(srcObjActualPath, obj.GetName()),
 			DstDirPath:   dstDirActualPath,
@@ -223,7 +260,8 @@ func transferObjPath(t *TransferTask) error {
 			dstObjPath := stdpath.Join(t.DstDirPath, srcObj.GetName())
 			TransferTaskManager.Add(&TransferTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.Creator,
+					Creator:  t.Creator,
+					Priority: t.Priority,
 				},
 				SrcObjPath:   srcObjPath,
 				DstDirPath:   dstObjPath,
@@ -241,6 +279,7 @@ func transferObjPath(t *TransferTask) error {
 }
 
 func transferObjFile(t *TransferTask) error {
+	defer op.KeepStorage(t.SrcStorage)()
 	srcFile, err := op.Get(t.Ctx(), t.SrcStorage, t.SrcObjPath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get src [%s] file", t.SrcObjPath)
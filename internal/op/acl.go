@@ -0,0 +1,99 @@
+package op
+
+import (
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// the entries are few and checked on every permission check, they are all
+// kept in memory and reloaded after any change
+var aclCache = cache.NewMemCache(cache.WithShards[[]model.ACLEntry](1))
+var aclG singleflight.Group[[]model.ACLEntry]
+
+const aclCacheKey = "acl"
+
+func init() {
+	model.FetchACLPaths = GetACLPathsOf
+}
+
+func GetACLEntries() ([]model.ACLEntry, error) {
+	if entries, ok := aclCache.Get(aclCacheKey); ok {
+		return entries, nil
+	}
+	entries, err, _ := aclG.Do(aclCacheKey, func() ([]model.ACLEntry, error) {
+		entries, err := db.GetACLEntries()
+		if err != nil {
+			return nil, err
+		}
+		aclCache.Set(aclCacheKey, entries, cache.WithEx[[]model.ACLEntry](time.Hour))
+		return entries, nil
+	})
+	return entries, err
+}
+
+// GetACLEntriesOf returns the entries granting u
+func GetACLEntriesOf(u *model.User) []model.ACLEntry {
+	entries, err := GetACLEntries()
+	if err != nil {
+		return nil
+	}
+	var res []model.ACLEntry
+	for _, e := range entries {
+		if e.AppliesTo(u) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func GetACLPathsOf(u *model.User) []string {
+	entries := GetACLEntriesOf(u)
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func GetACLEntryById(id uint) (*model.ACLEntry, error) {
+	return db.GetACLEntryById(id)
+}
+
+// GrantACL creates the entry, or replaces the permission of the entry of the
+// same path and subject. The entry made by another user is only replaced by an
+// admin and keeps its creator.
+func GrantACL(e *model.ACLEntry, byAdmin bool) error {
+	e.Path = utils.FixAndCleanPath(e.Path)
+	defer aclCache.Del(aclCacheKey)
+	old, err := db.GetACLEntry(e.Path, e.SubjectType, e.SubjectID)
+	if err == nil {
+		if old.CreatorID != e.CreatorID && !byAdmin {
+			return errs.PermissionDenied
+		}
+		old.Permission = e.Permission
+		*e = *old
+		return db.UpdateACLEntry(e)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return db.CreateACLEntry(e)
+}
+
+func RevokeACL(id uint) error {
+	defer aclCache.Del(aclCacheKey)
+	return db.DeleteACLEntryById(id)
+}
+
+func deleteACLEntriesOf(subjectType string, subjectID uint) error {
+	defer aclCache.Del(aclCacheKey)
+	return db.DeleteACLEntriesBySubject(subjectType, subjectID)
+}
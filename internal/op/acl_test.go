@@ -0,0 +1,66 @@
+package op_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestGrantACL(t *testing.T) {
+	var entries = []model.ACLEntry{
+		{Path: "/acl/a", SubjectType: model.ACLSubjectUser, SubjectID: 200, Permission: 1, CreatorID: 1},
+		{Path: "/acl/b/", SubjectType: model.ACLSubjectRole, SubjectID: 20, Permission: 2, CreatorID: 2},
+		// the same path and subject replaces the permission
+		{Path: "/acl/a", SubjectType: model.ACLSubjectUser, SubjectID: 200, Permission: 4, CreatorID: 1},
+	}
+	for i := range entries {
+		if err := op.GrantACL(&entries[i], false); err != nil {
+			t.Fatalf("failed to grant acl %d: %+v", i, err)
+		}
+	}
+	if entries[0].ID != entries[2].ID {
+		t.Errorf("expected the entry %d to be replaced, got %d", entries[0].ID, entries[2].ID)
+	}
+	// the entry of the admin 1 is only replaced by an admin and keeps its creator
+	other := model.ACLEntry{Path: "/acl/a", SubjectType: model.ACLSubjectUser, SubjectID: 200, Permission: 8, CreatorID: 3}
+	if err := op.GrantACL(&other, false); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected the entry of another user to be kept, got %+v", err)
+	}
+	other.Permission = 4
+	if err := op.GrantACL(&other, true); err != nil {
+		t.Fatalf("failed to grant acl by admin: %+v", err)
+	}
+	if other.ID != entries[0].ID || other.CreatorID != 1 {
+		t.Errorf("expected the entry to keep its creator, got %+v", other)
+	}
+	var users = []struct {
+		user  model.User
+		paths map[string]int32
+	}{
+		{user: model.User{ID: 200}, paths: map[string]int32{"/acl/a": 4}},
+		{user: model.User{ID: 201, Role: model.Roles{20}}, paths: map[string]int32{"/acl/b": 2}},
+		{user: model.User{ID: 200, Role: model.Roles{20}}, paths: map[string]int32{"/acl/a": 4, "/acl/b": 2}},
+		{user: model.User{ID: 201}, paths: map[string]int32{}},
+	}
+	for i, data := range users {
+		got := op.GetACLEntriesOf(&data.user)
+		if len(got) != len(data.paths) {
+			t.Errorf("TestGrantACL %d failed, got %+v", i, got)
+			continue
+		}
+		for _, e := range got {
+			if perm, ok := data.paths[e.Path]; !ok || perm != e.Permission {
+				t.Errorf("TestGrantACL %d failed, got %+v", i, got)
+			}
+		}
+	}
+	if err := op.RevokeACL(entries[1].ID); err != nil {
+		t.Fatalf("failed to revoke acl: %+v", err)
+	}
+	if got := op.GetACLEntriesOf(&model.User{ID: 201, Role: model.Roles{20}}); len(got) != 0 {
+		t.Errorf("expected the revoked entry to be gone, got %+v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+)
+
+// APITokenPrefix tells the api tokens from the login and the admin tokens
+const APITokenPrefix = "alist-api-"
+
+// the last use of a token is only recorded once a minute not to write the
+// database on every request
+const apiTokenTouchInterval = time.Minute
+
+func hashAPIToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
+
+// CreateAPIToken saves t with a new token which is returned, only its hash
+// is kept so it can't be shown again
+func CreateAPIToken(t *model.APIToken) (string, error) {
+	token := APITokenPrefix + random.String(48)
+	t.TokenHash = hashAPIToken(token)
+	t.Hint = token[len(token)-4:]
+	t.Path = utils.FixAndCleanPath(t.Path)
+	t.CreatedAt = time.Now()
+	if err := db.CreateAPIToken(t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func GetAPITokensByUser(userID uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUser(userID, pageIndex, pageSize)
+}
+
+// GetAPIToken returns the unexpired token of the given token string
+func GetAPIToken(token string) (*model.APIToken, error) {
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return nil, errs.InvalidAPIToken
+	}
+	t, err := db.GetAPITokenByHash(hashAPIToken(token))
+	if err != nil {
+		return nil, errs.InvalidAPIToken
+	}
+	now := time.Now()
+	if t.IsExpired(now) {
+		return nil, errs.APITokenExpired
+	}
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > apiTokenTouchInterval {
+		if err := db.TouchAPIToken(t.ID, now); err == nil {
+			t.LastUsedAt = &now
+		}
+	}
+	return t, nil
+}
+
+func DeleteAPIToken(userID, id uint) (bool, error) {
+	return db.DeleteAPIToken(userID, id)
+}
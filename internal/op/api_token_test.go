@@ -0,0 +1,37 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestGetAPIToken(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	later := time.Now().Add(time.Hour)
+	var tokens = []struct {
+		token model.APIToken
+		isErr bool
+	}{
+		{token: model.APIToken{UserID: 1, Name: "a", Scope: model.APITokenScopeRead}, isErr: false},
+		{token: model.APIToken{UserID: 1, Name: "b", Scope: model.APITokenScopeRead, ExpiresAt: &later}, isErr: false},
+		{token: model.APIToken{UserID: 1, Name: "c", Scope: model.APITokenScopeRead, ExpiresAt: &expired}, isErr: true},
+	}
+	for i, data := range tokens {
+		token, err := op.CreateAPIToken(&data.token)
+		if err != nil {
+			t.Fatalf("failed to create api token %d: %+v", i, err)
+		}
+		_, err = op.GetAPIToken(token)
+		if (err != nil) != data.isErr {
+			t.Errorf("TestGetAPIToken %d failed: %+v", i, err)
+		}
+	}
+	for _, token := range []string{"", "alist-api-unknown", "unknown"} {
+		if _, err := op.GetAPIToken(token); err == nil {
+			t.Errorf("expected [%s] to be refused", token)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package op
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/pkg/errors"
+)
+
+type storageSem struct {
+	limit int
+	ch    chan struct{}
+}
+
+type storageSems struct {
+	mu   sync.Mutex
+	sems map[uint]*storageSem
+}
+
+var (
+	opSems   = &storageSems{sems: make(map[uint]*storageSem)}
+	taskSems = &storageSems{sems: make(map[uint]*storageSem)}
+)
+
+// sem returns the semaphore of the limit slots of the storage
+func (s *storageSems) sem(storage driver.Driver, limit int) *storageSem {
+	id := storage.GetStorage().ID
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sem, ok := s.sems[id]
+	if !ok || sem.limit != limit {
+		// the holders of a replaced semaphore release it as usual
+		sem = &storageSem{limit: limit, ch: make(chan struct{}, limit)}
+		s.sems[id] = sem
+	}
+	return sem
+}
+
+// acquire waits for one of the limit slots of the storage, it fails when ctx
+// is done first. The slot is held until release.
+func (s *storageSems) acquire(ctx context.Context, storage driver.Driver, limit int, what string) (release func(), err error) {
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	sem := s.sem(storage, limit)
+	select {
+	case sem.ch <- struct{}{}:
+		return func() { <-sem.ch }, nil
+	case <-ctx.Done():
+		return nil, errors.WithMessagef(ctx.Err(), "waiting for a free %s slot of [%s]", what, storage.GetStorage().MountPath)
+	}
+}
+
+// tryAcquire takes one of the limit slots of the storage if one is free
+func (s *storageSems) tryAcquire(storage driver.Driver, limit int) (release func(), ok bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+	sem := s.sem(storage, limit)
+	select {
+	case sem.ch <- struct{}{}:
+		return func() { <-sem.ch }, true
+	default:
+		return nil, false
+	}
+}
+
+// acquireOp waits for a free slot among the max_concurrency driver operations of
+// the storage, it fails when ctx is done first. The slot is held until release.
+func acquireOp(ctx context.Context, storage driver.Driver) (release func(), err error) {
+	return tracked(storage)(opSems.acquire(ctx, storage, storage.GetStorage().MaxConcurrency, "operation"))
+}
+
+// AcquireTask waits for a free slot among the max_tasks copy and upload tasks
+// writing to the storage, the tasks over the limit wait in turn instead of
+// running at once. The slot is held until release.
+func AcquireTask(ctx context.Context, storage driver.Driver) (release func(), err error) {
+	return tracked(storage)(taskSems.acquire(ctx, storage, storage.GetStorage().MaxTasks, "task"))
+}
+
+// TryAcquireTask is AcquireTask without waiting, ok is false if no slot is free
+func TryAcquireTask(storage driver.Driver) (release func(), ok bool) {
+	release, ok = taskSems.tryAcquire(storage, storage.GetStorage().MaxTasks)
+	if !ok {
+		return nil, false
+	}
+	release, _ = tracked(storage)(release, nil)
+	return release, true
+}
+
+// tracked makes the release of an acquired slot count the storage instance
+// busy until then, not to be dropped by a reload meanwhile
+func tracked(storage driver.Driver) func(release func(), err error) (func(), error) {
+	return func(release func(), err error) (func(), error) {
+		if err != nil {
+			return nil, err
+		}
+		done := track(storage)
+		return func() {
+			release()
+			done()
+		}, nil
+	}
+}
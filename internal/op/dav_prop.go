@@ -0,0 +1,64 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// davPropKey returns the storage and the actual path the dead properties of
+// path are kept by, the balanced storages share the ones of their main storage
+func davPropKey(path string) (uint, string, error) {
+	storage, actualPath, err := GetStorageAndActualPath(path)
+	if err != nil {
+		return 0, "", errors.WithMessage(err, "failed get storage")
+	}
+	if mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath); mountPath != storage.GetStorage().MountPath {
+		if storage, err = GetStorageByMountPath(mountPath); err != nil {
+			return 0, "", err
+		}
+	}
+	return storage.GetStorage().ID, actualPath, nil
+}
+
+// GetDavProps returns the dead properties set by the webdav clients on path
+func GetDavProps(path string) ([]model.DavProp, error) {
+	storageID, actualPath, err := davPropKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetDavProps(storageID, actualPath)
+}
+
+// SetDavProps replaces the dead properties of path
+func SetDavProps(path string, props []model.DavProp) error {
+	storageID, actualPath, err := davPropKey(path)
+	if err != nil {
+		return err
+	}
+	return db.ReplaceDavProps(storageID, actualPath, props)
+}
+
+// RemoveDavProps removes the dead properties of path and the paths under it
+func RemoveDavProps(path string) error {
+	storageID, actualPath, err := davPropKey(path)
+	if err != nil {
+		return err
+	}
+	return db.DeleteDavProps(storageID, actualPath)
+}
+
+// CopyDavProps copies the dead properties of src and the paths under it to
+// dst, they are removed from src if move
+func CopyDavProps(src, dst string, move bool) error {
+	srcStorageID, srcActualPath, err := davPropKey(src)
+	if err != nil {
+		return err
+	}
+	dstStorageID, dstActualPath, err := davPropKey(dst)
+	if err != nil {
+		return err
+	}
+	return db.CopyDavProps(srcStorageID, srcActualPath, dstStorageID, dstActualPath, move)
+}
@@ -0,0 +1,44 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// the computed mtimes of dirs, dropped whenever the list cache of the dir changes
+var dirModCache = cache.NewMemCache(cache.WithShards[time.Time](16))
+var dirModG singleflight.Group[time.Time]
+
+// DirModTime computes the mtime of a dir as the latest mtime of its direct
+// children, for drivers which don't report the mtime of dirs. The zero time is
+// returned for an empty dir.
+func DirModTime(ctx context.Context, storage driver.Driver, path string) (time.Time, error) {
+	path = utils.FixAndCleanPath(path)
+	key := Key(storage, path)
+	if t, ok := dirModCache.Get(key); ok {
+		return t, nil
+	}
+	t, err, _ := dirModG.Do(key, func() (time.Time, error) {
+		objs, err := List(ctx, storage, path, model.ListArgs{})
+		if err != nil {
+			return time.Time{}, err
+		}
+		var latest time.Time
+		for _, obj := range objs {
+			if obj.ModTime().After(latest) {
+				latest = obj.ModTime()
+			}
+		}
+		if !storage.Config().NoCache {
+			dirModCache.Set(key, latest, cache.WithEx[time.Time](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		}
+		return latest, nil
+	})
+	return t, err
+}
@@ -0,0 +1,41 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+)
+
+// the disk usages are asked for every entry of a webdav listing, so they are
+// kept a while not to call the storages each time
+var diskUsageCache = cache.NewMemCache(cache.WithShards[*model.DiskUsage](16))
+var diskUsageG singleflight.Group[*model.DiskUsage]
+
+const diskUsageCacheExpiration = time.Minute
+
+// GetDiskUsage returns the space of storage, errs.NotImplement if the driver
+// doesn't know it
+func GetDiskUsage(ctx context.Context, storage driver.Driver) (*model.DiskUsage, error) {
+	q, ok := storage.(driver.Quota)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	key := storage.GetStorage().MountPath
+	if usage, ok := diskUsageCache.Get(key); ok {
+		return usage, nil
+	}
+	usage, err, _ := diskUsageG.Do(key, func() (*model.DiskUsage, error) {
+		usage, err := q.GetDiskUsage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		diskUsageCache.Set(key, usage, cache.WithEx[*model.DiskUsage](diskUsageCacheExpiration))
+		return usage, nil
+	})
+	return usage, err
+}
@@ -2,7 +2,9 @@ package op
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 
@@ -15,6 +17,10 @@ type DriverConstructor func() driver.Driver
 var driverMap = map[string]DriverConstructor{}
 var driverInfoMap = map[string]driver.Info{}
 
+// driverMu guards the maps against the drivers of the plugins registered at
+// runtime, the built-in ones are registered in init
+var driverMu sync.RWMutex
+
 func RegisterDriver(driver DriverConstructor) {
 	// log.Infof("register driver: [%s]", config.Name)
 	tempDriver := driver()
@@ -23,7 +29,36 @@ func RegisterDriver(driver DriverConstructor) {
 	driverMap[tempConfig.Name] = driver
 }
 
+// RegisterPluginDriver registers the driver of a plugin at runtime, whose
+// additional items are told by the plugin instead of the fields of a struct
+func RegisterPluginDriver(constructor DriverConstructor, additional []driver.Item) error {
+	config := constructor().Config()
+	driverMu.Lock()
+	defer driverMu.Unlock()
+	if _, ok := driverMap[config.Name]; ok {
+		return errors.Errorf("driver %s is already registered", config.Name)
+	}
+	driverInfoMap[config.Name] = driver.Info{
+		Common:     getMainItems(config),
+		Additional: additional,
+		Config:     config,
+	}
+	driverMap[config.Name] = constructor
+	return nil
+}
+
+// UnregisterDriver removes the driver of a plugin, the storages of the driver
+// must have been dropped
+func UnregisterDriver(name string) {
+	driverMu.Lock()
+	defer driverMu.Unlock()
+	delete(driverMap, name)
+	delete(driverInfoMap, name)
+}
+
 func GetDriver(name string) (DriverConstructor, error) {
+	driverMu.RLock()
+	defer driverMu.RUnlock()
 	n, ok := driverMap[name]
 	if !ok {
 		return nil, errors.Errorf("no driver named: %s", name)
@@ -32,6 +67,8 @@ func GetDriver(name string) (DriverConstructor, error) {
 }
 
 func GetDriverNames() []string {
+	driverMu.RLock()
+	defer driverMu.RUnlock()
 	var driverNames []string
 	for k := range driverInfoMap {
 		driverNames = append(driverNames, k)
@@ -40,7 +77,13 @@ func GetDriverNames() []string {
 }
 
 func GetDriverInfoMap() map[string]driver.Info {
-	return driverInfoMap
+	driverMu.RLock()
+	defer driverMu.RUnlock()
+	infoMap := make(map[string]driver.Info, len(driverInfoMap))
+	for k, v := range driverInfoMap {
+		infoMap[k] = v
+	}
+	return infoMap
 }
 
 func registerDriverItems(config driver.Config, addition driver.Additional) {
@@ -122,6 +165,19 @@ func getMainItems(config driver.Config) []driver.Item {
 		Type:    conf.TypeBool,
 		Default: "true",
 	})
+	if !config.OnlyLocal {
+		items = append(items, []driver.Item{{
+			Name:    "read_ahead_size",
+			Type:    conf.TypeNumber,
+			Default: "1024",
+			Help:    "Size in KB of the chunks prefetched when proxying, at most 4096",
+		}, {
+			Name:    "read_ahead_depth",
+			Type:    conf.TypeNumber,
+			Default: "0",
+			Help:    "Number of chunks prefetched ahead of the client when proxying, 0 to disable, at most 8",
+		}}...)
+	}
 	if config.LocalSort {
 		items = append(items, []driver.Item{{
 			Name:    "order_by",
@@ -150,6 +206,27 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	items = append(items, driver.Item{
+		Name:    "name_match",
+		Type:    conf.TypeSelect,
+		Options: "exact,case,unicode,case_unicode",
+		Default: "exact",
+		Help:    "How names are compared when checking whether they are taken, set it if the storage treats names differing in case or unicode normalization as the same",
+	})
+	items = append(items, driver.Item{
+		Name:    "max_concurrency",
+		Type:    conf.TypeNumber,
+		Default: strconv.Itoa(config.DefaultConcurrency),
+		Help:    "Max number of list, get, link and put calls to the driver at once, the others wait. 0 for no limit",
+	})
+	for _, name := range []string{"list_timeout", "get_timeout", "link_timeout", "put_timeout"} {
+		items = append(items, driver.Item{
+			Name:    name,
+			Type:    conf.TypeNumber,
+			Default: "0",
+			Help:    "Timeout in seconds of a single driver call, 0 to use the default of the driver",
+		})
+	}
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
@@ -2,6 +2,7 @@ package op
 
 import (
 	"context"
+	"io"
 	stdpath "path"
 	"slices"
 	"time"
@@ -9,8 +10,10 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -25,6 +28,7 @@ var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
 	key := Key(storage, path)
+	dirModCache.Del(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -45,6 +49,7 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 	key := Key(storage, path)
+	dirModCache.Del(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, oldObj := range objs {
@@ -61,6 +66,7 @@ var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 	key := Key(storage, path)
+	dirModCache.Del(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -101,12 +107,22 @@ func ClearCache(storage driver.Driver, path string) {
 		}
 	}
 	listCache.Del(Key(storage, path))
+	dirModCache.Del(Key(storage, path))
 }
 
 func Key(storage driver.Driver, path string) string {
 	return stdpath.Join(storage.GetStorage().MountPath, utils.FixAndCleanPath(path))
 }
 
+// countDriverErr counts err of the driver operation op in the metrics, the
+// canceled requests aren't failures of the driver
+func countDriverErr(storage driver.Driver, op string, err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	metrics.DriverErrors.WithLabelValues(storage.Config().Name, op).Inc()
+}
+
 // List files in storage, not contains virtual file
 func List(ctx context.Context, storage driver.Driver, path string, args model.ListArgs) ([]model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
@@ -116,7 +132,10 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !args.Refresh {
-		if files, ok := listCache.Get(key); ok {
+		files, ok := listCache.Get(key)
+		metrics.CacheLookup("list", ok)
+		setCacheHit(ctx, "list", ok)
+		if ok {
 			log.Debugf("use cache when list %s", path)
 			return files, nil
 		}
@@ -130,7 +149,18 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
-		files, err := storage.List(ctx, dir, args)
+		release, err := acquireOp(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		listCtx, cancel := withOpTimeout(ctx, storage, opList)
+		defer cancel()
+		listCtx, span := startDriverSpan(listCtx, storage, opList, path)
+		files, err := storage.List(listCtx, dir, args)
+		release()
+		err = wrapOpTimeout(ctx, listCtx, storage, opList, err)
+		tracing.End(span, err)
+		countDriverErr(storage, opList, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -155,6 +185,7 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		}
 		model.ExtractFolder(files, storage.GetStorage().ExtractFolder)
 
+		dirModCache.Del(key)
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
@@ -176,10 +207,24 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
-		obj, err := g.Get(ctx, path)
+		release, err := acquireOp(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		getCtx, cancel := withOpTimeout(ctx, storage, opGet)
+		getCtx, span := startDriverSpan(getCtx, storage, opGet, path)
+		obj, err := g.Get(getCtx, path)
+		err = wrapOpTimeout(ctx, getCtx, storage, opGet, err)
+		tracing.End(span, err)
+		cancel()
+		release()
 		if err == nil {
 			return model.WrapObjName(obj), nil
 		}
+		// listing the parent would most likely time out as well
+		if errors.Is(err, errs.OperationTimeout) {
+			return nil, err
+		}
 	}
 
 	// is root folder
@@ -237,6 +282,38 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 	return nil, errors.WithStack(errs.ObjectNotFound)
 }
 
+// GetCollision gets the obj at path, or an obj whose name collides with it
+// according to the name_match of the storage
+func GetCollision(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	obj, err := Get(ctx, storage, path)
+	if err == nil || !errs.IsObjectNotFound(err) {
+		return obj, err
+	}
+	return FindCollision(ctx, storage, path)
+}
+
+// FindCollision lists the parent of path looking for an obj whose name is
+// different from the base of path but collides with it
+func FindCollision(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	path = utils.FixAndCleanPath(path)
+	s := storage.GetStorage()
+	if s.NameMatch == "" || s.NameMatch == "exact" {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	dir, name := stdpath.Split(path)
+	key := s.NameKey(name)
+	files, err := List(ctx, storage, dir, model.ListArgs{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get parent list")
+	}
+	for _, f := range files {
+		if s.NameKey(f.GetName()) == key {
+			return f, nil
+		}
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
 func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
 	obj, err := Get(ctx, storage, path)
 	if err != nil {
@@ -261,11 +338,22 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
 	key := Key(storage, path)
-	if link, ok := linkCache.Get(key); ok {
+	link, ok := linkCache.Get(key)
+	metrics.CacheLookup("link", ok)
+	setCacheHit(ctx, "link", ok)
+	if ok {
 		return link, file, nil
 	}
 	fn := func() (*model.Link, error) {
-		link, err := storage.Link(ctx, file, args)
+		release, err := acquireOp(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		linkCtx, span := startDriverSpan(ctx, storage, opLink, path)
+		link, err := linkWithTimeout(linkCtx, storage, file, args)
+		tracing.End(span, err)
+		release()
+		countDriverErr(storage, opLink, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -283,7 +371,7 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return link, file, err
 	}
 
-	link, err, _ := linkG.Do(key, fn)
+	link, err, _ = linkG.Do(key, fn)
 	return link, file, err
 }
 
@@ -347,6 +435,10 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 				default:
 					return nil, errs.NotImplement
 				}
+				if err == nil {
+					callObjChangeHooks(storage, path, false)
+				}
+				countDriverErr(storage, "make_dir", err)
 				return nil, errors.WithStack(err)
 			}
 			return nil, errors.WithMessage(err, "failed to check if dir exists")
@@ -401,6 +493,11 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		callObjChangeHooks(storage, srcPath, true)
+		callObjChangeHooks(storage, stdpath.Join(dstDirPath, srcObj.GetName()), false)
+	}
+	countDriverErr(storage, "move", err)
 	return errors.WithStack(err)
 }
 
@@ -435,6 +532,11 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		callObjChangeHooks(storage, srcPath, true)
+		callObjChangeHooks(storage, stdpath.Join(srcDirPath, dstName), false)
+	}
+	countDriverErr(storage, "rename", err)
 	return errors.WithStack(err)
 }
 
@@ -473,6 +575,10 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		callObjChangeHooks(storage, stdpath.Join(dstDirPath, srcObj.GetName()), false)
+	}
+	countDriverErr(storage, "copy", err)
 	return errors.WithStack(err)
 }
 
@@ -504,10 +610,12 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 			if rawObj.IsDir() {
 				ClearCache(storage, path)
 			}
+			callObjChangeHooks(storage, path, true)
 		}
 	default:
 		return errs.NotImplement
 	}
+	countDriverErr(storage, "remove", err)
 	return errors.WithStack(err)
 }
 
@@ -531,6 +639,8 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	dstPath := stdpath.Join(dstDirPath, file.GetName())
 	tempName := file.GetName() + ".alist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
+	key := Key(storage, dstPath)
+	renameOld := false
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
 		if fi.GetSize() == 0 {
@@ -538,14 +648,30 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
-		} else if storage.Config().NoOverwriteUpload {
-			// try to rename old obj
-			err = Rename(ctx, storage, dstPath, tempName)
-			if err != nil {
-				return err
-			}
 		} else {
-			file.SetExist(fi)
+			renameOld = storage.Config().NoOverwriteUpload
+			// don't overwrite the content under in-flight reads
+			if IsBeingRead(key) {
+				switch getOverwriteReadingPolicy() {
+				case OverwriteReadingReject:
+					return errors.WithStack(errs.FileInUse)
+				case OverwriteReadingRename:
+					renameOld = true
+				default:
+					if err = WaitReadsDone(ctx, key); err != nil {
+						return errors.WithMessage(err, "failed wait for reads of the existing file")
+					}
+				}
+			}
+			if renameOld {
+				// try to rename old obj
+				err = Rename(ctx, storage, dstPath, tempName)
+				if err != nil {
+					return err
+				}
+			} else {
+				file.SetExist(fi)
+			}
 		}
 	}
 	err = MakeDir(ctx, storage, dstDirPath)
@@ -562,10 +688,17 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		up = func(p float64) {}
 	}
 
+	release, err := acquireOp(ctx, storage)
+	if err != nil {
+		return err
+	}
+	putCtx, cancel := withOpTimeout(ctx, storage, opPut)
+	defer cancel()
+	putCtx, span := startDriverSpan(putCtx, storage, opPut, dstPath)
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
+		newObj, err = s.Put(putCtx, parentDir, file, up)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -574,28 +707,48 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			}
 		}
 	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+		err = s.Put(putCtx, parentDir, file, up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
 	default:
+		release()
+		span.End()
 		return errs.NotImplement
 	}
+	// the rename and remove below take slots of their own
+	release()
+	err = wrapOpTimeout(ctx, putCtx, storage, opPut, err)
+	tracing.End(span, err)
+	countDriverErr(storage, opPut, err)
 	log.Debugf("put file [%s] done", file.GetName())
-	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
+	if err == nil {
+		callObjChangeHooks(storage, dstPath, false)
+	}
+	if renameOld {
 		if err != nil {
 			// upload failed, recover old obj
 			err := Rename(ctx, storage, tempPath, file.GetName())
 			if err != nil {
 				log.Errorf("failed recover old obj: %+v", err)
 			}
+		} else if IsBeingRead(key) {
+			// upload success, remove old obj once it isn't read anymore
+			linkCache.Del(key)
+			go func() {
+				if err := WaitReadsDone(context.Background(), key); err != nil {
+					log.Warnf("removing old obj %s still being read: %+v", tempPath, err)
+				}
+				if err := Remove(context.Background(), storage, tempPath); err != nil {
+					log.Errorf("failed remove old obj: %+v", err)
+				}
+			}()
 		} else {
 			// upload success, remove old obj
 			err := Remove(ctx, storage, tempPath)
 			if err != nil {
 				return err
 			} else {
-				key := Key(storage, stdpath.Join(dstDirPath, file.GetName()))
 				linkCache.Del(key)
 			}
 		}
@@ -603,6 +756,56 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	return errors.WithStack(err)
 }
 
+// Append appends reader to the file dstName under dstDirPath, see driver.Append
+func Append(ctx context.Context, storage driver.Driver, dstDirPath, dstName string, reader io.Reader) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	a, ok := storage.(driver.Append)
+	if !ok {
+		return errs.NotImplement
+	}
+	dstDirPath = utils.FixAndCleanPath(dstDirPath)
+	dstDir, err := GetUnwrap(ctx, storage, dstDirPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to append")
+	}
+	err = a.Append(ctx, dstDir, dstName, reader)
+	// the size changed even if appending was interrupted
+	ClearCache(storage, dstDirPath)
+	callObjChangeHooks(storage, stdpath.Join(dstDirPath, dstName), false)
+	return errors.WithStack(err)
+}
+
+// HardLink replaces the file at dstPath by a hard link to the file at srcPath, both in storage
+func HardLink(ctx context.Context, storage driver.Driver, srcPath, dstPath string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	l, ok := storage.(driver.HardLink)
+	if !ok {
+		return errs.NotImplement
+	}
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	srcObj, err := GetUnwrap(ctx, storage, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get src object")
+	}
+	dstObj, err := GetUnwrap(ctx, storage, dstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst object")
+	}
+	if srcObj.IsDir() || dstObj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	err = l.HardLink(ctx, srcObj, dstObj)
+	if err == nil {
+		ClearCache(storage, stdpath.Dir(dstPath))
+		callObjChangeHooks(storage, dstPath, false)
+	}
+	return errors.WithStack(err)
+}
+
 func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url string, lazyCache ...bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
@@ -640,5 +843,9 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 		return errs.NotImplement
 	}
 	log.Debugf("put url [%s](%s) done", dstName, url)
+	if err == nil {
+		callObjChangeHooks(storage, stdpath.Join(dstDirPath, dstName), false)
+	}
+	countDriverErr(storage, "put_url", err)
 	return errors.WithStack(err)
 }
@@ -0,0 +1,61 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// HomeQuotaUsers returns the users with a home quota whose base path holds path
+func HomeQuotaUsers(path string) ([]model.User, error) {
+	users, err := db.GetUsersWithHomeQuota()
+	if err != nil {
+		return nil, err
+	}
+	res := users[:0]
+	for _, u := range users {
+		if utils.IsSubPath(u.BasePath, path) {
+			res = append(res, u)
+		}
+	}
+	return res, nil
+}
+
+// CheckHomeQuota fails if storing size more bytes at path exceeds the home
+// quota of a user whose base path holds it
+func CheckHomeQuota(path string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	users, err := HomeQuotaUsers(path)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.HomeUsed+size > u.HomeQuota {
+			return errors.WithMessagef(errs.HomeQuotaExceeded, "of [%s]", u.Username)
+		}
+	}
+	return nil
+}
+
+// AddHomeUsed counts delta bytes stored at path, or removed if negative, for
+// the users whose base path holds it
+func AddHomeUsed(path string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	users, err := HomeQuotaUsers(path)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err = db.AddUserHomeUsed(u.ID, delta); err != nil {
+			return err
+		}
+		userCache.Del(u.Username)
+	}
+	return nil
+}
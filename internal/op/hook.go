@@ -1,6 +1,7 @@
 package op
 
 import (
+	stdpath "path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +31,23 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+// ObjChangeHook is called once the obj at the mount path is removed, or
+// created or overwritten if not removed. A move or rename removes the src and
+// creates the dst.
+type ObjChangeHook = func(path string, removed bool)
+
+var objChangeHooks = make([]ObjChangeHook, 0)
+
+func RegisterObjChangeHook(hook ObjChangeHook) {
+	objChangeHooks = append(objChangeHooks, hook)
+}
+
+func callObjChangeHooks(storage driver.Driver, path string, removed bool) {
+	for _, hook := range objChangeHooks {
+		hook(Key(storage, path), removed)
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -83,6 +101,20 @@ var settingItemHooks = map[string]SettingItemHook{
 		conf.SlicesMap[conf.IgnoreDirectLinkParams] = strings.Split(item.Value, ",")
 		return nil
 	},
+	conf.RecursiveExclude: func(item *model.SettingItem) error {
+		patterns := make([]string, 0)
+		for _, p := range strings.Split(item.Value, "\n") {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			if _, err := stdpath.Match(p, ""); err != nil {
+				return errors.Wrapf(err, "invalid pattern [%s]", p)
+			}
+			patterns = append(patterns, p)
+		}
+		conf.SlicesMap[conf.RecursiveExclude] = patterns
+		return nil
+	},
 	conf.DefaultRole: func(item *model.SettingItem) error {
 		v := strings.TrimSpace(item.Value)
 		if v == "" {
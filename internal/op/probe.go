@@ -0,0 +1,41 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// ProbeList times a refreshed listing of the root of the storage, the cache
+// isn't read. The wait for a free operation slot is part of the latency.
+func ProbeList(ctx context.Context, storage driver.Driver) (time.Duration, error) {
+	start := time.Now()
+	_, err := List(ctx, storage, "/", model.ListArgs{ReqPath: storage.GetStorage().MountPath, Refresh: true, NoUpdateIndex: true})
+	return time.Since(start), err
+}
+
+// ProbeGet times a get of the root of the storage, only the drivers implementing
+// driver.Getter can be probed, errs.NotSupport is returned otherwise
+func ProbeGet(ctx context.Context, storage driver.Driver) (time.Duration, error) {
+	g, ok := storage.(driver.Getter)
+	if !ok {
+		return 0, errs.NotSupport
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return 0, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	release, err := acquireOp(ctx, storage)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	getCtx, cancel := withOpTimeout(ctx, storage, opGet)
+	defer cancel()
+	start := time.Now()
+	_, err = g.Get(getCtx, "/")
+	return time.Since(start), wrapOpTimeout(ctx, getCtx, storage, opGet, err)
+}
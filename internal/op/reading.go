@@ -0,0 +1,104 @@
+package op
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OverwriteReadingWait waits for the in-flight reads before overwriting
+	OverwriteReadingWait = "wait"
+	// OverwriteReadingRename moves the file being read aside and removes it once the reads finish
+	OverwriteReadingRename = "rename"
+	// OverwriteReadingReject fails the overwrite with errs.FileInUse
+	OverwriteReadingReject = "reject"
+)
+
+// readsWaitTimeout bounds the wait of an overwrite for the reads of a file, a
+// read held by a stalled client doesn't block it forever
+const readsWaitTimeout = 10 * time.Minute
+
+type readingEntry struct {
+	count int
+	done  chan struct{}
+}
+
+var (
+	readings   = map[string]*readingEntry{}
+	readingsMu sync.Mutex
+)
+
+// AcquireRead marks the file at the mount path as being read until the returned
+// func is called, so that overwrites can coordinate with the read. The storage
+// instance serving it isn't dropped by a reload meanwhile either.
+func AcquireRead(path string) (release func()) {
+	path = utils.FixAndCleanPath(path)
+	keep := func() {}
+	if storage, _, err := GetStorageAndActualPath(path); err == nil {
+		keep = track(storage)
+	}
+	readingsMu.Lock()
+	e, ok := readings[path]
+	if !ok {
+		e = &readingEntry{done: make(chan struct{})}
+		readings[path] = e
+	}
+	e.count++
+	readingsMu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			readingsMu.Lock()
+			defer readingsMu.Unlock()
+			e.count--
+			if e.count == 0 {
+				close(e.done)
+				delete(readings, path)
+			}
+			keep()
+		})
+	}
+}
+
+func IsBeingRead(path string) bool {
+	readingsMu.Lock()
+	defer readingsMu.Unlock()
+	_, ok := readings[utils.FixAndCleanPath(path)]
+	return ok
+}
+
+// WaitReadsDone blocks until the file at the mount path isn't read anymore, it
+// fails with errs.FileInUse if the reads last longer than readsWaitTimeout
+func WaitReadsDone(ctx context.Context, path string) error {
+	timer := time.NewTimer(readsWaitTimeout)
+	defer timer.Stop()
+	for {
+		readingsMu.Lock()
+		e, ok := readings[utils.FixAndCleanPath(path)]
+		readingsMu.Unlock()
+		if !ok {
+			return nil
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return errors.WithStack(errs.FileInUse)
+		}
+	}
+}
+
+func getOverwriteReadingPolicy() string {
+	item, err := GetSettingItemByKey(conf.OverwriteReadingPolicy)
+	if err != nil || item == nil || item.Value == "" {
+		return OverwriteReadingWait
+	}
+	return item.Value
+}
@@ -0,0 +1,107 @@
+package op
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// drainTimeout is how long a replaced driver instance may keep serving the
+// calls and tasks started before the reload, it's dropped anyway after that
+const drainTimeout = 10 * time.Minute
+
+// inflight counts the driver calls and the tasks running on each driver
+// instance, keyed by the instance
+var inflight sync.Map
+
+// track counts a call on the storage driver instance until done
+func track(storage driver.Driver) (done func()) {
+	v, _ := inflight.LoadOrStore(storage, new(atomic.Int64))
+	n := v.(*atomic.Int64)
+	n.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { n.Add(-1) })
+	}
+}
+
+// KeepStorage keeps the storage driver instance from being dropped by a reload
+// until done, for the streams of its links which are read after the call
+func KeepStorage(storage driver.Driver) (done func()) {
+	return track(storage)
+}
+
+func inflightOf(storage driver.Driver) int64 {
+	if v, ok := inflight.Load(storage); ok {
+		return v.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+// retire drops the replaced driver instance once the calls and the tasks
+// running on it are done, in the background
+func retire(storage driver.Driver) {
+	go func() {
+		deadline := time.Now().Add(drainTimeout)
+		for inflightOf(storage) > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Second)
+		}
+		if n := inflightOf(storage); n > 0 {
+			log.Warnf("dropping the replaced instance of storage [%s] with %d calls running", storage.GetStorage().MountPath, n)
+		}
+		if err := storage.Drop(context.Background()); err != nil {
+			log.Warnf("failed drop the replaced instance of storage [%s]: %+v", storage.GetStorage().MountPath, err)
+		}
+		inflight.Delete(storage)
+	}()
+}
+
+// swapStorage initializes a new instance of the driver with storage and puts
+// it in place of old, if any, which keeps serving what was started on it.
+// The other storages aren't touched.
+func swapStorage(ctx context.Context, storage model.Storage, old driver.Driver) error {
+	driverNew, err := GetDriver(storage.Driver)
+	if err != nil {
+		return errors.WithMessage(err, "failed get driver new")
+	}
+	storageDriver := driverNew()
+	if old != nil && old.GetStorage().MountPath != storage.MountPath {
+		storagesMap.Delete(old.GetStorage().MountPath)
+	}
+	// the new instance replaces old in storagesMap only once initialized
+	err = initStorage(ctx, storage, storageDriver)
+	if old != nil {
+		retire(old)
+	}
+	go callStorageHooks("update", storageDriver)
+	log.Debugf("storage %+v is reloaded", storageDriver)
+	return err
+}
+
+// ReloadStorage re-initializes the storage with its config in the database,
+// without interrupting the calls and the transfers running on it
+func ReloadStorage(ctx context.Context, id uint) error {
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if storage.Disabled {
+		return errors.Errorf("this storage have disabled")
+	}
+	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+	old, err := GetStorageByMountPath(storage.MountPath)
+	if err == nil {
+		ClearCache(old, "/")
+	} else {
+		old = nil
+	}
+	return swapStorage(ctx, *storage, old)
+}
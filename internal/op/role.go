@@ -221,5 +221,8 @@ func DeleteRole(id uint) error {
 	}
 	roleCache.Del(fmt.Sprint(id))
 	roleCache.Del(old.Name)
+	if err := deleteACLEntriesOf(model.ACLSubjectRole, id); err != nil {
+		return err
+	}
 	return db.DeleteRole(id)
 }
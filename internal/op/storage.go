@@ -12,6 +12,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	mapset "github.com/deckarep/golang-set/v2"
@@ -62,6 +63,9 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	//if storage.MountPath == "/" {
 	//	return 0, errors.New("Mount path cannot be '/'")
 	//}
+	if err := checkReadAhead(storage.Proxy); err != nil {
+		return 0, err
+	}
 
 	var err error
 	// check driver first
@@ -86,6 +90,18 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	return storage.ID, nil
 }
 
+// checkReadAhead checks the read-ahead of the proxy is within the limits,
+// its buffers are allocated for each range requested
+func checkReadAhead(p model.Proxy) error {
+	if p.ReadAheadSize < 0 || p.ReadAheadSize > stream.MaxReadAheadSizeKB {
+		return errors.Errorf("read_ahead_size must be between 0 and %d", stream.MaxReadAheadSizeKB)
+	}
+	if p.ReadAheadDepth < 0 || p.ReadAheadDepth > stream.MaxReadAheadDepth {
+		return errors.Errorf("read_ahead_depth must be between 0 and %d", stream.MaxReadAheadDepth)
+	}
+	return nil
+}
+
 // LoadStorage load exist storage in db to memory
 func LoadStorage(ctx context.Context, storage model.Storage) error {
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
@@ -205,13 +221,14 @@ func DisableStorage(ctx context.Context, id uint) error {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
 	storagesMap.Delete(storage.MountPath)
+	inflight.Delete(storageDriver)
 	go callStorageHooks("del", storageDriver)
 	return nil
 }
 
 // UpdateStorage update storage
-// get old storage first
-// drop the storage then reinitialize
+// a new instance of the driver is initialized with the storage and replaces
+// the running one, which is dropped once what was started on it is done
 func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	oldStorage, err := db.GetStorageById(storage.ID)
 	if err != nil {
@@ -225,27 +242,25 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	//if storage.MountPath == "/" {
 	//	return errors.New("Mount path cannot be '/'")
 	//}
+	if err = checkReadAhead(storage.Proxy); err != nil {
+		return err
+	}
 	err = db.UpdateStorage(&storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	// nil if the storage was disabled
 	storageDriver, err := GetStorageByMountPath(oldStorage.MountPath)
 	if err == nil {
 		ClearCache(storageDriver, "/")
-	}
-	if storage.Disabled {
-		return nil
+	} else {
+		storageDriver = nil
 	}
 	if oldStorage.MountPath != storage.MountPath {
-		// mount path renamed, need to drop the storage
-		storagesMap.Delete(oldStorage.MountPath)
 		modifiedRoleIDs, err := db.UpdateRolePermissionsPathPrefix(oldStorage.MountPath, storage.MountPath)
 		if err != nil {
 			return errors.WithMessage(err, "failed to update role permissions")
 		}
-		for _, id := range modifiedRoleIDs {
-			roleCache.Del(fmt.Sprint(id))
-		}
 
 		//modifiedUsernames, err := db.UpdateUserBasePathPrefix(oldStorage.MountPath, storage.MountPath)
 		//if err != nil {
@@ -263,18 +278,15 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 			}
 		}
 	}
-	if err != nil {
-		return errors.WithMessage(err, "failed get storage driver")
-	}
-	err = storageDriver.Drop(ctx)
-	if err != nil {
-		return errors.Wrapf(err, "failed drop storage")
+	if storage.Disabled {
+		if storageDriver != nil {
+			storagesMap.Delete(oldStorage.MountPath)
+			retire(storageDriver)
+			go callStorageHooks("del", storageDriver)
+		}
+		return nil
 	}
-
-	err = initStorage(ctx, storage, storageDriver)
-	go callStorageHooks("update", storageDriver)
-	log.Debugf("storage %+v is update", storageDriver)
-	return err
+	return swapStorage(ctx, storage, storageDriver)
 }
 
 func DeleteStorageById(ctx context.Context, id uint) error {
@@ -321,12 +333,16 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 		}
 		// delete the storage in the memory
 		storagesMap.Delete(storage.MountPath)
+		inflight.Delete(storageDriver)
 		go callStorageHooks("del", storageDriver)
 	}
 	// delete the storage in the database
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteDavProps(id, "/"); err != nil {
+		log.Warnf("failed delete the dav props of storage %s: %+v", storage.MountPath, err)
+	}
 	return nil
 }
 
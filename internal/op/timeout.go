@@ -0,0 +1,101 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+const (
+	opList = "list"
+	opGet  = "get"
+	opLink = "link"
+	opPut  = "put"
+)
+
+// getOpTimeout returns the timeout of a single driver operation, the storage
+// setting takes precedence over the default of the driver in the config file
+func getOpTimeout(storage driver.Driver, op string) time.Duration {
+	s := storage.GetStorage()
+	d := conf.Conf.DriverTimeouts[s.Driver]
+	var seconds int
+	switch op {
+	case opList:
+		seconds = firstPositive(s.ListTimeout, d.List)
+	case opGet:
+		seconds = firstPositive(s.GetTimeout, d.Get)
+	case opLink:
+		seconds = firstPositive(s.LinkTimeout, d.Link)
+	case opPut:
+		seconds = firstPositive(s.PutTimeout, d.Put)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func firstPositive(a, b int) int {
+	if a > 0 {
+		return a
+	}
+	return b
+}
+
+// withOpTimeout bounds a driver operation by its timeout, the returned
+// context is ctx itself if the operation has no timeout
+func withOpTimeout(ctx context.Context, storage driver.Driver, op string) (context.Context, context.CancelFunc) {
+	timeout := getOpTimeout(storage, op)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// wrapOpTimeout replaces the error of an operation aborted by its own timeout
+// with errs.OperationTimeout, so it is not mistaken for a canceled request
+func wrapOpTimeout(ctx, opCtx context.Context, storage driver.Driver, op string, err error) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return errs.NewErr(errs.OperationTimeout, "%s on [%s] exceeded %s", op, storage.GetStorage().MountPath, getOpTimeout(storage, op))
+}
+
+// linkWithTimeout waits for storage.Link up to the link timeout. Links may keep
+// reading with ctx after being returned, so a stuck call is abandoned instead of
+// canceled, and whatever it returns later is closed.
+func linkWithTimeout(ctx context.Context, storage driver.Driver, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	timeout := getOpTimeout(storage, opLink)
+	if timeout <= 0 {
+		return storage.Link(ctx, file, args)
+	}
+	type result struct {
+		link *model.Link
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		link, err := storage.Link(ctx, file, args)
+		ch <- result{link: link, err: err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		return r.link, r.err
+	case <-timer.C:
+		go func() {
+			if r := <-ch; r.link != nil {
+				if r.link.MFile != nil {
+					_ = r.link.MFile.Close()
+				}
+				if r.link.RangeReadCloser != nil {
+					_ = r.link.RangeReadCloser.Close()
+				}
+			}
+		}()
+		return nil, errs.NewErr(errs.OperationTimeout, "%s on [%s] exceeded %s", opLink, storage.GetStorage().MountPath, timeout)
+	}
+}
@@ -0,0 +1,24 @@
+package op
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startDriverSpan starts the span of the call op to the driver of storage, so
+// a slow request can be traced to the provider it waited for
+func startDriverSpan(ctx context.Context, storage driver.Driver, op, path string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "driver."+op,
+		attribute.String("driver", storage.Config().Name),
+		attribute.String("mount_path", storage.GetStorage().MountPath),
+		attribute.String("path", path))
+}
+
+// setCacheHit notes on the current span whether the cache name was hit
+func setCacheHit(ctx context.Context, name string, hit bool) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool(name+"_cache_hit", hit))
+}
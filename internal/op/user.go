@@ -9,6 +9,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 var userCache = cache.NewMemCache(cache.WithShards[*model.User](2))
@@ -142,6 +143,12 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := db.DeleteAPITokensByUser(id); err != nil {
+		return err
+	}
+	if err := deleteACLEntriesOf(model.ACLSubjectUser, id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -169,7 +176,110 @@ func UpdateUser(u *model.User) error {
 	//		}
 	//	}
 	//}
-	return db.UpdateUser(u)
+	if err := db.UpdateUser(u); err != nil {
+		return err
+	}
+	// the tokens of the old password are refused from now on, so are the
+	// sessions they were used in and the api tokens
+	if u.PwdTS != old.PwdTS {
+		if err := db.MarkUserSessionsInactive(u.ID); err != nil {
+			return err
+		}
+		return db.DeleteAPITokensByUser(u.ID)
+	}
+	return nil
+}
+
+// RevokeUserSessions logs the user out everywhere, the login tokens issued
+// so far and the presigned urls are refused, the sessions deactivated and
+// the api tokens deleted
+func RevokeUserSessions(id uint) error {
+	u, err := db.GetUserById(id)
+	if err != nil {
+		return err
+	}
+	// the tokens are compared to the second, a token issued in the same
+	// second as the revocation mustn't stay valid
+	now := time.Now().Unix()
+	if now <= u.PwdTS {
+		now = u.PwdTS + 1
+	}
+	u.PwdTS = now
+	return UpdateUser(u)
+}
+
+// CheckUploadQuota fails if uploading size more bytes exceeds the quota of u,
+// a nil u is not limited
+func CheckUploadQuota(u *model.User, size int64) error {
+	if u == nil {
+		return nil
+	}
+	// the cached user may lag behind the uploads counted meanwhile
+	latest, err := db.GetUserById(u.ID)
+	if err != nil {
+		return err
+	}
+	if !latest.CanUpload(size) {
+		return errors.WithStack(errs.UploadQuotaExceeded)
+	}
+	return nil
+}
+
+// AddUploadUsed counts size uploaded bytes for u
+func AddUploadUsed(u *model.User, size int64) error {
+	if u == nil || size <= 0 {
+		return nil
+	}
+	if err := db.AddUserUploadUsed(u.ID, size); err != nil {
+		return err
+	}
+	if u.IsAdmin() {
+		adminUser = nil
+	}
+	if u.IsGuest() {
+		guestUser = nil
+	}
+	userCache.Del(u.Username)
+	return nil
+}
+
+func ResetUploadUsed(id uint) error {
+	u, err := db.GetUserById(id)
+	if err != nil {
+		return err
+	}
+	if err = db.ResetUserUploadUsed(id); err != nil {
+		return err
+	}
+	if u.IsAdmin() {
+		adminUser = nil
+	}
+	if u.IsGuest() {
+		guestUser = nil
+	}
+	userCache.Del(u.Username)
+	return nil
+}
+
+// DownloadSpeedOf returns the download speed limit of u in KB/s, 0 for no
+// limit. The speed of the user comes first, then the fastest of its roles.
+func DownloadSpeedOf(u *model.User) int {
+	if u.DownloadSpeed > 0 {
+		return u.DownloadSpeed
+	}
+	roles, err := GetRolesByUserID(u.ID)
+	if err != nil {
+		return 0
+	}
+	speed := 0
+	for _, r := range roles {
+		if r.DownloadSpeed <= 0 {
+			// a role without limit lifts it
+			return 0
+		}
+		speed = max(speed, r.DownloadSpeed)
+	}
+	return speed
 }
 
 func Cancel2FAByUser(u *model.User) error {
@@ -0,0 +1,37 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestRevokeUserSessions(t *testing.T) {
+	u := &model.User{Username: "revoked", Role: model.Roles{model.GENERAL}}
+	if err := op.CreateUser(u); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	token, err := op.CreateAPIToken(&model.APIToken{UserID: u.ID, Name: "a", Scope: model.APITokenScopeAll})
+	if err != nil {
+		t.Fatalf("failed to create api token: %+v", err)
+	}
+	// the revocations of the same second still change the timestamp
+	var pwdTS int64
+	for i := 0; i < 2; i++ {
+		if err := op.RevokeUserSessions(u.ID); err != nil {
+			t.Fatalf("failed to revoke sessions: %+v", err)
+		}
+		revoked, err := op.GetUserById(u.ID)
+		if err != nil {
+			t.Fatalf("failed to get user: %+v", err)
+		}
+		if revoked.PwdTS <= pwdTS {
+			t.Errorf("expected the pwd_ts %d to be after %d", revoked.PwdTS, pwdTS)
+		}
+		pwdTS = revoked.PwdTS
+	}
+	if _, err := op.GetAPIToken(token); err == nil {
+		t.Errorf("expected the api token to be deleted")
+	}
+}
@@ -0,0 +1,79 @@
+package op
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/pkg/errors"
+)
+
+// the webhooks are matched against every file change, they are all kept in
+// memory and reloaded after any change
+var webhookCache = cache.NewMemCache(cache.WithShards[[]model.Webhook](1))
+var webhookG singleflight.Group[[]model.Webhook]
+
+const webhookCacheKey = "webhook"
+
+func GetWebhooks() ([]model.Webhook, error) {
+	if hooks, ok := webhookCache.Get(webhookCacheKey); ok {
+		return hooks, nil
+	}
+	hooks, err, _ := webhookG.Do(webhookCacheKey, func() ([]model.Webhook, error) {
+		hooks, err := db.GetWebhooks()
+		if err != nil {
+			return nil, err
+		}
+		webhookCache.Set(webhookCacheKey, hooks, cache.WithEx[[]model.Webhook](time.Hour))
+		return hooks, nil
+	})
+	return hooks, err
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func validateWebhook(h *model.Webhook) error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook url: %s", h.URL)
+	}
+	for _, e := range strings.Split(h.Events, ",") {
+		if e = strings.TrimSpace(e); e != "" && !model.ValidFileEvent(e) {
+			return errors.Errorf("invalid webhook event: %s", e)
+		}
+	}
+	return nil
+}
+
+func CreateWebhook(h *model.Webhook) error {
+	if err := validateWebhook(h); err != nil {
+		return err
+	}
+	defer webhookCache.Del(webhookCacheKey)
+	return db.CreateWebhook(h)
+}
+
+func UpdateWebhook(h *model.Webhook) error {
+	if err := validateWebhook(h); err != nil {
+		return err
+	}
+	defer webhookCache.Del(webhookCacheKey)
+	return db.UpdateWebhook(h)
+}
+
+func DeleteWebhookById(id uint) error {
+	defer webhookCache.Del(webhookCacheKey)
+	return db.DeleteWebhookById(id)
+}
+
+// RecordWebhookDelivery keeps the result of a delivery, the cached webhooks
+// are left as is as they don't need it
+func RecordWebhookDelivery(id uint, status int, errMsg string) error {
+	return db.UpdateWebhookDelivery(id, status, errMsg, time.Now())
+}
@@ -0,0 +1,231 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	sdk "github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Driver is the driver of the storages of a plugin, calling it over gRPC
+type Driver struct {
+	model.Storage
+	Addition map[string]any
+	plugin   *Plugin
+}
+
+func (d *Driver) Config() driver.Config {
+	return d.plugin.config
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+// instance identifies the storage in the plugin
+func (d *Driver) instance() string {
+	return strconv.FormatUint(uint64(d.ID), 10)
+}
+
+func (d *Driver) Init(ctx context.Context) error {
+	addition, err := utils.Json.MarshalToString(d.Addition)
+	if err != nil {
+		return err
+	}
+	resp, err := d.plugin.client.Init(ctx, sdk.InitReq{Instance: d.instance(), Addition: addition})
+	if err != nil {
+		return toErr(err)
+	}
+	if resp.Addition != "" && resp.Addition != addition {
+		d.Addition = nil
+		if err = utils.Json.UnmarshalFromString(resp.Addition, &d.Addition); err != nil {
+			return err
+		}
+		op.MustSaveDriverStorage(d)
+	}
+	return nil
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	return toErr(d.plugin.client.Drop(ctx, d.instance()))
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	objs, err := d.plugin.client.List(ctx, sdk.ListReq{Instance: d.instance(), Dir: fromObj(dir), Refresh: args.Refresh})
+	if err != nil {
+		return nil, toErr(err)
+	}
+	return utils.SliceConvert(objs, func(o sdk.Obj) (model.Obj, error) {
+		return toObj(o), nil
+	})
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	link, err := d.plugin.client.Link(ctx, sdk.LinkReq{
+		Instance: d.instance(),
+		File:     fromObj(file),
+		IP:       args.IP,
+		Header:   args.Header,
+		Type:     args.Type,
+	})
+	if err != nil {
+		return nil, toErr(err)
+	}
+	res := &model.Link{URL: link.URL, Header: link.Header}
+	if link.Expiration > 0 {
+		exp := time.Duration(link.Expiration) * time.Second
+		res.Expiration = &exp
+	}
+	return res, nil
+}
+
+func (d *Driver) Get(ctx context.Context, path string) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapGet) {
+		return nil, errs.NotImplement
+	}
+	obj, err := d.plugin.client.Get(ctx, sdk.GetReq{Instance: d.instance(), Path: path})
+	if err != nil {
+		return nil, toErr(err)
+	}
+	if obj == nil {
+		return nil, errs.ObjectNotFound
+	}
+	return toObj(*obj), nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapMakeDir) {
+		return nil, errs.NotImplement
+	}
+	return toResult(d.plugin.client.MakeDir(ctx, sdk.MakeDirReq{Instance: d.instance(), ParentDir: fromObj(parentDir), Name: dirName}))
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapMove) {
+		return nil, errs.NotImplement
+	}
+	return toResult(d.plugin.client.Move(ctx, sdk.MoveReq{Instance: d.instance(), Src: fromObj(srcObj), DstDir: fromObj(dstDir)}))
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapRename) {
+		return nil, errs.NotImplement
+	}
+	return toResult(d.plugin.client.Rename(ctx, sdk.RenameReq{Instance: d.instance(), Src: fromObj(srcObj), NewName: newName}))
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapCopy) {
+		return nil, errs.NotImplement
+	}
+	return toResult(d.plugin.client.Copy(ctx, sdk.MoveReq{Instance: d.instance(), Src: fromObj(srcObj), DstDir: fromObj(dstDir)}))
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	if !d.plugin.has(sdk.CapRemove) {
+		return errs.NotImplement
+	}
+	return toErr(d.plugin.client.Remove(ctx, sdk.ObjReq{Instance: d.instance(), Obj: fromObj(obj)}))
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (model.Obj, error) {
+	if !d.plugin.has(sdk.CapPut) {
+		return nil, errs.NotImplement
+	}
+	meta := sdk.PutMeta{
+		Instance: d.instance(),
+		DstDir:   fromObj(dstDir),
+		Name:     file.GetName(),
+		Size:     file.GetSize(),
+		Mimetype: file.GetMimetype(),
+	}
+	size := file.GetSize()
+	return toResult(d.plugin.client.Put(ctx, meta, driver.NewLimitedUploadStream(ctx, file), func(sent int64) {
+		if size > 0 {
+			up(float64(sent) / float64(size) * 100)
+		}
+	}))
+}
+
+func toObj(o sdk.Obj) model.Obj {
+	hashes := map[*utils.HashType]string{}
+	for name, sum := range o.Hashes {
+		if ht, ok := utils.GetHashByName(name); ok {
+			hashes[ht] = sum
+		}
+	}
+	return &model.Object{
+		ID:       o.ID,
+		Path:     o.Path,
+		Name:     o.Name,
+		Size:     o.Size,
+		Modified: o.Modified,
+		Ctime:    o.Ctime,
+		IsFolder: o.IsDir,
+		HashInfo: utils.NewHashInfoByMap(hashes),
+	}
+}
+
+func fromObj(o model.Obj) sdk.Obj {
+	obj := sdk.Obj{
+		ID:       o.GetID(),
+		Path:     o.GetPath(),
+		Name:     o.GetName(),
+		Size:     o.GetSize(),
+		Modified: o.ModTime(),
+		Ctime:    o.CreateTime(),
+		IsDir:    o.IsDir(),
+	}
+	for ht, sum := range o.GetHash().All() {
+		if obj.Hashes == nil {
+			obj.Hashes = map[string]string{}
+		}
+		obj.Hashes[ht.Name] = sum
+	}
+	return obj
+}
+
+// toResult returns a nil obj if the plugin doesn't tell it, for the cache to
+// be refreshed
+func toResult(obj *sdk.Obj, err error) (model.Obj, error) {
+	if err != nil {
+		return nil, toErr(err)
+	}
+	if obj == nil {
+		return nil, nil
+	}
+	return toObj(*obj), nil
+}
+
+// toErr maps the status of the plugin to the errors of alist
+func toErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.Unimplemented:
+		return errs.NotImplement
+	case codes.NotFound:
+		return errs.ObjectNotFound
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+	return errors.New(s.Message())
+}
+
+var _ driver.Driver = (*Driver)(nil)
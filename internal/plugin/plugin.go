@@ -0,0 +1,350 @@
+// Package plugin runs the driver plugins and registers their drivers, see
+// pkg/plugin for the protocol and writing one.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	sdk "github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// startTimeout is how long a plugin has to serve after being started
+const startTimeout = 10 * time.Second
+
+// Plugin is a driver plugin loaded
+type Plugin struct {
+	info   sdk.Info
+	config driver.Config
+	client *sdk.Client
+	cmd    *exec.Cmd
+	exited chan struct{}
+	logger io.Closer
+	sock   string
+}
+
+func (p *Plugin) has(capability string) bool {
+	return slices.Contains(p.info.Capabilities, capability)
+}
+
+// Status is the state of a plugin for the admins
+type Status struct {
+	model.DriverPlugin
+	Loaded       bool     `json:"loaded"`
+	Driver       string   `json:"driver"`
+	Version      string   `json:"version"`
+	Capabilities []string `json:"capabilities"`
+	Error        string   `json:"error,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	plugins = map[uint]*Plugin{}
+	// loadErrs are the errors of the plugins failed to load
+	loadErrs = map[uint]string{}
+)
+
+// Init loads the enabled plugins, before the storages are loaded
+func Init() {
+	dps, err := db.GetDriverPlugins()
+	if err != nil {
+		utils.Log.Errorf("failed get driver plugins: %+v", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, dp := range dps {
+		if dp.Disabled {
+			continue
+		}
+		wg.Add(1)
+		go func(dp model.DriverPlugin) {
+			defer wg.Done()
+			if err := load(context.Background(), dp); err != nil {
+				utils.Log.Errorf("failed load driver plugin [%s]: %+v", dp.Name, err)
+			} else {
+				utils.Log.Infof("success load driver plugin [%s]", dp.Name)
+			}
+		}(dp)
+	}
+	wg.Wait()
+}
+
+// Shutdown stops the plugins started by alist
+func Shutdown() {
+	mu.Lock()
+	defer mu.Unlock()
+	for id, p := range plugins {
+		op.UnregisterDriver(p.info.Name)
+		p.stop()
+		delete(plugins, id)
+	}
+}
+
+func load(ctx context.Context, dp model.DriverPlugin) error {
+	p, err := start(ctx, dp)
+	mu.Lock()
+	defer mu.Unlock()
+	if err == nil {
+		err = op.RegisterPluginDriver(func() driver.Driver {
+			return &Driver{plugin: p}
+		}, utils.MustSliceConvert(p.info.Additional, func(i sdk.Item) driver.Item {
+			return driver.Item(i)
+		}))
+		if err != nil {
+			p.stop()
+		}
+	}
+	if err != nil {
+		loadErrs[dp.ID] = err.Error()
+		return err
+	}
+	delete(loadErrs, dp.ID)
+	plugins[dp.ID] = p
+	return nil
+}
+
+// binDir is where the plugin binaries are put, only those can be run
+func binDir() string {
+	return filepath.Join(flags.DataDir, "plugins")
+}
+
+// binPath returns the path of the plugin binary named by the command, which
+// is a file name in binDir without arguments
+func binPath(command string) (string, error) {
+	if command == "." || command == ".." || strings.ContainsAny(command, "/\\ \t\r\n") {
+		return "", errors.Errorf("the command should be the name of a binary in %s, without arguments", binDir())
+	}
+	return filepath.Join(binDir(), command), nil
+}
+
+// sockDir is private to alist, not to be connected to by the other users
+func sockDir() (string, error) {
+	dir := filepath.Join(conf.Conf.TempDir, "plugins")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, os.Chmod(dir, 0o700)
+}
+
+// start runs the command of the plugin, if any, and waits for it to serve
+func start(ctx context.Context, dp model.DriverPlugin) (*Plugin, error) {
+	p := &Plugin{}
+	addr := dp.Address
+	if dp.Command != "" {
+		bin, err := binPath(dp.Command)
+		if err != nil {
+			return nil, err
+		}
+		dir, err := sockDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the socket dir")
+		}
+		p.sock = filepath.Join(dir, fmt.Sprintf("%d.sock", dp.ID))
+		_ = os.Remove(p.sock)
+		addr = "unix://" + p.sock
+		w := log.WithField("plugin", dp.Name).WriterLevel(log.InfoLevel)
+		p.logger = w
+		p.cmd = exec.Command(bin)
+		p.cmd.Env = append(os.Environ(), sdk.AddrEnv+"="+addr)
+		p.cmd.Stdout = w
+		p.cmd.Stderr = w
+		if err := p.cmd.Start(); err != nil {
+			_ = w.Close()
+			return nil, errors.Wrap(err, "failed to start the plugin")
+		}
+		p.exited = make(chan struct{})
+		go func() {
+			err := p.cmd.Wait()
+			close(p.exited)
+			log.Warnf("driver plugin [%s] exited: %v", dp.Name, err)
+		}()
+	} else if addr == "" {
+		return nil, errors.New("either the command or the address of the plugin is required")
+	}
+	client, err := sdk.NewClient(addr)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+	p.client = client
+	info, err := waitInfo(ctx, p)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+	if info.ProtocolVersion != sdk.ProtocolVersion {
+		p.stop()
+		return nil, errors.Errorf("the plugin speaks protocol %d, not %d", info.ProtocolVersion, sdk.ProtocolVersion)
+	}
+	if info.Name == "" {
+		p.stop()
+		return nil, errors.New("the plugin has no driver name")
+	}
+	p.info = *info
+	p.config = driver.Config{
+		Name:        info.Name,
+		LocalSort:   info.LocalSort,
+		NoCache:     info.NoCache,
+		NoUpload:    info.NoUpload || !p.has(sdk.CapPut),
+		DefaultRoot: info.DefaultRoot,
+		Alert:       info.Alert,
+	}
+	return p, nil
+}
+
+// waitInfo gets the info of the plugin, retrying until it's serving
+func waitInfo(ctx context.Context, p *Plugin) (*sdk.Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+	for {
+		callCtx, callCancel := context.WithTimeout(ctx, time.Second)
+		info, err := p.client.Info(callCtx)
+		callCancel()
+		if err == nil {
+			return info, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(err, "the plugin isn't serving")
+		case <-p.exited:
+			return nil, errors.New("the plugin exited")
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func (p *Plugin) stop() {
+	if p.client != nil {
+		_ = p.client.Close()
+	}
+	if p.cmd != nil {
+		select {
+		case <-p.exited:
+		default:
+			_ = p.cmd.Process.Kill()
+			<-p.exited
+		}
+		_ = p.logger.Close()
+		_ = os.Remove(p.sock)
+	}
+}
+
+// unload stops the plugin, refusing it if storages of its driver are loaded
+func unload(id uint) error {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(loadErrs, id)
+	p, ok := plugins[id]
+	if !ok {
+		return nil
+	}
+	for _, storage := range op.GetAllStorages() {
+		if storage.Config().Name == p.info.Name {
+			return errors.Errorf("the driver is used by storage [%s], disable it first", storage.GetStorage().MountPath)
+		}
+	}
+	op.UnregisterDriver(p.info.Name)
+	p.stop()
+	delete(plugins, id)
+	return nil
+}
+
+func List() ([]Status, error) {
+	dps, err := db.GetDriverPlugins()
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	res := make([]Status, 0, len(dps))
+	for _, dp := range dps {
+		s := Status{DriverPlugin: dp, Error: loadErrs[dp.ID]}
+		if p, ok := plugins[dp.ID]; ok {
+			s.Loaded = true
+			s.Driver = p.info.Name
+			s.Version = p.info.Version
+			s.Capabilities = p.info.Capabilities
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func validate(dp *model.DriverPlugin) error {
+	if dp.Command == "" {
+		return nil
+	}
+	_, err := binPath(dp.Command)
+	return err
+}
+
+func Create(ctx context.Context, dp *model.DriverPlugin) error {
+	if err := validate(dp); err != nil {
+		return err
+	}
+	if err := db.CreateDriverPlugin(dp); err != nil {
+		return err
+	}
+	if dp.Disabled {
+		return nil
+	}
+	return load(ctx, *dp)
+}
+
+func Update(ctx context.Context, dp *model.DriverPlugin) error {
+	if err := validate(dp); err != nil {
+		return err
+	}
+	if _, err := db.GetDriverPluginById(dp.ID); err != nil {
+		return err
+	}
+	if err := unload(dp.ID); err != nil {
+		return err
+	}
+	if err := db.UpdateDriverPlugin(dp); err != nil {
+		return err
+	}
+	if dp.Disabled {
+		return nil
+	}
+	return load(ctx, *dp)
+}
+
+func Delete(id uint) error {
+	if err := unload(id); err != nil {
+		return err
+	}
+	return db.DeleteDriverPluginById(id)
+}
+
+// Reload restarts the plugin, to pick up a new binary
+func Reload(ctx context.Context, id uint) error {
+	dp, err := db.GetDriverPluginById(id)
+	if err != nil {
+		return err
+	}
+	if err = unload(id); err != nil {
+		return err
+	}
+	if dp.Disabled {
+		return nil
+	}
+	return load(ctx, *dp)
+}
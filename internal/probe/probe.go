@@ -0,0 +1,224 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/notify"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the samples kept per storage and operation whatever the window
+const maxSamples = 1000
+
+// how long a probe may take, a hung driver fails it instead of stalling the rounds
+const probeTimeout = time.Minute
+
+var probes = map[string]func(context.Context, driver.Driver) (time.Duration, error){
+	"list": op.ProbeList,
+	"get":  op.ProbeGet,
+}
+
+type Sample struct {
+	Time time.Time `json:"time"`
+	// Latency is in milliseconds
+	Latency int64  `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type OpStats struct {
+	Count  int `json:"count"`
+	Errors int `json:"errors"`
+	// the percentiles of the latency of the successful probes, in milliseconds
+	P50  int64   `json:"p50"`
+	P90  int64   `json:"p90"`
+	P99  int64   `json:"p99"`
+	Last *Sample `json:"last,omitempty"`
+	// Recent are the latest samples, the newest first
+	Recent []Sample `json:"recent"`
+}
+
+type StorageStats struct {
+	MountPath string              `json:"mount_path"`
+	Ops       map[string]*OpStats `json:"ops"`
+}
+
+var (
+	samplesMu sync.Mutex
+	// samples by mount path then operation, the oldest first
+	samples   = map[string]map[string][]Sample{}
+	startOnce sync.Once
+	lastRun   time.Time
+)
+
+// Init starts probing the storages at the interval configured in the settings
+func Init() {
+	startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				runScheduled()
+			}
+		}()
+	})
+}
+
+func runScheduled() {
+	interval := setting.GetInt(conf.StorageProbeInterval, 0)
+	if interval <= 0 || time.Since(lastRun) < time.Duration(interval)*time.Second {
+		return
+	}
+	lastRun = time.Now()
+	ops := enabledOps()
+	var wg sync.WaitGroup
+	for _, storage := range op.GetAllStorages() {
+		// the storages which failed to init have nothing to probe
+		if storage.GetStorage().Disabled || storage.GetStorage().Status != op.WORK {
+			continue
+		}
+		wg.Add(1)
+		go func(storage driver.Driver) {
+			defer wg.Done()
+			probeStorage(storage, ops)
+		}(storage)
+	}
+	// a slow storage delays the next round instead of piling up probes
+	wg.Wait()
+}
+
+func enabledOps() []string {
+	var ops []string
+	for _, name := range strings.Split(setting.GetStr(conf.StorageProbeOps, "list"), ",") {
+		if name = strings.TrimSpace(name); probes[name] != nil {
+			ops = append(ops, name)
+		}
+	}
+	return ops
+}
+
+func probeStorage(storage driver.Driver, ops []string) {
+	mountPath := storage.GetStorage().MountPath
+	for _, name := range ops {
+		latency, err := runProbe(name, storage)
+		if errors.Is(err, errs.NotSupport) {
+			continue
+		}
+		s := Sample{Time: time.Now(), Latency: latency.Milliseconds()}
+		if err != nil {
+			s.Error = err.Error()
+			log.Debugf("probe %s of %s failed: %+v", name, mountPath, err)
+			// notified once it starts failing, not on every probe
+			if !lastFailed(mountPath, name) {
+				notify.StorageFailed(mountPath, fmt.Sprintf("the %s probe failed: %s", name, s.Error))
+			}
+		}
+		record(mountPath, name, s)
+	}
+}
+
+// runProbe runs the probe name on storage within probeTimeout, a panic of the
+// driver fails the probe
+func runProbe(name string, storage driver.Driver) (latency time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	return probes[name](ctx, storage)
+}
+
+func lastFailed(mountPath, name string) bool {
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+	list := samples[mountPath][name]
+	return len(list) > 0 && list[len(list)-1].Error != ""
+}
+
+func record(mountPath, name string, s Sample) {
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+	byOp, ok := samples[mountPath]
+	if !ok {
+		byOp = map[string][]Sample{}
+		samples[mountPath] = byOp
+	}
+	list := append(byOp[name], s)
+	if len(list) > maxSamples {
+		list = list[len(list)-maxSamples:]
+	}
+	byOp[name] = list
+}
+
+// Stats returns the latency of the probes of each storage within the window
+// of storage_probe_window minutes, or of the storage at mountPath only
+func Stats(mountPath string, recent int) []StorageStats {
+	window := time.Duration(setting.GetInt(conf.StorageProbeWindow, 60)) * time.Minute
+	since := time.Now().Add(-window)
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+	res := make([]StorageStats, 0, len(samples))
+	for mp, byOp := range samples {
+		if mountPath != "" && mp != mountPath {
+			continue
+		}
+		// the removed storages are forgotten
+		if _, err := op.GetStorageByMountPath(mp); err != nil {
+			delete(samples, mp)
+			continue
+		}
+		ss := StorageStats{MountPath: mp, Ops: map[string]*OpStats{}}
+		for name, list := range byOp {
+			i := sort.Search(len(list), func(i int) bool { return !list[i].Time.Before(since) })
+			list = list[i:]
+			byOp[name] = list
+			ss.Ops[name] = opStats(list, recent)
+		}
+		res = append(res, ss)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].MountPath < res[j].MountPath })
+	return res
+}
+
+func opStats(list []Sample, recent int) *OpStats {
+	st := &OpStats{Count: len(list), Recent: []Sample{}}
+	var latencies []int64
+	for _, s := range list {
+		if s.Error != "" {
+			st.Errors++
+			continue
+		}
+		latencies = append(latencies, s.Latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	st.P50, st.P90, st.P99 = percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99)
+	if len(list) > 0 {
+		last := list[len(list)-1]
+		st.Last = &last
+	}
+	for i := len(list) - 1; i >= 0 && len(st.Recent) < recent; i-- {
+		st.Recent = append(st.Recent, list[i])
+	}
+	return st
+}
+
+// percentile uses the nearest rank of the sorted values
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
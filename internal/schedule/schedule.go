@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// Check cleans the paths of the job and validates its fields
+func Check(job *model.CopyJob) error {
+	if job.SrcPath == "" || job.DstPath == "" {
+		return errors.New("src_path and dst_path are required")
+	}
+	job.SrcPath = utils.FixAndCleanPath(job.SrcPath)
+	job.DstPath = utils.FixAndCleanPath(job.DstPath)
+	if job.ConflictPolicy == "" {
+		job.ConflictPolicy = model.CopyJobOverwrite
+	}
+	if job.ConflictPolicy != model.CopyJobOverwrite && job.ConflictPolicy != model.CopyJobSkip {
+		return errors.Errorf("invalid conflict_policy: %s", job.ConflictPolicy)
+	}
+	if job.Mode == "" {
+		job.Mode = model.CopyJobModeCopy
+	}
+	if job.Mode != model.CopyJobModeCopy && job.Mode != model.CopyJobModeSync {
+		return errors.Errorf("invalid mode: %s", job.Mode)
+	}
+	if _, err := cron.Parse(job.Cron); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run copies the src of the job into its dst as the admin and records the run
+func Run(job *model.CopyJob, manual bool) (*model.CopyJobRun, error) {
+	run := &model.CopyJobRun{JobID: job.ID, Manual: manual, StartedAt: time.Now()}
+	if err := copyOnce(job, run); err != nil {
+		run.Error = err.Error()
+	}
+	if err := db.SetCopyJobLastRun(job.ID, run.StartedAt); err != nil {
+		log.Warnf("failed record last run of copy job %d: %+v", job.ID, err)
+	}
+	return run, db.CreateCopyJobRun(run)
+}
+
+func copyOnce(job *model.CopyJob, run *model.CopyJobRun) error {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	ctx := context.WithValue(context.Background(), "user", admin)
+	var t task.TaskExtensionInfo
+	if job.Mode == model.CopyJobModeSync {
+		t, err = fs.SyncAsTask(ctx, job.SrcPath, job.DstPath, job.SyncCompare, job.SyncDelete)
+	} else {
+		if job.ConflictPolicy == model.CopyJobSkip {
+			ctx = context.WithValue(ctx, conf.SkipExistingKey, struct{}{})
+		}
+		t, err = fs.Copy(ctx, job.SrcPath, job.DstPath)
+	}
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		run.TaskID = t.GetID()
+	}
+	return nil
+}
+
+// CheckSyncPair cleans the paths of the pair and validates its fields
+func CheckSyncPair(pair *model.SyncPair) error {
+	if pair.PathA == "" || pair.PathB == "" {
+		return errors.New("path_a and path_b are required")
+	}
+	pair.PathA = utils.FixAndCleanPath(pair.PathA)
+	pair.PathB = utils.FixAndCleanPath(pair.PathB)
+	if utils.IsSubPath(pair.PathA, pair.PathB) || utils.IsSubPath(pair.PathB, pair.PathA) {
+		return errors.New("the paths of a sync pair can't contain each other")
+	}
+	if pair.Conflict == "" {
+		pair.Conflict = model.SyncConflictNewest
+	}
+	if !utils.SliceContains([]string{model.SyncConflictNewest, model.SyncConflictKeepBoth, model.SyncConflictManual}, pair.Conflict) {
+		return errors.Errorf("invalid conflict: %s", pair.Conflict)
+	}
+	if pair.Cron != "" {
+		if _, err := cron.Parse(pair.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunSyncPair adds a task syncing the pair as the admin
+func RunSyncPair(pair *model.SyncPair) (task.TaskExtensionInfo, error) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return fs.SyncPairAsTask(context.WithValue(context.Background(), "user", admin), pair)
+}
+
+var startOnce sync.Once
+
+// Init starts running the copy jobs and the sync pairs at the minutes of
+// their cron
+func Init() {
+	startOnce.Do(func() {
+		go func() {
+			for {
+				now := time.Now()
+				next := now.Truncate(time.Minute).Add(time.Minute)
+				time.Sleep(next.Sub(now))
+				runDue(next)
+			}
+		}()
+	})
+}
+
+func runDue(minute time.Time) {
+	runDueCopyJobs(minute)
+	runDueSyncPairs(minute)
+}
+
+func runDueSyncPairs(minute time.Time) {
+	pairs, err := db.GetSyncPairs()
+	if err != nil {
+		log.Errorf("failed get sync pairs: %+v", err)
+		return
+	}
+	for i := range pairs {
+		pair := &pairs[i]
+		if pair.Disabled || pair.Cron == "" {
+			continue
+		}
+		s, err := cron.Parse(pair.Cron)
+		if err != nil {
+			log.Warnf("invalid cron of sync pair %d: %+v", pair.ID, err)
+			continue
+		}
+		if !s.Match(minute) {
+			continue
+		}
+		// skip the pair while its last run goes on
+		if len(fs.SyncPairTaskManager.GetByCondition(func(t *fs.SyncPairTask) bool {
+			return t.PairID == pair.ID &&
+				!utils.SliceContains([]tache.State{tache.StateSucceeded, tache.StateCanceled, tache.StateFailed}, t.GetState())
+		})) > 0 {
+			continue
+		}
+		if _, err = RunSyncPair(pair); err != nil {
+			log.Errorf("failed run sync pair %d: %+v", pair.ID, err)
+		}
+	}
+}
+
+func runDueCopyJobs(minute time.Time) {
+	jobs, err := db.GetCopyJobs()
+	if err != nil {
+		log.Errorf("failed get copy jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Disabled {
+			continue
+		}
+		s, err := cron.Parse(job.Cron)
+		if err != nil {
+			log.Warnf("invalid cron of copy job %d: %+v", job.ID, err)
+			continue
+		}
+		// a manual run in the same minute doesn't skip the scheduled one
+		if !s.Match(minute) {
+			continue
+		}
+		if _, err = Run(job, false); err != nil {
+			log.Errorf("failed record run of copy job %d: %+v", job.ID, err)
+		}
+	}
+}
@@ -11,6 +11,20 @@ var config = searcher.Config{
 	Name: "bleve",
 }
 
+// pathField is the keyword of the parent, the indexes created before it have
+// no such field, so they can't be searched under a path until rebuilt
+const pathField = "parent_path"
+
+var pathFieldKey = []byte("path_field")
+
+func hasPathField(index bleve.Index) bool {
+	v, err := index.GetInternal(pathFieldKey)
+	if err == nil && v == nil {
+		log.Warnf("the bleve index has no %s, rebuild it to filter the search results in it", pathField)
+	}
+	return err == nil && v != nil
+}
+
 func Init(indexPath *string) (bleve.Index, error) {
 	log.Debugf("bleve path: %s", *indexPath)
 	fileIndex, err := bleve.Open(*indexPath)
@@ -21,15 +35,26 @@ func Init(indexPath *string) (bleve.Index, error) {
 		searchNodeMapping.AddFieldMappingsAt("is_dir", bleve.NewBooleanFieldMapping())
 		// TODO: appoint analyzer
 		parentFieldMapping := bleve.NewTextFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping)
+		// the parent as a whole, to search under a path
+		pathFieldMapping := bleve.NewKeywordFieldMapping()
+		pathFieldMapping.Name = pathField
+		searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping, pathFieldMapping)
 		// TODO: appoint analyzer
 		nameFieldMapping := bleve.NewKeywordFieldMapping()
 		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+		searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+		// the content is only matched, so it's not stored
+		contentFieldMapping := bleve.NewTextFieldMapping()
+		contentFieldMapping.Store = false
+		searchNodeMapping.AddFieldMappingsAt("content", contentFieldMapping)
 		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
 		fileIndex, err = bleve.New(*indexPath, indexMapping)
 		if err != nil {
 			return nil, err
 		}
+		if err = fileIndex.SetInternal(pathFieldKey, []byte(pathField)); err != nil {
+			return nil, err
+		}
 	} else if err != nil {
 		return nil, err
 	}
@@ -42,6 +67,6 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		return &Bleve{BIndex: b}, nil
+		return &Bleve{BIndex: b, Scoped: hasPathField(b)}, nil
 	})
 }
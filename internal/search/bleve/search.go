@@ -3,6 +3,9 @@ package bleve
 import (
 	"context"
 	"os"
+	"path"
+	"strings"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -19,6 +22,8 @@ import (
 
 type Bleve struct {
 	BIndex bleve.Index
+	// Scoped reports whether the index can filter by path
+	Scoped bool
 }
 
 func (b *Bleve) Config() searcher.Config {
@@ -29,34 +34,177 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 	var queries []query2.Query
 	query := bleve.NewMatchQuery(req.Keywords)
 	query.SetField("name")
-	queries = append(queries, query)
+	contentQuery := bleve.NewMatchQuery(req.Keywords)
+	contentQuery.SetField("content")
+	queries = append(queries, bleve.NewDisjunctionQuery(query, contentQuery))
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
 		queries = append(queries, isDirQuery)
 	}
-	reqQuery := bleve.NewConjunctionQuery(queries...)
+	queries = append(queries, filterQueries(req)...)
+	var hidden []query2.Query
+	if b.Scoped {
+		if q := inParent(req.Parent); q != nil {
+			queries = append(queries, q)
+		}
+		var restrictQueries []query2.Query
+		restrictQueries, hidden = restrictionQueries(req.Restrict)
+		queries = append(queries, restrictQueries...)
+	}
+	reqQuery := booleanQuery(queries, hidden)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
-	search.From = (req.Page - 1) * req.PerPage
+	search.SortBy(sortFields(req))
+	search.From = req.Offset()
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	search.Fields = []string{"parent", "name", "is_dir", "size", "modified"}
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
+		// the nodes indexed by older versions have no modified time
+		var modified time.Time
+		if v, ok := src.Fields["modified"].(string); ok {
+			modified, _ = time.Parse(time.RFC3339, v)
+		}
 		return model.SearchNode{
-			Parent: src.Fields["parent"].(string),
-			Name:   src.Fields["name"].(string),
-			IsDir:  src.Fields["is_dir"].(bool),
-			Size:   int64(src.Fields["size"].(float64)),
+			Parent:   src.Fields["parent"].(string),
+			Name:     src.Fields["name"].(string),
+			IsDir:    src.Fields["is_dir"].(bool),
+			Size:     int64(src.Fields["size"].(float64)),
+			Modified: modified,
 		}, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+// sortFields returns the fields to sort the results of req by
+func sortFields(req model.SearchReq) []string {
+	var fields []string
+	switch req.OrderBy {
+	case model.SearchOrderSize:
+		fields = []string{"size", "parent", "name"}
+	case model.SearchOrderModified:
+		fields = []string{"modified", "parent", "name"}
+	case model.SearchOrderRelevance:
+		return []string{"-_score"}
+	default:
+		fields = []string{"name", "parent"}
+	}
+	if req.Desc() {
+		for i := range fields {
+			fields[i] = "-" + fields[i]
+		}
+	}
+	return fields
+}
+
+// filterQueries translates the filters of req but the scope
+func filterQueries(req model.SearchReq) []query2.Query {
+	var queries []query2.Query
+	if req.FilesOnly() {
+		q := bleve.NewBoolFieldQuery(false)
+		q.SetField("is_dir")
+		queries = append(queries, q)
+	}
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var lo, hi *float64
+		if req.MinSize > 0 {
+			v := float64(req.MinSize)
+			lo = &v
+		}
+		if req.MaxSize > 0 {
+			v := float64(req.MaxSize)
+			hi = &v
+		}
+		inclusive := true
+		q := bleve.NewNumericRangeInclusiveQuery(lo, hi, &inclusive, &inclusive)
+		q.SetField("size")
+		queries = append(queries, q)
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		var start, end time.Time
+		if req.ModifiedAfter != nil {
+			start = *req.ModifiedAfter
+		}
+		if req.ModifiedBefore != nil {
+			end = *req.ModifiedBefore
+		}
+		inclusive := true
+		q := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		q.SetField("modified")
+		queries = append(queries, q)
+	}
+	if len(req.Exts) > 0 {
+		// the name is a keyword, so it's matched case sensitively
+		var extQueries []query2.Query
+		for _, ext := range req.Exts {
+			for _, e := range []string{ext, strings.ToUpper(ext)} {
+				q := bleve.NewWildcardQuery("*." + e)
+				q.SetField("name")
+				extQueries = append(extQueries, q)
+			}
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(extQueries...))
+	}
+	return queries
+}
+
+// restrictionQueries translates the restriction into the queries to match
+// and the queries of the hidden nodes, the hide rules which aren't globs are left
+func restrictionQueries(r *model.SearchRestriction) ([]query2.Query, []query2.Query) {
+	var queries, hidden []query2.Query
+	if r == nil {
+		return nil, nil
+	}
+	if r.Roots != nil {
+		roots := []query2.Query{bleve.NewMatchNoneQuery()}
+		for _, root := range r.Roots {
+			parentQuery := bleve.NewTermQuery(path.Dir(root))
+			parentQuery.SetField(pathField)
+			nameQuery := bleve.NewTermQuery(path.Base(root))
+			nameQuery.SetField("name")
+			roots = append(roots, inParent(root), bleve.NewConjunctionQuery(parentQuery, nameQuery))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(roots...))
+	}
+	for _, hide := range r.Hides {
+		if hide.Glob == "" {
+			continue
+		}
+		nameQuery := bleve.NewWildcardQuery(hide.Glob)
+		nameQuery.SetField("name")
+		must := []query2.Query{nameQuery}
+		if !hide.Sub {
+			q := bleve.NewTermQuery(hide.Dir)
+			q.SetField(pathField)
+			must = append(must, q)
+		} else if q := inParent(hide.Dir); q != nil {
+			must = append(must, q)
+		}
+		var excepts []query2.Query
+		for _, except := range hide.Except {
+			excepts = append(excepts, inParent(except))
+		}
+		hidden = append(hidden, booleanQuery(must, excepts))
+	}
+	return queries, hidden
+}
+
+// inParent matches the nodes under dir, nil for the root
+func inParent(dir string) query2.Query {
+	if dir == "" || dir == "/" {
+		return nil
+	}
+	q := bleve.NewTermQuery(dir)
+	q.SetField(pathField)
+	prefixQuery := bleve.NewPrefixQuery(dir + "/")
+	prefixQuery.SetField(pathField)
+	return bleve.NewDisjunctionQuery(q, prefixQuery)
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
 	return b.BIndex.Index(uuid.NewString(), node)
 }
@@ -98,8 +246,21 @@ func (b *Bleve) Clear(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	b.BIndex = bIndex
+	b.BIndex, b.Scoped = bIndex, true
 	return nil
 }
 
 var _ searcher.Searcher = (*Bleve)(nil)
+
+// booleanQuery matches the documents satisfying all of must and none of mustNot,
+// empty clauses are left out so they don't match nothing
+func booleanQuery(must, mustNot []query2.Query) *query2.BooleanQuery {
+	q := bleve.NewBooleanQuery()
+	if len(must) > 0 {
+		q.AddMust(must...)
+	}
+	if len(mustNot) > 0 {
+		q.AddMustNot(mustNot...)
+	}
+	return q
+}
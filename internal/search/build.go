@@ -162,6 +162,10 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 					return filepath.SkipDir
 				}
 			}
+			// the dirs are listed by the walk once this returns
+			if info.IsDir() && !waitThrottle(ctx, &running) {
+				return filepath.SkipDir
+			}
 			// ignore root
 			if indexPath == "/" {
 				return nil
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type objChange struct {
+	path    string
+	removed bool
+}
+
+// changes are applied in order by a single goroutine, so the removal of the
+// src of a move comes before the creation of the dst
+var changes = make(chan objChange, 1024)
+
+// onObjChange updates the index for a mutation made through alist, a change
+// which doesn't fit in the queue is left for the next full build
+func onObjChange(objPath string, removed bool) {
+	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) {
+		return
+	}
+	if isIgnorePath(objPath) {
+		return
+	}
+	select {
+	case changes <- objChange{path: objPath, removed: removed}:
+	default:
+		log.Warnf("search index update queue is full, dropped the change of %s", objPath)
+	}
+}
+
+func applyChanges() {
+	for c := range changes {
+		if err := applyChange(context.Background(), c); err != nil {
+			log.Errorf("update search index error while apply the change of %s: %+v", c.path, err)
+		}
+	}
+}
+
+func applyChange(ctx context.Context, c objChange) error {
+	// the build indexes the paths changed meanwhile as it walks
+	if instance == nil || Running() {
+		return nil
+	}
+	progress, err := Progress()
+	if err != nil {
+		return err
+	}
+	// only update when index have built
+	if !progress.IsDone {
+		return nil
+	}
+	// an overwritten obj is removed first not to be indexed twice
+	if err = instance.Del(ctx, c.path); err != nil && !errors.Is(err, errs.NotSupport) {
+		return err
+	}
+	if c.removed {
+		return nil
+	}
+	obj, err := fs.Get(ctx, c.path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !obj.IsDir() {
+		return Index(ctx, path.Dir(c.path), obj)
+	}
+	return BuildIndex(ctx, []string{c.path}, conf.SlicesMap[conf.IgnorePaths],
+		setting.GetInt(conf.MaxIndexDepth, 20)-strings.Count(c.path, "/"), false)
+}
+
+func init() {
+	op.RegisterObjChangeHook(onObjChange)
+	go applyChanges()
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/doctext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxContentLen caps the text indexed for a file, so it fits a text column of mysql
+const maxContentLen = 60 << 10
+
+// fileContent returns the text to index for the file obj under parent, empty
+// if the content isn't indexed or can't be extracted
+func fileContent(ctx context.Context, parent string, obj model.Obj) string {
+	if obj.IsDir() || !setting.GetBool(conf.IndexContent) || !doctext.Supported(obj.GetName()) {
+		return ""
+	}
+	if obj.GetSize() > int64(setting.GetInt(conf.IndexContentMaxSize, 10))<<20 {
+		return ""
+	}
+	filePath := path.Join(parent, obj.GetName())
+	data, err := readFile(ctx, filePath, obj)
+	if err != nil {
+		log.Warnf("failed read %s to index its content: %+v", filePath, err)
+		return ""
+	}
+	text, err := doctext.Extract(obj.GetName(), data, maxContentLen)
+	if err != nil {
+		log.Warnf("failed extract the content of %s: %+v", filePath, err)
+		return ""
+	}
+	return text
+}
+
+func readFile(ctx context.Context, filePath string, obj model.Obj) ([]byte, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(filePath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	return io.ReadAll(io.LimitReader(ss, obj.GetSize()))
+}
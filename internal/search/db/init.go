@@ -27,6 +27,11 @@ func init() {
 				log.Errorf("failed to create full text index: %v", err)
 				return nil, err
 			}
+			tx = db.Exec(fmt.Sprintf("CREATE FULLTEXT INDEX idx_%s_content_fulltext ON %s(content);", tableName, tableName))
+			if err := tx.Error; err != nil && !strings.Contains(err.Error(), "Error 1061 (42000)") {
+				log.Errorf("failed to create full text index: %v", err)
+				return nil, err
+			}
 		case "postgres":
 			db.Exec("CREATE EXTENSION pg_trgm;")
 			db.Exec("CREATE EXTENSION btree_gin;")
@@ -0,0 +1,67 @@
+package elasticsearch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+)
+
+var config = searcher.Config{
+	Name:       "elasticsearch",
+	AutoUpdate: true,
+}
+
+// mapping of the index, the name is also kept as a keyword to match parts of
+// the names the analyzer doesn't split
+var mapping = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"path":   map[string]any{"type": "keyword"},
+			"parent": map[string]any{"type": "keyword"},
+			"name": map[string]any{
+				"type": "text",
+				"fields": map[string]any{
+					"keyword": map[string]any{"type": "keyword", "ignore_above": 1024},
+				},
+			},
+			"is_dir":   map[string]any{"type": "boolean"},
+			"size":     map[string]any{"type": "long"},
+			"modified": map[string]any{"type": "date"},
+			"content":  map[string]any{"type": "text"},
+		},
+	},
+}
+
+func init() {
+	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
+		c := conf.Conf.Elasticsearch
+		client := base.NewRestyClient().
+			SetBaseURL(strings.TrimSuffix(c.Host, "/")).
+			SetHeader("Content-Type", "application/json")
+		if c.APIKey != "" {
+			client.SetHeader("Authorization", "ApiKey "+c.APIKey)
+		} else if c.Username != "" {
+			client.SetBasicAuth(c.Username, c.Password)
+		}
+		e := &Elasticsearch{
+			Client:    client,
+			IndexName: c.IndexPrefix + "alist",
+		}
+		res, err := e.Client.R().Head("/" + e.IndexName)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode() == http.StatusNotFound {
+			res, err = e.Client.R().SetBody(mapping).Put("/" + e.IndexName)
+			if err = checkResp(res, err); err != nil {
+				return nil, err
+			}
+		} else if err = checkResp(res, nil); err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
@@ -0,0 +1,380 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+// Elasticsearch indexes the nodes in an Elasticsearch or OpenSearch index, a
+// node by path so indexing it again replaces it
+type Elasticsearch struct {
+	Client    *resty.Client
+	IndexName string
+}
+
+type document struct {
+	Path string `json:"path"`
+	model.SearchNode
+}
+
+type searchResult struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source document `json:"_source"`
+			Sort   []any    `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type esError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type bulkResult struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Error *esError `json:"error"`
+	} `json:"items"`
+}
+
+func (e *Elasticsearch) Config() searcher.Config {
+	return config
+}
+
+// Search ranks the nodes by relevance, the names containing the keywords as a
+// whole are matched too. The api of Elasticsearch pages up to 10000 hits.
+func (e *Elasticsearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	filters := []any{}
+	if f := inParent(req.Parent); f != nil {
+		filters = append(filters, f)
+	}
+	if req.Scope != 0 {
+		filters = append(filters, map[string]any{"term": map[string]any{"is_dir": req.Scope == 1}})
+	}
+	filters = append(filters, filterQueries(req)...)
+	restrictFilters, hidden := restrictionQueries(req.Restrict)
+	filters = append(filters, restrictFilters...)
+	query := map[string]any{
+		"from":             req.Offset(),
+		"size":             req.PerPage,
+		"track_total_hits": true,
+		"_source":          map[string]any{"excludes": []string{"content"}},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"should": []any{
+					map[string]any{"multi_match": map[string]any{
+						"query":  req.Keywords,
+						"fields": []string{"name^3", "content"},
+					}},
+					map[string]any{"wildcard": map[string]any{"name.keyword": map[string]any{
+						"value":            "*" + escapeWildcard(req.Keywords) + "*",
+						"case_insensitive": true,
+					}}},
+				},
+				"minimum_should_match": 1,
+				"filter":               filters,
+				"must_not":             hidden,
+			},
+		},
+	}
+	if sort, after := sortQuery(req); sort != nil {
+		query["sort"] = sort
+		if req.Seek() {
+			query["from"], query["search_after"] = 0, after
+		}
+	}
+	var result searchResult
+	res, err := e.Client.R().SetContext(ctx).SetBody(query).SetResult(&result).Post("/" + e.IndexName + "/_search")
+	if err = checkResp(res, err); err != nil {
+		return nil, 0, err
+	}
+	nodes := make([]model.SearchNode, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		nodes = append(nodes, hit.Source.SearchNode)
+	}
+	return nodes, result.Hits.Total.Value, nil
+}
+
+func (e *Elasticsearch) Index(ctx context.Context, node model.SearchNode) error {
+	return e.BatchIndex(ctx, []model.SearchNode{node})
+}
+
+func (e *Elasticsearch) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
+	var body bytes.Buffer
+	for _, node := range nodes {
+		p := path.Join(node.Parent, node.Name)
+		action, err := utils.Json.Marshal(map[string]any{"index": map[string]any{"_id": docID(p)}})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		doc, err := utils.Json.Marshal(document{Path: p, SearchNode: node})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+	var result bulkResult
+	res, err := e.Client.R().SetContext(ctx).
+		SetHeader("Content-Type", "application/x-ndjson").
+		SetBody(body.Bytes()).
+		SetResult(&result).
+		Post("/" + e.IndexName + "/_bulk")
+	if err = checkResp(res, err); err != nil {
+		return err
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Error != nil {
+					return errors.Errorf("elasticsearch: %s: %s", op.Error.Type, op.Error.Reason)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Get pages through the children of parent in the order of their paths
+func (e *Elasticsearch) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
+	const size = 1000
+	var (
+		nodes []model.SearchNode
+		after []any
+	)
+	for {
+		query := map[string]any{
+			"size":    size,
+			"_source": map[string]any{"excludes": []string{"content"}},
+			"query":   map[string]any{"term": map[string]any{"parent": parent}},
+			"sort":    []any{map[string]any{"path": "asc"}},
+		}
+		if after != nil {
+			query["search_after"] = after
+		}
+		var result searchResult
+		res, err := e.Client.R().SetContext(ctx).SetBody(query).SetResult(&result).Post("/" + e.IndexName + "/_search")
+		if err = checkResp(res, err); err != nil {
+			return nil, err
+		}
+		for _, hit := range result.Hits.Hits {
+			nodes = append(nodes, hit.Source.SearchNode)
+		}
+		if len(result.Hits.Hits) < size {
+			return nodes, nil
+		}
+		after = result.Hits.Hits[len(result.Hits.Hits)-1].Sort
+	}
+}
+
+// Del removes the node at prefix and the nodes under it
+func (e *Elasticsearch) Del(ctx context.Context, prefix string) error {
+	prefix = utils.FixAndCleanPath(prefix)
+	query := map[string]any{"match_all": map[string]any{}}
+	if f := inParent(prefix); f != nil {
+		query = map[string]any{"bool": map[string]any{
+			"should": []any{
+				map[string]any{"term": map[string]any{"path": prefix}},
+				f,
+			},
+			"minimum_should_match": 1,
+		}}
+	}
+	return e.deleteByQuery(ctx, query)
+}
+
+func (e *Elasticsearch) Release(ctx context.Context) error {
+	return nil
+}
+
+func (e *Elasticsearch) Clear(ctx context.Context) error {
+	return e.deleteByQuery(ctx, map[string]any{"match_all": map[string]any{}})
+}
+
+func (e *Elasticsearch) deleteByQuery(ctx context.Context, query map[string]any) error {
+	res, err := e.Client.R().SetContext(ctx).
+		SetQueryParams(map[string]string{"conflicts": "proceed", "refresh": "true"}).
+		SetBody(map[string]any{"query": query}).
+		Post("/" + e.IndexName + "/_delete_by_query")
+	return checkResp(res, err)
+}
+
+// sortQuery returns the sort of req and the search_after of its cursor, nil
+// to sort by relevance
+func sortQuery(req model.SearchReq) ([]any, []any) {
+	dir := "asc"
+	if req.Desc() {
+		dir = "desc"
+	}
+	var (
+		field string
+		value any
+	)
+	after := req.After
+	if after == nil {
+		after = &model.SearchCursor{}
+	}
+	switch req.OrderBy {
+	case model.SearchOrderName:
+		field, value = "name.keyword", after.Name
+	case model.SearchOrderSize:
+		field, value = "size", after.Size
+	case model.SearchOrderModified:
+		// the sort values of the dates are in milliseconds
+		field, value = "modified", after.Modified.UnixMilli()
+	default:
+		return nil, nil
+	}
+	sort := []any{
+		map[string]any{field: dir},
+		map[string]any{"path": dir},
+	}
+	return sort, []any{value, path.Join(after.Parent, after.Name)}
+}
+
+// filterQueries translates the filters of req but the scope
+func filterQueries(req model.SearchReq) []any {
+	var filters []any
+	if req.FilesOnly() {
+		filters = append(filters, map[string]any{"term": map[string]any{"is_dir": false}})
+	}
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		r := map[string]any{}
+		if req.MinSize > 0 {
+			r["gte"] = req.MinSize
+		}
+		if req.MaxSize > 0 {
+			r["lte"] = req.MaxSize
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"size": r}})
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		r := map[string]any{}
+		if req.ModifiedAfter != nil {
+			r["gte"] = req.ModifiedAfter.Format(time.RFC3339)
+		}
+		if req.ModifiedBefore != nil {
+			r["lte"] = req.ModifiedBefore.Format(time.RFC3339)
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"modified": r}})
+	}
+	if len(req.Exts) > 0 {
+		var exts []any
+		for _, ext := range req.Exts {
+			exts = append(exts, map[string]any{"wildcard": map[string]any{"name.keyword": map[string]any{
+				"value":            "*." + escapeWildcard(ext),
+				"case_insensitive": true,
+			}}})
+		}
+		filters = append(filters, map[string]any{"bool": map[string]any{
+			"should":               exts,
+			"minimum_should_match": 1,
+		}})
+	}
+	return filters
+}
+
+// restrictionQueries translates the restriction into the filters and the
+// queries of the hidden nodes, the hide rules which aren't globs are left
+func restrictionQueries(r *model.SearchRestriction) ([]any, []any) {
+	filters, hidden := []any{}, []any{}
+	if r == nil {
+		return filters, hidden
+	}
+	if r.Roots != nil {
+		roots := []any{}
+		for _, root := range r.Roots {
+			roots = append(roots,
+				map[string]any{"term": map[string]any{"path": root}},
+				map[string]any{"prefix": map[string]any{"path": root + "/"}})
+		}
+		filters = append(filters, map[string]any{"bool": map[string]any{
+			"should":               roots,
+			"minimum_should_match": 1,
+		}})
+	}
+	for _, hide := range r.Hides {
+		if hide.Glob == "" {
+			continue
+		}
+		parts := strings.Split(hide.Glob, "*")
+		for i := range parts {
+			parts[i] = escapeWildcard(parts[i])
+		}
+		hideFilters := []any{map[string]any{"wildcard": map[string]any{"name.keyword": strings.Join(parts, "*")}}}
+		if !hide.Sub {
+			hideFilters = append(hideFilters, map[string]any{"term": map[string]any{"parent": hide.Dir}})
+		} else if f := inParent(hide.Dir); f != nil {
+			hideFilters = append(hideFilters, f)
+		}
+		excepts := []any{}
+		for _, except := range hide.Except {
+			excepts = append(excepts, inParent(except))
+		}
+		hidden = append(hidden, map[string]any{"bool": map[string]any{
+			"filter":   hideFilters,
+			"must_not": excepts,
+		}})
+	}
+	return filters, hidden
+}
+
+// inParent filters the nodes under parent, nil for the root
+func inParent(parent string) map[string]any {
+	if parent == "" || parent == "/" {
+		return nil
+	}
+	return map[string]any{"bool": map[string]any{
+		"should": []any{
+			map[string]any{"term": map[string]any{"parent": parent}},
+			map[string]any{"prefix": map[string]any{"parent": parent + "/"}},
+		},
+		"minimum_should_match": 1,
+	}}
+}
+
+func docID(p string) string {
+	sum := sha1.Sum([]byte(p))
+	return hex.EncodeToString(sum[:])
+}
+
+var wildcardReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+func escapeWildcard(s string) string {
+	return wildcardReplacer.Replace(s)
+}
+
+func checkResp(res *resty.Response, err error) error {
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !res.IsError() {
+		return nil
+	}
+	var e struct {
+		Error esError `json:"error"`
+	}
+	if utils.Json.Unmarshal(res.Body(), &e) == nil && e.Error.Type != "" {
+		return errors.Errorf("elasticsearch: %s: %s", e.Error.Type, e.Error.Reason)
+	}
+	return errors.Errorf("elasticsearch: %s", res.Status())
+}
+
+var _ searcher.Searcher = (*Elasticsearch)(nil)
@@ -16,6 +16,11 @@ import (
 type searchDocument struct {
 	ID string `json:"id"`
 	model.SearchNode
+	// for the filters, as only numbers can be compared
+	ModifiedUnix int64  `json:"modified_unix"`
+	Ext          string `json:"ext"`
+	// Ancestors are the parent and the dirs above it, to filter by path
+	Ancestors []string `json:"ancestors"`
 }
 
 type Meilisearch struct {
@@ -23,6 +28,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 }
 
 func (m *Meilisearch) Config() searcher.Config {
@@ -32,11 +38,53 @@ func (m *Meilisearch) Config() searcher.Config {
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	mReq := &meilisearch.SearchRequest{
 		AttributesToSearchOn: m.SearchableAttributes,
-		Page:                 int64(req.Page),
-		HitsPerPage:          int64(req.PerPage),
+		AttributesToRetrieve: []string{"parent", "name", "is_dir", "size", "modified"},
+		Offset:               int64(req.Offset()),
+		Limit:                int64(req.PerPage),
 	}
+	var filters []string
 	if req.Scope != 0 {
-		mReq.Filter = fmt.Sprintf("is_dir = %v", req.Scope == 1)
+		filters = append(filters, fmt.Sprintf("is_dir = %v", req.Scope == 1))
+	}
+	if req.FilesOnly() {
+		filters = append(filters, "is_dir = false")
+	}
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Exts) > 0 {
+		exts := utils.MustSliceConvert(req.Exts, func(ext string) string {
+			return "'" + strings.ReplaceAll(ext, "'", "\\'") + "'"
+		})
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ",")))
+	}
+	if req.Parent != "" && req.Parent != "/" {
+		filters = append(filters, inAncestors(req.Parent))
+	}
+	filters = append(filters, restrictionFilters(req.Restrict)...)
+	if len(filters) > 0 {
+		mReq.Filter = strings.Join(filters, " AND ")
+	}
+	dir := "asc"
+	if req.Desc() {
+		dir = "desc"
+	}
+	switch req.OrderBy {
+	case model.SearchOrderName:
+		mReq.Sort = []string{"name:" + dir, "parent:" + dir}
+	case model.SearchOrderSize:
+		mReq.Sort = []string{"size:" + dir, "parent:" + dir, "name:" + dir}
+	case model.SearchOrderModified:
+		mReq.Sort = []string{"modified_unix:" + dir, "parent:" + dir, "name:" + dir}
 	}
 	search, err := m.Client.Index(m.IndexUid).Search(req.Keywords, mReq)
 	if err != nil {
@@ -44,17 +92,78 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
 		srcMap := src.(map[string]any)
+		var modified time.Time
+		if v, ok := srcMap["modified"].(string); ok {
+			modified, _ = time.Parse(time.RFC3339, v)
+		}
 		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
+			Parent:   srcMap["parent"].(string),
+			Name:     srcMap["name"].(string),
+			IsDir:    srcMap["is_dir"].(bool),
+			Size:     int64(srcMap["size"].(float64)),
+			Modified: modified,
 		}, nil
 	})
 	if err != nil {
 		return nil, 0, err
 	}
-	return nodes, search.TotalHits, nil
+	return nodes, search.EstimatedTotalHits, nil
+}
+
+// restrictionFilters translates the restriction into filters, only the hide
+// rules of whole names are, the others are left
+func restrictionFilters(r *model.SearchRestriction) []string {
+	if r == nil {
+		return nil
+	}
+	var filters []string
+	if r.Roots != nil && len(r.Roots) == 0 {
+		// nothing can be seen
+		return []string{"is_dir = true AND is_dir = false"}
+	}
+	if r.Roots != nil {
+		var roots []string
+		for _, root := range r.Roots {
+			roots = append(roots, inAncestors(root), fmt.Sprintf("(parent = %s AND name = %s)",
+				quote(path.Dir(root)), quote(path.Base(root))))
+		}
+		filters = append(filters, "("+strings.Join(roots, " OR ")+")")
+	}
+	for _, hide := range r.Hides {
+		if hide.Glob == "" || strings.Contains(hide.Glob, "*") {
+			continue
+		}
+		conds := []string{"name = " + quote(hide.Glob)}
+		if hide.Sub {
+			conds = append(conds, "ancestors = "+quote(hide.Dir))
+		} else {
+			conds = append(conds, "parent = "+quote(hide.Dir))
+		}
+		for _, except := range hide.Except {
+			conds = append(conds, "NOT ancestors = "+quote(except))
+		}
+		filters = append(filters, "NOT ("+strings.Join(conds, " AND ")+")")
+	}
+	return filters
+}
+
+// inAncestors filters the nodes under dir, the documents indexed before the
+// ancestors are kept, to be checked by the caller
+func inAncestors(dir string) string {
+	return fmt.Sprintf("(ancestors = %s OR ancestors NOT EXISTS)", quote(dir))
+}
+
+func ancestors(parent string) []string {
+	dirs := []string{parent}
+	for parent != "/" && parent != "." {
+		parent = path.Dir(parent)
+		dirs = append(dirs, parent)
+	}
+	return dirs
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "\\'") + "'"
 }
 
 func (m *Meilisearch) Index(ctx context.Context, node model.SearchNode) error {
@@ -64,10 +173,16 @@ func (m *Meilisearch) Index(ctx context.Context, node model.SearchNode) error {
 func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
 	documents, _ := utils.SliceConvert(nodes, func(src model.SearchNode) (*searchDocument, error) {
 
-		return &searchDocument{
+		doc := &searchDocument{
 			ID:         uuid.NewString(),
 			SearchNode: src,
-		}, nil
+			Ext:        strings.ToLower(strings.TrimPrefix(path.Ext(src.Name), ".")),
+			Ancestors:  ancestors(src.Parent),
+		}
+		if !src.Modified.IsZero() {
+			doc.ModifiedUnix = src.Modified.Unix()
+		}
+		return doc, nil
 	})
 
 	_, err := m.Client.Index(m.IndexUid).AddDocuments(documents)
@@ -56,17 +56,18 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 				return nil
 			}
 		}
-		// scope: 0 for all, 1 for dir, 2 for file
-		if (req.Scope == 1 && !info.IsDir()) || (req.Scope == 2 && info.IsDir()) {
-			return nil
+		if info.IsDir() && !req.Restrict.Reachable(reqPath) {
+			return filepath.SkipDir
+		}
+		node := model.SearchNode{
+			Parent:   path.Dir(reqPath),
+			Name:     info.GetName(),
+			IsDir:    info.IsDir(),
+			Size:     info.GetSize(),
+			Modified: info.ModTime(),
 		}
-		if matchKeywords(info.GetName(), keywords) {
-			nodes = append(nodes, model.SearchNode{
-				Parent: path.Dir(reqPath),
-				Name:   info.GetName(),
-				IsDir:  info.IsDir(),
-				Size:   info.GetSize(),
-			})
+		if req.Filter(&node) && req.Restrict.Allow(&node) && matchKeywords(info.GetName(), keywords) {
+			nodes = append(nodes, node)
 			if len(nodes) >= maxResults {
 				return errStop
 			}
@@ -79,14 +80,11 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 
 	// stable ordering so pagination is consistent across repeated calls
 	sort.Slice(nodes, func(i, j int) bool {
-		if nodes[i].Name != nodes[j].Name {
-			return nodes[i].Name < nodes[j].Name
-		}
-		return nodes[i].Parent < nodes[j].Parent
+		return less(req, &nodes[i], &nodes[j])
 	})
 
 	total := int64(len(nodes))
-	start := (req.Page - 1) * req.PerPage
+	start := req.Offset()
 	if start >= len(nodes) {
 		return []model.SearchNode{}, total, nil
 	}
@@ -97,6 +95,27 @@ func (NoIndex) Search(ctx context.Context, req model.SearchReq) ([]model.SearchN
 	return nodes[start:end], total, nil
 }
 
+// less orders the nodes by the order of req, then by their paths
+func less(req model.SearchReq, a, b *model.SearchNode) bool {
+	if req.Desc() {
+		a, b = b, a
+	}
+	switch req.OrderBy {
+	case model.SearchOrderSize:
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+	case model.SearchOrderModified:
+		if !a.Modified.Equal(b.Modified) {
+			return a.Modified.Before(b.Modified)
+		}
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Parent < b.Parent
+}
+
 // matchKeywords reports whether name contains every keyword (case-insensitive),
 // matching the AND semantics of the indexed searchers. Empty keywords match all.
 func matchKeywords(name string, keywords []string) bool {
@@ -56,10 +56,12 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 		return errs.SearchNotAvailable
 	}
 	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Content:  fileContent(ctx, parent, obj),
 	})
 }
 
@@ -78,10 +80,12 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	var searchNodes []model.SearchNode
 	for i := range objs {
 		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
+			Parent:   objs[i].Parent,
+			Name:     objs[i].GetName(),
+			IsDir:    objs[i].IsDir(),
+			Size:     objs[i].GetSize(),
+			Modified: objs[i].ModTime(),
+			Content:  fileContent(ctx, objs[i].Parent, objs[i].Obj),
 		})
 	}
 	return instance.BatchIndex(ctx, searchNodes)
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// how often a held back walk checks whether it may go on
+	throttlePollInterval = time.Second
+	// the latency of the user requests is forgotten once no request is seen for this long
+	latencyStaleAfter = time.Minute
+	// the weight of a new sample in the moving average of the latency
+	latencySmoothing = 0.2
+)
+
+type ThrottleState struct {
+	// Paused is set by an admin, the indexing is held until resumed
+	Paused bool `json:"paused"`
+	// PeakHours is set while the current time is within the configured peak hours
+	PeakHours bool `json:"peak_hours"`
+	// Latency is the moving average of the latency of the user requests in milliseconds
+	Latency int64 `json:"latency"`
+	// Backoff is set while the latency is over the configured threshold
+	Backoff      bool `json:"backoff"`
+	OpsPerSecond int  `json:"ops_per_second"`
+	// Held is set while a walk is waiting for one of the conditions above to clear
+	Held bool `json:"held"`
+}
+
+var throttle struct {
+	paused atomic.Bool
+	held   atomic.Bool
+
+	mu       sync.Mutex
+	limiter  *rate.Limiter
+	latency  float64
+	lastSeen time.Time
+}
+
+// ObserveLatency records the latency of a user request, the indexing backs off
+// while the average is over the threshold
+func ObserveLatency(d time.Duration) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	ms := float64(d.Milliseconds())
+	if time.Since(throttle.lastSeen) > latencyStaleAfter {
+		throttle.latency = ms
+	} else {
+		throttle.latency += (ms - throttle.latency) * latencySmoothing
+	}
+	throttle.lastSeen = time.Now()
+}
+
+func PauseIndex() {
+	throttle.paused.Store(true)
+}
+
+func ResumeIndex() {
+	throttle.paused.Store(false)
+}
+
+func GetThrottleState() ThrottleState {
+	s := ThrottleState{
+		Paused:       throttle.paused.Load(),
+		PeakHours:    inPeakHours(time.Now()),
+		Latency:      currentLatency(),
+		OpsPerSecond: setting.GetInt(conf.IndexOpsPerSecond, 0),
+		Held:         throttle.held.Load(),
+	}
+	if threshold := setting.GetInt(conf.IndexLatencyThreshold, 0); threshold > 0 {
+		s.Backoff = s.Latency > int64(threshold)
+	}
+	return s
+}
+
+func currentLatency() int64 {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	if time.Since(throttle.lastSeen) > latencyStaleAfter {
+		return 0
+	}
+	return int64(throttle.latency)
+}
+
+// waitThrottle blocks the walk while the indexing is paused, in the peak hours
+// or backing off, then waits for the rate limit. It returns false if the walk
+// was stopped meanwhile.
+func waitThrottle(ctx context.Context, running *atomic.Bool) bool {
+	for {
+		if !running.Load() || ctx.Err() != nil {
+			return false
+		}
+		s := GetThrottleState()
+		if !s.Paused && !s.PeakHours && !s.Backoff {
+			throttle.held.Store(false)
+			return waitRate(ctx, s.OpsPerSecond)
+		}
+		throttle.held.Store(true)
+		select {
+		case <-ctx.Done():
+			throttle.held.Store(false)
+			return false
+		case <-time.After(throttlePollInterval):
+		}
+	}
+}
+
+func waitRate(ctx context.Context, opsPerSecond int) bool {
+	if opsPerSecond <= 0 {
+		return true
+	}
+	throttle.mu.Lock()
+	if throttle.limiter == nil || throttle.limiter.Limit() != rate.Limit(opsPerSecond) {
+		throttle.limiter = rate.NewLimiter(rate.Limit(opsPerSecond), 1)
+	}
+	limiter := throttle.limiter
+	throttle.mu.Unlock()
+	return limiter.Wait(ctx) == nil
+}
+
+// inPeakHours reports whether t is within the peak hours, given as start-end
+// hours in the local time, such as 9-18 or 22-6 wrapping over midnight
+func inPeakHours(t time.Time) bool {
+	start, end, ok := parsePeakHours(setting.GetStr(conf.IndexPeakHours))
+	if !ok {
+		return false
+	}
+	h := t.Hour()
+	if start <= end {
+		return h >= start && h < end
+	}
+	return h >= start || h < end
+}
+
+func parsePeakHours(s string) (int, int, bool) {
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(startStr))
+	if err != nil || start < 0 || start > 23 {
+		return 0, 0, false
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endStr))
+	if err != nil || end < 0 || end > 24 || start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
@@ -0,0 +1,28 @@
+package sign
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+)
+
+var onceUpload sync.Once
+var instanceUpload sign.Sign
+
+// the upload links always expire, so there is no SignUpload/NotExpiredUpload
+func WithDurationUpload(data string, d time.Duration) string {
+	onceUpload.Do(InstanceUpload)
+	return instanceUpload.Sign(data, time.Now().Add(d).Unix())
+}
+
+func VerifyUpload(data string, sign string) error {
+	onceUpload.Do(InstanceUpload)
+	return instanceUpload.Verify(data, sign)
+}
+
+func InstanceUpload() {
+	instanceUpload = sign.NewHMACSign([]byte(setting.GetStr(conf.Token) + "-upload"))
+}
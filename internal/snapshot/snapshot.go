@@ -0,0 +1,238 @@
+package snapshot
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Take walks path and records its structure and the known hashes of its files
+func Take(ctx context.Context, path string, auto bool) (*model.Snapshot, error) {
+	path = utils.FixAndCleanPath(path)
+	root, err := fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	if !root.IsDir() {
+		return nil, errors.Errorf("[%s] is not a folder", path)
+	}
+	snapshot := &model.Snapshot{Path: path, Auto: auto}
+	var entries []model.SnapshotEntry
+	err = fs.WalkFS(ctx, -1, path, root, func(reqPath string, obj model.Obj) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if reqPath == path {
+			return nil
+		}
+		entry := model.SnapshotEntry{
+			Path:     strings.TrimPrefix(reqPath, strings.TrimSuffix(path, "/")+"/"),
+			IsDir:    obj.IsDir(),
+			Modified: obj.ModTime(),
+		}
+		if obj.IsDir() {
+			snapshot.DirCount++
+		} else {
+			snapshot.FileCount++
+			snapshot.TotalSize += obj.GetSize()
+			entry.Size = obj.GetSize()
+			if len(obj.GetHash().Export()) > 0 {
+				entry.HashInfo = obj.GetHash().String()
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Entries, err = utils.Json.MarshalToString(entries)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.CreateSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	if auto {
+		if err = db.DeleteAutoSnapshotsExceptLatest(path, setting.GetInt(conf.SnapshotRetention, 7)); err != nil {
+			log.Warnf("failed prune snapshots of %s: %+v", path, err)
+		}
+	}
+	return snapshot, nil
+}
+
+func GetEntries(snapshot *model.Snapshot) ([]model.SnapshotEntry, error) {
+	var entries []model.SnapshotEntry
+	if err := utils.Json.UnmarshalFromString(snapshot.Entries, &entries); err != nil {
+		return nil, errors.WithMessage(err, "invalid snapshot entries")
+	}
+	return entries, nil
+}
+
+// Restore re-creates the entries of the snapshot missing from its directory.
+// Missing entries are moved back from the trash if they were trashed as they
+// are in the snapshot, otherwise missing files are copied from the same
+// relative path under backupDir if the backup still matches the snapshot,
+// copies between storages run as tasks.
+func Restore(ctx context.Context, snapshot *model.Snapshot, backupDir string) (*model.SnapshotRestoreResult, error) {
+	entries, err := GetEntries(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.SnapshotRestoreResult{}
+	fail := func(path string, err error) {
+		res.Failed = append(res.Failed, model.SnapshotRestoreFailure{Path: path, Error: err.Error()})
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		dstPath, err := utils.JoinUnderBase(snapshot.Path, entry.Path)
+		if err != nil {
+			fail(entry.Path, err)
+			continue
+		}
+		if _, err := fs.Get(ctx, dstPath, &fs.GetArgs{NoLog: true}); err == nil {
+			continue
+		}
+		if found, err := fromTrash(ctx, entry, dstPath); found {
+			if err != nil {
+				fail(entry.Path, err)
+			} else {
+				res.Restored = append(res.Restored, entry.Path)
+			}
+			continue
+		}
+		if entry.IsDir {
+			if err := fs.MakeDir(ctx, dstPath); err != nil {
+				fail(entry.Path, err)
+			} else {
+				res.Restored = append(res.Restored, entry.Path)
+			}
+			continue
+		}
+		if backupDir == "" {
+			fail(entry.Path, errors.New("no backup source"))
+			continue
+		}
+		srcPath, err := utils.JoinUnderBase(backupDir, entry.Path)
+		if err != nil {
+			fail(entry.Path, err)
+			continue
+		}
+		src, err := fs.Get(ctx, srcPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			fail(entry.Path, errors.New("not found in backup source"))
+			continue
+		}
+		if !matches(entry, src) {
+			fail(entry.Path, errors.New("backup differs from snapshot"))
+			continue
+		}
+		if err := fs.MakeDir(ctx, stdpath.Dir(dstPath)); err != nil {
+			fail(entry.Path, err)
+			continue
+		}
+		t, err := fs.Copy(ctx, srcPath, stdpath.Dir(dstPath))
+		if err != nil {
+			fail(entry.Path, err)
+			continue
+		}
+		if t != nil {
+			res.Tasks = append(res.Tasks, t.GetID())
+		} else {
+			res.Restored = append(res.Restored, entry.Path)
+		}
+	}
+	return res, nil
+}
+
+// fromTrash moves the entry back from the trash if it was last trashed as it is
+// in the snapshot, a trashed dir comes back with its content. It reports
+// whether the entry was found there.
+func fromTrash(ctx context.Context, entry model.SnapshotEntry, dstPath string) (bool, error) {
+	item, err := db.GetTrashItemByOriginalPath(dstPath)
+	if err != nil || item.IsDir != entry.IsDir {
+		return false, nil
+	}
+	if !entry.IsDir {
+		obj, err := fs.Get(ctx, stdpath.Join(item.TrashPath, item.Name), &fs.GetArgs{NoLog: true})
+		if err != nil || !matches(entry, obj) {
+			return false, nil
+		}
+	}
+	return true, fs.RestoreTrash(ctx, item)
+}
+
+// matches compares the size and the hashes known by both sides
+func matches(entry model.SnapshotEntry, obj model.Obj) bool {
+	if obj.IsDir() || obj.GetSize() != entry.Size {
+		return false
+	}
+	if entry.HashInfo == "" {
+		return true
+	}
+	recorded := utils.FromString(entry.HashInfo)
+	for ht, v := range obj.GetHash().All() {
+		if r := recorded.GetHash(ht); r != "" && r != v {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	lastRun   = map[string]time.Time{}
+	lastRunMu sync.Mutex
+	startOnce sync.Once
+)
+
+// Init starts taking the automatic snapshots configured in the settings
+func Init() {
+	startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				runScheduled()
+			}
+		}()
+	})
+}
+
+func runScheduled() {
+	interval := setting.GetInt(conf.SnapshotInterval, 0)
+	if interval <= 0 {
+		return
+	}
+	for _, path := range strings.Split(setting.GetStr(conf.SnapshotPaths), "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		path = utils.FixAndCleanPath(path)
+		lastRunMu.Lock()
+		due := time.Since(lastRun[path]) >= time.Duration(interval)*time.Minute
+		if due {
+			lastRun[path] = time.Now()
+		}
+		lastRunMu.Unlock()
+		if !due {
+			continue
+		}
+		if _, err := Take(context.Background(), path, true); err != nil {
+			log.Errorf("failed take snapshot of %s: %+v", path, err)
+		}
+	}
+}
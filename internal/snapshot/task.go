@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/xhofe/tache"
+)
+
+// SnapshotTask takes a snapshot of Path, or restores the snapshot SnapshotID
+// when it is set, as the trees walked may be large
+type SnapshotTask struct {
+	task.TaskExtension
+	Status     string `json:"-"`
+	Path       string `json:"path"`
+	SnapshotID uint   `json:"snapshot_id"`
+	BackupDir  string `json:"backup_dir"`
+	// Result is the restore result, the snapshot taken is Snapshot
+	Result   *model.SnapshotRestoreResult `json:"result,omitempty"`
+	Snapshot *model.Snapshot              `json:"snapshot,omitempty"`
+}
+
+var TaskManager *tache.Manager[*SnapshotTask]
+
+var _ task.TaskExtensionInfo = (*SnapshotTask)(nil)
+
+func (t *SnapshotTask) GetName() string {
+	if t.SnapshotID != 0 {
+		return fmt.Sprintf("restore snapshot %d of [%s]", t.SnapshotID, t.Path)
+	}
+	return fmt.Sprintf("take snapshot of [%s]", t.Path)
+}
+
+func (t *SnapshotTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SnapshotTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	if t.SnapshotID == 0 {
+		t.Status = "walking"
+		s, err := Take(t.Ctx(), t.Path, false)
+		if err != nil {
+			return err
+		}
+		// the entries are got by the snapshot api
+		s.Entries = ""
+		t.Snapshot = s
+		t.Status = fmt.Sprintf("done, %d dirs and %d files", s.DirCount, s.FileCount)
+		return nil
+	}
+	s, err := db.GetSnapshotById(t.SnapshotID)
+	if err != nil {
+		return err
+	}
+	t.Status = "restoring"
+	res, err := Restore(t.Ctx(), s, t.BackupDir)
+	t.Result = res
+	if err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("done, %d restored, %d copying, %d failed", len(res.Restored), len(res.Tasks), len(res.Failed))
+	return nil
+}
+
+// TakeAsTask adds a task taking a snapshot of path
+func TakeAsTask(creator *model.User, path string) *SnapshotTask {
+	t := &SnapshotTask{
+		TaskExtension: task.TaskExtension{Creator: creator},
+		Status:        "queued",
+		Path:          path,
+	}
+	TaskManager.Add(t)
+	return t
+}
+
+// RestoreAsTask adds a task restoring the snapshot
+func RestoreAsTask(creator *model.User, snapshot *model.Snapshot, backupDir string) *SnapshotTask {
+	t := &SnapshotTask{
+		TaskExtension: task.TaskExtension{Creator: creator},
+		Status:        "queued",
+		Path:          snapshot.Path,
+		SnapshotID:    snapshot.ID,
+		BackupDir:     backupDir,
+	}
+	TaskManager.Add(t)
+	return t
+}
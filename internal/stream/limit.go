@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"golang.org/x/time/rate"
 	"io"
+	"sync"
 	"time"
 )
 
@@ -34,6 +35,52 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+// BlockBurstLimiter waits for more tokens than its burst in several times
+type BlockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l BlockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
+type userLimiter struct {
+	speed   int
+	limiter Limiter
+}
+
+var (
+	userDownloadLimitsMu sync.Mutex
+	userDownloadLimits   = map[uint]userLimiter{}
+)
+
+// UserDownloadLimit returns the limiter shared by the downloads of a user at
+// speed KB/s, it's renewed when the speed changes
+func UserDownloadLimit(userID uint, speed int) Limiter {
+	userDownloadLimitsMu.Lock()
+	defer userDownloadLimitsMu.Unlock()
+	if l, ok := userDownloadLimits[userID]; ok && l.speed == speed {
+		return l.limiter
+	}
+	l := userLimiter{
+		speed:   speed,
+		limiter: BlockBurstLimiter{Limiter: rate.NewLimiter(rate.Limit(speed)*1024.0, speed*1024)},
+	}
+	userDownloadLimits[userID] = l
+	return l.limiter
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+// the limits of the read-ahead of a storage, a range holds at most Depth+1
+// chunks, allocated as the prefetch gets ahead of the client
+const (
+	MaxReadAheadSizeKB = 4 * 1024
+	MaxReadAheadDepth  = 8
+)
+
+// ReadAheadRangeReadCloser prefetches up to Depth chunks of ChunkSize bytes of
+// each requested range while the previous ones are being consumed
+type ReadAheadRangeReadCloser struct {
+	model.RangeReadCloserIF
+	ChunkSize int
+	Depth     int
+}
+
+func (rrc *ReadAheadRangeReadCloser) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	rc, err := rrc.RangeReadCloserIF.RangeRead(ctx, httpRange)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r := &readAheadReader{
+		rc:        rc,
+		ctx:       ctx,
+		cancel:    cancel,
+		chunkSize: rrc.ChunkSize,
+		maxBufs:   rrc.Depth + 1,
+		chunks:    make(chan readAheadChunk, rrc.Depth),
+		free:      make(chan []byte, rrc.Depth+1),
+		done:      make(chan struct{}),
+	}
+	go r.prefetch()
+	return r, nil
+}
+
+type readAheadChunk struct {
+	buf []byte
+	err error
+}
+
+type readAheadReader struct {
+	rc     io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+	// bufs are the buffers allocated by the prefetch, at most maxBufs
+	chunkSize int
+	maxBufs   int
+	bufs      int
+	// chunks are the prefetched chunks, free are the buffers to prefetch into
+	chunks chan readAheadChunk
+	free   chan []byte
+	done   chan struct{}
+	cur    []byte
+	curBuf []byte
+	err    error
+	once   sync.Once
+}
+
+// nextBuf returns a consumed buffer, or a new one while under maxBufs
+func (r *readAheadReader) nextBuf() ([]byte, bool) {
+	select {
+	case buf := <-r.free:
+		return buf, true
+	default:
+	}
+	if r.bufs < r.maxBufs {
+		r.bufs++
+		return make([]byte, r.chunkSize), true
+	}
+	select {
+	case buf := <-r.free:
+		return buf, true
+	case <-r.ctx.Done():
+		return nil, false
+	}
+}
+
+func (r *readAheadReader) prefetch() {
+	defer close(r.done)
+	defer close(r.chunks)
+	for {
+		buf, ok := r.nextBuf()
+		if !ok {
+			return
+		}
+		n, err := io.ReadFull(r.rc, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		select {
+		case r.chunks <- readAheadChunk{buf: buf[:n], err: err}:
+		case <-r.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *readAheadReader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.curBuf != nil {
+			r.free <- r.curBuf[:cap(r.curBuf)]
+			r.curBuf = nil
+		}
+		select {
+		case c, ok := <-r.chunks:
+			if !ok {
+				// the prefetch was aborted by the context
+				r.err = r.ctx.Err()
+				if r.err == nil {
+					r.err = io.ErrUnexpectedEOF
+				}
+				continue
+			}
+			r.cur, r.curBuf, r.err = c.buf, c.buf, c.err
+		case <-r.ctx.Done():
+			r.err = r.ctx.Err()
+		}
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+// Close aborts the prefetch, closing the source unblocks a pending read of it
+func (r *readAheadReader) Close() error {
+	var err error
+	r.once.Do(func() {
+		r.cancel()
+		err = r.rc.Close()
+		<-r.done
+	})
+	return err
+}
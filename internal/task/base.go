@@ -14,9 +14,11 @@ type TaskExtension struct {
 	ctx          context.Context
 	ctxInitMutex sync.Mutex
 	Creator      *model.User
-	startTime    *time.Time
-	endTime      *time.Time
-	totalBytes   int64
+	// Priority orders the tasks waiting for a slot of a PriorityPool
+	Priority   int `json:"priority,omitempty"`
+	startTime  *time.Time
+	endTime    *time.Time
+	totalBytes int64
 }
 
 func (t *TaskExtension) SetCreator(creator *model.User) {
@@ -28,6 +30,10 @@ func (t *TaskExtension) GetCreator() *model.User {
 	return t.Creator
 }
 
+func (t *TaskExtension) GetPriority() int {
+	return t.Priority
+}
+
 func (t *TaskExtension) SetStartTime(startTime time.Time) {
 	t.startTime = &startTime
 }
@@ -60,13 +66,23 @@ func (t *TaskExtension) Ctx() context.Context {
 	if t.ctx == nil {
 		t.ctxInitMutex.Lock()
 		if t.ctx == nil {
-			t.ctx = context.WithValue(t.Base.Ctx(), "user", t.Creator)
+			ctx := context.WithValue(t.Base.Ctx(), "user", t.Creator)
+			t.ctx = context.WithValue(ctx, conf.TaskPriorityKey, t.Priority)
 		}
 		t.ctxInitMutex.Unlock()
 	}
 	return t.ctx
 }
 
+// WaitTurn takes a slot of pool by the priority of the task, pool may be nil
+// for the managers which aren't gated
+func (t *TaskExtension) WaitTurn(pool *PriorityPool) (func(), error) {
+	if pool == nil {
+		return func() {}, nil
+	}
+	return pool.Acquire(t.Ctx(), t.Priority)
+}
+
 func (t *TaskExtension) ReinitCtx() {
 	if !conf.Conf.Tasks.AllowRetryCanceled {
 		return
@@ -84,6 +100,7 @@ func (t *TaskExtension) ReinitCtx() {
 type TaskExtensionInfo interface {
 	tache.TaskWithInfo
 	GetCreator() *model.User
+	GetPriority() int
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
@@ -0,0 +1,142 @@
+package task
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+)
+
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// FixPriority clamps the priority of a request to the known levels
+func FixPriority(priority int) int {
+	if priority < PriorityLow {
+		return PriorityLow
+	}
+	if priority > PriorityHigh {
+		return PriorityHigh
+	}
+	return priority
+}
+
+// PriorityOf returns the priority put in ctx by a request or by the task
+// which adds the new one, normal if none
+func PriorityOf(ctx context.Context) int {
+	if p, ok := ctx.Value(conf.TaskPriorityKey).(int); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// PoolWorkers is the number of tache workers of a manager gated by a
+// PriorityPool, the real number of running tasks is the size of the pool
+const PoolWorkers = 1024
+
+// PriorityPool hands its slots to the waiting tasks of the highest priority
+// first, in the order they came within a priority. The tache queue being
+// FIFO, the tasks take a tache worker at once and wait here for a slot.
+type PriorityPool struct {
+	mu      sync.Mutex
+	size    int
+	running int
+	waiting waiters
+	seq     uint64
+}
+
+func NewPriorityPool(size int) *PriorityPool {
+	return &PriorityPool{size: size}
+}
+
+// SetSize changes the number of slots, the running tasks over it keep their
+// slot until they end
+func (p *PriorityPool) SetSize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	p.grant()
+}
+
+// Acquire waits for a slot, the returned func gives it back
+func (p *PriorityPool) Acquire(ctx context.Context, priority int) (func(), error) {
+	p.mu.Lock()
+	w := &waiter{priority: priority, seq: p.seq, ready: make(chan struct{})}
+	p.seq++
+	heap.Push(&p.waiting, w)
+	p.grant()
+	p.mu.Unlock()
+	select {
+	case <-w.ready:
+		return p.release, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if w.index < 0 {
+			// granted meanwhile
+			p.running--
+			p.grant()
+		} else {
+			heap.Remove(&p.waiting, w.index)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (p *PriorityPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	p.grant()
+}
+
+func (p *PriorityPool) grant() {
+	for p.running < p.size && p.waiting.Len() > 0 {
+		w := heap.Pop(&p.waiting).(*waiter)
+		p.running++
+		close(w.ready)
+	}
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+type waiters []*waiter
+
+func (ws waiters) Len() int { return len(ws) }
+
+func (ws waiters) Less(i, j int) bool {
+	if ws[i].priority != ws[j].priority {
+		return ws[i].priority > ws[j].priority
+	}
+	return ws[i].seq < ws[j].seq
+}
+
+func (ws waiters) Swap(i, j int) {
+	ws[i], ws[j] = ws[j], ws[i]
+	ws[i].index = i
+	ws[j].index = j
+}
+
+func (ws *waiters) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*ws)
+	*ws = append(*ws, w)
+}
+
+func (ws *waiters) Pop() any {
+	old := *ws
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*ws = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,148 @@
+package task
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy is how the failed tasks of a type are retried, the policies are
+// set by type in the task_retry_policies setting, "*" for the other types
+type RetryPolicy struct {
+	// MaxRetry is the number of retries after the first attempt, the max_retry
+	// of the config if not set
+	MaxRetry *int `json:"max_retry"`
+	// Backoff is none, fixed, linear or exponential
+	Backoff string `json:"backoff"`
+	// Delay is the delay before the first retry in seconds
+	Delay int `json:"delay"`
+	// MaxDelay caps the delay in seconds, 0 for no cap
+	MaxDelay int `json:"max_delay"`
+	// RetryOn are the error classes retried, all of them if empty
+	RetryOn []string `json:"retry_on"`
+}
+
+// the classes of the errors of RetryPolicy.RetryOn
+const (
+	ErrClassTimeout   = "timeout"
+	ErrClassNetwork   = "network"
+	ErrClassRateLimit = "rate_limit"
+	ErrClassServer    = "server"
+	ErrClassOther     = "other"
+)
+
+func getRetryPolicy(typ string) *RetryPolicy {
+	var policies map[string]*RetryPolicy
+	if err := utils.Json.UnmarshalFromString(setting.GetStr(conf.TaskRetryPolicies, "{}"), &policies); err != nil {
+		log.Warnf("invalid task retry policies: %+v", err)
+		return nil
+	}
+	if p, ok := policies[typ]; ok {
+		return p
+	}
+	return policies["*"]
+}
+
+// ErrClass tells the class of err, as most providers return plain errors it
+// falls back on the message
+func ErrClass(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err):
+		return ErrClassTimeout
+	case errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE):
+		return ErrClassNetwork
+	}
+	msg := strings.ToLower(err.Error())
+	has := func(subs ...string) bool {
+		for _, sub := range subs {
+			if strings.Contains(msg, sub) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("timeout", "timed out"):
+		return ErrClassTimeout
+	case has("429", "too many requests", "rate limit"):
+		return ErrClassRateLimit
+	case has("connection reset", "connection refused", "broken pipe", "unexpected eof", "no such host"):
+		return ErrClassNetwork
+	case has("500", "502", "503", "504", "internal server error", "bad gateway", "service unavailable", "gateway timeout"):
+		return ErrClassServer
+	}
+	return ErrClassOther
+}
+
+// RetryableAs applies the retry policy of typ to the failed task, it's called
+// by the Retryable of the tasks before tache counts the retry
+func (t *TaskExtension) RetryableAs(typ string) bool {
+	p := getRetryPolicy(typ)
+	if p == nil {
+		return true
+	}
+	if p.MaxRetry != nil {
+		retry, _ := t.GetRetry()
+		t.SetRetry(retry, *p.MaxRetry)
+	}
+	if len(p.RetryOn) == 0 || t.GetErr() == nil {
+		return true
+	}
+	return utils.SliceContains(p.RetryOn, ErrClass(t.GetErr()))
+}
+
+// WaitBackoffAs waits the delay of the retry policy of typ, it's called by
+// the OnBeforeRetry of the tasks. The task keeps its tache worker meanwhile.
+func (t *TaskExtension) WaitBackoffAs(typ string) {
+	p := getRetryPolicy(typ)
+	if p == nil {
+		return
+	}
+	retry, _ := t.GetRetry()
+	delay := backoff(p, retry)
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-t.Base.Ctx().Done():
+	case <-timer.C:
+	}
+}
+
+// backoff returns the delay before the retry-th retry, a retry from the api
+// resets the count and runs at once
+func backoff(p *RetryPolicy, retry int) time.Duration {
+	if retry < 1 {
+		return 0
+	}
+	delay := time.Duration(p.Delay) * time.Second
+	switch p.Backoff {
+	case "fixed":
+	case "linear":
+		delay *= time.Duration(retry)
+	case "exponential":
+		for i := 1; i < retry && i < 30; i++ {
+			delay *= 2
+		}
+	default:
+		return 0
+	}
+	if p.MaxDelay > 0 && delay > time.Duration(p.MaxDelay)*time.Second {
+		delay = time.Duration(p.MaxDelay) * time.Second
+	}
+	return delay
+}
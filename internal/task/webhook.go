@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/notify"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	WebhookSucceeded = "succeeded"
+	WebhookFailed    = "failed"
+	WebhookCanceled  = "canceled"
+)
+
+type WebhookPayload struct {
+	// Event is succeeded, failed or canceled
+	Event string `json:"event"`
+	// Type is the manager of the task, such as copy or offline_download
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Creator    string     `json:"creator"`
+	SrcPath    string     `json:"src_path,omitempty"`
+	DstPath    string     `json:"dst_path"`
+	TotalBytes int64      `json:"total_bytes"`
+	StartTime  *time.Time `json:"start_time"`
+	EndTime    *time.Time `json:"end_time"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// FireWebhook posts the end of t to the task webhook in the background, and
+// notifies a failure through the notification channels. It's called by the
+// OnSucceeded and OnFailed hooks of the tasks. The paths are mount paths,
+// srcPath is the url of an offline download and empty for an upload.
+func FireWebhook(t TaskExtensionInfo, typ, srcPath, dstPath string) {
+	if err := t.GetErr(); err != nil && !errors.Is(err, context.Canceled) {
+		notify.Notify(notify.TaskFailed, fmt.Sprintf("The %s task failed", typ), fmt.Sprintf("%s\n%s", t.GetName(), err))
+	}
+	url := setting.GetStr(conf.TaskWebhookURL)
+	if url == "" {
+		return
+	}
+	event := WebhookSucceeded
+	if err := t.GetErr(); errors.Is(err, context.Canceled) {
+		event = WebhookCanceled
+	} else if err != nil {
+		event = WebhookFailed
+	}
+	events := strings.Split(setting.GetStr(conf.TaskWebhookEvents), ",")
+	if !utils.SliceContains(utils.MustSliceConvert(events, strings.TrimSpace), event) {
+		return
+	}
+	payload := WebhookPayload{
+		Event:      event,
+		Type:       typ,
+		ID:         t.GetID(),
+		Name:       t.GetName(),
+		SrcPath:    srcPath,
+		DstPath:    dstPath,
+		TotalBytes: t.GetTotalBytes(),
+		StartTime:  t.GetStartTime(),
+		EndTime:    t.GetEndTime(),
+	}
+	if creator := t.GetCreator(); creator != nil {
+		payload.Creator = creator.Username
+	}
+	if err := t.GetErr(); err != nil {
+		payload.Error = err.Error()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("failed marshal task webhook: %+v", err)
+		return
+	}
+	secret := setting.GetStr(conf.TaskWebhookSecret)
+	go postWebhook(url, secret, body)
+}
+
+func postWebhook(url, secret string, body []byte) {
+	req := base.RestyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.SetHeader("X-Alist-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := req.Post(url)
+	if err != nil {
+		log.Warnf("failed post task webhook to %s: %+v", url, err)
+		return
+	}
+	if res.IsError() {
+		log.Warnf("task webhook %s responded %s", url, res.Status())
+	}
+}
@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exporter sends the spans to an OTLP/HTTP endpoint in the JSON encoding
+type exporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newExporter returns an exporter of endpoint, the traces path of OTLP is
+// appended if endpoint has no path, as for OTEL_EXPORTER_OTLP_ENDPOINT
+func newExporter(endpoint string, headers map[string]string) *exporter {
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		u.Path = "/v1/traces"
+		endpoint = u.String()
+	}
+	return &exporter{
+		url:     endpoint,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	Name         string         `json:"name"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+func (e *exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	var scopes []*otlpScopeSpans
+	byScope := map[string]*otlpScopeSpans{}
+	for _, s := range spans {
+		scope := s.InstrumentationScope()
+		ss, ok := byScope[scope.Name]
+		if !ok {
+			ss = &otlpScopeSpans{}
+			ss.Scope.Name = scope.Name
+			ss.Scope.Version = scope.Version
+			byScope[scope.Name] = ss
+			scopes = append(scopes, ss)
+		}
+		ss.Spans = append(ss.Spans, toOTLPSpan(s))
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource":   map[string]any{"attributes": toOTLPAttrs(spans[0].Resource().Attributes())},
+			"scopeSpans": scopes,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export spans: %s", res.Status)
+	}
+	return nil
+}
+
+func (e *exporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func toOTLPSpan(s sdktrace.ReadOnlySpan) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.SpanContext().TraceID().String(),
+		SpanID:            s.SpanContext().SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:        toOTLPAttrs(s.Attributes()),
+	}
+	if s.Parent().HasSpanID() {
+		span.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, ev := range s.Events() {
+		span.Events = append(span.Events, otlpEvent{
+			Name:         ev.Name,
+			TimeUnixNano: strconv.FormatInt(ev.Time.UnixNano(), 10),
+			Attributes:   toOTLPAttrs(ev.Attributes),
+		})
+	}
+	// the codes of OTLP are unset 0, ok 1 and error 2
+	switch s.Status().Code {
+	case codes.Ok:
+		span.Status.Code = 1
+	case codes.Error:
+		span.Status.Code = 2
+		span.Status.Message = s.Status().Description
+	}
+	return span
+}
+
+func toOTLPAttrs(attrs []attribute.KeyValue) []otlpKeyValue {
+	res := make([]otlpKeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		var v map[string]any
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			v = map[string]any{"boolValue": kv.Value.AsBool()}
+		case attribute.INT64:
+			// the 64 bits integers are strings in the JSON of OTLP
+			v = map[string]any{"intValue": strconv.FormatInt(kv.Value.AsInt64(), 10)}
+		case attribute.FLOAT64:
+			v = map[string]any{"doubleValue": kv.Value.AsFloat64()}
+		default:
+			v = map[string]any{"stringValue": kv.Value.Emit()}
+		}
+		res = append(res, otlpKeyValue{Key: string(kv.Key), Value: v})
+	}
+	return res
+}
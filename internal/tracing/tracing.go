@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanKey is the key of the span of a request in the gin context, as the gin
+// context doesn't look up the values of the request context
+const SpanKey = "otel_span"
+
+const scopeName = "github.com/alist-org/alist/v3"
+
+var provider *sdktrace.TracerProvider
+
+// Init sets up the exporting of the spans to the OTLP endpoint, the spans are
+// dropped by the default noop provider if it's not enabled
+func Init(c conf.Tracing) {
+	if !c.Enable || c.Endpoint == "" {
+		return
+	}
+	ratio := c.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	name := c.ServiceName
+	if name == "" {
+		name = "alist"
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newExporter(c.Endpoint, c.Headers)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", name),
+			attribute.String("service.version", conf.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Shutdown flushes the spans not exported yet
+func Shutdown() {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = provider.Shutdown(ctx)
+}
+
+// Enabled reports whether the spans are exported
+func Enabled() bool {
+	return provider != nil
+}
+
+// Start starts a span as a child of the span in ctx, which may be the one of
+// the request kept in the gin context under SpanKey
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if span, ok := ctx.Value(SpanKey).(trace.Span); ok {
+			ctx = trace.ContextWithSpan(ctx, span)
+		}
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Tracer returns the tracer of alist
+func Tracer() trace.Tracer {
+	return otel.Tracer(scopeName)
+}
+
+// End ends span, marking it failed by err if any
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -14,3 +14,46 @@ func TestCron(t *testing.T) {
 	c.Stop()
 	c.Stop()
 }
+
+func TestScheduleMatch(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		expr string
+		time string
+		want bool
+	}{
+		{"* * * * *", "2024-05-01 13:37", true},
+		{"30 2 * * *", "2024-05-01 02:30", true},
+		{"30 2 * * *", "2024-05-01 02:31", false},
+		{"*/15 * * * *", "2024-05-01 10:45", true},
+		{"*/15 * * * *", "2024-05-01 10:50", false},
+		{"0 9-17/4 * * *", "2024-05-01 13:00", true},
+		{"0 9-17/4 * * *", "2024-05-01 15:00", false},
+		{"0 0 * * 0", "2024-05-05 00:00", true},
+		{"0 0 * * 7", "2024-05-05 00:00", true},
+		{"0 0 1,15 * *", "2024-05-15 00:00", true},
+		// both days restricted, either matches
+		{"0 0 1 * 1", "2024-05-06 00:00", true},
+		{"0 0 1 * 1", "2024-05-07 00:00", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.expr, err)
+		}
+		if got := s.Match(at(tt.time)); got != tt.want {
+			t.Errorf("%q at %s = %v, want %v", tt.expr, tt.time, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("parse %q: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package doctext extracts the plain text of documents for indexing. The
+// extraction is best-effort: a pdf gives only the text its content streams
+// draw with literal strings, which misses the fonts with custom encodings.
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+var extractors = map[string]func(data []byte) (string, error){
+	".txt":  plainText,
+	".md":   plainText,
+	".docx": docxText,
+	".pdf":  pdfText,
+}
+
+// Supported reports whether the text of the file name can be extracted
+func Supported(name string) bool {
+	_, ok := extractors[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// Extract returns the text of the file name of content data, cut to maxLen
+// bytes if maxLen > 0
+func Extract(name string, data []byte, maxLen int) (string, error) {
+	extract, ok := extractors[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return "", errors.Errorf("unsupported document: %s", name)
+	}
+	text, err := extract(data)
+	if err != nil {
+		return "", err
+	}
+	return truncate(strings.TrimSpace(text), maxLen), nil
+}
+
+// truncate cuts s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func plainText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// docxText returns the text runs of the main part, a line per paragraph
+func docxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	var sb strings.Builder
+	inText := false
+	d := xml.NewDecoder(f)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(tok)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// pdfText returns the strings shown by the text operators of the content
+// streams, the streams which aren't flate encoded or plain are skipped
+func pdfText(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", errors.New("not a pdf")
+	}
+	var sb strings.Builder
+	for rest := data; ; {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		dict := rest[:start]
+		if i := bytes.LastIndex(dict, []byte("<<")); i >= 0 {
+			dict = dict[i:]
+		}
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+		content := body[:end]
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) {
+				continue
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			// a stream cut short still gives its beginning
+			content, _ = io.ReadAll(zr)
+			_ = zr.Close()
+		}
+		pdfContentText(content, &sb)
+	}
+	return sb.String(), nil
+}
+
+// pdfContentText writes the strings of the Tj, TJ, ' and " operators of a
+// content stream, a line per text object
+func pdfContentText(content []byte, sb *strings.Builder) {
+	var pending []string
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteral(content[i:])
+			pending = append(pending, s)
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			// a hex string, mostly glyph ids which can't be mapped without the font
+			for i < len(content) && content[i] != '>' {
+				i++
+			}
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '/':
+			// a name, skipped not to be taken for an operator
+			for i++; i < len(content) && !isPdfDelimiter(content[i]); i++ {
+			}
+		case isPdfDelimiter(c) || c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+			i++
+		default:
+			j := i
+			for j < len(content) && !isPdfDelimiter(content[j]) {
+				j++
+			}
+			switch string(content[i:j]) {
+			case "Tj", "TJ", "'", "\"":
+				sb.WriteString(strings.Join(pending, ""))
+			case "ET":
+				sb.WriteByte('\n')
+			}
+			pending = pending[:0]
+			i = j
+		}
+	}
+}
+
+func isPdfDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f()<>[]{}/%", c) >= 0
+}
+
+// pdfLiteral decodes the literal string at the start of b, and returns it
+// with the count of bytes it takes. The bytes above ascii are taken as latin-1.
+func pdfLiteral(b []byte) (string, int) {
+	var sb strings.Builder
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return sb.String(), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(b) {
+				return sb.String(), i
+			}
+			switch e := b[i]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// a line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for k := 0; k < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; k++ {
+						v = v*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					sb.WriteRune(rune(v & 0xff))
+				} else {
+					sb.WriteByte(e)
+				}
+			}
+			continue
+		}
+		if c < utf8.RuneSelf {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String(), len(b)
+}
@@ -0,0 +1,64 @@
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+)
+
+func TestExtractPlain(t *testing.T) {
+	text, err := Extract("a.MD", []byte("\xef\xbb\xbf# title\nhello wörld\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "# title\nhello wörld" {
+		t.Errorf("got %q", text)
+	}
+	// ö takes 2 bytes, it mustn't be split
+	if text, _ = Extract("a.txt", []byte("hello wörld"), 8); text != "hello w" {
+		t.Errorf("got %q", text)
+	}
+	if Supported("a.doc") {
+		t.Errorf("doc is not supported")
+	}
+}
+
+func TestExtractDocx(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<?xml version="1.0"?><w:document xmlns:w="w"><w:body>` +
+		`<w:p><w:r><w:t>first</w:t></w:r><w:r><w:tab/><w:t>line</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>second &amp; last</w:t></w:r></w:p></w:body></w:document>`))
+	_ = zw.Close()
+	text, err := Extract("a.docx", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "first\tline\nsecond & last" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestExtractPdf(t *testing.T) {
+	content := `BT /F1 12 Tf 72 712 Td (Hello \(pdf\)) Tj ET
+BT [(Wor) -20 (ld) ] TJ <0048> Tj (caf\351) Tj ET`
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	_, _ = zw.Write([]byte(content))
+	_ = zw.Close()
+	pdf := "%PDF-1.4\n1 0 obj << /Length 10 /Filter /FlateDecode >>\nstream\n" + z.String() +
+		"\nendstream\nendobj\n2 0 obj << /Filter /DCTDecode >>\nstream\n(skipped) Tj\nendstream\n%%EOF"
+	text, err := Extract("a.pdf", []byte(pdf), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello (pdf)\nWorldcafé" {
+		t.Errorf("got %q", text)
+	}
+	if _, err = Extract("b.pdf", []byte("not a pdf"), 0); err == nil || !strings.Contains(err.Error(), "not a pdf") {
+		t.Errorf("expected an error, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// chunkSize is the size of the content in a message of the put stream
+const chunkSize = 1024 * 1024
+
+// Client calls the Driver service of a plugin
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient connects to the plugin listening on addr, a unix socket as
+// unix:///path or a tcp address as host:port
+func NewClient(addr string) (*Client, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp any) error {
+	return c.conn.Invoke(ctx, fullMethod(method), req, resp)
+}
+
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var resp Info
+	err := c.invoke(ctx, "Info", &Empty{}, &resp)
+	return &resp, err
+}
+
+func (c *Client) Init(ctx context.Context, req InitReq) (*InitResp, error) {
+	var resp InitResp
+	err := c.invoke(ctx, "Init", &req, &resp)
+	return &resp, err
+}
+
+func (c *Client) Drop(ctx context.Context, instance string) error {
+	return c.invoke(ctx, "Drop", &InstanceReq{Instance: instance}, &Empty{})
+}
+
+func (c *Client) List(ctx context.Context, req ListReq) ([]Obj, error) {
+	var resp ListResp
+	err := c.invoke(ctx, "List", &req, &resp)
+	return resp.Objs, err
+}
+
+func (c *Client) Link(ctx context.Context, req LinkReq) (*Link, error) {
+	var resp Link
+	err := c.invoke(ctx, "Link", &req, &resp)
+	return &resp, err
+}
+
+func (c *Client) Get(ctx context.Context, req GetReq) (*Obj, error) {
+	return c.objCall(ctx, "Get", &req)
+}
+
+func (c *Client) MakeDir(ctx context.Context, req MakeDirReq) (*Obj, error) {
+	return c.objCall(ctx, "MakeDir", &req)
+}
+
+func (c *Client) Move(ctx context.Context, req MoveReq) (*Obj, error) {
+	return c.objCall(ctx, "Move", &req)
+}
+
+func (c *Client) Rename(ctx context.Context, req RenameReq) (*Obj, error) {
+	return c.objCall(ctx, "Rename", &req)
+}
+
+func (c *Client) Copy(ctx context.Context, req MoveReq) (*Obj, error) {
+	return c.objCall(ctx, "Copy", &req)
+}
+
+func (c *Client) Remove(ctx context.Context, req ObjReq) error {
+	return c.invoke(ctx, "Remove", &req, &Empty{})
+}
+
+func (c *Client) objCall(ctx context.Context, method string, req any) (*Obj, error) {
+	var resp ObjResp
+	if err := c.invoke(ctx, method, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Obj, nil
+}
+
+// Put streams the content read from r to the plugin, up is called with the
+// bytes sent so far
+func (c *Client) Put(ctx context.Context, meta PutMeta, r io.Reader, up func(sent int64)) (*Obj, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], fullMethod("Put"))
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.SendMsg(&PutMsg{Meta: &meta}); err != nil {
+		return nil, recvErr(stream, err)
+	}
+	buf := make([]byte, chunkSize)
+	var sent int64
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if n > 0 {
+			if err = stream.SendMsg(&PutMsg{Data: buf[:n]}); err != nil {
+				return nil, recvErr(stream, err)
+			}
+			sent += int64(n)
+			if up != nil {
+				up(sent)
+			}
+		}
+		if errors.Is(rerr, io.EOF) || errors.Is(rerr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var resp ObjResp
+	if err = stream.RecvMsg(&resp); err != nil {
+		return nil, err
+	}
+	return resp.Obj, nil
+}
+
+// recvErr gets the status the plugin ended the stream with, as SendMsg
+// returns io.EOF only
+func recvErr(stream grpc.ClientStream, err error) error {
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+	if rerr := stream.RecvMsg(&ObjResp{}); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// IsNotFound reports whether err is the ErrNotFound of the plugin
+func IsNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// IsUnimplemented reports whether the plugin doesn't implement the method
+func IsUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
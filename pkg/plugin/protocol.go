@@ -0,0 +1,186 @@
+// Package plugin is the protocol of the external storage drivers. A plugin is
+// a separate binary serving the Driver service over gRPC, alist connects to it
+// and registers its driver at runtime like a built-in one.
+//
+// The messages are encoded in JSON by the codec named "json", so the plugins
+// don't need protobuf definitions or generated code, see Serve for writing one.
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// ProtocolVersion is bumped on breaking changes of the messages, alist refuses
+// the plugins of another version
+const ProtocolVersion = 1
+
+// AddrEnv is the environment variable the address to listen on is passed in
+// to the plugins started by alist, such as unix:///path/to/plugin.sock
+const AddrEnv = "ALIST_PLUGIN_ADDR"
+
+const (
+	serviceName = "alist.plugin.Driver"
+	codecName   = "json"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// Item is an option of the storages of the driver, as driver.Item in alist
+type Item struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Options  string `json:"options"`
+	Required bool   `json:"required"`
+	Help     string `json:"help"`
+}
+
+type Info struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	// the options of driver.Config
+	LocalSort   bool   `json:"local_sort"`
+	NoCache     bool   `json:"no_cache"`
+	NoUpload    bool   `json:"no_upload"`
+	DefaultRoot string `json:"default_root"`
+	Alert       string `json:"alert"`
+	Additional  []Item `json:"additional"`
+	// Capabilities are the optional methods implemented, among get, make_dir,
+	// move, rename, copy, remove and put
+	Capabilities []string `json:"capabilities"`
+}
+
+type Obj struct {
+	ID       string            `json:"id"`
+	Path     string            `json:"path"`
+	Name     string            `json:"name"`
+	Size     int64             `json:"size"`
+	Modified time.Time         `json:"modified"`
+	Ctime    time.Time         `json:"ctime"`
+	IsDir    bool              `json:"is_dir"`
+	Hashes   map[string]string `json:"hashes,omitempty"`
+}
+
+type Link struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	// Expiration is in seconds, 0 not to cache the link
+	Expiration int64 `json:"expiration,omitempty"`
+}
+
+type Empty struct{}
+
+// InitReq initializes the storage of alist identified by Instance, Addition
+// is the JSON of its additional options
+type InitReq struct {
+	Instance string `json:"instance"`
+	Addition string `json:"addition"`
+}
+
+// InitResp has the addition to save, if the driver updated it, such as a
+// refreshed token
+type InitResp struct {
+	Addition string `json:"addition,omitempty"`
+}
+
+type InstanceReq struct {
+	Instance string `json:"instance"`
+}
+
+type ListReq struct {
+	Instance string `json:"instance"`
+	Dir      Obj    `json:"dir"`
+	Refresh  bool   `json:"refresh"`
+}
+
+type ListResp struct {
+	Objs []Obj `json:"objs"`
+}
+
+type LinkReq struct {
+	Instance string      `json:"instance"`
+	File     Obj         `json:"file"`
+	IP       string      `json:"ip"`
+	Header   http.Header `json:"header,omitempty"`
+	Type     string      `json:"type"`
+}
+
+type GetReq struct {
+	Instance string `json:"instance"`
+	Path     string `json:"path"`
+}
+
+type MakeDirReq struct {
+	Instance  string `json:"instance"`
+	ParentDir Obj    `json:"parent_dir"`
+	Name      string `json:"name"`
+}
+
+// MoveReq is the request of move and copy
+type MoveReq struct {
+	Instance string `json:"instance"`
+	Src      Obj    `json:"src"`
+	DstDir   Obj    `json:"dst_dir"`
+}
+
+type RenameReq struct {
+	Instance string `json:"instance"`
+	Src      Obj    `json:"src"`
+	NewName  string `json:"new_name"`
+}
+
+type ObjReq struct {
+	Instance string `json:"instance"`
+	Obj      Obj    `json:"obj"`
+}
+
+// ObjResp is the obj made by a call, nil if the driver doesn't tell it
+type ObjResp struct {
+	Obj *Obj `json:"obj,omitempty"`
+}
+
+// PutMsg is a message of the put stream, the first one has Meta only and the
+// next ones the content
+type PutMsg struct {
+	Meta *PutMeta `json:"meta,omitempty"`
+	Data []byte   `json:"data,omitempty"`
+}
+
+type PutMeta struct {
+	Instance string `json:"instance"`
+	DstDir   Obj    `json:"dst_dir"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Mimetype string `json:"mimetype"`
+}
+
+const (
+	CapGet     = "get"
+	CapMakeDir = "make_dir"
+	CapMove    = "move"
+	CapRename  = "rename"
+	CapCopy    = "copy"
+	CapRemove  = "remove"
+	CapPut     = "put"
+)
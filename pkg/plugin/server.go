@@ -0,0 +1,355 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Driver is implemented by the plugins, an instance serves a storage of alist
+type Driver interface {
+	// Init initializes the instance with the JSON of the additional options,
+	// the addition returned is saved if not empty
+	Init(ctx context.Context, addition string) (string, error)
+	Drop(ctx context.Context) error
+	List(ctx context.Context, dir Obj, refresh bool) ([]Obj, error)
+	Link(ctx context.Context, file Obj, req LinkReq) (*Link, error)
+}
+
+// the optional methods of the drivers, the objs they return may be nil if
+// unknown, alist refreshes the listing then
+
+type Getter interface {
+	Get(ctx context.Context, path string) (*Obj, error)
+}
+
+type MakeDirer interface {
+	MakeDir(ctx context.Context, parentDir Obj, name string) (*Obj, error)
+}
+
+type Mover interface {
+	Move(ctx context.Context, src, dstDir Obj) (*Obj, error)
+}
+
+type Renamer interface {
+	Rename(ctx context.Context, src Obj, newName string) (*Obj, error)
+}
+
+type Copier interface {
+	Copy(ctx context.Context, src, dstDir Obj) (*Obj, error)
+}
+
+type Remover interface {
+	Remove(ctx context.Context, obj Obj) error
+}
+
+type Putter interface {
+	Put(ctx context.Context, meta PutMeta, r io.Reader) (*Obj, error)
+}
+
+// ErrNotFound is returned by the drivers for the objs which don't exist
+var ErrNotFound = errors.New("object not found")
+
+type driverServer interface {
+	instance(id string) (Driver, error)
+}
+
+type server struct {
+	info      Info
+	newDriver func() Driver
+	mu        sync.Mutex
+	instances map[string]Driver
+}
+
+func (s *server) instance(id string) (Driver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.instances[id]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "instance %s isn't initialized", id)
+	}
+	return d, nil
+}
+
+// Serve serves the driver described by info until the process is stopped,
+// newDriver makes the instance of each storage. It listens on the address in
+// AddrEnv, a unix socket as unix:///path or a tcp address as host:port.
+func Serve(info Info, newDriver func() Driver) error {
+	addr := os.Getenv(AddrEnv)
+	if addr == "" {
+		return errors.New(AddrEnv + " is not set")
+	}
+	info.ProtocolVersion = ProtocolVersion
+	info.Capabilities = capabilities(newDriver())
+	network, address := "tcp", addr
+	if strings.HasPrefix(addr, "unix://") {
+		network, address = "unix", strings.TrimPrefix(addr, "unix://")
+		_ = os.Remove(address)
+	}
+	lis, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	gs.RegisterService(&serviceDesc, &server{info: info, newDriver: newDriver, instances: map[string]Driver{}})
+	return gs.Serve(lis)
+}
+
+func capabilities(d Driver) []string {
+	var caps []string
+	for name, ok := range map[string]bool{
+		CapGet:     is[Getter](d),
+		CapMakeDir: is[MakeDirer](d),
+		CapMove:    is[Mover](d),
+		CapRename:  is[Renamer](d),
+		CapCopy:    is[Copier](d),
+		CapRemove:  is[Remover](d),
+		CapPut:     is[Putter](d),
+	} {
+		if ok {
+			caps = append(caps, name)
+		}
+	}
+	slices.Sort(caps)
+	return caps
+}
+
+func is[T any](d Driver) bool {
+	_, ok := d.(T)
+	return ok
+}
+
+// toStatus maps the errors of the drivers to the codes alist understands
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func unimplemented(method string) error {
+	return status.Errorf(codes.Unimplemented, "%s is not implemented", method)
+}
+
+// unary makes the handler of the method name, call gets the decoded request
+func unary[Req any](name string, call func(s *server, ctx context.Context, req *Req) (any, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			s := srv.(*server)
+			handle := func(ctx context.Context, req any) (any, error) {
+				res, err := call(s, ctx, req.(*Req))
+				return res, toStatus(err)
+			}
+			if interceptor == nil {
+				return handle(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(name)}, handle)
+		},
+	}
+}
+
+func fullMethod(name string) string {
+	return "/" + serviceName + "/" + name
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*driverServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("Info", func(s *server, ctx context.Context, req *Empty) (any, error) {
+			return &s.info, nil
+		}),
+		unary("Init", func(s *server, ctx context.Context, req *InitReq) (any, error) {
+			s.mu.Lock()
+			old, ok := s.instances[req.Instance]
+			delete(s.instances, req.Instance)
+			s.mu.Unlock()
+			if ok {
+				_ = old.Drop(ctx)
+			}
+			d := s.newDriver()
+			addition, err := d.Init(ctx, req.Addition)
+			if err != nil {
+				return nil, err
+			}
+			s.mu.Lock()
+			s.instances[req.Instance] = d
+			s.mu.Unlock()
+			return &InitResp{Addition: addition}, nil
+		}),
+		unary("Drop", func(s *server, ctx context.Context, req *InstanceReq) (any, error) {
+			s.mu.Lock()
+			d, ok := s.instances[req.Instance]
+			delete(s.instances, req.Instance)
+			s.mu.Unlock()
+			if !ok {
+				return &Empty{}, nil
+			}
+			return &Empty{}, d.Drop(ctx)
+		}),
+		unary("List", func(s *server, ctx context.Context, req *ListReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			objs, err := d.List(ctx, req.Dir, req.Refresh)
+			if err != nil {
+				return nil, err
+			}
+			return &ListResp{Objs: objs}, nil
+		}),
+		unary("Link", func(s *server, ctx context.Context, req *LinkReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			return d.Link(ctx, req.File, *req)
+		}),
+		unary("Get", func(s *server, ctx context.Context, req *GetReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			g, ok := d.(Getter)
+			if !ok {
+				return nil, unimplemented("Get")
+			}
+			obj, err := g.Get(ctx, req.Path)
+			return &ObjResp{Obj: obj}, err
+		}),
+		unary("MakeDir", func(s *server, ctx context.Context, req *MakeDirReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			m, ok := d.(MakeDirer)
+			if !ok {
+				return nil, unimplemented("MakeDir")
+			}
+			obj, err := m.MakeDir(ctx, req.ParentDir, req.Name)
+			return &ObjResp{Obj: obj}, err
+		}),
+		unary("Move", func(s *server, ctx context.Context, req *MoveReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			m, ok := d.(Mover)
+			if !ok {
+				return nil, unimplemented("Move")
+			}
+			obj, err := m.Move(ctx, req.Src, req.DstDir)
+			return &ObjResp{Obj: obj}, err
+		}),
+		unary("Rename", func(s *server, ctx context.Context, req *RenameReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := d.(Renamer)
+			if !ok {
+				return nil, unimplemented("Rename")
+			}
+			obj, err := r.Rename(ctx, req.Src, req.NewName)
+			return &ObjResp{Obj: obj}, err
+		}),
+		unary("Copy", func(s *server, ctx context.Context, req *MoveReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			c, ok := d.(Copier)
+			if !ok {
+				return nil, unimplemented("Copy")
+			}
+			obj, err := c.Copy(ctx, req.Src, req.DstDir)
+			return &ObjResp{Obj: obj}, err
+		}),
+		unary("Remove", func(s *server, ctx context.Context, req *ObjReq) (any, error) {
+			d, err := s.instance(req.Instance)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := d.(Remover)
+			if !ok {
+				return nil, unimplemented("Remove")
+			}
+			return &Empty{}, r.Remove(ctx, req.Obj)
+		}),
+	},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Put",
+		Handler:       putHandler,
+		ClientStreams: true,
+	}},
+	Metadata: "alist/plugin",
+}
+
+func putHandler(srv any, stream grpc.ServerStream) error {
+	var first PutMsg
+	if err := stream.RecvMsg(&first); err != nil {
+		return err
+	}
+	if first.Meta == nil {
+		return status.Error(codes.InvalidArgument, "the first message of put has no meta")
+	}
+	d, err := srv.(*server).instance(first.Meta.Instance)
+	if err != nil {
+		return err
+	}
+	p, ok := d.(Putter)
+	if !ok {
+		return unimplemented("Put")
+	}
+	obj, err := p.Put(stream.Context(), *first.Meta, &putReader{stream: stream})
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendMsg(&ObjResp{Obj: obj})
+}
+
+// putReader reads the content sent in the put stream
+type putReader struct {
+	stream grpc.ServerStream
+	buf    []byte
+}
+
+func (r *putReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		var msg PutMsg
+		// io.EOF once the client closed the stream
+		if err := r.stream.RecvMsg(&msg); err != nil {
+			return 0, err
+		}
+		r.buf = msg.Data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -76,6 +76,15 @@ func RegisterHashWithParam(name, alias string, width int, newFunc func(...any) h
 	return newType
 }
 
+// GetHashByName returns the registered hash type with the name or alias
+func GetHashByName(name string) (*HashType, bool) {
+	if ht, ok := name2hash[name]; ok {
+		return ht, true
+	}
+	ht, ok := alias2hash[name]
+	return ht, ok
+}
+
 var (
 	// MD5 indicates MD5 support
 	MD5 = RegisterHash("md5", "MD5", 32, md5.New)
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"net/url"
 	stdpath "path"
 	"strings"
@@ -82,10 +83,7 @@ func JoinBasePath(basePath, reqPath string) (string, error) {
 	 * 4. /../
 	 * 5. /a/b/..
 	 */
-	if reqPath == ".." ||
-		strings.HasSuffix(reqPath, "/..") ||
-		strings.HasPrefix(reqPath, "../") ||
-		strings.Contains(reqPath, "/../") {
+	if HasParentSegment(reqPath) {
 		return "", errs.RelativePath
 	}
 
@@ -98,27 +96,48 @@ func JoinBasePath(basePath, reqPath string) (string, error) {
 	return stdpath.Join(FixAndCleanPath(basePath), FixAndCleanPath(reqPath)), nil
 }
 
+// HasParentSegment reports whether the path contains a ".." segment
+func HasParentSegment(path string) bool {
+	for _, seg := range strings.Split(path, "/") {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeRequestPath collapses the duplicate slashes and "." segments of a
+// request path. ".." segments are resolved lexically, so they never climb above
+// "/", when resolveParent is true, otherwise they are rejected.
+func NormalizeRequestPath(path string, resolveParent bool) (string, error) {
+	if !resolveParent && HasParentSegment(path) {
+		return "", errs.RelativePath
+	}
+	return FixAndCleanPath(path), nil
+}
+
 func GetFullPath(mountPath, path string) string {
 	return stdpath.Join(GetActualMountPath(mountPath), path)
 }
 
 // ValidateNameComponent validates a single path component.
 // It rejects empty names, dot segments, separators, ".." sequences, and NUL bytes.
+// The returned error wraps errs.InvalidName with the reason.
 func ValidateNameComponent(name string) error {
 	if name == "" {
-		return errs.InvalidName
+		return fmt.Errorf("%w: empty", errs.InvalidName)
 	}
 	if name == "." || name == ".." {
-		return errs.InvalidName
+		return fmt.Errorf("%w: dot segment", errs.InvalidName)
 	}
 	if strings.Contains(name, "/") || strings.Contains(name, "\\") {
-		return errs.InvalidName
+		return fmt.Errorf("%w: contains a path separator", errs.InvalidName)
 	}
 	if strings.Contains(name, "..") {
-		return errs.InvalidName
+		return fmt.Errorf("%w: contains \"..\"", errs.InvalidName)
 	}
 	if strings.ContainsRune(name, 0) {
-		return errs.InvalidName
+		return fmt.Errorf("%w: contains a NUL byte", errs.InvalidName)
 	}
 	return nil
 }
@@ -1,6 +1,11 @@
 package utils
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+)
 
 func TestEncodePath(t *testing.T) {
 	t.Log(EncodePath("http://localhost:5244/d/123#.png"))
@@ -45,6 +50,8 @@ func TestValidateNameComponent(t *testing.T) {
 	for _, name := range invalidNames {
 		if err := ValidateNameComponent(name); err == nil {
 			t.Fatalf("expected invalid name %q to be rejected", name)
+		} else if !errors.Is(err, errs.InvalidName) {
+			t.Fatalf("expected the error of %q to wrap errs.InvalidName, got %v", name, err)
 		}
 	}
 }
@@ -66,3 +73,24 @@ func TestJoinUnderBase(t *testing.T) {
 		t.Fatalf("expected nested path to be rejected")
 	}
 }
+
+func TestNormalizeRequestPath(t *testing.T) {
+	datas := map[string]string{
+		"//a///b//": "/a/b",
+		"/./a/.":    "/a",
+		"/a/../../": "/",
+		"/a/..b":    "/a/..b",
+	}
+	for key, value := range datas {
+		out, err := NormalizeRequestPath(key, true)
+		if err != nil || out != value {
+			t.Fatalf("NormalizeRequestPath(%q) = %q, %v, want %q", key, out, err, value)
+		}
+	}
+	if _, err := NormalizeRequestPath("/a/../b", false); err == nil {
+		t.Fatalf("expected parent segment to be rejected")
+	}
+	if out, err := NormalizeRequestPath("/a/..b", false); err != nil || out != "/a/..b" {
+		t.Fatalf("unexpected result for name containing dots: %q, %v", out, err)
+	}
+}
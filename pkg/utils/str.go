@@ -5,8 +5,22 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
+// NormalizeName returns the key two names collide on, foldCase makes File.txt
+// and file.txt collide, unicode makes the NFC and NFD forms collide
+func NormalizeName(name string, foldCase, unicode bool) string {
+	if foldCase {
+		name = cases.Fold().String(name)
+	}
+	if unicode {
+		name = norm.NFC.String(name)
+	}
+	return name
+}
+
 func MappingName(name string) string {
 	for k, v := range conf.FilenameCharMap {
 		name = strings.ReplaceAll(name, k, v)
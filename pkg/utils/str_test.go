@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	const (
+		nfc = "caf\u00e9.txt"  // é precomposed
+		nfd = "cafe\u0301.txt" // e followed by the combining acute accent
+	)
+	tests := []struct {
+		a, b              string
+		foldCase, unicode bool
+		collide           bool
+	}{
+		{nfc, nfd, false, false, false},
+		{nfc, nfd, true, false, false},
+		{nfc, nfd, false, true, true},
+		{nfc, nfd, true, true, true},
+		{"File.txt", "file.txt", false, false, false},
+		{"File.txt", "file.txt", false, true, false},
+		{"File.txt", "file.txt", true, false, true},
+		{"CAF\u00c9.txt", nfd, true, true, true},
+		{"CAF\u00c9.txt", nfd, false, true, false},
+		// hangul syllables decompose into jamo
+		{"\ud55c\uae00", "\u1112\u1161\u11ab\u1100\u1173\u11af", false, true, true},
+		{"a.txt", "b.txt", true, true, false},
+	}
+	for _, tt := range tests {
+		got := NormalizeName(tt.a, tt.foldCase, tt.unicode) == NormalizeName(tt.b, tt.foldCase, tt.unicode)
+		if got != tt.collide {
+			t.Errorf("NormalizeName(%q, %q, fold=%v, unicode=%v) collide = %v, want %v",
+				tt.a, tt.b, tt.foldCase, tt.unicode, got, tt.collide)
+		}
+	}
+}
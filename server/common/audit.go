@@ -0,0 +1,37 @@
+package common
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Audit records an operation which is not a file mutation, such as a login or
+// a change of the permissions, made by user or the user of c if nil. target is
+// the user, role or acl subject operated, path the path of storage or acl
+func Audit(c *gin.Context, user *model.User, action, target, path string, err error) {
+	if !setting.GetBool(conf.AuditLogEnabled) {
+		return
+	}
+	if user == nil {
+		user, _ = c.Value("user").(*model.User)
+	}
+	entry := &model.AuditLog{
+		Action: action,
+		Target: target,
+		Path:   path,
+		IP:     c.ClientIP(),
+	}
+	if user != nil {
+		entry.UserID, entry.Username = user.ID, user.Username
+	}
+	if err != nil {
+		entry.Failed, entry.Error = true, err.Error()
+	}
+	if err := db.CreateAuditLog(entry); err != nil {
+		log.Errorf("failed record audit log of %s %s: %+v", action, target, err)
+	}
+}
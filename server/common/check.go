@@ -1,11 +1,15 @@
 package common
 
 import (
+	stdpath "path"
+
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 func IsStorageSignEnabled(rawPath string) bool {
@@ -20,6 +24,37 @@ func CanWrite(meta *model.Meta, path string) bool {
 	return meta.WSub || meta.Path == path
 }
 
+// InDropFolder reports whether reqPath is in a file drop of meta for u, the
+// users who can write there are not restricted
+func InDropFolder(u *model.User, meta *model.Meta, reqPath string) bool {
+	if meta == nil || !meta.Drop || !utils.IsSubPath(meta.Path, reqPath) {
+		return false
+	}
+	return !HasPermission(MergeRolePermissions(u, reqPath), PermWrite)
+}
+
+// CheckUpload checks whether u can upload a file to path, drop reports that
+// path is in a file drop of u, where nothing can be overwritten
+func CheckUpload(u *model.User, path, password string) (drop bool, err error) {
+	meta, err := op.GetNearestMeta(stdpath.Dir(path))
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false, err
+	}
+	if InDropFolder(u, meta, path) {
+		// what is dropped can't be replaced by the next one dropping
+		if !CanAccessWithRoles(u, meta, stdpath.Dir(path), password) {
+			return true, errs.PermissionDenied
+		}
+		return true, nil
+	}
+	perm := MergeRolePermissions(u, path)
+	if !(CanAccessWithRoles(u, meta, path, password) &&
+		(HasPermission(perm, PermWrite) || CanWrite(meta, stdpath.Dir(path)))) {
+		return false, errs.PermissionDenied
+	}
+	return false, nil
+}
+
 func IsApply(metaPath, reqPath string, applySub bool) bool {
 	if utils.PathEqual(metaPath, reqPath) {
 		return true
@@ -1,6 +1,10 @@
 package common
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
 
 func TestIsApply(t *testing.T) {
 	datas := []struct {
@@ -22,3 +26,25 @@ func TestIsApply(t *testing.T) {
 		}
 	}
 }
+
+func TestInDropFolder(t *testing.T) {
+	drop := &model.Meta{Path: "/drop", Drop: true}
+	datas := []struct {
+		user    model.User
+		meta    *model.Meta
+		reqPath string
+		result  bool
+	}{
+		{user: model.User{Role: model.Roles{int(roleReader)}}, meta: drop, reqPath: "/drop/a", result: true},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, meta: drop, reqPath: "/other", result: false},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, meta: &model.Meta{Path: "/drop"}, reqPath: "/drop/a", result: false},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, meta: nil, reqPath: "/drop/a", result: false},
+		// the users who can write there see the folder as usual
+		{user: model.User{Role: model.Roles{int(roleWriter)}}, meta: drop, reqPath: "/drop/a", result: false},
+	}
+	for i, data := range datas {
+		if InDropFolder(&data.user, data.meta, data.reqPath) != data.result {
+			t.Errorf("TestInDropFolder %d failed", i)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package common
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// contentEncodings maps the extension of a stored-compressed file to its content coding
+var contentEncodings = map[string]string{
+	"gz":  "gzip",
+	"br":  "br",
+	"zst": "zstd",
+}
+
+// encodedAssetExts are the extensions of the files stored compressed to be
+// served as web assets, which the browsers decode
+var encodedAssetExts = map[string]bool{
+	"html": true, "htm": true, "css": true, "js": true, "mjs": true, "json": true,
+	"map": true, "svg": true, "xml": true, "txt": true, "wasm": true,
+}
+
+// GetContentEncoding returns the content coding of a stored-compressed file
+// and the name of the file once decoded
+func GetContentEncoding(name string) (encoding string, decodedName string, ok bool) {
+	ext := utils.Ext(name)
+	encoding, ok = contentEncodings[ext]
+	if !ok {
+		return "", "", false
+	}
+	decodedName = name[:len(name)-len(ext)-1]
+	// the others such as "backup.tar.gz" are downloads in their own right
+	if !encodedAssetExts[utils.Ext(decodedName)] {
+		return "", "", false
+	}
+	return encoding, decodedName, true
+}
+
+// AcceptsEncoding reports whether the Accept-Encoding header allows the coding
+func AcceptsEncoding(r *http.Request, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case encoding:
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+type encodingResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	contentType string
+	wroteHeader bool
+}
+
+func (w *encodingResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		h.Add("Vary", "Accept-Encoding")
+		// the errors and the empty responses don't carry the encoded content
+		if code >= 200 && code < 300 && code != http.StatusNoContent {
+			h.Set("Content-Encoding", w.encoding)
+			h.Set("Content-Type", w.contentType)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *encodingResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Size and Written are those of gin.ResponseWriter, the handlers check them
+// to know whether the response has started
+func (w *encodingResponseWriter) Size() int {
+	if s, ok := w.ResponseWriter.(interface{ Size() int }); ok {
+		return s.Size()
+	}
+	return -1
+}
+
+func (w *encodingResponseWriter) Written() bool {
+	if s, ok := w.ResponseWriter.(interface{ Written() bool }); ok {
+		return s.Written()
+	}
+	return w.wroteHeader
+}
+
+func (w *encodingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *encodingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// the largest range served of a decoded content, it is decoded in memory to
+// know where it ends
+const maxDecodedRange = 16 * utils.MB
+
+// decodedRange parses the Range of a request for the decoded content, whose
+// size isn't known. Only a single range with both ends is served, the others
+// get the whole content. A weak etag can't satisfy If-Range, so a request
+// with it gets the whole content too
+func decodedRange(r *http.Request) (start, end int64, ok bool) {
+	spec, found := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	if !found || strings.Contains(spec, ",") || r.Header.Get("If-Range") != "" {
+		return 0, 0, false
+	}
+	first, last, _ := strings.Cut(strings.TrimSpace(spec), "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start || end-start >= maxDecodedRange {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// ProxyEncoded serves a stored-compressed file under its decoded name. The stored
+// bytes are sent as-is with Content-Encoding when the client accepts the coding,
+// ranges then apply to the encoded bytes as usual. Otherwise the content is decoded
+// on the fly, a range of it is served by decoding the content up to its end.
+func ProxyEncoded(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj, encoding, decodedName string) error {
+	decoded := &model.ObjWrapName{Name: decodedName, Obj: file}
+	if AcceptsEncoding(r, encoding) {
+		return Proxy(&encodingResponseWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			contentType:    utils.GetMimeType(decodedName),
+		}, r, link, decoded)
+	}
+	rc, err := openLink(r.Context(), link, file.GetSize())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	reader, err := newDecoder(encoding, &stream.RateLimitReader{
+		Reader:  rc,
+		Limiter: stream.ServerDownloadLimit,
+		Ctx:     r.Context(),
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	attachHeader(w, decoded)
+	// the decoded representation differs from the stored bytes
+	w.Header().Set("Etag", "W/"+GetEtag(file))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Add("Vary", "Accept-Encoding")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	start, end, ranged := decodedRange(r)
+	if !ranged {
+		w.WriteHeader(http.StatusOK)
+		_, err = utils.CopyWithBuffer(w, reader)
+		return err
+	}
+	// the content before the range has to be decoded anyway
+	skipped, err := io.CopyN(io.Discard, reader, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	buf := make([]byte, end-start+1)
+	n := 0
+	if err == nil {
+		n, err = io.ReadFull(reader, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+	}
+	if n == 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", skipped))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return nil
+	}
+	// the whole size is known once the content ended within the range
+	size := "*"
+	if n < len(buf) {
+		size = strconv.FormatInt(start+int64(n), 10)
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, start+int64(n)-1, size))
+	w.Header().Set("Content-Length", strconv.Itoa(n))
+	w.WriteHeader(http.StatusPartialContent)
+	_, err = w.Write(buf[:n])
+	return err
+}
+
+func openLink(ctx context.Context, link *model.Link, size int64) (io.ReadCloser, error) {
+	if link.MFile != nil {
+		return link.MFile, nil
+	}
+	rrc := link.RangeReadCloser
+	if rrc == nil {
+		var err error
+		rrc, err = stream.GetRangeReadCloserFromLink(size, link)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rrc.RangeRead(ctx, http_range.Range{Length: -1})
+}
+
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	default:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+}
@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestGetContentEncoding(t *testing.T) {
+	datas := []struct {
+		name        string
+		encoding    string
+		decodedName string
+		ok          bool
+	}{
+		{name: "app.js.gz", encoding: "gzip", decodedName: "app.js", ok: true},
+		{name: "style.css.br", encoding: "br", decodedName: "style.css", ok: true},
+		{name: "main.wasm.zst", encoding: "zstd", decodedName: "main.wasm", ok: true},
+		{name: "app.js", ok: false},
+		{name: "backup.gz", ok: false},
+		// the compressed archives and dumps are downloaded as they are
+		{name: "backup.tar.gz", ok: false},
+		{name: "dump.sql.gz", ok: false},
+	}
+	for i, data := range datas {
+		encoding, decodedName, ok := GetContentEncoding(data.name)
+		if encoding != data.encoding || decodedName != data.decodedName || ok != data.ok {
+			t.Errorf("TestGetContentEncoding %d failed", i)
+		}
+	}
+}
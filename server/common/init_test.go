@@ -0,0 +1,41 @@
+package common
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// the roles of the tests, their ids are above the ones of the builtin roles
+const (
+	roleReader uint = iota + 10
+	roleWriter
+	roleSecure
+	roleOffice
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:common?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+	roles := []model.Role{
+		{ID: roleReader, Name: "reader", PermissionScopes: []model.PermissionEntry{{Path: "/a/b", Permission: 1 << PermSeeHides}}},
+		{ID: roleWriter, Name: "writer", PermissionScopes: []model.PermissionEntry{{Path: "/", Permission: 1 << PermWrite}}},
+		{ID: roleSecure, Name: "secure", Require2FA: true},
+		{ID: roleOffice, Name: "office", AllowedIPs: "10.0.0.0/8"},
+	}
+	for i := range roles {
+		if err := op.CreateRole(&roles[i]); err != nil {
+			panic(err)
+		}
+	}
+	if err := op.GrantACL(&model.ACLEntry{Path: "/a/b/c", SubjectType: model.ACLSubjectUser, SubjectID: 100, Permission: 1 << PermRename}, true); err != nil {
+		panic(err)
+	}
+}
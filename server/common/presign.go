@@ -0,0 +1,13 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// PresignUploadData is what the presigned upload links of u sign, the links
+// are revoked with the password of u changed
+func PresignUploadData(u *model.User, path string) string {
+	return fmt.Sprintf("%s:%d:%s", u.Username, u.PwdTS, path)
+}
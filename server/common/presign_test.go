@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestPresignUploadData(t *testing.T) {
+	u := &model.User{Username: "a", PwdTS: 1}
+	data := PresignUploadData(u, "/a/b")
+	if PresignUploadData(u, "/a/c") == data {
+		t.Errorf("TestPresignUploadData failed, the paths sign the same")
+	}
+	// changing the password revokes the links
+	u.PwdTS = 2
+	if PresignUploadData(u, "/a/b") == data {
+		t.Errorf("TestPresignUploadData failed, the link outlives the password")
+	}
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// the longest side of converted previews, enough for a full screen view
+const previewMaxSide = "4096x4096>"
+
+var previewG singleflight.Group[string]
+
+var (
+	previewSem   chan struct{}
+	previewSemMu sync.Mutex
+)
+
+// ShouldConvertPreview reports whether the preview of the file is converted
+// to a format the browser can display
+func ShouldConvertPreview(name string) bool {
+	if !setting.GetBool(conf.PreviewConvert) {
+		return false
+	}
+	ext := utils.Ext(name)
+	for _, t := range strings.Split(setting.GetStr(conf.PreviewConvertTypes), ",") {
+		if strings.TrimSpace(t) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// previewConverter returns the ImageMagick binary, which reads HEIC through
+// libheif and camera RAWs through libraw when it is built with them
+func previewConverter() (string, error) {
+	for _, name := range []string{"magick", "convert"} {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	return "", errors.New("no preview converter (ImageMagick) found")
+}
+
+// acquirePreview bounds the conversions running at once, the semaphore is
+// replaced when the setting changes and running conversions release the old one
+func acquirePreview(ctx context.Context) (func(), error) {
+	n := setting.GetInt(conf.PreviewConvertThreads, 2)
+	if n <= 0 {
+		n = 1
+	}
+	previewSemMu.Lock()
+	if previewSem == nil || cap(previewSem) != n {
+		previewSem = make(chan struct{}, n)
+	}
+	sem := previewSem
+	previewSemMu.Unlock()
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ConvertPreview converts the file to jpeg or webp and returns the path of the
+// converted file, conversions are cached by path and modification time
+func ConvertPreview(ctx context.Context, link *model.Link, file model.Obj, rawPath string) (string, error) {
+	bin, err := previewConverter()
+	if err != nil {
+		return "", err
+	}
+	format := setting.GetStr(conf.PreviewConvertFormat, "jpeg")
+	if format != "webp" {
+		format = "jpeg"
+	}
+	key := utils.HashData(utils.SHA1, []byte(fmt.Sprintf("%s\x00%d\x00%d\x00%s",
+		rawPath, file.ModTime().UnixNano(), file.GetSize(), format)))
+	dir := filepath.Join(conf.Conf.TempDir, "preview")
+	dst := filepath.Join(dir, key+"."+format)
+	if _, err := os.Stat(dst); err == nil {
+		// the hits are kept longer by the pruning
+		now := time.Now()
+		_ = os.Chtimes(dst, now, now)
+		return dst, nil
+	}
+	v, err, _ := previewG.Do(key, func() (string, error) {
+		if _, err := os.Stat(dst); err == nil {
+			return dst, nil
+		}
+		release, err := acquirePreview(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer release()
+		if err := os.MkdirAll(dir, 0o777); err != nil {
+			return "", err
+		}
+		rc, err := openLink(ctx, link, file.GetSize())
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		tmp := dst + ".tmp"
+		// the extension tells ImageMagick how to decode stdin, [0] picks the primary image
+		cmd := exec.CommandContext(ctx, bin, utils.Ext(file.GetName())+":-[0]",
+			"-auto-orient", "-resize", previewMaxSide, "-quality", "85", format+":"+tmp)
+		cmd.Stdin = rc
+		if out, err := cmd.CombinedOutput(); err != nil {
+			_ = os.Remove(tmp)
+			return "", errors.Wrapf(err, "failed convert %s: %s", rawPath, strings.TrimSpace(string(out)))
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			_ = os.Remove(tmp)
+			return "", err
+		}
+		go prunePreviews(dir)
+		return dst, nil
+	})
+	return v, err
+}
+
+var pruning atomic.Bool
+
+// prunePreviews removes the least recently used previews beyond the size of
+// preview_cache_size
+func prunePreviews(dir string) {
+	if !pruning.CompareAndSwap(false, true) {
+		return
+	}
+	defer pruning.Store(false)
+	limit := int64(setting.GetInt(conf.PreviewCacheSize, 1024)) * utils.MB
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.IsDir() || strings.HasSuffix(info.Name(), ".tmp") {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	if total <= limit {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err == nil {
+			total -= f.Size()
+		}
+	}
+}
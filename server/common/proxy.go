@@ -11,6 +11,7 @@ import (
 
 	"maps"
 
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/net"
 	"github.com/alist-org/alist/v3/internal/sign"
@@ -21,6 +22,13 @@ import (
 )
 
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
+	// the gin writers tell the bytes written so far
+	if sw, ok := w.(interface{ Size() int }); ok {
+		before := max(sw.Size(), 0)
+		defer func() {
+			metrics.ProxiedBytes.Add(float64(max(sw.Size(), 0) - before))
+		}()
+	}
 	if link.MFile != nil {
 		defer link.MFile.Close()
 		attachHeader(w, file)
@@ -133,6 +141,34 @@ func ProxyRange(link *model.Link, size int64) {
 	}
 }
 
+// ReadAhead makes the proxy prefetch the requested range of the link in chunks
+// of sizeKB while the client consumes the previous ones, the values saved
+// before they were validated are clamped
+func ReadAhead(link *model.Link, size int64, sizeKB, depth int) {
+	if link.MFile != nil || link.RangeReadCloser == NoProxyRange || depth <= 0 {
+		return
+	}
+	if sizeKB <= 0 {
+		sizeKB = 1024
+	}
+	sizeKB = min(sizeKB, stream.MaxReadAheadSizeKB)
+	depth = min(depth, stream.MaxReadAheadDepth)
+	rrc := link.RangeReadCloser
+	if rrc == nil {
+		var err error
+		rrc, err = stream.GetRangeReadCloserFromLink(size, link)
+		if err != nil {
+			log.Warnf("ReadAhead error: %s", err)
+			return
+		}
+	}
+	link.RangeReadCloser = &stream.ReadAheadRangeReadCloser{
+		RangeReadCloserIF: rrc,
+		ChunkSize:         sizeKB * 1024,
+		Depth:             depth,
+	}
+}
+
 func BuildDownProxyURL(downProxyURL, path string, useSign bool) string {
 	base := strings.Split(downProxyURL, "\n")[0]
 	if useSign {
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"net"
 	"path"
 	"strings"
 
@@ -57,6 +58,12 @@ func MergeRolePermissions(u *model.User, reqPath string) int32 {
 			}
 		}
 	}
+	// the acl grants on top of the roles
+	for _, entry := range op.GetACLEntriesOf(u) {
+		if utils.IsSubPath(entry.Path, reqPath) {
+			perm |= entry.Permission
+		}
+	}
 	return perm
 }
 
@@ -64,6 +71,10 @@ func CanAccessWithRoles(u *model.User, meta *model.Meta, reqPath, password strin
 	if !CanReadPathByRole(u, reqPath) {
 		return false
 	}
+	// the content of a file drop is only for its owners
+	if InDropFolder(u, meta, reqPath) && !utils.PathEqual(meta.Path, reqPath) {
+		return false
+	}
 	perm := MergeRolePermissions(u, reqPath)
 	if meta != nil && !HasPermission(perm, PermSeeHides) && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) {
@@ -104,6 +115,11 @@ func CanReadPathByRole(u *model.User, reqPath string) bool {
 			}
 		}
 	}
+	for _, entry := range op.GetACLEntriesOf(u) {
+		if utils.IsSubPath(entry.Path, reqPath) || utils.IsSubPath(reqPath, entry.Path) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -124,6 +140,11 @@ func HasChildPermission(u *model.User, reqPath string, bit uint) bool {
 			}
 		}
 	}
+	for _, entry := range op.GetACLEntriesOf(u) {
+		if utils.IsSubPath(reqPath, entry.Path) && HasPermission(entry.Permission, bit) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -137,3 +158,48 @@ func CheckPathLimitWithRoles(u *model.User, reqPath string) bool {
 	}
 	return true
 }
+
+// Needs2FAEnrollment reports whether u is in a role requiring 2FA but hasn't
+// enrolled TOTP yet
+func Needs2FAEnrollment(u *model.User) bool {
+	if u.OtpSecret != "" || u.IsGuest() {
+		return false
+	}
+	for _, rid := range u.Role {
+		role, err := op.GetRole(uint(rid))
+		if err == nil && role.Require2FA {
+			return true
+		}
+	}
+	return false
+}
+
+// IPAllowed reports whether u can be used from ip. The ranges of u apply if
+// set, else ip has to be in the ranges of one of its roles setting some.
+func IPAllowed(u *model.User, ip string) bool {
+	if u.AllowedIPs != "" {
+		nets, err := utils.ParseCIDRs(u.AllowedIPs)
+		return err == nil && utils.IPInCIDRs(ip, nets)
+	}
+	restricted := false
+	for _, rid := range u.Role {
+		role, err := op.GetRole(uint(rid))
+		if err != nil || role.AllowedIPs == "" {
+			continue
+		}
+		restricted = true
+		nets, err := utils.ParseCIDRs(role.AllowedIPs)
+		if err == nil && utils.IPInCIDRs(ip, nets) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+// RemoteIPAllowed is IPAllowed for the host:port address of a connection
+func RemoteIPAllowed(u *model.User, addr string) bool {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return IPAllowed(u, addr)
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestNeeds2FAEnrollment(t *testing.T) {
+	datas := []struct {
+		user   model.User
+		result bool
+	}{
+		{user: model.User{Role: model.Roles{int(roleReader)}}, result: false},
+		{user: model.User{Role: model.Roles{int(roleReader), int(roleSecure)}}, result: true},
+		{user: model.User{Role: model.Roles{int(roleSecure)}, OtpSecret: "secret"}, result: false},
+		{user: model.User{Role: model.Roles{model.GUEST, int(roleSecure)}}, result: false},
+	}
+	for i, data := range datas {
+		if Needs2FAEnrollment(&data.user) != data.result {
+			t.Errorf("TestNeeds2FAEnrollment %d failed", i)
+		}
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	datas := []struct {
+		user   model.User
+		ip     string
+		result bool
+	}{
+		{user: model.User{}, ip: "1.2.3.4", result: true},
+		{user: model.User{AllowedIPs: "1.2.3.4"}, ip: "1.2.3.4", result: true},
+		{user: model.User{AllowedIPs: "1.2.3.0/24"}, ip: "1.2.4.1", result: false},
+		{user: model.User{AllowedIPs: "bad"}, ip: "1.2.3.4", result: false},
+		{user: model.User{Role: model.Roles{int(roleOffice)}}, ip: "10.1.2.3", result: true},
+		{user: model.User{Role: model.Roles{int(roleOffice)}}, ip: "1.2.3.4", result: false},
+		// the ranges of the user replace the ones of its roles
+		{user: model.User{AllowedIPs: "1.2.3.4", Role: model.Roles{int(roleOffice)}}, ip: "1.2.3.4", result: true},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, ip: "1.2.3.4", result: true},
+	}
+	for i, data := range datas {
+		if IPAllowed(&data.user, data.ip) != data.result {
+			t.Errorf("TestIPAllowed %d failed", i)
+		}
+	}
+}
+
+func TestMergeRolePermissions(t *testing.T) {
+	datas := []struct {
+		user    model.User
+		reqPath string
+		result  int32
+	}{
+		{user: model.User{Role: model.Roles{int(roleReader)}}, reqPath: "/a", result: 0},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, reqPath: "/a/b/d", result: 1 << PermSeeHides},
+		{user: model.User{Role: model.Roles{int(roleReader), int(roleWriter)}}, reqPath: "/a/b", result: 1<<PermSeeHides | 1<<PermWrite},
+		// the acl entry of the user adds to its roles under its path only
+		{user: model.User{ID: 100, Role: model.Roles{int(roleReader)}}, reqPath: "/a/b/c/d", result: 1<<PermSeeHides | 1<<PermRename},
+		{user: model.User{ID: 100, Role: model.Roles{int(roleReader)}}, reqPath: "/a/b/d", result: 1 << PermSeeHides},
+		{user: model.User{ID: 101, Role: model.Roles{int(roleReader)}}, reqPath: "/a/b/c", result: 1 << PermSeeHides},
+	}
+	for i, data := range datas {
+		if perm := MergeRolePermissions(&data.user, data.reqPath); perm != data.result {
+			t.Errorf("TestMergeRolePermissions %d failed, got %b", i, perm)
+		}
+	}
+}
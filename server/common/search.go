@@ -0,0 +1,69 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// SearchRestriction builds what u can't see of the nodes under parent, the
+// same as CanAccessWithRoles but the passwords, which are left to the results
+func SearchRestriction(u *model.User, parent string) (*model.SearchRestriction, error) {
+	var scopes []model.PermissionEntry
+	for _, rid := range u.Role {
+		role, err := op.GetRole(uint(rid))
+		if err != nil {
+			continue
+		}
+		scopes = append(scopes, role.PermissionScopes...)
+	}
+	r := &model.SearchRestriction{Roots: []string{}}
+	for _, scope := range scopes {
+		if utils.IsSubPath(scope.Path, parent) {
+			r.Roots = nil
+			break
+		}
+		if utils.IsSubPath(parent, scope.Path) {
+			r.Roots = append(r.Roots, utils.FixAndCleanPath(scope.Path))
+		}
+	}
+	metas, _, err := op.GetMetas(1, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, meta := range metas {
+		if meta.Hide == "" {
+			continue
+		}
+		// the rules apply to the dir of the meta, and under it only with HSub
+		if !utils.IsSubPath(parent, meta.Path) && !(meta.HSub && utils.IsSubPath(meta.Path, parent)) {
+			continue
+		}
+		var (
+			perm   int32
+			except []string
+		)
+		for _, scope := range scopes {
+			if utils.IsSubPath(scope.Path, meta.Path) {
+				perm |= scope.Permission
+			} else if utils.IsSubPath(meta.Path, scope.Path) && HasPermission(scope.Permission, PermSeeHides) {
+				except = append(except, utils.FixAndCleanPath(scope.Path))
+			}
+		}
+		if HasPermission(perm, PermSeeHides) {
+			continue
+		}
+		// the nearest meta applies, so the dirs of the metas under it are out
+		for _, m := range metas {
+			if m.Path != meta.Path && utils.IsSubPath(meta.Path, m.Path) {
+				except = append(except, m.Path)
+			}
+		}
+		for _, rule := range strings.Split(meta.Hide, "\n") {
+			r.Hides = append(r.Hides, model.NewSearchHide(meta.Path, meta.HSub, except, rule))
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestSearchRestriction(t *testing.T) {
+	datas := []struct {
+		user   model.User
+		parent string
+		roots  []string
+	}{
+		{user: model.User{Role: model.Roles{int(roleReader)}}, parent: "/a", roots: []string{"/a/b"}},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, parent: "/a/b/c", roots: nil},
+		{user: model.User{Role: model.Roles{int(roleReader)}}, parent: "/x", roots: []string{}},
+		{user: model.User{Role: model.Roles{int(roleWriter)}}, parent: "/x", roots: nil},
+	}
+	for i, data := range datas {
+		r, err := SearchRestriction(&data.user, data.parent)
+		if err != nil {
+			t.Fatalf("TestSearchRestriction %d failed: %+v", i, err)
+		}
+		if (r.Roots == nil) != (data.roots == nil) || len(r.Roots) != len(data.roots) {
+			t.Errorf("TestSearchRestriction %d failed, got %+v", i, r.Roots)
+			continue
+		}
+		for j := range r.Roots {
+			if r.Roots[j] != data.roots[j] {
+				t.Errorf("TestSearchRestriction %d failed, got %+v", i, r.Roots)
+			}
+		}
+	}
+}
@@ -56,6 +56,10 @@ func NewMainDriver() (*FtpMainDriver, error) {
 	if err != nil && tlsRequired != ftpserver.ClearOrEncrypted {
 		return nil, fmt.Errorf("FTP mandatory TLS has been enabled, but the certificate failed to load: %w", err)
 	}
+	if err != nil && !errors.Is(err, errTLSNotProvided) {
+		// AUTH TLS would be refused, and the clients fall back to plaintext
+		utils.Log.Warnf("failed to load the FTP TLS certificate, only plaintext FTP is available: %v", err)
+	}
 	return &FtpMainDriver{
 		settings: &ftpserver.Settings{
 			ListenAddr:                conf.Conf.FTP.Listen,
@@ -135,6 +139,12 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via FTP")
 	}
+	if !common.RemoteIPAllowed(userObj, cc.RemoteAddr().String()) {
+		return nil, errors.New("the account can't be used from your IP")
+	}
+	if common.Needs2FAEnrollment(userObj) {
+		return nil, errors.New("2FA is required for the role of the account, enroll it first")
+	}
 
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, "user", userObj)
@@ -270,9 +280,11 @@ func newPortMapper(str string) ftpserver.PasvPortGetter {
 	}
 }
 
+var errTLSNotProvided = errors.New("private key or certificate is not provided")
+
 func getTlsConf(keyPath, certPath string) (*tls.Config, error) {
 	if keyPath == "" || certPath == "" {
-		return nil, errors.New("private key or certificate is not provided")
+		return nil, errTLSNotProvided
 	}
 	cert, err := os.ReadFile(certPath)
 	if err != nil {
@@ -286,5 +298,8 @@ func getTlsConf(keyPath, certPath string) (*tls.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &tls.Config{Certificates: []tls.Certificate{tlsCert}}, nil
+	return &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
 }
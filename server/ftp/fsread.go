@@ -19,6 +19,8 @@ import (
 type FileDownloadProxy struct {
 	ftpserver.FileTransfer
 	reader stream.SStreamReadAtSeeker
+	// release ends the read of the file for the overwrites waiting for it
+	release func()
 }
 
 func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
@@ -36,11 +38,13 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 
 	// directly use proxy
 	header := *(ctx.Value("proxy_header").(*http.Header))
+	release := op.AcquireRead(reqPath)
 	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{
 		IP:     ctx.Value("client_ip").(string),
 		Header: header,
 	})
 	if err != nil {
+		release()
 		return nil, err
 	}
 	fileStream := stream.FileStream{
@@ -49,14 +53,16 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 	}
 	ss, err := stream.NewSeekableStream(fileStream, link)
 	if err != nil {
+		release()
 		return nil, err
 	}
 	reader, err := stream.NewReadAtSeeker(ss, offset)
 	if err != nil {
 		_ = ss.Close()
+		release()
 		return nil, err
 	}
-	return &FileDownloadProxy{reader: reader}, nil
+	return &FileDownloadProxy{reader: reader, release: release}, nil
 }
 
 func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
@@ -77,6 +83,7 @@ func (f *FileDownloadProxy) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (f *FileDownloadProxy) Close() error {
+	defer f.release()
 	return f.reader.Close()
 }
 
@@ -0,0 +1,162 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ACLGrantReq struct {
+	Path        string `json:"path" binding:"required"`
+	SubjectType string `json:"subject_type" binding:"required"`
+	// Subject is the name of the user or the role
+	Subject    string `json:"subject" binding:"required"`
+	Permission int32  `json:"permission"`
+}
+
+type ACLEntryResp struct {
+	model.ACLEntry
+	Subject string `json:"subject"`
+}
+
+// canShare reports whether user can grant on path, the users who can write
+// it are taken as its owners
+func canShare(user *model.User, path string) bool {
+	return user.IsAdmin() || common.HasPermission(common.MergeRolePermissions(user, path), common.PermWrite)
+}
+
+func aclSubjectName(e model.ACLEntry) string {
+	switch e.SubjectType {
+	case model.ACLSubjectUser:
+		if u, err := op.GetUserById(e.SubjectID); err == nil {
+			return u.Username
+		}
+	case model.ACLSubjectRole:
+		if r, err := op.GetRole(e.SubjectID); err == nil {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// FsACLList lists the entries on the path and under it
+func FsACLList(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(c.Query("path"))
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !canShare(user, reqPath) {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	entries, err := op.GetACLEntries()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]ACLEntryResp, 0)
+	for _, e := range entries {
+		if utils.IsSubPath(reqPath, e.Path) {
+			resp = append(resp, ACLEntryResp{ACLEntry: e, Subject: aclSubjectName(e)})
+		}
+	}
+	common.SuccessResp(c, resp)
+}
+
+func FsACLGrant(c *gin.Context) {
+	var req ACLGrantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !canShare(user, reqPath) {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	// the users can only pass on the permissions they have
+	if !user.IsAdmin() && req.Permission&^common.MergeRolePermissions(user, reqPath) != 0 {
+		common.ErrorStrResp(c, "can't grant the permissions you don't have", 403)
+		return
+	}
+	if _, err := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true}); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	entry := &model.ACLEntry{
+		Path:        reqPath,
+		SubjectType: req.SubjectType,
+		Permission:  req.Permission,
+		CreatorID:   user.ID,
+	}
+	switch req.SubjectType {
+	case model.ACLSubjectUser:
+		u, err := op.GetUserByName(req.Subject)
+		if err != nil {
+			common.ErrorStrResp(c, "user not found", 404)
+			return
+		}
+		entry.SubjectID = u.ID
+	case model.ACLSubjectRole:
+		r, err := op.GetRoleByName(req.Subject)
+		if err != nil {
+			common.ErrorStrResp(c, "role not found", 404)
+			return
+		}
+		entry.SubjectID = r.ID
+	default:
+		common.ErrorStrResp(c, "subject_type must be user or role", 400)
+		return
+	}
+	err = op.GrantACL(entry, user.IsAdmin())
+	common.Audit(c, nil, model.AuditACLGrant, req.SubjectType+" "+req.Subject, reqPath, err)
+	if errors.Is(err, errs.PermissionDenied) {
+		common.ErrorStrResp(c, "the entry is made by another user", 403)
+		return
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, ACLEntryResp{ACLEntry: *entry, Subject: req.Subject})
+}
+
+// FsACLRevoke deletes an entry, the users can only delete the ones they made
+func FsACLRevoke(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	entry, err := op.GetACLEntryById(uint(id))
+	if err != nil {
+		common.ErrorStrResp(c, "acl entry not found", 404)
+		return
+	}
+	if !user.IsAdmin() && entry.CreatorID != user.ID {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	err = op.RevokeACL(entry.ID)
+	common.Audit(c, nil, model.AuditACLRevoke, entry.SubjectType+" "+aclSubjectName(*entry), entry.Path, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
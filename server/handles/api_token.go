@@ -0,0 +1,113 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// the longest validity of a token, far below the overflow of a time.Duration
+const maxAPITokenExpire = 10 * 365 * 24 * 60 * 60
+
+type CreateAPITokenReq struct {
+	Name  string `json:"name" binding:"required"`
+	Scope string `json:"scope" binding:"required"`
+	Path  string `json:"path"`
+	// seconds the token is valid, 0 for never expiring
+	Expire int64 `json:"expire"`
+}
+
+func ListMyAPITokens(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUser(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{Content: tokens, Total: total})
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req CreateAPITokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !model.ValidAPITokenScope(req.Scope) {
+		common.ErrorStrResp(c, "scope must be one of all, read and upload", 400)
+		return
+	}
+	if req.Expire < 0 {
+		common.ErrorStrResp(c, "expire can't be negative", 400)
+		return
+	}
+	if req.Expire > maxAPITokenExpire {
+		common.ErrorStrResp(c, "expire can't be longer than 10 years", 400)
+		return
+	}
+	if req.Path == "" {
+		req.Path = "/"
+	}
+	// the token can't reach more than its user
+	if _, err := user.JoinPath(req.Path); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	t := &model.APIToken{
+		UserID: user.ID,
+		Name:   req.Name,
+		Scope:  req.Scope,
+		Path:   req.Path,
+	}
+	if req.Expire > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.Expire) * time.Second)
+		t.ExpiresAt = &expiresAt
+	}
+	token, err := op.CreateAPIToken(t)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"token": token,
+		"info":  t,
+	})
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	ok, err := op.DeleteAPIToken(user.ID, uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !ok {
+		common.ErrorStrResp(c, "api token not found", 404)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -245,6 +245,9 @@ type ArchiveDecompressReq struct {
 	InnerPath     string        `json:"inner_path" form:"inner_path"`
 	CacheFull     bool          `json:"cache_full" form:"cache_full"`
 	PutIntoNewDir bool          `json:"put_into_new_dir" form:"put_into_new_dir"`
+	// ConflictPolicy is overwrite or skip for the files already in DstDir,
+	// overwrite by default
+	ConflictPolicy string `json:"conflict_policy" form:"conflict_policy"`
 }
 
 func FsArchiveDecompress(c *gin.Context) {
@@ -253,6 +256,13 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if req.ConflictPolicy == "" {
+		req.ConflictPolicy = OVERWRITE
+	}
+	if req.ConflictPolicy != OVERWRITE && req.ConflictPolicy != SKIP {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [conflict_policy]: %s", req.ConflictPolicy), 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
@@ -302,6 +312,7 @@ func FsArchiveDecompress(c *gin.Context) {
 			},
 			CacheFull:     req.CacheFull,
 			PutIntoNewDir: req.PutIntoNewDir,
+			SkipExisting:  req.ConflictPolicy == SKIP,
 		})
 		if e != nil {
 			if errors.Is(e, errs.WrongArchivePassword) {
@@ -382,7 +393,7 @@ func ArchiveProxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
+		localProxy(c, link, file, storage.GetStorage().Proxy)
 	} else {
 		common.ErrorStrResp(c, "proxy not allowed", 403)
 		return
@@ -433,3 +444,74 @@ func ArchiveExtensions(c *gin.Context) {
 	}
 	common.SuccessResp(c, ext)
 }
+
+type ArchiveVerifyReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+// FsArchiveVerify checks the integrity of an archive before it is decompressed.
+// Small archives are verified within the request, larger ones by a task.
+func FsArchiveVerify(c *gin.Context) {
+	var req ArchiveVerifyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if !common.HasPermission(perm, common.PermReadArchives) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() || !fs.IsVerifiableArchive(obj.GetName()) {
+		common.ErrorStrResp(c, "only zip and tar.gz archives can be verified", 400)
+		return
+	}
+	if obj.GetSize() > fs.ArchiveVerifySyncSize {
+		t := &fs.ArchiveVerifyTask{
+			TaskExtension: task.TaskExtension{Creator: user},
+			Status:        "queued",
+			Path:          reqPath,
+		}
+		fs.ArchiveVerifyTaskManager.Add(t)
+		common.SuccessResp(c, gin.H{
+			"task": getTaskInfo(t),
+		})
+		return
+	}
+	res, err := fs.VerifyArchive(c, reqPath, func(float64) {})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"result": res,
+	})
+}
@@ -0,0 +1,152 @@
+package handles
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type FsActivityReq struct {
+	model.PageReq
+	Path string `json:"path" form:"path"`
+	// Username defaults to the current user, admins may leave it empty to query all users
+	Username string     `json:"username" form:"username"`
+	Actions  []string   `json:"actions" form:"actions"`
+	From     *time.Time `json:"from" form:"from"`
+	To       *time.Time `json:"to" form:"to"`
+}
+
+// FsActivity lists the objects under a path created or modified by a user
+func FsActivity(c *gin.Context) {
+	var req FsActivityReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	q := model.AuditLogQuery{
+		Parent:  reqPath,
+		Actions: req.Actions,
+		From:    req.From,
+		To:      req.To,
+		PageReq: req.PageReq,
+	}
+	switch {
+	case req.Username == "" && user.IsAdmin():
+	case req.Username == "" || req.Username == user.Username:
+		q.UserID = user.ID
+	case user.IsAdmin():
+		target, err := op.GetUserByName(req.Username)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		q.UserID = target.ID
+	default:
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	logs, total, err := db.GetAuditLogs(q)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+type AuditLogReq struct {
+	model.PageReq
+	Path       string     `json:"path" form:"path"`
+	Username   string     `json:"username" form:"username"`
+	Actions    []string   `json:"actions" form:"actions"`
+	From       *time.Time `json:"from" form:"from"`
+	To         *time.Time `json:"to" form:"to"`
+	FailedOnly bool       `json:"failed_only" form:"failed_only"`
+}
+
+func (r *AuditLogReq) query() model.AuditLogQuery {
+	return model.AuditLogQuery{
+		Parent:     utils.FixAndCleanPath(r.Path),
+		Username:   r.Username,
+		Actions:    r.Actions,
+		From:       r.From,
+		To:         r.To,
+		WithFailed: true,
+		FailedOnly: r.FailedOnly,
+		PageReq:    r.PageReq,
+	}
+}
+
+// ListAuditLogs lists the audit logs of all the users, failed or not
+func ListAuditLogs(c *gin.Context) {
+	var req AuditLogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := db.GetAuditLogs(req.query())
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// the logs are exported by batches not to load them all at once
+const auditExportBatch = 500
+
+// ExportAuditLogs writes the audit logs matching the filters as JSON lines
+func ExportAuditLogs(c *gin.Context) {
+	var req AuditLogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	q := req.query()
+	q.PerPage = auditExportBatch
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	enc := json.NewEncoder(c.Writer)
+	for q.Page = 1; ; q.Page++ {
+		logs, _, err := db.GetAuditLogs(q)
+		if err != nil {
+			// the status is sent with the first line, the error can only be logged
+			log.Errorf("failed export audit logs: %+v", err)
+			return
+		}
+		for i := range logs {
+			if err := enc.Encode(&logs[i]); err != nil {
+				return
+			}
+		}
+		if len(logs) < auditExportBatch {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
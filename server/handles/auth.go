@@ -15,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/device"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/notify"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/session"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -70,24 +71,30 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	// check username
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
+		common.Audit(c, &model.User{Username: req.Username}, model.AuditLogin, "password", "", errs.WrongPassword)
 		common.ErrorStrResp(c, invalidLoginCredentialsMsg, 400)
-		loginCache.Set(ip, count+1)
+		loginFailed(ip, req.Username, count)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
+		common.Audit(c, user, model.AuditLogin, "password", "", err)
 		common.ErrorStrResp(c, invalidLoginCredentialsMsg, 400)
-		loginCache.Set(ip, count+1)
+		loginFailed(ip, req.Username, count)
 		return
 	}
 	// check 2FA
 	if user.OtpSecret != "" {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
+			common.Audit(c, user, model.AuditLogin, "password", "", errors.New("invalid 2FA code"))
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
-			loginCache.Set(ip, count+1)
+			loginFailed(ip, req.Username, count)
 			return
 		}
 	}
+	if !loginIPAllowed(c, user, "password") {
+		return
+	}
 
 	clientID := c.GetHeader("Client-Id")
 	if clientID == "" {
@@ -111,8 +118,87 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
-	common.SuccessResp(c, gin.H{"token": token, "device_key": key})
+	common.Audit(c, user, model.AuditLogin, "password", "", nil)
+	resp := gin.H{"token": token, "device_key": key}
+	// the client is to lead the user to enroll before anything else
+	if common.Needs2FAEnrollment(user) {
+		resp["require_2fa_enroll"] = true
+	}
+	common.SuccessResp(c, resp)
+	loginCache.Del(ip)
+}
+
+// loginFailed counts a failed sign-in from ip, reaching the limit is notified
+// as it may be a brute force
+func loginFailed(ip, username string, count int) {
+	loginCache.Set(ip, count+1)
+	if count+1 == defaultTimes {
+		notify.Notify(notify.LoginAnomaly, "Too many failed sign-in attempts",
+			fmt.Sprintf("%s is locked out after %d failed sign-in attempts, the last one as %s", ip, defaultTimes, username))
+	}
+}
+
+// loginIPAllowed responds if user can't sign in from the client ip, the
+// attempt is audited and notified
+func loginIPAllowed(c *gin.Context, user *model.User, method string) bool {
+	if ipAllowed(c, user, method) {
+		return true
+	}
+	common.ErrorStrResp(c, "The account can't be used from your IP", 403)
+	return false
+}
+
+func ipAllowed(c *gin.Context, user *model.User, method string) bool {
+	ip := c.ClientIP()
+	if common.IPAllowed(user, ip) {
+		return true
+	}
+	common.Audit(c, user, model.AuditLogin, method, "", errors.New("ip not allowed"))
+	notify.Notify(notify.LoginAnomaly, "Sign-in from a disallowed IP",
+		fmt.Sprintf("%s tried to sign in from %s, which isn't in the allowed IPs", user.Username, ip))
+	return false
+}
+
+// BasicLogin signs in with the password given on every request of an api, as
+// the basic auth of /rc. The failures count towards the limit of the sign-ins,
+// and the users with 2FA are refused as the code can't be given. The status
+// to respond is returned with the error
+func BasicLogin(c *gin.Context, username, password, method string) (*model.User, int, error) {
+	ip := c.ClientIP()
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		loginCache.Expire(ip, defaultDuration)
+		return nil, 429, errors.New("too many unsuccessful sign-in attempts, try again later")
+	}
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		common.Audit(c, &model.User{Username: username}, model.AuditLogin, method, "", errs.WrongPassword)
+		loginFailed(ip, username, count)
+		return nil, 401, errors.New(invalidLoginCredentialsMsg)
+	}
+	if err := user.ValidateRawPassword(password); err != nil {
+		common.Audit(c, user, model.AuditLogin, method, "", err)
+		loginFailed(ip, username, count)
+		return nil, 401, errors.New(invalidLoginCredentialsMsg)
+	}
+	if user.Disabled {
+		err := errors.New("current user is disabled")
+		common.Audit(c, user, model.AuditLogin, method, "", err)
+		return nil, 401, err
+	}
+	if user.OtpSecret != "" {
+		err := errors.New("the account has 2FA, use an api token instead of the password")
+		common.Audit(c, user, model.AuditLogin, method, "", err)
+		return nil, 401, err
+	}
+	if !ipAllowed(c, user, method) {
+		return nil, 403, errors.New("the account can't be used from your IP")
+	}
+	if common.Needs2FAEnrollment(user) {
+		return nil, 403, errors.New("2FA is required for your role, enroll it first")
+	}
 	loginCache.Del(ip)
+	return user, 200, nil
 }
 
 type RegisterReq struct {
@@ -147,6 +233,7 @@ func Register(c *gin.Context) {
 type UserResp struct {
 	model.User
 	Otp         bool                    `json:"otp"`
+	Require2FA  bool                    `json:"require_2fa_enroll"`
 	RoleNames   []string                `json:"role_names"`
 	Permissions []model.PermissionEntry `json:"permissions"`
 }
@@ -163,6 +250,7 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	userResp.Require2FA = common.Needs2FAEnrollment(user)
 
 	var roleNames []string
 	permMap := map[string]int32{}
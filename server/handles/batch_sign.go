@@ -0,0 +1,108 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxBatchSignItems = 1000
+	// the longest lifetime of a link given, far below the overflow of a
+	// time.Duration
+	maxBatchSignExpire = 365 * 24 * 60 * 60
+)
+
+type BatchSignItem struct {
+	Path string `json:"path"`
+	// Expire is the lifetime of the link in seconds, 0 for the link_expiration setting
+	Expire int64 `json:"expire"`
+}
+
+type BatchSignReq struct {
+	Items    []BatchSignItem `json:"items"`
+	Password string          `json:"password"`
+}
+
+type BatchSignResp struct {
+	Path string `json:"path"`
+	URL  string `json:"url,omitempty"`
+	Sign string `json:"sign,omitempty"`
+	// Error is the reason the path was skipped
+	Error string `json:"error,omitempty"`
+}
+
+// FsBatchSign signs the download links of many files at once, the files the
+// user can't read are skipped with the reason instead of failing the request
+func FsBatchSign(c *gin.Context) {
+	var req BatchSignReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Items) > maxBatchSignItems {
+		common.ErrorStrResp(c, fmt.Sprintf("items can't > %d", maxBatchSignItems), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	apiUrl := common.GetApiUrl(c.Request)
+	resp := make([]BatchSignResp, 0, len(req.Items))
+	for _, item := range req.Items {
+		res := BatchSignResp{Path: item.Path}
+		reqPath, _, s, err := batchSignItem(c, user, item, req.Password)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Sign = s
+			res.URL = fmt.Sprintf("%s/d%s?sign=%s", apiUrl, utils.EncodePath(reqPath, true), s)
+		}
+		resp = append(resp, res)
+	}
+	common.SuccessResp(c, resp)
+}
+
+func batchSignItem(c *gin.Context, user *model.User, item BatchSignItem, password string) (string, model.Obj, string, error) {
+	if item.Expire < 0 || item.Expire > maxBatchSignExpire {
+		return "", nil, "", errors.Errorf("expire must be between 0 and %d", maxBatchSignExpire)
+	}
+	// the links can't outlive the ones signed by default
+	if hours := setting.GetInt(conf.LinkExpiration, 0); hours > 0 && item.Expire > int64(hours)*60*60 {
+		return "", nil, "", errors.Errorf("expire can't be longer than the link expiration of %d hours", hours)
+	}
+	reqPath, err := user.JoinPath(item.Path)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		return "", nil, "", errs.PermissionDenied
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", nil, "", err
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, password) {
+		return "", nil, "", errors.New("password is incorrect or you have no permission")
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return "", nil, "", err
+	}
+	if obj.IsDir() {
+		return "", nil, "", errs.NotFile
+	}
+	if item.Expire == 0 {
+		return reqPath, obj, sign.Sign(reqPath), nil
+	}
+	return reqPath, obj, sign.WithDuration(reqPath, time.Duration(item.Expire)*time.Second), nil
+}
@@ -0,0 +1,117 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/schedule"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListCopyJobs(c *gin.Context) {
+	jobs, err := db.GetCopyJobs()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, jobs)
+}
+
+func CreateCopyJob(c *gin.Context) {
+	var req model.CopyJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID, req.LastRunAt = 0, nil
+	if err := schedule.Check(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.CreateCopyJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateCopyJob(c *gin.Context) {
+	var req model.CopyJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	old, err := db.GetCopyJobById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err = schedule.Check(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.LastRunAt, req.CreatedAt = old.LastRunAt, old.CreatedAt
+	if err = db.UpdateCopyJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteCopyJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = db.DeleteCopyJobById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunCopyJob runs a job now, out of its schedule
+func RunCopyJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	job, err := db.GetCopyJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	run, err := schedule.Run(job, true)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, run)
+}
+
+type ListCopyJobRunsReq struct {
+	model.PageReq
+	ID uint `json:"id" form:"id"`
+}
+
+func ListCopyJobRuns(c *gin.Context) {
+	var req ListCopyJobRunsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	runs, total, err := db.GetCopyJobRuns(req.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: runs,
+		Total:   total,
+	})
+}
@@ -0,0 +1,86 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/xhofe/tache"
+)
+
+type DedupScanReq struct {
+	Paths []string `json:"paths"`
+	// MinSize skips the smaller files, in bytes
+	MinSize int64 `json:"min_size"`
+}
+
+// DedupScan adds a task finding the duplicate files under the paths, the sets
+// are got from DedupSets once it succeeded
+func DedupScan(c *gin.Context) {
+	var req DedupScanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	paths := utils.MustSliceConvert(req.Paths, utils.FixAndCleanPath)
+	t, err := fs.DedupAsTask(c, paths, req.MinSize)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+func DedupSets(c *gin.Context) {
+	t, ok := fs.DedupTaskManager.GetByID(c.Query("tid"))
+	if !ok {
+		common.ErrorStrResp(c, "task not found", 404)
+		return
+	}
+	if t.GetState() != tache.StateSucceeded {
+		common.ErrorStrResp(c, "the scan is not done", 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"scanned": t.Scanned,
+		"sets":    t.Sets,
+	})
+}
+
+type DedupApplyReq struct {
+	TaskID string `json:"tid"`
+	// Set is the index of the set in the sets of the task
+	Set  int    `json:"set"`
+	Keep string `json:"keep"`
+	// Action is remove or link
+	Action string `json:"action"`
+}
+
+// DedupApply removes or hard links the files of a set found by a scan except
+// the one kept
+func DedupApply(c *gin.Context) {
+	var req DedupApplyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t, ok := fs.DedupTaskManager.GetByID(req.TaskID)
+	if !ok {
+		common.ErrorStrResp(c, "task not found", 404)
+		return
+	}
+	if t.GetState() != tache.StateSucceeded || req.Set < 0 || req.Set >= len(t.Sets) {
+		common.ErrorStrResp(c, "set not found", 404)
+		return
+	}
+	done, err := fs.DedupSet(c, t.Sets[req.Set], req.Keep, req.Action)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"done": done,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
@@ -33,6 +34,9 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	} else {
+		if convertedPreview(c, rawPath) {
+			return
+		}
 		link, _, err := fs.Link(c, rawPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
@@ -58,6 +62,8 @@ func Proxy(c *gin.Context) {
 	}
 	if c.Query("type") == "preview" && storage.GetStorage().Driver == "DoubaoNew" {
 		// Force proxy for DoubaoNew preview so headers are preserved.
+		release := op.AcquireRead(rawPath)
+		defer release()
 		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			Header:  c.Request.Header,
 			Type:    c.Query("type"),
@@ -67,7 +73,7 @@ func Proxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
+		localProxy(c, link, file, storage.GetStorage().Proxy)
 		return
 	}
 	if canProxy(storage, filename) {
@@ -80,6 +86,11 @@ func Proxy(c *gin.Context) {
 				return
 			}
 		}
+		if convertedPreview(c, rawPath) {
+			return
+		}
+		release := op.AcquireRead(rawPath)
+		defer release()
 		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			Header:  c.Request.Header,
 			Type:    c.Query("type"),
@@ -89,7 +100,7 @@ func Proxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
+		localProxy(c, link, file, storage.GetStorage().Proxy)
 	} else {
 		common.ErrorStrResp(c, "proxy not allowed", 403)
 		return
@@ -122,7 +133,7 @@ func down(c *gin.Context, link *model.Link) {
 	c.Redirect(302, link.URL)
 }
 
-func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange bool) {
+func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxy model.Proxy) {
 	var err error
 	if link.URL != "" && setting.GetBool(conf.ForwardDirectLinkParams) {
 		query := c.Request.URL.Query()
@@ -135,9 +146,10 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 			return
 		}
 	}
-	if proxyRange {
+	if proxy.ProxyRange {
 		common.ProxyRange(link, file.GetSize())
 	}
+	common.ReadAhead(link, file.GetSize(), proxy.ReadAheadSize, proxy.ReadAheadDepth)
 	Writer := &common.WrittenResponseWriter{ResponseWriter: c.Writer}
 
 	//优先处理md文件
@@ -165,6 +177,8 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 				}
 			}
 		}
+	} else if encoding, decodedName, ok := common.GetContentEncoding(file.GetName()); ok && setting.GetBool(conf.ProxyContentEncoding) {
+		err = common.ProxyEncoded(Writer, c.Request, link, file, encoding, decodedName)
 	} else {
 		err = common.Proxy(Writer, c.Request, link, file)
 	}
@@ -178,6 +192,38 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 	}
 }
 
+// convertedPreview serves the preview of a format browsers can't display
+// converted to an image, it returns false to fall back to the original file
+func convertedPreview(c *gin.Context, rawPath string) bool {
+	if c.Query("type") != "preview" || !common.ShouldConvertPreview(rawPath) {
+		return false
+	}
+	release := op.AcquireRead(rawPath)
+	defer release()
+	link, file, err := fs.Link(c, rawPath, model.LinkArgs{
+		Header:  c.Request.Header,
+		HttpReq: c.Request,
+	})
+	if err != nil {
+		return false
+	}
+	defer func() {
+		if link.MFile != nil {
+			_ = link.MFile.Close()
+		}
+		if link.RangeReadCloser != nil {
+			_ = link.RangeReadCloser.Close()
+		}
+	}()
+	p, err := common.ConvertPreview(c, link, file, rawPath)
+	if err != nil {
+		log.Warnf("preview conversion of %s failed, serving the original: %+v", rawPath, err)
+		return false
+	}
+	c.File(p)
+	return true
+}
+
 // TODO need optimize
 // when can be proxy?
 // 1. text file
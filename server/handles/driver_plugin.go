@@ -0,0 +1,73 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/plugin"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListDriverPlugins(c *gin.Context) {
+	plugins, err := plugin.List()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, plugins)
+}
+
+func CreateDriverPlugin(c *gin.Context) {
+	var req model.DriverPlugin
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	// the plugin is saved even if it fails to load, to be fixed and reloaded
+	if err := plugin.Create(c.Request.Context(), &req); err != nil {
+		common.ErrorWithDataResp(c, err, 500, req)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateDriverPlugin(c *gin.Context) {
+	var req model.DriverPlugin
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := plugin.Update(c.Request.Context(), &req); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteDriverPlugin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := plugin.Delete(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ReloadDriverPlugin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := plugin.Reload(c.Request.Context(), uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
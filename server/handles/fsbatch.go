@@ -20,6 +20,8 @@ type RecursiveMoveReq struct {
 	SrcDir         string `json:"src_dir"`
 	DstDir         string `json:"dst_dir"`
 	ConflictPolicy string `json:"conflict_policy"`
+	// IncludeAll moves the objs matching recursive_exclude too
+	IncludeAll bool `json:"include_all"`
 }
 
 func FsRecursiveMove(c *gin.Context) {
@@ -82,6 +84,7 @@ func FsRecursiveMove(c *gin.Context) {
 		}
 	}
 
+	excluded := 0
 	// record the file path
 	filePathMap := make(map[model.Obj]string)
 	movingFiles := generic.NewQueue[model.Obj]()
@@ -95,6 +98,10 @@ func FsRecursiveMove(c *gin.Context) {
 
 		movingFile := movingFiles.Pop()
 		movingFilePath := filePathMap[movingFile]
+		if !req.IncludeAll && fs.IsExcluded(movingFile.GetName()) {
+			excluded++
+			continue
+		}
 		movingFileName := fmt.Sprintf("%s/%s", movingFilePath, movingFile.GetName())
 		if movingFile.IsDir() {
 			// directory, recursive move
@@ -141,15 +148,21 @@ func FsRecursiveMove(c *gin.Context) {
 		count++
 	}
 
-	common.SuccessWithMsgResp(c, fmt.Sprintf("Successfully moved %d %s", count, common.Pluralize(count, "file", "files")))
+	msg := fmt.Sprintf("Successfully moved %d %s", count, common.Pluralize(count, "file", "files"))
+	if excluded > 0 {
+		msg += fmt.Sprintf(", excluded %d %s", excluded, common.Pluralize(excluded, "object", "objects"))
+	}
+	common.SuccessWithMsgResp(c, msg)
+}
+
+type RenameObject struct {
+	SrcName string `json:"src_name"`
+	NewName string `json:"new_name"`
 }
 
 type BatchRenameReq struct {
-	SrcDir        string `json:"src_dir"`
-	RenameObjects []struct {
-		SrcName string `json:"src_name"`
-		NewName string `json:"new_name"`
-	} `json:"rename_objects"`
+	SrcDir        string         `json:"src_dir"`
+	RenameObjects []RenameObject `json:"rename_objects"`
 }
 
 func FsBatchRename(c *gin.Context) {
@@ -206,6 +219,43 @@ func FsBatchRename(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+// regexRenames computes the renames of the names of a dir matching re. All of
+// them are checked before any is renamed, so a bad template fails the whole
+// batch. The renames are done one after another and a storage may replace an
+// existing obj on rename, so a new name can't be the name of any obj of the
+// dir, even one renamed too, which refuses the chains and the swaps.
+func regexRenames(names []string, nameKey func(string) string, re *regexp.Regexp, replacement string) ([]RenameObject, error) {
+	current := make(map[string]string, len(names))
+	for _, name := range names {
+		current[nameKey(name)] = name
+	}
+	taken := make(map[string]string)
+	var renames []RenameObject
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		newName := re.ReplaceAllString(name, replacement)
+		if newName == name {
+			continue
+		}
+		if err := utils.ValidateNameComponent(newName); err != nil {
+			return nil, errors.WithMessagef(err, "[%s] would be renamed to [%s]", name, newName)
+		}
+		key := nameKey(newName)
+		// a name differing only in what the storage ignores is the obj itself
+		if other, ok := current[key]; ok && other != name {
+			return nil, errors.Errorf("[%s] would be renamed to [%s] which is taken by [%s]", name, newName, other)
+		}
+		if other, ok := taken[key]; ok {
+			return nil, errors.Errorf("[%s] would be renamed to [%s] which is taken by [%s]", name, newName, other)
+		}
+		taken[key] = name
+		renames = append(renames, RenameObject{SrcName: name, NewName: newName})
+	}
+	return renames, nil
+}
+
 type RegexRenameReq struct {
 	SrcDir       string `json:"src_dir"`
 	SrcNameRegex string `json:"src_name_regex"`
@@ -246,7 +296,7 @@ func FsRegexRename(c *gin.Context) {
 
 	srcRegexp, err := regexp.Compile(req.SrcNameRegex)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, errors.WithMessage(err, "invalid field [src_name_regex]"), 400)
 		return
 	}
 
@@ -255,29 +305,35 @@ func FsRegexRename(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	names := make([]string, 0, len(files))
 	for _, file := range files {
-
-		if srcRegexp.MatchString(file.GetName()) {
-			filePath, err := utils.JoinUnderBase(reqPath, file.GetName())
-			if err != nil {
-				common.ErrorResp(c, err, 500)
-				return
-			}
-			if !canRenamePath(c, filePath) {
-				return
-			}
-			newFileName := srcRegexp.ReplaceAllString(file.GetName(), req.NewNameRegex)
-			if err := utils.ValidateNameComponent(newFileName); err != nil {
-				common.ErrorResp(c, err, 400)
-				return
-			}
-			if err := fs.Rename(c, filePath, newFileName); err != nil {
-				common.ErrorResp(c, err, 500)
-				return
-			}
+		names = append(names, file.GetName())
+	}
+	renames, err := regexRenames(names, storage.GetStorage().NameKey, srcRegexp, req.NewNameRegex)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	filePaths := make([]string, len(renames))
+	for i, r := range renames {
+		if filePaths[i], err = utils.JoinUnderBase(reqPath, r.SrcName); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		if !canRenamePath(c, filePaths[i]) {
+			return
+		}
+	}
+	for i, r := range renames {
+		if err := fs.Rename(c, filePaths[i], r.NewName); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
 		}
-
 	}
 
 	common.SuccessResp(c)
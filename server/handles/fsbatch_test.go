@@ -0,0 +1,121 @@
+package handles
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestRegexRenames(t *testing.T) {
+	exact := (&model.Storage{}).NameKey
+	caseless := (&model.Storage{NameMatch: "case"}).NameKey
+	datas := []struct {
+		names       []string
+		nameKey     func(string) string
+		pattern     string
+		replacement string
+		result      []RenameObject
+		isErr       bool
+	}{
+		{
+			names:       []string{"Show.S01E01.mkv", "Show.S01E02.mkv", "notes.txt"},
+			nameKey:     exact,
+			pattern:     `^Show\.S(\d+)E(\d+)\.mkv$`,
+			replacement: "Show - ${1}x${2}.mkv",
+			result: []RenameObject{
+				{SrcName: "Show.S01E01.mkv", NewName: "Show - 01x01.mkv"},
+				{SrcName: "Show.S01E02.mkv", NewName: "Show - 01x02.mkv"},
+			},
+		},
+		{
+			names:       []string{"a.txt", "b.txt"},
+			nameKey:     exact,
+			pattern:     `^(a|b)`,
+			replacement: "${1}b",
+			result: []RenameObject{
+				{SrcName: "a.txt", NewName: "ab.txt"},
+				{SrcName: "b.txt", NewName: "bb.txt"},
+			},
+		},
+		{
+			names:       []string{"a.txt", "b.txt"},
+			nameKey:     exact,
+			pattern:     `^a`,
+			replacement: "b",
+			isErr:       true,
+		},
+		// a chain would overwrite ab before it is renamed
+		{
+			names:       []string{"a", "ab"},
+			nameKey:     exact,
+			pattern:     `^a`,
+			replacement: "ab",
+			isErr:       true,
+		},
+		{
+			names:       []string{"x-y", "y-x"},
+			nameKey:     exact,
+			pattern:     `^(\w)-(\w)$`,
+			replacement: "${2}-${1}",
+			isErr:       true,
+		},
+		{
+			names:       []string{"a.txt"},
+			nameKey:     caseless,
+			pattern:     `^a`,
+			replacement: "A",
+			result:      []RenameObject{{SrcName: "a.txt", NewName: "A.txt"}},
+		},
+		{
+			names:       []string{"a1.txt", "a2.txt"},
+			nameKey:     exact,
+			pattern:     `\d`,
+			replacement: "",
+			isErr:       true,
+		},
+		{
+			names:       []string{"a.txt", "B.txt"},
+			nameKey:     exact,
+			pattern:     `^a`,
+			replacement: "b",
+			result:      []RenameObject{{SrcName: "a.txt", NewName: "b.txt"}},
+		},
+		{
+			names:       []string{"a.txt", "B.txt"},
+			nameKey:     caseless,
+			pattern:     `^a`,
+			replacement: "b",
+			isErr:       true,
+		},
+		{
+			names:       []string{"a.txt"},
+			nameKey:     exact,
+			pattern:     `^a`,
+			replacement: "../a",
+			isErr:       true,
+		},
+		{
+			names:       []string{"a.txt"},
+			nameKey:     exact,
+			pattern:     `^c`,
+			replacement: "d",
+		},
+	}
+	for i, data := range datas {
+		renames, err := regexRenames(data.names, data.nameKey, regexp.MustCompile(data.pattern), data.replacement)
+		if (err != nil) != data.isErr {
+			t.Errorf("TestRegexRenames %d failed: %+v", i, err)
+			continue
+		}
+		if len(renames) != len(data.result) {
+			t.Errorf("TestRegexRenames %d failed, got %+v", i, renames)
+			continue
+		}
+		for j := range renames {
+			if renames[j] != data.result[j] {
+				t.Errorf("TestRegexRenames %d failed, got %+v", i, renames)
+			}
+		}
+	}
+}
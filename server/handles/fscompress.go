@@ -0,0 +1,114 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type CompressReq struct {
+	SrcDir string   `json:"src_dir"`
+	Names  []string `json:"names"`
+	DstDir string   `json:"dst_dir"`
+	// Name is the name of the archive, the name of the single obj or of SrcDir
+	// followed by the extension of Format if empty
+	Name string `json:"name"`
+	// Format is zip or tar.gz, zip by default
+	Format    string `json:"format"`
+	Password  string `json:"password"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// FsCompress adds a task archiving some objs of a dir into another dir
+func FsCompress(c *gin.Context) {
+	var req CompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = fs.CompressZip
+	}
+	if req.Format != fs.CompressZip && req.Format != fs.CompressTarGz {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [format]: %s", req.Format), 400)
+		return
+	}
+	if !checkPathField(c, "src_dir", req.SrcDir) || !checkPathField(c, "dst_dir", req.DstDir) ||
+		!checkNamesField(c, "names", req.Names) {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if req.Name == "" {
+		req.Name = stdpath.Base(srcDir)
+		if len(req.Names) == 1 {
+			req.Name = req.Names[0]
+		}
+		req.Name += "." + req.Format
+	}
+	if !checkNameField(c, "name", req.Name) {
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, srcDir) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcMeta, err := op.GetNearestMeta(srcDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, srcMeta, srcDir, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, srcDir), common.PermCopy) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, dstDir), common.PermWrite) {
+		dstMeta, err := op.GetNearestMeta(dstDir)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(dstMeta, dstDir) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	if !req.Overwrite {
+		if res, _ := fs.Get(c, stdpath.Join(dstDir, req.Name), &fs.GetArgs{NoLog: true, Collision: true}); res != nil {
+			common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
+			return
+		}
+	}
+	t, err := fs.CompressAsTask(c, srcDir, req.Password, req.Names, dstDir, req.Name, req.Format)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
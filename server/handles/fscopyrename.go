@@ -0,0 +1,173 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type CopyRenameReq struct {
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+	// Mapping is the new name of each copied obj by its name in SrcDir
+	Mapping map[string]string `json:"mapping"`
+	// Names are the objs copied, all the objs of SrcDir if empty
+	Names []string `json:"names"`
+	// SkipUnmapped skips the objs without a new name instead of copying them as is
+	SkipUnmapped bool `json:"skip_unmapped"`
+	// ConflictPolicy is cancel, skip or overwrite, cancel by default
+	ConflictPolicy string `json:"conflict_policy"`
+}
+
+type CopyRenameResult struct {
+	Name    string `json:"name"`
+	DstName string `json:"dst_name"`
+	// Status is copied, queued, skipped or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FsCopyRename copies objs of a dir writing each under the name given by a mapping
+func FsCopyRename(c *gin.Context) {
+	var req CopyRenameReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.ConflictPolicy == "" {
+		req.ConflictPolicy = CANCEL
+	}
+	if req.ConflictPolicy != CANCEL && req.ConflictPolicy != SKIP && req.ConflictPolicy != OVERWRITE {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [conflict_policy]: %s", req.ConflictPolicy), 400)
+		return
+	}
+	if !checkPathField(c, "src_dir", req.SrcDir) || !checkPathField(c, "dst_dir", req.DstDir) {
+		return
+	}
+	if len(req.Mapping) == 0 {
+		common.ErrorStrResp(c, "invalid field [mapping]: empty", 400)
+		return
+	}
+	for name, dstName := range req.Mapping {
+		if !checkNameField(c, "mapping", name) || !checkNameField(c, fmt.Sprintf("mapping[%s]", name), dstName) {
+			return
+		}
+	}
+	if len(req.Names) > 0 && !checkNamesField(c, "names", req.Names) {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, srcDir) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, srcDir), common.PermCopy) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, dstDir), common.PermWrite) {
+		meta, err := op.GetNearestMeta(dstDir)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(meta, dstDir) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	names := req.Names
+	if len(names) == 0 {
+		meta, _ := op.GetNearestMeta(srcDir)
+		objs, err := fs.List(context.WithValue(c, "meta", meta), srcDir, &fs.ListArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		for _, obj := range objs {
+			names = append(names, obj.GetName())
+		}
+		sort.Strings(names)
+	}
+	results := make([]CopyRenameResult, 0, len(names))
+	// the new names claimed by this request, two objs mapped to one name collide
+	claimed := make(map[string]string)
+	var toCopy []int
+	for _, name := range names {
+		res := CopyRenameResult{Name: name, DstName: name}
+		dstName, mapped := req.Mapping[name]
+		if mapped {
+			res.DstName = dstName
+		} else if req.SkipUnmapped {
+			res.Status, res.Error = "skipped", "not mapped"
+			results = append(results, res)
+			continue
+		}
+		if other, ok := claimed[res.DstName]; ok {
+			if req.ConflictPolicy == CANCEL {
+				common.ErrorStrResp(c, fmt.Sprintf("[%s] and [%s] are both copied as [%s]", other, name, res.DstName), 403)
+				return
+			}
+			res.Status, res.Error = "skipped", fmt.Sprintf("[%s] is copied as the same name", other)
+			results = append(results, res)
+			continue
+		}
+		claimed[res.DstName] = name
+		if req.ConflictPolicy != OVERWRITE {
+			if obj, _ := fs.Get(c, stdpath.Join(dstDir, res.DstName), &fs.GetArgs{NoLog: true, Collision: true}); obj != nil {
+				if req.ConflictPolicy == CANCEL {
+					common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", obj.GetName()), 403)
+					return
+				}
+				res.Status, res.Error = "skipped", "exists in the destination"
+				results = append(results, res)
+				continue
+			}
+		}
+		toCopy = append(toCopy, len(results))
+		results = append(results, res)
+	}
+	var addedTasks []task.TaskExtensionInfo
+	for i, idx := range toCopy {
+		res := &results[idx]
+		t, err := fs.CopyAs(c, stdpath.Join(srcDir, res.Name), dstDir, res.DstName, len(toCopy) > i+1)
+		switch {
+		case err != nil:
+			res.Status, res.Error = "failed", err.Error()
+		case t != nil:
+			res.Status = "queued"
+			addedTasks = append(addedTasks, t)
+		default:
+			res.Status = "copied"
+		}
+	}
+	if len(addedTasks) == 0 && len(toCopy) > 0 {
+		refreshAfterWrite(c, dstDir)
+	}
+	common.SuccessResp(c, gin.H{
+		"results": results,
+		"tasks":   getTaskInfos(addedTasks),
+	})
+}
@@ -0,0 +1,46 @@
+package handles
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// checkPathField responds with a 400 naming the field if a required path is empty
+// and the empty_path_policy is reject, an empty path is the base path of the user
+// otherwise. It returns false if the request has been responded.
+func checkPathField(c *gin.Context, field, value string) bool {
+	if strings.TrimSpace(value) != "" || setting.GetStr(conf.EmptyPathPolicy) != "reject" {
+		return true
+	}
+	common.ErrorStrResp(c, fmt.Sprintf("invalid field [%s]: required", field), 400)
+	return false
+}
+
+// checkNameField responds with a 400 naming the field and why if name isn't a
+// single path component
+func checkNameField(c *gin.Context, field, name string) bool {
+	if err := utils.ValidateNameComponent(name); err != nil {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [%s]: %v", field, err), 400)
+		return false
+	}
+	return true
+}
+
+func checkNamesField(c *gin.Context, field string, names []string) bool {
+	if len(names) == 0 {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [%s]: empty file names", field), 400)
+		return false
+	}
+	for i, name := range names {
+		if !checkNameField(c, fmt.Sprintf("%s[%d]", field, i), name) {
+			return false
+		}
+	}
+	return true
+}
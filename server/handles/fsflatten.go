@@ -0,0 +1,134 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FlattenReq struct {
+	Path string `json:"path"`
+	// ConflictPolicy is cancel, skip or overwrite, cancel by default
+	ConflictPolicy string `json:"conflict_policy"`
+}
+
+type FlattenResult struct {
+	Name string `json:"name"`
+	// Status is moved, skipped or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FsFlatten moves the children of a folder into its parent, then removes the
+// folder if it has been emptied
+func FsFlatten(c *gin.Context) {
+	var req FlattenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.ConflictPolicy == "" {
+		req.ConflictPolicy = CANCEL
+	}
+	if req.ConflictPolicy != CANCEL && req.ConflictPolicy != SKIP && req.ConflictPolicy != OVERWRITE {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid field [conflict_policy]: %s", req.ConflictPolicy), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if reqPath == "/" {
+		common.ErrorStrResp(c, "the root folder can't be flattened", 400)
+		return
+	}
+	parent := stdpath.Dir(reqPath)
+	if !common.CheckPathLimitWithRoles(user, reqPath) || !common.CheckPathLimitWithRoles(user, parent) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, reqPath), common.PermMove) ||
+		!common.HasPermission(common.MergeRolePermissions(user, parent), common.PermRemove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !obj.IsDir() {
+		common.ErrorResp(c, errs.NotFolder, 400)
+		return
+	}
+	children, err := fs.List(context.WithValue(c, "meta", meta), reqPath, &fs.ListArgs{Refresh: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// the children colliding with an obj of the parent
+	conflicts := make(map[string]bool)
+	for _, child := range children {
+		name := child.GetName()
+		if name == obj.GetName() {
+			// the folder itself is in the way until it's removed
+			conflicts[name] = true
+			continue
+		}
+		if res, _ := fs.Get(c, stdpath.Join(parent, name), &fs.GetArgs{NoLog: true, Collision: true}); res != nil {
+			conflicts[name] = true
+			if req.ConflictPolicy == CANCEL {
+				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
+				return
+			}
+		}
+	}
+	results := make([]FlattenResult, 0, len(children))
+	moved := 0
+	for i, child := range children {
+		res := FlattenResult{Name: child.GetName()}
+		switch {
+		case child.GetName() == obj.GetName():
+			res.Status, res.Error = "skipped", "same name as the flattened folder"
+		case conflicts[child.GetName()] && req.ConflictPolicy == SKIP:
+			res.Status, res.Error = "skipped", "exists in the parent"
+		default:
+			if err := fs.Move(c, stdpath.Join(reqPath, child.GetName()), parent, len(children) > i+1); err != nil {
+				res.Status, res.Error = "failed", err.Error()
+			} else {
+				res.Status = "moved"
+				moved++
+			}
+		}
+		results = append(results, res)
+	}
+	removed := false
+	if moved == len(children) {
+		if err = fs.Remove(c, reqPath); err != nil {
+			common.ErrorResp(c, errors.WithMessage(err, "moved all the children but failed to remove the folder"), 500)
+			return
+		}
+		removed = true
+	}
+	common.SuccessResp(c, gin.H{
+		"results": results,
+		"removed": removed,
+	})
+}
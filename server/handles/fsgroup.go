@@ -0,0 +1,133 @@
+package handles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// ListGroupReq groups the listing into sections computed over all the entries,
+// so the sections are consistent across pages
+type ListGroupReq struct {
+	// GroupBy is type, letter or date, empty for no grouping
+	GroupBy string `json:"group_by" form:"group_by"`
+	// DateBucket is day, week, month or year, month by default
+	DateBucket string `json:"date_bucket" form:"date_bucket"`
+	// TzOffset is the offset in minutes east of UTC the dates are bucketed in
+	TzOffset int `json:"tz_offset" form:"tz_offset"`
+}
+
+type ListGroup struct {
+	Key   string `json:"key"`
+	Total int    `json:"total"`
+}
+
+var typeGroups = map[int]string{
+	conf.FOLDER:  "folder",
+	conf.VIDEO:   "video",
+	conf.AUDIO:   "audio",
+	conf.TEXT:    "text",
+	conf.IMAGE:   "image",
+	conf.UNKNOWN: "other",
+}
+
+// groupKeyFunc returns the key of the section of an entry and the order of the sections
+func groupKeyFunc(req ListGroupReq) (func(obj model.Obj) string, func(a, b string) bool, error) {
+	switch req.GroupBy {
+	case "type":
+		order := map[string]int{}
+		for _, t := range []int{conf.FOLDER, conf.IMAGE, conf.VIDEO, conf.AUDIO, conf.TEXT, conf.UNKNOWN} {
+			order[typeGroups[t]] = len(order)
+		}
+		return func(obj model.Obj) string {
+			return typeGroups[utils.GetObjType(obj.GetName(), obj.IsDir())]
+		}, func(a, b string) bool { return order[a] < order[b] }, nil
+	case "letter":
+		return letterGroup, func(a, b string) bool {
+			// digits first, then letters, then the rest
+			if (a == "#") != (b == "#") {
+				return b == "#"
+			}
+			return a < b
+		}, nil
+	case "date":
+		layout, err := dateBucketLayout(req.DateBucket)
+		if err != nil {
+			return nil, nil, err
+		}
+		loc := time.FixedZone("", req.TzOffset*60)
+		return func(obj model.Obj) string {
+			t := obj.ModTime().In(loc)
+			if layout == "week" {
+				year, week := t.ISOWeek()
+				return fmt.Sprintf("%04d-W%02d", year, week)
+			}
+			return t.Format(layout)
+		}, func(a, b string) bool { return a > b }, nil
+	default:
+		return nil, nil, errors.Errorf("invalid group_by: %s", req.GroupBy)
+	}
+}
+
+func dateBucketLayout(bucket string) (string, error) {
+	switch bucket {
+	case "day":
+		return "2006-01-02", nil
+	case "week":
+		return "week", nil
+	case "", "month":
+		return "2006-01", nil
+	case "year":
+		return "2006", nil
+	default:
+		return "", errors.Errorf("invalid date_bucket: %s", bucket)
+	}
+}
+
+func letterGroup(obj model.Obj) string {
+	r, _ := utf8.DecodeRuneInString(obj.GetName())
+	switch {
+	case unicode.IsDigit(r):
+		return "0-9"
+	case unicode.IsLetter(r):
+		return strings.ToUpper(string(r))
+	default:
+		return "#"
+	}
+}
+
+// groupObjs orders objs by section keeping their order within each section
+func groupObjs(objs []model.Obj, keyOf func(obj model.Obj) string, less func(a, b string) bool) []ListGroup {
+	type keyed struct {
+		obj model.Obj
+		key string
+	}
+	items := make([]keyed, len(objs))
+	totals := make(map[string]int)
+	for i, obj := range objs {
+		items[i] = keyed{obj: obj, key: keyOf(obj)}
+		totals[items[i].key]++
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i].key, items[j].key)
+	})
+	for i := range items {
+		objs[i] = items[i].obj
+	}
+	groups := make([]ListGroup, 0, len(totals))
+	for k, total := range totals {
+		groups = append(groups, ListGroup{Key: k, Total: total})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return less(groups[i].Key, groups[j].Key)
+	})
+	return groups
+}
@@ -0,0 +1,74 @@
+package handles
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsHashReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Types are among md5, sha1 and sha256, all of them if empty
+	Types []string `json:"types" form:"types"`
+	// Refresh computes the hashes the driver doesn't provide even if recorded
+	Refresh bool `json:"refresh" form:"refresh"`
+}
+
+// FsHash returns the checksums of a file, reading it only when neither the
+// driver nor a previous computation provides them
+func FsHash(c *gin.Context) {
+	var req FsHashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	types := fs.ComputableHashes
+	if len(req.Types) > 0 {
+		types = nil
+		for _, name := range req.Types {
+			t, ok := utils.GetHashByName(strings.ToLower(name))
+			if !ok || !slices.Contains(fs.ComputableHashes, t) {
+				common.ErrorStrResp(c, fmt.Sprintf("invalid field [types]: unsupported hash %s", name), 400)
+				return
+			}
+			types = append(types, t)
+		}
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	res, err := fs.FileHashes(c, reqPath, types, req.Refresh)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, res)
+}
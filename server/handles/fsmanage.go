@@ -13,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/generic"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -32,6 +33,9 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if !checkPathField(c, "path", req.Path) {
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
@@ -60,6 +64,7 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	refreshAfterWrite(c, stdpath.Dir(reqPath))
 	common.SuccessResp(c)
 }
 
@@ -71,6 +76,12 @@ type MoveCopyReq struct {
 	// SkipExisting only takes effect on copy: existing destination files
 	// with the same size are skipped instead of failing the request
 	SkipExisting bool `json:"skip_existing"`
+	// IncludeAll only takes effect between storages: the objs matching
+	// recursive_exclude are copied or moved too
+	IncludeAll bool `json:"include_all"`
+	// Priority only takes effect on copy between storages: the tasks of a
+	// higher priority run before the waiting ones, -1 low, 0 normal, 1 high
+	Priority int `json:"priority"`
 }
 
 func FsMove(c *gin.Context) {
@@ -79,8 +90,8 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if len(req.Names) == 0 {
-		common.ErrorStrResp(c, "Empty file names", 400)
+	if !checkPathField(c, "src_dir", req.SrcDir) || !checkPathField(c, "dst_dir", req.DstDir) ||
+		!checkNamesField(c, "names", req.Names) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
@@ -114,30 +125,111 @@ func FsMove(c *gin.Context) {
 				common.ErrorResp(c, err, 400)
 				return
 			}
-			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true}); res != nil {
-				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
+			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true, Collision: true}); res != nil {
+				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
 				return
 			}
 		}
 	}
-	for i, name := range req.Names {
-		srcPath, err := utils.JoinUnderBase(srcDir, name)
-		if err != nil {
+	for _, name := range req.Names {
+		if _, err := utils.JoinUnderBase(srcDir, name); err != nil {
 			common.ErrorResp(c, err, 400)
 			return
 		}
-		_, err = utils.JoinUnderBase(dstDir, name)
-		if err != nil {
+		if _, err := utils.JoinUnderBase(dstDir, name); err != nil {
 			common.ErrorResp(c, err, 400)
 			return
 		}
-		err = fs.Move(c, srcPath, dstDir, len(req.Names) > i+1)
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
+	}
+	var ctx context.Context = c
+	if req.IncludeAll {
+		ctx = context.WithValue(ctx, conf.IncludeAllKey, struct{}{})
+	}
+	// a failed obj doesn't stop the batch, each is reported
+	var addedTasks []task.TaskExtensionInfo
+	results := make([]MoveResult, 0, len(req.Names))
+	failed := 0
+	for i, name := range req.Names {
+		res := MoveResult{Name: name}
+		t, err := fs.MoveAsTask(ctx, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		switch {
+		case err != nil:
+			res.Status, res.Error = "failed", err.Error()
+			failed++
+		case t != nil:
+			res.Status, res.TaskID = "queued", t.GetID()
+			addedTasks = append(addedTasks, t)
+		default:
+			res.Status = "moved"
 		}
+		results = append(results, res)
 	}
-	common.SuccessResp(c)
+	if failed == len(req.Names) {
+		common.ErrorStrResp(c, results[0].Error, 500)
+		return
+	}
+	refreshAfterWrite(c, dstDir)
+	common.SuccessResp(c, gin.H{
+		"tasks":   getTaskInfos(addedTasks),
+		"results": results,
+	})
+}
+
+type MoveResult struct {
+	Name string `json:"name"`
+	// Status is moved, queued when moving between two storages, or failed
+	Status string `json:"status"`
+	TaskID string `json:"task_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type TransferReq struct {
+	SrcPath string `json:"src_path"`
+	DstDir  string `json:"dst_dir"`
+}
+
+// FsTransfer adds a task copying a single file to another storage, which can be
+// paused and resumed through the fs_transfer task routes
+func FsTransfer(c *gin.Context) {
+	var req TransferReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcPath, err := user.JoinPath(req.SrcPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, srcPath) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, stdpath.Dir(srcPath))
+	if !common.HasPermission(perm, common.PermCopy) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if res, _ := fs.Get(c, stdpath.Join(dstDir, stdpath.Base(srcPath)), &fs.GetArgs{NoLog: true, Collision: true}); res != nil {
+		common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
+		return
+	}
+	t := &fs.TransferTask{
+		TaskExtension: task.TaskExtension{Creator: user},
+		Status:        "queued",
+		SrcPath:       srcPath,
+		DstDirPath:    dstDir,
+	}
+	fs.TransferTaskManager.Add(t)
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
 }
 
 func FsCopy(c *gin.Context) {
@@ -146,8 +238,8 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if len(req.Names) == 0 {
-		common.ErrorStrResp(c, "Empty file names", 400)
+	if !checkPathField(c, "src_dir", req.SrcDir) || !checkPathField(c, "dst_dir", req.DstDir) ||
+		!checkNamesField(c, "names", req.Names) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
@@ -181,8 +273,8 @@ func FsCopy(c *gin.Context) {
 				common.ErrorResp(c, err, 400)
 				return
 			}
-			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true}); res != nil {
-				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
+			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true, Collision: true}); res != nil {
+				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
 				return
 			}
 		}
@@ -191,6 +283,10 @@ func FsCopy(c *gin.Context) {
 	if req.SkipExisting {
 		ctx = context.WithValue(ctx, conf.SkipExistingKey, struct{}{})
 	}
+	if req.IncludeAll {
+		ctx = context.WithValue(ctx, conf.IncludeAllKey, struct{}{})
+	}
+	ctx = context.WithValue(ctx, conf.TaskPriorityKey, task.FixPriority(req.Priority))
 	var addedTasks []task.TaskExtensionInfo
 	for i, name := range req.Names {
 		srcPath, err := utils.JoinUnderBase(srcDir, name)
@@ -212,6 +308,10 @@ func FsCopy(c *gin.Context) {
 			return
 		}
 	}
+	// the copies run by tasks write later
+	if len(addedTasks) == 0 {
+		refreshAfterWrite(c, dstDir)
+	}
 	common.SuccessResp(c, gin.H{
 		"tasks": getTaskInfos(addedTasks),
 	})
@@ -245,6 +345,9 @@ func FsRename(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if !checkPathField(c, "path", req.Path) || !checkNameField(c, "name", req.Name) {
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
@@ -263,10 +366,6 @@ func FsRename(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	if err := utils.ValidateNameComponent(req.Name); err != nil {
-		common.ErrorResp(c, err, 400)
-		return
-	}
 	if !req.Overwrite {
 		dstPath, err := utils.JoinUnderBase(stdpath.Dir(reqPath), req.Name)
 		if err != nil {
@@ -274,8 +373,9 @@ func FsRename(c *gin.Context) {
 			return
 		}
 		if dstPath != reqPath {
-			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true}); res != nil {
-				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", req.Name), 403)
+			// a rename changing only the case or the normalization collides with the obj itself
+			if res, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true, Collision: true}); res != nil && res.GetName() != stdpath.Base(reqPath) {
+				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", res.GetName()), 403)
 				return
 			}
 		}
@@ -290,6 +390,8 @@ func FsRename(c *gin.Context) {
 type RemoveReq struct {
 	Dir   string   `json:"dir"`
 	Names []string `json:"names"`
+	// Permanent removes the objs even if trash_enabled
+	Permanent bool `json:"permanent"`
 }
 
 func FsRemove(c *gin.Context) {
@@ -298,8 +400,7 @@ func FsRemove(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if len(req.Names) == 0 {
-		common.ErrorStrResp(c, "Empty file names", 400)
+	if !checkPathField(c, "dir", req.Dir) || !checkNamesField(c, "names", req.Names) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
@@ -323,7 +424,11 @@ func FsRemove(c *gin.Context) {
 			common.ErrorResp(c, err, 400)
 			return
 		}
-		err = fs.Remove(c, removePath)
+		if setting.GetBool(conf.TrashEnabled) && !req.Permanent {
+			err = fs.Trash(c, removePath)
+		} else {
+			err = fs.Remove(c, removePath)
+		}
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -1,7 +1,9 @@
 package handles
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	stdpath "path"
 	"strings"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type ListReq struct {
@@ -24,6 +27,51 @@ type ListReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
 	Refresh  bool   `json:"refresh"`
+	// ArchivePass unlocks an encrypted archive listed as a dir
+	ArchivePass string `json:"archive_pass" form:"archive_pass"`
+	HashSelectReq
+	ListGroupReq
+	DirModTimeReq
+}
+
+// DirModTimeReq requests the mtime of the dirs computed as the latest mtime of
+// their direct children, returned as computed_modified beside the driver's one
+type DirModTimeReq struct {
+	DirModTime bool `json:"dir_mtime" form:"dir_mtime"`
+}
+
+// the dirs of a page whose mtime is computed, each one may need a list
+const maxComputedDirModTimes = 50
+
+// HashSelectReq selects the hash algorithms returned for each file, all the known
+// ones are returned when Hashes is empty. Computing reads the whole file, so the
+// missing ones are only computed when ComputeHashes is set.
+type HashSelectReq struct {
+	Hashes        []string `json:"hashes" form:"hashes"`
+	ComputeHashes bool     `json:"compute_hashes" form:"compute_hashes"`
+}
+
+// the files of a request whose missing hashes are computed, beyond them the
+// hashes are reported as unavailable
+const (
+	maxComputedHashFiles = 20
+	maxComputedHashSize  = 1024 * utils.MB
+)
+
+// hashBudget counts the files read in full to compute hashes in a request
+type hashBudget struct {
+	files int
+	size  int64
+}
+
+// take reports whether obj can still be read to compute its hashes
+func (b *hashBudget) take(obj model.Obj) bool {
+	if b.files >= maxComputedHashFiles || b.size+obj.GetSize() > maxComputedHashSize {
+		return false
+	}
+	b.files++
+	b.size += obj.GetSize()
+	return true
 }
 
 type DirReq struct {
@@ -47,6 +95,10 @@ type ObjResp struct {
 	HashInfoStr  string                     `json:"hashinfo"`
 	HashInfo     map[*utils.HashType]string `json:"hash_info"`
 	StorageClass string                     `json:"storage_class,omitempty"`
+	// UnavailableHashes lists the requested hashes that are neither known nor computed
+	UnavailableHashes []string `json:"unavailable_hashes,omitempty"`
+	// ComputedModified is the latest mtime of the children of a dir, not reported by the driver
+	ComputedModified *time.Time `json:"computed_modified,omitempty"`
 }
 
 type FsListResp struct {
@@ -61,6 +113,8 @@ type FsListResp struct {
 	Header        string         `json:"header"`
 	Write         bool           `json:"write"`
 	Provider      string         `json:"provider"`
+	// Groups are the sections of the whole listing in order, set when grouping
+	Groups []ListGroup `json:"groups,omitempty"`
 }
 
 type ObjLabelResp struct {
@@ -79,6 +133,12 @@ type ObjLabelResp struct {
 	HashInfo     map[*utils.HashType]string `json:"hash_info"`
 	LabelList    []model.Label              `json:"label_list"`
 	StorageClass string                     `json:"storage_class,omitempty"`
+	// UnavailableHashes lists the requested hashes that are neither known nor computed
+	UnavailableHashes []string `json:"unavailable_hashes,omitempty"`
+	// Group is the key of the section of the entry when grouping
+	Group string `json:"group,omitempty"`
+	// ComputedModified is the latest mtime of the children of a dir, not reported by the driver
+	ComputedModified *time.Time `json:"computed_modified,omitempty"`
 }
 
 const (
@@ -93,6 +153,15 @@ func FsList(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	var groupKey func(obj model.Obj) string
+	var groupLess func(a, b string) bool
+	if req.GroupBy != "" {
+		var err error
+		if groupKey, groupLess, err = groupKeyFunc(req.ListGroupReq); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
 	effPage, effPerPage := normalizeListPage(req.Page, req.PerPage)
 	req.Page = effPage
 	req.PerPage = effPerPage
@@ -124,10 +193,15 @@ func FsList(c *gin.Context) {
 	if storageErr == nil {
 		provider = storage.GetStorage().Driver
 	}
-	objs, err := fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh})
-	if err != nil {
-		common.ErrorResp(c, err, 500)
-		return
+	// a file drop is listed empty, only to be uploaded to
+	drop := common.InDropFolder(user, meta, reqPath)
+	var objs []model.Obj
+	if !drop {
+		objs, err = fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh, ArchivePass: req.ArchivePass})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
 	}
 	filtered := make([]model.Obj, 0, len(objs))
 	for _, obj := range objs {
@@ -136,8 +210,44 @@ func FsList(c *gin.Context) {
 			filtered = append(filtered, obj)
 		}
 	}
+	var groups []ListGroup
+	if groupKey != nil {
+		groups = groupObjs(filtered, groupKey, groupLess)
+	}
 	total, pageObjs := pagination(filtered, &req.PageReq)
 	respContent := toObjsResp(pageObjs, reqPath, isEncrypt(meta, reqPath))
+	if groupKey != nil {
+		for i, obj := range pageObjs {
+			respContent[i].Group = groupKey(obj)
+		}
+	}
+	if len(req.Hashes) > 0 {
+		budget := &hashBudget{}
+		for i, obj := range pageObjs {
+			if obj.IsDir() {
+				continue
+			}
+			hi, unavailable := selectHashes(c, stdpath.Join(reqPath, obj.GetName()), obj, req.HashSelectReq, budget)
+			respContent[i].HashInfoStr = hi.String()
+			respContent[i].HashInfo = hi.Export()
+			respContent[i].UnavailableHashes = unavailable
+		}
+	}
+	if req.DirModTime {
+		computed := 0
+		for i, obj := range pageObjs {
+			if !obj.IsDir() || computed >= maxComputedDirModTimes {
+				continue
+			}
+			childPath := stdpath.Join(reqPath, obj.GetName())
+			// the children of a dir locked by its own password aren't exposed
+			if childMeta, _ := op.GetNearestMeta(childPath); !common.CanAccessWithRoles(user, childMeta, childPath, req.Password) {
+				continue
+			}
+			computed++
+			respContent[i].ComputedModified = computedDirModTime(c, childPath)
+		}
+	}
 	pagesTotal := calcPagesTotal(total, req.PerPage)
 	hasMore := req.PerPage != AllPerPage && req.Page*req.PerPage < total
 
@@ -151,8 +261,9 @@ func FsList(c *gin.Context) {
 		PagesTotal:    pagesTotal,
 		Readme:        getReadme(meta, reqPath),
 		Header:        getHeader(meta, reqPath),
-		Write:         common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath),
+		Write:         common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath) || drop,
 		Provider:      provider,
+		Groups:        groups,
 	})
 }
 
@@ -341,6 +452,10 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 type FsGetReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
+	// ArchivePass unlocks an encrypted archive the obj is in
+	ArchivePass string `json:"archive_pass" form:"archive_pass"`
+	HashSelectReq
+	DirModTimeReq
 }
 
 type FsGetResp struct {
@@ -377,19 +492,31 @@ func FsGet(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{ArchivePass: req.ArchivePass})
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
 	var rawURL string
+	archivePath, innerPath, inArchive := fs.SplitArchivePath(c, reqPath)
+	inArchive = inArchive && innerPath != "/"
 
 	storage, storageErr := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	provider := "unknown"
 	if storageErr == nil {
 		provider = storage.Config().Name
 	}
-	if !obj.IsDir() {
+	if !obj.IsDir() && inArchive {
+		// the entries are extracted from the archive on the fly
+		query := url.Values{"inner": {innerPath}}
+		if req.ArchivePass != "" {
+			query.Set("pass", req.ArchivePass)
+		}
+		if isEncrypt(meta, archivePath) || setting.GetBool(conf.SignAll) {
+			query.Set("sign", sign.SignArchive(archivePath))
+		}
+		rawURL = fmt.Sprintf("%s/ae%s?%s", common.GetApiUrl(c.Request), utils.EncodePath(archivePath, true), query.Encode())
+	} else if !obj.IsDir() {
 		if storageErr != nil {
 			common.ErrorResp(c, storageErr, 500)
 			return
@@ -451,6 +578,14 @@ func FsGet(c *gin.Context) {
 	parentMeta, _ := op.GetNearestMeta(parentPath)
 	thumb, _ := model.GetThumb(obj)
 	storageClass, _ := model.GetStorageClass(obj)
+	hashInfo, unavailableHashes := obj.GetHash(), []string(nil)
+	if len(req.Hashes) > 0 && !obj.IsDir() {
+		hashInfo, unavailableHashes = selectHashes(c, reqPath, obj, req.HashSelectReq, &hashBudget{})
+	}
+	var computedModified *time.Time
+	if req.DirModTime && obj.IsDir() {
+		computedModified = computedDirModTime(c, reqPath)
+	}
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
 			Id:           obj.GetID(),
@@ -461,12 +596,15 @@ func FsGet(c *gin.Context) {
 			IsDir:        obj.IsDir(),
 			Modified:     obj.ModTime(),
 			Created:      obj.CreateTime(),
-			HashInfoStr:  obj.GetHash().String(),
-			HashInfo:     obj.GetHash().Export(),
+			HashInfoStr:  hashInfo.String(),
+			HashInfo:     hashInfo.Export(),
 			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			StorageClass: storageClass,
+
+			UnavailableHashes: unavailableHashes,
+			ComputedModified:  computedModified,
 		},
 		RawURL:   rawURL,
 		Readme:   getReadme(meta, reqPath),
@@ -477,6 +615,62 @@ func FsGet(c *gin.Context) {
 	})
 }
 
+// computedDirModTime returns nil if the dir is empty or can't be listed
+func computedDirModTime(ctx context.Context, path string) *time.Time {
+	t, err := fs.DirModTime(ctx, path)
+	if err != nil {
+		log.Debugf("failed compute mtime of %s: %+v", path, err)
+		return nil
+	}
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// selectHashes keeps only the requested hashes of obj, the missing ones are
+// computed if requested and budget allows, the rest are reported as unavailable
+func selectHashes(ctx context.Context, path string, obj model.Obj, req HashSelectReq, budget *hashBudget) (utils.HashInfo, []string) {
+	known := obj.GetHash()
+	selected := make(map[*utils.HashType]string)
+	var missing []*utils.HashType
+	var unavailable []string
+	for _, name := range req.Hashes {
+		ht, ok := utils.GetHashByName(name)
+		if !ok {
+			unavailable = append(unavailable, name)
+			continue
+		}
+		if v := known.GetHash(ht); v != "" {
+			selected[ht] = v
+		} else if req.ComputeHashes && utils.SliceContains(fs.ComputableHashes, ht) {
+			missing = append(missing, ht)
+		} else {
+			unavailable = append(unavailable, name)
+		}
+	}
+	if len(missing) > 0 && !budget.take(obj) {
+		for _, ht := range missing {
+			unavailable = append(unavailable, ht.Name)
+		}
+		missing = nil
+	}
+	if len(missing) > 0 {
+		computed, err := fs.ComputeHashes(ctx, path, obj, missing)
+		if err != nil {
+			log.Warnf("failed compute hashes of %s: %+v", path, err)
+		}
+		for _, ht := range missing {
+			if v := computed.GetHash(ht); err == nil && v != "" {
+				selected[ht] = v
+			} else {
+				unavailable = append(unavailable, ht.Name)
+			}
+		}
+	}
+	return utils.NewHashInfoByMap(selected), unavailable
+}
+
 func filterRelated(objs []model.Obj, obj model.Obj) []model.Obj {
 	var related []model.Obj
 	nameWithoutExt := strings.TrimSuffix(obj.GetName(), stdpath.Ext(obj.GetName()))
@@ -0,0 +1,25 @@
+package handles
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// refreshAfterWrite re-lists the dir written to when the refresh_after_write setting
+// or the Refresh-After-Write header asks for it, so the next read is warm instead of
+// missing the cache. A failed refresh only leaves the cache cleared by the write.
+func refreshAfterWrite(c *gin.Context, dir string) {
+	if !setting.GetBool(conf.RefreshAfterWrite) && c.GetHeader("Refresh-After-Write") != "true" {
+		return
+	}
+	meta, _ := op.GetNearestMeta(dir)
+	if _, err := fs.List(context.WithValue(c, "meta", meta), dir, &fs.ListArgs{Refresh: true, NoLog: true}); err != nil {
+		log.Warnf("failed refresh %s after write: %+v", dir, err)
+	}
+}
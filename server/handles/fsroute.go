@@ -0,0 +1,218 @@
+package handles
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// ContentRouteRule routes a file to DstDir when any of its conditions match.
+// Types accepts video, audio, image and text (as configured in the preview settings),
+// Exts is a list of extensions without dot and Mime is a mime type prefix such as "image/".
+type ContentRouteRule struct {
+	Types  []string `json:"types"`
+	Exts   []string `json:"exts"`
+	Mime   string   `json:"mime"`
+	DstDir string   `json:"dst_dir"`
+}
+
+var contentRouteTypes = map[string]int{
+	"video": conf.VIDEO,
+	"audio": conf.AUDIO,
+	"image": conf.IMAGE,
+	"text":  conf.TEXT,
+}
+
+func (r ContentRouteRule) match(name string) bool {
+	fileType := utils.GetFileType(name)
+	for _, t := range r.Types {
+		if typ, ok := contentRouteTypes[strings.ToLower(t)]; ok && typ == fileType {
+			return true
+		}
+	}
+	ext := utils.Ext(name)
+	for _, e := range r.Exts {
+		if strings.ToLower(strings.TrimPrefix(e, ".")) == ext {
+			return true
+		}
+	}
+	if r.Mime != "" && strings.HasPrefix(utils.GetMimeType(name), strings.ToLower(r.Mime)) {
+		return true
+	}
+	return false
+}
+
+type RouteContentReq struct {
+	SrcDir string `json:"src_dir"`
+	// Rules overrides the content_route_rules setting when not empty
+	Rules  []ContentRouteRule `json:"rules"`
+	DryRun bool               `json:"dry_run"`
+}
+
+type RouteContentResult struct {
+	Name   string `json:"name"`
+	DstDir string `json:"dst_dir"`
+	Moved  bool   `json:"moved"`
+	// TaskID is the task moving it to another storage, it's only queued then
+	TaskID string `json:"task_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func getContentRouteRules() ([]ContentRouteRule, error) {
+	var rules []ContentRouteRule
+	if err := utils.Json.UnmarshalFromString(setting.GetStr(conf.ContentRouteRules, "[]"), &rules); err != nil {
+		return nil, errors.WithMessage(err, "invalid content route rules")
+	}
+	return rules, nil
+}
+
+// FsRouteContent moves the files directly under src_dir into destination
+// directories chosen by the first matching rule, files without a match stay in place.
+func FsRouteContent(c *gin.Context) {
+	var req RouteContentReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, srcDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, srcDir)
+	if !common.HasPermission(perm, common.PermMove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	rules := req.Rules
+	if len(rules) == 0 {
+		rules, err = getContentRouteRules()
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if len(rules) == 0 {
+		common.ErrorStrResp(c, "no content route rules", 400)
+		return
+	}
+	dstDirs := make([]string, len(rules))
+	for i, rule := range rules {
+		dstDir, err := user.JoinPath(rule.DstDir)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		if !common.CheckPathLimitWithRoles(user, dstDir) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		if !common.HasPermission(common.MergeRolePermissions(user, dstDir), common.PermWrite) {
+			dstMeta, err := op.GetNearestMeta(dstDir)
+			if err != nil {
+				if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+					common.ErrorResp(c, err, 500, true)
+					return
+				}
+			}
+			if !common.CanWrite(dstMeta, dstDir) {
+				common.ErrorResp(c, errs.PermissionDenied, 403)
+				return
+			}
+		}
+		dstDirs[i] = dstDir
+	}
+
+	meta, err := op.GetNearestMeta(srcDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	c.Set("meta", meta)
+	objs, err := fs.List(c, srcDir, &fs.ListArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+
+	results := make([]RouteContentResult, 0, len(objs))
+	for _, obj := range objs {
+		if obj.IsDir() {
+			continue
+		}
+		name := obj.GetName()
+		ruleIdx := -1
+		for i, rule := range rules {
+			if rule.match(name) {
+				ruleIdx = i
+				break
+			}
+		}
+		if ruleIdx == -1 {
+			continue
+		}
+		dstDir := dstDirs[ruleIdx]
+		res := RouteContentResult{Name: name, DstDir: dstDir}
+		if utils.PathEqual(dstDir, srcDir) {
+			res.Error = "already in destination"
+			results = append(results, res)
+			continue
+		}
+		dstPath, err := utils.JoinUnderBase(dstDir, name)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		if dst, _ := fs.Get(c, dstPath, &fs.GetArgs{NoLog: true, Collision: true}); dst != nil {
+			res.Error = fmt.Sprintf("file [%s] exists", dst.GetName())
+			results = append(results, res)
+			continue
+		}
+		if req.DryRun {
+			results = append(results, res)
+			continue
+		}
+		if err := fs.MakeDir(c, dstDir); err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		srcPath, err := utils.JoinUnderBase(srcDir, name)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		// a file routed to another storage is moved by a task
+		if t, err := fs.MoveAsTask(c, srcPath, dstDir); err != nil {
+			res.Error = err.Error()
+		} else if t != nil {
+			res.TaskID = t.GetID()
+		} else {
+			res.Moved = true
+		}
+		results = append(results, res)
+	}
+	common.SuccessResp(c, gin.H{
+		"dry_run": req.DryRun,
+		"results": results,
+	})
+}
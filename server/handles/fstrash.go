@@ -0,0 +1,97 @@
+package handles
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// FsTrashList lists the objs trashed by the current user, or by anyone for admins
+func FsTrashList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	var userID uint
+	if !user.IsAdmin() {
+		userID = user.ID
+	}
+	items, total, err := db.GetTrashItems(userID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashIdsReq struct {
+	Ids []uint `json:"ids"`
+}
+
+type TrashResult struct {
+	ID uint `json:"id"`
+	// Status is done or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FsTrashRestore moves trashed objs back to where they were removed from
+func FsTrashRestore(c *gin.Context) {
+	trashBatch(c, fs.RestoreTrash, true)
+}
+
+// FsTrashPurge removes trashed objs for good
+func FsTrashPurge(c *gin.Context) {
+	trashBatch(c, fs.PurgeTrash, false)
+}
+
+func trashBatch(c *gin.Context, fn func(context.Context, *model.TrashItem) error, refresh bool) {
+	var req TrashIdsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Ids) == 0 {
+		common.ErrorStrResp(c, "invalid field [ids]: empty", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	results := make([]TrashResult, 0, len(req.Ids))
+	var restored []string
+	for _, id := range req.Ids {
+		res := TrashResult{ID: id, Status: "failed"}
+		item, err := db.GetTrashItemById(id)
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case !user.IsAdmin() && (item.UserID != user.ID || !common.CheckPathLimitWithRoles(user, item.OriginalPath)):
+			res.Error = errs.PermissionDenied.Error()
+		default:
+			if err = fn(c, item); err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Status = "done"
+				if refresh {
+					restored = append(restored, stdpath.Dir(item.OriginalPath))
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	for _, dir := range restored {
+		refreshAfterWrite(c, dir)
+	}
+	common.SuccessResp(c, results)
+}
@@ -0,0 +1,203 @@
+package handles
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTreeDepth = 3
+	maxTreeDepth     = 10
+	// the nodes of a tree, the walk stops once reached
+	maxTreeNodes = 5000
+)
+
+type TreeReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	Depth    int    `json:"depth" form:"depth"`
+	// Files includes the files, only the dirs are returned otherwise
+	Files bool `json:"files" form:"files"`
+	// Format is json or ascii
+	Format string `json:"format" form:"format"`
+	// Inaccessible is skip to omit the dirs the user can't access or mark to
+	// return them as locked without their children
+	Inaccessible string `json:"inaccessible" form:"inaccessible"`
+}
+
+type TreeNode struct {
+	Name     string      `json:"name"`
+	IsDir    bool        `json:"is_dir"`
+	Size     int64       `json:"size"`
+	Children []*TreeNode `json:"children,omitempty"`
+	Locked   bool        `json:"locked,omitempty"`
+	// Truncated is set on the dirs whose children weren't walked
+	// because of the depth or the node limit
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type treeWalker struct {
+	user  *model.User
+	req   TreeReq
+	nodes int
+	// truncated is set once the node limit is reached
+	truncated bool
+	// dirs are the nodes of the dirs to walk by path, with the depth left
+	dirs map[string]treeDir
+}
+
+type treeDir struct {
+	node  *TreeNode
+	depth int
+}
+
+// FsTree returns the structure of a dir as a nested tree or a rendered ascii tree
+func FsTree(c *gin.Context) {
+	var req TreeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Depth <= 0 {
+		req.Depth = defaultTreeDepth
+	}
+	req.Depth = min(req.Depth, maxTreeDepth)
+	if req.Inaccessible != "mark" {
+		req.Inaccessible = "skip"
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	w := &treeWalker{user: user, req: req}
+	root := &TreeNode{Name: obj.GetName(), IsDir: obj.IsDir(), Size: obj.GetSize()}
+	if reqPath == "/" {
+		root.Name = "/"
+	}
+	w.nodes++
+	if obj.IsDir() {
+		w.dirs = map[string]treeDir{reqPath: {node: root, depth: req.Depth}}
+		if err = fs.WalkAccessible(c, user, reqPath, req.Password, w.visit); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if req.Format == "ascii" {
+		var b strings.Builder
+		b.WriteString(root.Name + "\n")
+		renderTree(&b, root.Children, "")
+		common.SuccessResp(c, gin.H{
+			"text":      b.String(),
+			"nodes":     w.nodes,
+			"truncated": w.truncated,
+		})
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"tree":      root,
+		"nodes":     w.nodes,
+		"truncated": w.truncated,
+	})
+}
+
+// visit adds the children of the dir at path to its node, and returns the
+// dirs to walk within the depth and the node limit
+func (w *treeWalker) visit(path string, objs []model.Obj, err error) ([]model.Obj, error) {
+	dir := w.dirs[path]
+	delete(w.dirs, path)
+	node := dir.node
+	if err != nil {
+		node.Error = err.Error()
+		return nil, nil
+	}
+	if w.truncated {
+		node.Truncated = true
+		return nil, nil
+	}
+	var dirs []model.Obj
+	for _, obj := range objs {
+		if !obj.IsDir() && !w.req.Files {
+			continue
+		}
+		childPath := stdpath.Join(path, obj.GetName())
+		child := &TreeNode{Name: obj.GetName(), IsDir: obj.IsDir(), Size: obj.GetSize()}
+		if obj.IsDir() && !fs.CanAccessDir(w.user, childPath, w.req.Password) {
+			if w.req.Inaccessible == "skip" {
+				continue
+			}
+			child.Locked = true
+		}
+		if w.nodes >= maxTreeNodes {
+			w.truncated = true
+			node.Truncated = true
+			break
+		}
+		w.nodes++
+		node.Children = append(node.Children, child)
+		if !obj.IsDir() || child.Locked {
+			continue
+		}
+		if dir.depth <= 1 {
+			child.Truncated = true
+			continue
+		}
+		w.dirs[childPath] = treeDir{node: child, depth: dir.depth - 1}
+		dirs = append(dirs, obj)
+	}
+	return dirs, nil
+}
+
+func renderTree(b *strings.Builder, nodes []*TreeNode, prefix string) {
+	for i, n := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		b.WriteString(prefix + branch + n.Name)
+		if n.IsDir {
+			b.WriteString("/")
+		}
+		if n.Locked {
+			b.WriteString(" [locked]")
+		}
+		if n.Error != "" {
+			b.WriteString(" [error: " + n.Error + "]")
+		}
+		if n.Truncated {
+			b.WriteString(" ...")
+		}
+		b.WriteString("\n")
+		renderTree(b, n.Children, prefix+indent)
+	}
+}
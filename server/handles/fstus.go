@@ -0,0 +1,204 @@
+package handles
+
+import (
+	"encoding/base64"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the tus protocol answers with plain status codes, the clients don't read a body
+const tusVersion = "1.0.0"
+
+func tusHeaders(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+}
+
+// tusCheckVersion answers 412 to the requests of another version of the protocol
+func tusCheckVersion(c *gin.Context) bool {
+	tusHeaders(c)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(412)
+		return false
+	}
+	return true
+}
+
+// tusMetadata decodes the Upload-Metadata header, pairs of a key and a base64 value
+func tusMetadata(c *gin.Context) map[string]string {
+	res := make(map[string]string)
+	for _, pair := range strings.Split(c.GetHeader("Upload-Metadata"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		res[key] = string(decoded)
+	}
+	return res
+}
+
+// getTusUpload returns the upload of the current user, it answers 404 otherwise
+func getTusUpload(c *gin.Context) (*model.TusUpload, bool) {
+	u, err := db.GetTusUploadById(c.Param("id"))
+	if err != nil || u.UserID != c.MustGet("user").(*model.User).ID {
+		c.AbortWithStatus(404)
+		return nil, false
+	}
+	return u, true
+}
+
+func TusOptions(c *gin.Context) {
+	tusHeaders(c)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,termination,expiration")
+	c.Status(204)
+}
+
+// TusCreate starts a resumable upload to the File-Path header, checked by the
+// FsUp middleware like the other uploads
+func TusCreate(c *gin.Context) {
+	if !tusCheckVersion(c) {
+		return
+	}
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		c.AbortWithStatus(400)
+		return
+	}
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		c.AbortWithStatus(400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	path, err = user.JoinPath(path)
+	if err != nil {
+		c.AbortWithStatus(403)
+		return
+	}
+	meta := tusMetadata(c)
+	path, ok := uploadPath(c, path, func() (string, error) {
+		if name := meta["filename"]; name != "" {
+			return name, nil
+		}
+		return "", errors.New("no filename in the upload metadata")
+	})
+	if !ok {
+		return
+	}
+	// the quota is checked again once the upload is complete
+	if err = op.CheckUploadQuota(user, size); err != nil {
+		common.ErrorResp(c, err, putErrorCode(err))
+		return
+	}
+	overwrite := c.GetHeader("Overwrite") != "false"
+	if !overwrite {
+		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
+			common.ErrorStrResp(c, "file exists", 403)
+			return
+		}
+	}
+	mimetype := meta["filetype"]
+	if mimetype == "" {
+		mimetype = utils.GetMimeType(path)
+	}
+	u := &model.TusUpload{
+		Path:      path,
+		Size:      size,
+		Mimetype:  mimetype,
+		Modified:  getLastModified(c),
+		UserID:    user.ID,
+		Overwrite: overwrite,
+	}
+	if err = fs.CreateTusUpload(c, u); err != nil {
+		log.Errorf("failed create tus upload of %s: %+v", path, err)
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Header("Location", common.GetApiUrl(c.Request)+"/api/fs/tus/"+u.ID)
+	c.Header("Upload-Expires", u.CreatedAt.Add(fs.TusExpiration).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"))
+	c.Status(201)
+}
+
+func TusHead(c *gin.Context) {
+	if !tusCheckVersion(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Size, 10))
+	c.Status(200)
+}
+
+// TusPatch appends a chunk to an upload, the complete file is put to its storage
+// before the response
+func TusPatch(c *gin.Context) {
+	if !tusCheckVersion(c) {
+		return
+	}
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.AbortWithStatus(415)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.AbortWithStatus(400)
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	err = fs.WriteTusUpload(c, u, offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	switch {
+	case errors.Is(err, fs.ErrTusOffsetMismatch):
+		c.AbortWithStatus(409)
+	case errors.Is(err, fs.ErrTusBusy):
+		c.AbortWithStatus(423)
+	case err != nil:
+		log.Errorf("failed write tus upload of %s: %+v", u.Path, err)
+		c.AbortWithStatus(500)
+	default:
+		if u.Offset == u.Size {
+			refreshAfterWrite(c, stdpath.Dir(u.Path))
+		}
+		c.Status(204)
+	}
+}
+
+func TusDelete(c *gin.Context) {
+	if !tusCheckVersion(c) {
+		return
+	}
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if err := fs.DeleteTusUpload(u); err != nil {
+		log.Errorf("failed delete tus upload of %s: %+v", u.Path, err)
+		c.AbortWithStatus(500)
+		return
+	}
+	c.Status(204)
+}
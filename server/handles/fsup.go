@@ -1,19 +1,25 @@
 package handles
 
 import (
+	"fmt"
 	"io"
 	"net/url"
 	stdpath "path"
 	"strconv"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func getLastModified(c *gin.Context) time.Time {
@@ -27,6 +33,75 @@ func getLastModified(c *gin.Context) time.Time {
 	return lastModified
 }
 
+// putErrorCode is the status code of a failed put
+func putErrorCode(err error) int {
+	if errors.Is(err, errs.UploadQuotaExceeded) || errors.Is(err, errs.HomeQuotaExceeded) {
+		return 413
+	}
+	return 500
+}
+
+// verifyUpload checks the uploaded file against the hashes given by the client
+// and removes it on mismatch, it returns false if the request has been responded
+func verifyUpload(c *gin.Context, path string, expected map[*utils.HashType]string) (map[string]string, bool) {
+	hashes, err := fs.VerifyHashes(c, path, expected)
+	switch {
+	case err != nil && hashes == nil:
+		// the hashes couldn't be got, the upload is kept
+		common.ErrorResp(c, err, 500)
+		return nil, false
+	case err != nil:
+		if e := fs.Remove(c, path); e != nil {
+			log.Errorf("failed remove %s after hash mismatch: %+v", path, e)
+		}
+		common.ErrorStrResp(c, err.Error()+", the upload is removed", 400)
+		return nil, false
+	}
+	return hashes, true
+}
+
+// uploadPath handles an upload to a path which is a folder according to the
+// upload_to_folder_policy, it returns false if the request has been responded.
+// originalName returns the name of the uploaded file for the inside policy,
+// it's nil if the upload has no other name than the one of its path.
+func uploadPath(c *gin.Context, path string, originalName func() (string, error)) (string, bool) {
+	res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true})
+	if res == nil || !res.IsDir() {
+		return path, true
+	}
+	if setting.GetStr(conf.UploadToFolderPolicy) != "inside" || originalName == nil {
+		_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		common.ErrorStrResp(c, fmt.Sprintf("[%s] is a folder, upload to a file path inside it", stdpath.Base(path)), 409)
+		return "", false
+	}
+	name, err := originalName()
+	if err == nil {
+		err = utils.ValidateNameComponent(name)
+	}
+	if err != nil {
+		_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		common.ErrorResp(c, err, 400)
+		return "", false
+	}
+	// the permissions were checked on the folder, not on the file in it
+	path = stdpath.Join(path, name)
+	user := c.MustGet("user").(*model.User)
+	drop, err := common.CheckUpload(user, path, c.GetHeader("Password"))
+	if err != nil {
+		_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		if errors.Is(err, errs.PermissionDenied) {
+			common.ErrorResp(c, err, 403)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return "", false
+	}
+	if drop {
+		c.Request.Header.Set("Overwrite", "false")
+	}
+	return path, true
+}
+
 func FsStream(c *gin.Context) {
 	path := c.GetHeader("File-Path")
 	path, err := url.PathUnescape(path)
@@ -35,13 +110,22 @@ func FsStream(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
-	overwrite := c.GetHeader("Overwrite") != "false"
+	verify := c.GetHeader("Verify-Hash") == "true"
+	if verify && asTask {
+		common.ErrorStrResp(c, "the hash of an upload as a task can't be verified", 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	path, ok := uploadPath(c, path, nil)
+	if !ok {
+		return
+	}
+	overwrite := c.GetHeader("Overwrite") != "false"
 	if !overwrite {
 		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
 			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
@@ -66,6 +150,10 @@ func FsStream(c *gin.Context) {
 	if sha256 := c.GetHeader("X-File-Sha256"); sha256 != "" {
 		h[utils.SHA256] = sha256
 	}
+	if verify && len(h) == 0 {
+		common.ErrorStrResp(c, "no hash to verify, give X-File-Md5 or X-File-Sha256", 400)
+		return
+	}
 	mimetype := c.GetHeader("Content-Type")
 	if len(mimetype) == 0 {
 		mimetype = utils.GetMimeType(name)
@@ -85,17 +173,28 @@ func FsStream(c *gin.Context) {
 	if asTask {
 		t, err = fs.PutAsTask(c, dir, s)
 	} else {
-		err = fs.PutDirectly(c, dir, s, true)
+		// the cache is refreshed for the verification to see the uploaded file
+		err = fs.PutDirectly(c, dir, s, !verify)
 	}
 	defer c.Request.Body.Close()
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrorCode(err))
 		return
 	}
 	if t == nil {
 		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
 			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
 		}
+		if verify {
+			hashes, ok := verifyUpload(c, path, h)
+			if !ok {
+				return
+			}
+			refreshAfterWrite(c, dir)
+			common.SuccessResp(c, gin.H{"hash": hashes})
+			return
+		}
+		refreshAfterWrite(c, dir)
 		common.SuccessResp(c)
 		return
 	}
@@ -112,13 +211,28 @@ func FsForm(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
-	overwrite := c.GetHeader("Overwrite") != "false"
+	verify := c.GetHeader("Verify-Hash") == "true"
+	if verify && asTask {
+		common.ErrorStrResp(c, "the hash of an upload as a task can't be verified", 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	path, ok := uploadPath(c, path, func() (string, error) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return "", err
+		}
+		return file.Filename, nil
+	})
+	if !ok {
+		return
+	}
+	overwrite := c.GetHeader("Overwrite") != "false"
 	if !overwrite {
 		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
 			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
@@ -157,6 +271,10 @@ func FsForm(c *gin.Context) {
 	if sha256 := c.GetHeader("X-File-Sha256"); sha256 != "" {
 		h[utils.SHA256] = sha256
 	}
+	if verify && len(h) == 0 {
+		common.ErrorStrResp(c, "no hash to verify, give X-File-Md5 or X-File-Sha256", 400)
+		return
+	}
 	mimetype := file.Header.Get("Content-Type")
 	if len(mimetype) == 0 {
 		mimetype = utils.GetMimeType(name)
@@ -179,13 +297,24 @@ func FsForm(c *gin.Context) {
 		}{f}
 		t, err = fs.PutAsTask(c, dir, &s)
 	} else {
-		err = fs.PutDirectly(c, dir, &s, true)
+		// the cache is refreshed for the verification to see the uploaded file
+		err = fs.PutDirectly(c, dir, &s, !verify)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrorCode(err))
 		return
 	}
 	if t == nil {
+		if verify {
+			hashes, ok := verifyUpload(c, path, h)
+			if !ok {
+				return
+			}
+			refreshAfterWrite(c, dir)
+			common.SuccessResp(c, gin.H{"hash": hashes})
+			return
+		}
+		refreshAfterWrite(c, dir)
 		common.SuccessResp(c)
 		return
 	}
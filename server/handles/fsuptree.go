@@ -0,0 +1,166 @@
+package handles
+
+import (
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type TreeUploadResult struct {
+	Path string `json:"path"`
+	// Status is uploaded, skipped or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FsFormTree uploads a folder tree in one multipart request. Each file part
+// carries its path relative to the File-Path dir as its filename, such as
+// subdir/a/b.txt, and the missing dirs are made on the way. The permissions
+// are checked once for the File-Path dir.
+func FsFormTree(c *gin.Context) {
+	rootPath, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	overwrite := c.GetHeader("Overwrite") != "false"
+	user := c.MustGet("user").(*model.User)
+	root, err := user.JoinPath(rootPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, root) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(root)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, root, c.GetHeader("Password")) ||
+		!(common.HasPermission(common.MergeRolePermissions(user, root), common.PermWrite) || common.CanWrite(meta, root)) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	madeDirs := map[string]bool{root: true}
+	var results []TreeUploadResult
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		// Part.FileName keeps only the base of the name, the relative path is
+		// read from the header
+		_, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		rel := params["filename"]
+		if rel == "" {
+			_ = part.Close()
+			continue
+		}
+		res := TreeUploadResult{Path: rel}
+		path, err := joinRelPath(root, rel)
+		if err == nil {
+			res.Path = strings.TrimPrefix(path, root)
+			res.Status, err = uploadTreeFile(c, part, path, overwrite, madeDirs)
+		}
+		_ = part.Close()
+		if err != nil {
+			res.Status, res.Error = "failed", err.Error()
+		}
+		results = append(results, res)
+	}
+	for dir := range madeDirs {
+		refreshAfterWrite(c, dir)
+	}
+	common.SuccessResp(c, gin.H{
+		"results": results,
+	})
+}
+
+// joinRelPath joins a path relative to root component by component, so that
+// it can't get out of root
+func joinRelPath(root, rel string) (string, error) {
+	path := root
+	for _, name := range strings.Split(strings.Trim(rel, "/"), "/") {
+		var err error
+		if path, err = utils.JoinUnderBase(path, name); err != nil {
+			return "", errors.WithMessagef(err, "invalid path [%s]", rel)
+		}
+	}
+	return path, nil
+}
+
+// uploadTreeFile saves the part in a temp file for its size to be known, then
+// makes its dir and puts it to path
+func uploadTreeFile(c *gin.Context, part io.Reader, path string, overwrite bool, madeDirs map[string]bool) (string, error) {
+	dir, name := stdpath.Split(path)
+	dir = utils.FixAndCleanPath(dir)
+	if !overwrite {
+		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
+			_, _ = utils.CopyWithBuffer(io.Discard, part)
+			return "skipped", nil
+		}
+	}
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "tree-*")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	size, err := utils.CopyWithBuffer(tmp, part)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return "", errors.WithStack(err)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   tmp,
+		Mimetype: utils.GetMimeType(name),
+	}
+	// the dirs are only made for the files put, a skipped one leaves none
+	if !madeDirs[dir] {
+		if err = fs.MakeDir(c, dir); err != nil {
+			return "", errors.WithMessagef(err, "failed make [%s]", dir)
+		}
+		madeDirs[dir] = true
+	}
+	if err = fs.PutDirectly(c, dir, s, true); err != nil {
+		return "", err
+	}
+	return "uploaded", nil
+}
@@ -0,0 +1,135 @@
+package handles
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type VersionListReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+// FsVersionList lists the previous versions kept of a file, newest first
+func FsVersionList(c *gin.Context) {
+	var req VersionListReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := fs.GetVersions(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, versions)
+}
+
+type VersionRestoreReq struct {
+	ID       uint   `json:"id"`
+	Password string `json:"password"`
+}
+
+// FsVersionRestore replaces a file with one of its previous versions
+func FsVersionRestore(c *gin.Context) {
+	var req VersionRestoreReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	v, err := db.GetFileVersionById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !common.CheckPathLimitWithRoles(user, v.Path) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(v.Path)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, v.Path, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, v.Path), common.PermWrite) && !common.CanWrite(meta, v.Path) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = fs.RestoreVersion(c, v); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	refreshAfterWrite(c, stdpath.Dir(v.Path))
+	common.SuccessResp(c)
+}
+
+type VersionDeleteReq struct {
+	Ids []uint `json:"ids"`
+}
+
+// FsVersionDelete removes previous versions of files for good
+func FsVersionDelete(c *gin.Context) {
+	var req VersionDeleteReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Ids) == 0 {
+		common.ErrorStrResp(c, "invalid field [ids]: empty", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	for _, id := range req.Ids {
+		v, err := db.GetFileVersionById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 404)
+			return
+		}
+		if !common.CheckPathLimitWithRoles(user, v.Path) ||
+			!common.HasPermission(common.MergeRolePermissions(user, v.Path), common.PermRemove) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		if err = fs.DeleteVersion(c, v); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,85 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type ZipReq struct {
+	Dir string `json:"dir" form:"dir"`
+	// Names are the objs zipped, all the objs of Dir if empty
+	Names    []string `json:"names" form:"names"`
+	Password string   `json:"password" form:"password"`
+}
+
+// FsZip streams a zip of a dir or of some of its objs, built while it is sent
+func FsZip(c *gin.Context) {
+	var req ZipReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !checkPathField(c, "dir", req.Dir) {
+		return
+	}
+	if len(req.Names) > 0 && !checkNamesField(c, "names", req.Names) {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqDir, err := user.JoinPath(req.Dir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqDir, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	names := req.Names
+	if len(names) == 0 {
+		objs, err := fs.List(context.WithValue(c, "meta", meta), reqDir, &fs.ListArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		for _, obj := range objs {
+			names = append(names, obj.GetName())
+		}
+	}
+	filename := stdpath.Base(reqDir)
+	if len(req.Names) == 1 {
+		filename = req.Names[0]
+	} else if reqDir == "/" {
+		filename = "root"
+	}
+	filename += ".zip"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename)))
+	c.Status(200)
+	// the size isn't known beforehand, an error can only cut the response short
+	if err = fs.WriteZip(c, c.Writer, reqDir, req.Password, names); err != nil {
+		log.Errorf("failed zip %s: %+v", reqDir, err)
+	}
+}
@@ -0,0 +1,145 @@
+package handles
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+type ComponentHealth struct {
+	Status string `json:"status"`
+	// Latency is in milliseconds
+	Latency int64  `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type StorageHealth struct {
+	MountPath string `json:"mount_path"`
+	Driver    string `json:"driver"`
+	ComponentHealth
+}
+
+type HealthResp struct {
+	Status         string          `json:"status"`
+	Version        string          `json:"version"`
+	StoragesLoaded bool            `json:"storages_loaded"`
+	DB             ComponentHealth `json:"db"`
+	Cache          ComponentHealth `json:"cache"`
+	Storages       []StorageHealth `json:"storages,omitempty"`
+}
+
+var healthCache = cache.NewMemCache[int64]()
+
+func checkComponent(ctx context.Context, check func(ctx context.Context) error) ComponentHealth {
+	start := time.Now()
+	err := check(ctx)
+	h := ComponentHealth{Status: healthOK, Latency: time.Since(start).Milliseconds()}
+	if err != nil {
+		h.Status = healthDown
+		h.Error = err.Error()
+	}
+	return h
+}
+
+func checkCache(ctx context.Context) error {
+	now := time.Now().UnixNano()
+	healthCache.Set("probe", now)
+	if v, ok := healthCache.Get("probe"); !ok || v != now {
+		return errors.New("the value set isn't read back")
+	}
+	healthCache.Del("probe")
+	// the settings are read through their cache
+	_, err := op.GetSettingItemByKey(conf.VERSION)
+	return err
+}
+
+func checkStorage(ctx context.Context, storage driver.Driver) StorageHealth {
+	s := storage.GetStorage()
+	h := StorageHealth{MountPath: s.MountPath, Driver: s.Driver}
+	if s.Status != op.WORK {
+		h.Status = healthDown
+		h.Error = s.Status
+		return h
+	}
+	h.ComponentHealth = checkComponent(ctx, func(ctx context.Context) error {
+		_, err := op.ProbeList(ctx, storage)
+		return err
+	})
+	return h
+}
+
+// Health checks the database, the cache and, with storages=true, lists the
+// root of each enabled storage, each check within timeout seconds (5 by
+// default). It responds 503 if the database or the cache is down, and
+// reports degraded if some storage is down only.
+func Health(c *gin.Context) {
+	timeout := 5 * time.Second
+	if t, err := strconv.Atoi(c.Query("timeout")); err == nil && t > 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+	check := func(f func(ctx context.Context) error) ComponentHealth {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		return checkComponent(ctx, f)
+	}
+	resp := HealthResp{
+		Status:         healthOK,
+		Version:        conf.Version,
+		StoragesLoaded: conf.StoragesLoaded,
+		DB:             check(db.Ping),
+		Cache:          check(checkCache),
+	}
+	if c.Query("storages") == "true" {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		resp.Storages = []StorageHealth{}
+		for _, storage := range op.GetAllStorages() {
+			if storage.GetStorage().Disabled {
+				continue
+			}
+			wg.Add(1)
+			go func(storage driver.Driver) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+				defer cancel()
+				h := checkStorage(ctx, storage)
+				mu.Lock()
+				resp.Storages = append(resp.Storages, h)
+				mu.Unlock()
+			}(storage)
+		}
+		wg.Wait()
+		sort.Slice(resp.Storages, func(i, j int) bool {
+			return resp.Storages[i].MountPath < resp.Storages[j].MountPath
+		})
+		for _, s := range resp.Storages {
+			if s.Status != healthOK {
+				resp.Status = healthDegraded
+				break
+			}
+		}
+	}
+	if resp.DB.Status != healthOK || resp.Cache.Status != healthOK {
+		resp.Status = healthDown
+		c.JSON(503, common.Resp[HealthResp]{Code: 503, Message: healthDown, Data: resp})
+		return
+	}
+	common.SuccessResp(c, resp)
+}
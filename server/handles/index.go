@@ -107,3 +107,18 @@ func GetProgress(c *gin.Context) {
 	}
 	common.SuccessResp(c, progress)
 }
+
+func GetIndexThrottle(c *gin.Context) {
+	common.SuccessResp(c, search.GetThrottleState())
+}
+
+// PauseIndex holds the indexing until resumed, the walk keeps its position
+func PauseIndex(c *gin.Context) {
+	search.PauseIndex()
+	common.SuccessResp(c, search.GetThrottleState())
+}
+
+func ResumeIndex(c *gin.Context) {
+	search.ResumeIndex()
+	common.SuccessResp(c, search.GetThrottleState())
+}
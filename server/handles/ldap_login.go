@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -49,6 +50,13 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
 	ldapUserSearchBase := setting.GetStr(conf.LdapUserSearchBase)
 	ldapUserSearchFilter := setting.GetStr(conf.LdapUserSearchFilter) // (uid=%s)
+	ldapGroupAttribute := setting.GetStr(conf.LdapGroupAttribute)
+
+	// the servers take a bind without a password as an anonymous one
+	if req.Password == "" {
+		common.ErrorStrResp(c, "password is required", 400)
+		return
+	}
 
 	// Connect to LdapServer
 	l, err := dial(ldapServer)
@@ -69,11 +77,15 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	}
 
 	// Search for the given username
+	attributes := []string{"dn"}
+	if ldapGroupAttribute != "" {
+		attributes = append(attributes, ldapGroupAttribute)
+	}
 	searchRequest := ldap.NewSearchRequest(
 		ldapUserSearchBase,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
-		fmt.Sprintf(ldapUserSearchFilter, req.Username),
-		[]string{"dn"},
+		fmt.Sprintf(ldapUserSearchFilter, ldap.EscapeFilter(req.Username)),
+		attributes,
 		nil,
 	)
 	sr, err := l.Search(searchRequest)
@@ -93,8 +105,9 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	err = l.Bind(userDN, req.Password)
 	if err != nil {
 		utils.Log.Errorf("Failed to auth. %v", err)
+		common.Audit(c, &model.User{Username: req.Username}, model.AuditLogin, "ldap", "", err)
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		loginFailed(ip, req.Username, count)
 		return
 	} else {
 		utils.Log.Infof("Auth successful username:%s", req.Username)
@@ -103,13 +116,32 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
+		if !setting.GetBool(conf.LdapAutoRegister) {
+			common.ErrorStrResp(c, "the user is not registered, ask the admin to create it", 403)
+			return
+		}
 		user, err = ladpRegister(req.Username)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
-			loginCache.Set(ip, count+1)
+			loginFailed(ip, req.Username, count)
 			return
 		}
 	}
+	// the roles follow the groups, but the admin's not to be locked out
+	if ldapGroupAttribute != "" && !user.IsAdmin() {
+		groups := sr.Entries[0].GetAttributeValues(ldapGroupAttribute)
+		roles := mapRoles(groups, setting.GetStr(conf.LdapGroupRoleMapping), true)
+		if !slices.Equal(roles, user.Role) {
+			user.Role = roles
+			if err = op.UpdateUser(user); err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+		}
+	}
+	if !loginIPAllowed(c, user, "ldap") {
+		return
+	}
 
 	// generate token
 	token, err := common.GenerateToken(user)
@@ -117,6 +149,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
+	common.Audit(c, user, model.AuditLogin, "ldap", "", nil)
 	common.SuccessResp(c, gin.H{"token": token})
 	loginCache.Del(ip)
 }
@@ -131,7 +164,7 @@ func ladpRegister(username string) (*model.User, error) {
 		Password:   random.String(16),
 		Permission: int32(setting.GetInt(conf.LdapDefaultPermission, 0)),
 		BasePath:   setting.GetStr(conf.LdapDefaultDir),
-		Role:       nil,
+		Role:       model.Roles{op.GetDefaultRoleID()},
 		Disabled:   false,
 	}
 	if err := db.CreateUser(user); err != nil {
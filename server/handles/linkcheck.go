@@ -0,0 +1,27 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type LinkCheckReq struct {
+	Path string `json:"path" form:"path"`
+}
+
+// CheckLinks reports the cycles and the dangling targets of the link objs under path
+func CheckLinks(c *gin.Context) {
+	var req LinkCheckReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	report, err := fs.CheckLinks(c, utils.FixAndCleanPath(req.Path))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, report)
+}
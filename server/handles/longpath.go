@@ -0,0 +1,93 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// the limit used when none is given, the usual limit of a name or a path component
+const defaultPathLimit = 255
+
+type LongPathReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Limit is the maximum length of the backend path
+	Limit      int  `json:"limit" form:"limit"`
+	CountBytes bool `json:"count_bytes" form:"count_bytes"`
+	// Apply adds a task renaming the objs to the suggested names,
+	// otherwise it's a dry run only reporting them
+	Apply bool `json:"apply" form:"apply"`
+}
+
+// FsLongPaths reports the objs under path whose backend path exceeds the limit
+// with suggested shorter names, and the longest paths found
+func FsLongPaths(c *gin.Context) {
+	var req LongPathReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultPathLimit
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if req.Apply && !common.HasPermission(common.MergeRolePermissions(user, reqPath), common.PermRename) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	report, err := fs.ScanLongPaths(c, reqPath, fs.LongPathArgs{
+		Limit:      req.Limit,
+		CountBytes: req.CountBytes,
+		Password:   req.Password,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	resp := gin.H{"report": report}
+	if req.Apply {
+		var renames []fs.LongPath
+		for _, lp := range report.Over {
+			if lp.Suggested != "" {
+				renames = append(renames, lp)
+			}
+		}
+		if len(renames) > 0 {
+			t := &fs.LongPathTrimTask{
+				TaskExtension: task.TaskExtension{Creator: user},
+				Status:        "queued",
+				Path:          reqPath,
+				Renames:       renames,
+			}
+			fs.LongPathTrimTaskManager.Add(t)
+			resp["task"] = getTaskInfo(t)
+		}
+	}
+	common.SuccessResp(c, resp)
+}
@@ -0,0 +1,97 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ManifestReq struct {
+	Paths    []string `json:"paths"`
+	Password string   `json:"password"`
+	// Expire is the lifetime of the links in seconds, 0 for the link_expiration setting
+	Expire int64 `json:"expire"`
+	// Format is json, or aria2 for an input file of aria2 which most download
+	// managers can import
+	Format string `json:"format"`
+}
+
+type ManifestEntry struct {
+	Path     string                     `json:"path"`
+	Name     string                     `json:"name"`
+	URL      string                     `json:"url"`
+	Size     int64                      `json:"size"`
+	HashInfo map[*utils.HashType]string `json:"hash_info"`
+}
+
+type ManifestSkipped struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// aria2 verifies the downloads with the first of these it finds
+var aria2Checksums = []struct {
+	ht   *utils.HashType
+	name string
+}{
+	{utils.SHA256, "sha-256"},
+	{utils.SHA1, "sha-1"},
+	{utils.MD5, "md5"},
+}
+
+// FsManifest returns the signed download links of many files so a download
+// manager can fetch them in parallel, the paths the user can't read are skipped
+func FsManifest(c *gin.Context) {
+	var req ManifestReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) > maxBatchSignItems {
+		common.ErrorStrResp(c, fmt.Sprintf("paths can't > %d", maxBatchSignItems), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	apiUrl := common.GetApiUrl(c.Request)
+	entries := make([]ManifestEntry, 0, len(req.Paths))
+	var skipped []ManifestSkipped
+	for _, p := range req.Paths {
+		reqPath, obj, s, err := batchSignItem(c, user, BatchSignItem{Path: p, Expire: req.Expire}, req.Password)
+		if err != nil {
+			skipped = append(skipped, ManifestSkipped{Path: p, Error: err.Error()})
+			continue
+		}
+		entries = append(entries, ManifestEntry{
+			Path:     reqPath,
+			Name:     obj.GetName(),
+			URL:      fmt.Sprintf("%s/d%s?sign=%s", apiUrl, utils.EncodePath(reqPath, true), s),
+			Size:     obj.GetSize(),
+			HashInfo: obj.GetHash().Export(),
+		})
+	}
+	if req.Format == "aria2" {
+		var b strings.Builder
+		for _, e := range entries {
+			b.WriteString(e.URL + "\n")
+			b.WriteString("  out=" + e.Name + "\n")
+			for _, cs := range aria2Checksums {
+				if v := e.HashInfo[cs.ht]; v != "" {
+					b.WriteString("  checksum=" + cs.name + "=" + v + "\n")
+					break
+				}
+			}
+		}
+		c.Header("Content-Disposition", `attachment; filename="manifest.aria2"`)
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(b.String()))
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"entries": entries,
+		"skipped": skipped,
+	})
+}
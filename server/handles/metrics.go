@@ -0,0 +1,101 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/snapshot"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xhofe/tache"
+)
+
+var taskQueueDepthDesc = prometheus.NewDesc("alist_task_queue_depth",
+	"Count of the unfinished tasks by task type and state.", []string{"type", "state"}, nil)
+
+// taskCollector reports the depth of the task queues when scraped
+type taskCollector struct{}
+
+func (taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskQueueDepthDesc
+}
+
+func (taskCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, states := range map[string][]tache.State{
+		"upload":                    taskStates(fs.UploadTaskManager),
+		"copy":                      taskStates(fs.CopyTaskManager),
+		"move":                      taskStates(fs.MoveTaskManager),
+		"compress":                  taskStates(fs.CompressTaskManager),
+		"offline_download":          taskStates(tool.DownloadTaskManager),
+		"offline_download_transfer": taskStates(tool.TransferTaskManager),
+		"s3_transition":             taskStates(fs.S3TransitionTaskManager),
+		"rehash":                    taskStates(fs.RehashTaskManager),
+		"archive_verify":            taskStates(fs.ArchiveVerifyTaskManager),
+		"ext_check":                 taskStates(fs.ExtCheckTaskManager),
+		"path_trim":                 taskStates(fs.LongPathTrimTaskManager),
+		"sync":                      taskStates(fs.SyncTaskManager),
+		"sync_pair":                 taskStates(fs.SyncPairTaskManager),
+		"dedup":                     taskStates(fs.DedupTaskManager),
+		"snapshot":                  taskStates(snapshot.TaskManager),
+		"transfer":                  taskStates(fs.TransferTaskManager),
+		"decompress":                taskStates(fs.ArchiveDownloadTaskManager),
+		"decompress_upload":         taskStates(fs.ArchiveContentUploadTaskManager),
+	} {
+		var pending, running, retrying int
+		for _, state := range states {
+			switch state {
+			case tache.StatePending:
+				pending++
+			case tache.StateRunning, tache.StateCanceling:
+				running++
+			case tache.StateWaitingRetry, tache.StateBeforeRetry:
+				retrying++
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(taskQueueDepthDesc, prometheus.GaugeValue, float64(pending), name, "pending")
+		ch <- prometheus.MustNewConstMetric(taskQueueDepthDesc, prometheus.GaugeValue, float64(running), name, "running")
+		ch <- prometheus.MustNewConstMetric(taskQueueDepthDesc, prometheus.GaugeValue, float64(retrying), name, "retrying")
+	}
+}
+
+func taskStates[T task.TaskExtensionInfo](manager task.Manager[T]) []tache.State {
+	tasks := manager.GetAll()
+	states := make([]tache.State, 0, len(tasks))
+	for _, t := range tasks {
+		states = append(states, t.GetState())
+	}
+	return states
+}
+
+var (
+	metricsOnce    sync.Once
+	metricsHandler gin.HandlerFunc
+)
+
+// Metrics serves the metrics in the prometheus format, to the scrapers having
+// the metrics token only
+func Metrics(c *gin.Context) {
+	token := setting.GetStr(conf.MetricsToken)
+	if token == "" {
+		c.Status(404)
+		return
+	}
+	auth := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+		c.Status(401)
+		return
+	}
+	metricsOnce.Do(func() {
+		metrics.Registry.MustRegister(taskCollector{})
+		metricsHandler = gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	})
+	metricsHandler(c)
+}
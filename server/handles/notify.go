@@ -0,0 +1,21 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/notify"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListNotifiers responds the notification channels and whether they are
+// configured
+func ListNotifiers(c *gin.Context) {
+	common.SuccessResp(c, notify.Names())
+}
+
+func TestNotifier(c *gin.Context) {
+	if err := notify.Test(c.Query("name")); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -295,6 +295,8 @@ type AddOfflineDownloadReq struct {
 	Path         string   `json:"path"`
 	Tool         string   `json:"tool"`
 	DeletePolicy string   `json:"delete_policy"`
+	// Priority of the tasks, -1 low, 0 normal, 1 high
+	Priority int `json:"priority"`
 }
 
 func AddOfflineDownload(c *gin.Context) {
@@ -326,6 +328,7 @@ func AddOfflineDownload(c *gin.Context) {
 			DstDirPath:   reqPath,
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
+			Priority:     task.FixPriority(req.Priority),
 		})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -339,3 +342,10 @@ func AddOfflineDownload(c *gin.Context) {
 		"tasks": getTaskInfos(tasks),
 	})
 }
+
+// OfflineDownloadCancel cancels an offline download and cleans up its temp files
+// and the partially transferred objects, which are kept if keep_partial is true
+func OfflineDownloadCancel(c *gin.Context, t *tool.DownloadTask) {
+	keepPartial := c.Query("keep_partial") == "true"
+	common.SuccessResp(c, tool.Cancel(c, t, keepPartial))
+}
@@ -0,0 +1,105 @@
+package handles
+
+import (
+	"fmt"
+	"net/url"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPresignUploadExpire = 3600
+	// the longest lifetime of a presigned upload link, as the one of s3
+	maxPresignUploadExpire = 7 * 24 * 3600
+)
+
+type PresignReq struct {
+	Path string `json:"path" binding:"required"`
+	// Method is GET for a download link or PUT for an upload one
+	Method string `json:"method"`
+	// Expire is the lifetime of the link in seconds, 0 for the link_expiration
+	// setting for the downloads and an hour for the uploads
+	Expire   int64  `json:"expire"`
+	Password string `json:"password"`
+}
+
+type PresignResp struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+	Sign   string `json:"sign"`
+}
+
+// FsPresign makes a link to download or upload a file without the token of
+// the user, the uploads are PUT with the file as the body like /api/fs/put
+func FsPresign(c *gin.Context) {
+	var req PresignReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	apiUrl := common.GetApiUrl(c.Request)
+	switch req.Method {
+	case "", "GET":
+		reqPath, _, s, err := batchSignItem(c, user, BatchSignItem{Path: req.Path, Expire: req.Expire}, req.Password)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		common.SuccessResp(c, PresignResp{
+			URL:    fmt.Sprintf("%s/d%s?sign=%s", apiUrl, utils.EncodePath(reqPath, true), s),
+			Method: "GET",
+			Sign:   s,
+		})
+	case "PUT":
+		reqPath, s, err := presignUpload(user, req)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		common.SuccessResp(c, PresignResp{
+			URL: fmt.Sprintf("%s/u%s?user=%s&sign=%s", apiUrl, utils.EncodePath(reqPath, true),
+				url.QueryEscape(user.Username), s),
+			Method: "PUT",
+			Sign:   s,
+		})
+	default:
+		common.ErrorStrResp(c, "method must be GET or PUT", 400)
+	}
+}
+
+// presignUpload signs the path of req as given by the user, as the upload is
+// done as the user with the permissions checked again
+func presignUpload(user *model.User, req PresignReq) (string, string, error) {
+	if req.Expire < 0 || req.Expire > maxPresignUploadExpire {
+		return "", "", errors.Errorf("expire must be between 0 and %d", maxPresignUploadExpire)
+	}
+	if req.Expire == 0 {
+		req.Expire = defaultPresignUploadExpire
+	}
+	reqPath := utils.FixAndCleanPath(req.Path)
+	path, err := user.JoinPath(reqPath)
+	if err != nil {
+		return "", "", err
+	}
+	meta, err := op.GetNearestMeta(stdpath.Dir(path))
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", "", err
+	}
+	perm := common.MergeRolePermissions(user, path)
+	if !(common.CanAccessWithRoles(user, meta, path, req.Password) &&
+		(common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, stdpath.Dir(path)))) {
+		return "", "", errs.PermissionDenied
+	}
+	return reqPath, sign.WithDurationUpload(common.PresignUploadData(user, reqPath),
+		time.Duration(req.Expire)*time.Second), nil
+}
@@ -0,0 +1,132 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultQRCodeSize = 256
+	maxQRCodeSize     = 2048
+)
+
+var qrcodeLevels = map[string]qrcode.RecoveryLevel{
+	"low":     qrcode.Low,
+	"medium":  qrcode.Medium,
+	"high":    qrcode.High,
+	"highest": qrcode.Highest,
+}
+
+type QRCodeReq struct {
+	// Size is the width and height of the png in pixels
+	Size int `json:"size" form:"size"`
+	// Level is the error correction level: low, medium, high or highest
+	Level string `json:"level" form:"level"`
+}
+
+type FsQRCodeReq struct {
+	QRCodeReq
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type ShareQRCodeReq struct {
+	QRCodeReq
+	ShareID string `json:"share_id" form:"share_id"`
+}
+
+func writeQRCode(c *gin.Context, req QRCodeReq, content string) {
+	size := req.Size
+	if size <= 0 {
+		size = defaultQRCodeSize
+	}
+	if size > maxQRCodeSize {
+		common.ErrorStrResp(c, fmt.Sprintf("size can't > %d", maxQRCodeSize), 400)
+		return
+	}
+	level := qrcode.Medium
+	if req.Level != "" {
+		l, ok := qrcodeLevels[req.Level]
+		if !ok {
+			common.ErrorStrResp(c, "invalid level", 400)
+			return
+		}
+		level = l
+	}
+	png, err := qrcode.Encode(content, level, size)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// FsQRCode returns a QR code of the signed download link of a file
+func FsQRCode(c *gin.Context) {
+	var req FsQRCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	c.Set("meta", meta)
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorResp(c, errs.NotFile, 400)
+		return
+	}
+	// the link is opened on another device without the session, so it is always signed
+	link := fmt.Sprintf("%s/d%s?sign=%s",
+		common.GetApiUrl(c.Request),
+		utils.EncodePath(reqPath, true),
+		sign.Sign(reqPath))
+	writeQRCode(c, req.QRCodeReq, link)
+}
+
+// ShareQRCode returns a QR code of the url of a share created by the user
+func ShareQRCode(c *gin.Context) {
+	var req ShareQRCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if _, err := db.GetShareByCreatorAndShareID(user.ID, req.ShareID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	writeQRCode(c, req.QRCodeReq, shareURL(c, req.ShareID))
+}
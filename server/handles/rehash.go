@@ -0,0 +1,34 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type RehashReq struct {
+	Path string `json:"path" form:"path"`
+}
+
+// Rehash adds a task refreshing the recorded hashes of the files under path,
+// the updated files are listed in the task once it finishes
+func Rehash(c *gin.Context) {
+	var req RehashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	t := &fs.RehashTask{
+		TaskExtension: task.TaskExtension{Creator: user},
+		Status:        "queued",
+		Path:          utils.FixAndCleanPath(req.Path),
+	}
+	fs.RehashTaskManager.Add(t)
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
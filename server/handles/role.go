@@ -6,6 +6,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -48,7 +49,13 @@ func CreateRole(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.CreateRole(&req); err != nil {
+	if _, err := utils.ParseCIDRs(req.AllowedIPs); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := op.CreateRole(&req)
+	common.Audit(c, nil, model.AuditRoleCreate, req.Name, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -62,11 +69,19 @@ func UpdateRole(c *gin.Context) {
 		Description      string                  `json:"description"`
 		PermissionScopes []model.PermissionEntry `json:"permission_scopes"`
 		Default          *bool                   `json:"default"`
+		Require2FA       *bool                   `json:"require_2fa"`
+		AllowedIPs       *string                 `json:"allowed_ips"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if req.AllowedIPs != nil {
+		if _, err := utils.ParseCIDRs(*req.AllowedIPs); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
 	role, err := op.GetRole(req.ID)
 	if err != nil {
 		common.ErrorResp(c, err, 500, true)
@@ -83,10 +98,20 @@ func UpdateRole(c *gin.Context) {
 	role.Name = req.Name
 	role.Description = req.Description
 	role.PermissionScopes = req.PermissionScopes
+	// the ranges are kept if not given, like the other optional fields
+	if req.AllowedIPs != nil {
+		role.AllowedIPs = *req.AllowedIPs
+	}
 	if req.Default != nil {
 		role.Default = *req.Default
 	}
-	if err := op.UpdateRole(role); err != nil {
+	// the guest can't enroll 2FA
+	if req.Require2FA != nil && role.Name != "guest" {
+		role.Require2FA = *req.Require2FA
+	}
+	err = op.UpdateRole(role)
+	common.Audit(c, nil, model.AuditRoleUpdate, role.Name, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -109,7 +134,9 @@ func DeleteRole(c *gin.Context) {
 		common.ErrorResp(c, errs.ErrChangeDefaultRole, 403)
 		return
 	}
-	if err := op.DeleteRole(uint(id)); err != nil {
+	err = op.DeleteRole(uint(id))
+	common.Audit(c, nil, model.AuditRoleDelete, role.Name, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -19,6 +19,12 @@ type SearchReq struct {
 	Password string `json:"password"`
 }
 
+type SearchPageResp struct {
+	common.PageResp
+	// Cursor gets the next page, empty after the last one
+	Cursor string `json:"cursor"`
+}
+
 type SearchResp struct {
 	model.SearchNode
 	Type int `json:"type"`
@@ -43,8 +49,16 @@ func Search(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	// the searchers leave out what the user can't see, the results are still
+	// checked for the passwords and the rules they can't filter
+	req.Restrict, err = common.SearchRestriction(user, req.Parent)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
 	var (
 		filteredNodes []model.SearchNode
+		next          *model.SearchCursor
 	)
 	for len(filteredNodes) < req.PerPage {
 		nodes, _, err := search.Search(c, req.SearchReq)
@@ -53,9 +67,12 @@ func Search(c *gin.Context) {
 			return
 		}
 		if len(nodes) == 0 {
+			next = nil
 			break
 		}
+		consumed := 0
 		for _, node := range nodes {
+			consumed++
 			if !strings.HasPrefix(node.Parent, user.BasePath) {
 				continue
 			}
@@ -71,12 +88,24 @@ func Search(c *gin.Context) {
 				break
 			}
 		}
-		req.Page++
+		next = req.NextCursor(nodes[consumed-1], consumed)
+		// a short page is the last one
+		if len(nodes) < req.PerPage && consumed == len(nodes) {
+			next = nil
+			break
+		}
+		req.After = next
+	}
+	resp := SearchPageResp{
+		PageResp: common.PageResp{
+			Content: utils.MustSliceConvert(filteredNodes, nodeToSearchResp),
+			Total:   int64(len(filteredNodes)),
+		},
+	}
+	if next != nil {
+		resp.Cursor = next.Encode()
 	}
-	common.SuccessResp(c, common.PageResp{
-		Content: utils.MustSliceConvert(filteredNodes, nodeToSearchResp),
-		Total:   int64(len(filteredNodes)),
-	})
+	common.SuccessResp(c, resp)
 }
 
 func nodeToSearchResp(node model.SearchNode) SearchResp {
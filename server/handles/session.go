@@ -3,6 +3,7 @@ package handles
 import (
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +15,7 @@ type SessionResp struct {
 	Status     int    `json:"status"`
 	UA         string `json:"ua"`
 	IP         string `json:"ip"`
+	Current    bool   `json:"current,omitempty"`
 }
 
 func ListMySessions(c *gin.Context) {
@@ -31,6 +33,7 @@ func ListMySessions(c *gin.Context) {
 			Status:     s.Status,
 			UA:         s.UserAgent,
 			IP:         s.IP,
+			Current:    s.DeviceKey == c.GetString("device_key"),
 		}
 	}
 	common.SuccessResp(c, resp)
@@ -40,6 +43,10 @@ type EvictSessionReq struct {
 	SessionID string `json:"session_id"`
 }
 
+type EvictUserSessionsReq struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
 func EvictMySession(c *gin.Context) {
 	var req EvictSessionReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -90,3 +97,31 @@ func EvictSession(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+// EvictMySessions logs the current user out everywhere, including the
+// current session
+func EvictMySessions(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	if err := op.RevokeUserSessions(user.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func EvictUserSessions(c *gin.Context) {
+	var req EvictUserSessionsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.RevokeUserSessions(req.UserID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -42,6 +42,7 @@ func ResetToken(c *gin.Context) {
 		return
 	}
 	sign.Instance()
+	sign.InstanceUpload()
 	common.SuccessResp(c, token)
 }
 
@@ -57,6 +58,7 @@ func SetToken(c *gin.Context) {
 		return
 	}
 	sign.Instance()
+	sign.InstanceUpload()
 	common.SuccessResp(c, req.Token)
 }
 
@@ -11,15 +11,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/db"
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 const shareAccessTokenLifetime = 24 * time.Hour
@@ -98,6 +101,7 @@ type ShareResp struct {
 	ExpiresAt         *time.Time `json:"expires_at"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
+	CreatorID         uint       `json:"creator_id"`
 	URL               string     `json:"url"`
 }
 
@@ -173,6 +177,7 @@ func toShareResp(c *gin.Context, share *model.Share) ShareResp {
 		ExpiresAt:         share.ExpiresAt,
 		CreatedAt:         share.CreatedAt,
 		UpdatedAt:         share.UpdatedAt,
+		CreatorID:         share.CreatorID,
 		URL:               shareURL(c, share.ShareID),
 	}
 }
@@ -353,8 +358,55 @@ func ensureShareAccess(c *gin.Context, share *model.Share, token string) bool {
 	return true
 }
 
-func shouldTrackShareContentAccess(c *gin.Context) bool {
-	return c.Request.Method != http.MethodHead
+// recordShareEvent records an access of share for its owner, the bytes are
+// the ones of the response written so far
+func recordShareEvent(c *gin.Context, share *model.Share, kind, relPath string) {
+	bytes := int64(c.Writer.Size())
+	if bytes < 0 {
+		bytes = 0
+	}
+	err := db.CreateShareAccess(&model.ShareAccess{
+		ShareID:   share.ShareID,
+		Kind:      kind,
+		Path:      relPath,
+		IP:        utils.MaskIP(c.ClientIP()),
+		UserAgent: c.Request.UserAgent(),
+		Bytes:     bytes,
+	})
+	if err != nil {
+		log.Warnf("failed record access of share %s: %+v", share.ShareID, err)
+	}
+}
+
+// the clients which got a counted access of a shared file, their ranged
+// requests continuing it aren't counted again for a while
+var shareRangeCache = cache.NewMemCache[struct{}]()
+
+const shareRangeExpire = time.Hour
+
+// shouldTrackShareContentAccess reports whether the request of the file at
+// relPath counts as an access, the players requesting a file by ranges count
+// once at its start. A range from elsewhere only continues an access counted
+// for the same client, it counts on its own otherwise
+func shouldTrackShareContentAccess(c *gin.Context, share *model.Share, relPath string, size int64) bool {
+	if c.Request.Method == http.MethodHead {
+		return false
+	}
+	key := share.ShareID + "-" + relPath + "-" + c.ClientIP()
+	ranges, err := http_range.ParseRange(c.GetHeader("Range"), size)
+	continued := err == nil && len(ranges) > 0
+	for _, r := range ranges {
+		if r.Start == 0 {
+			continued = false
+		}
+	}
+	if continued {
+		if _, ok := shareRangeCache.Get(key); ok {
+			return false
+		}
+	}
+	shareRangeCache.Set(key, struct{}{}, cache.WithEx[struct{}](shareRangeExpire))
+	return true
 }
 
 func resolveShareTarget(share *model.Share, rawRelPath string) (string, string, error) {
@@ -633,3 +685,120 @@ func DeleteShare(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+// ListAllShares lists the shares of all the users for the admin
+func ListAllShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	shares, total, err := db.GetShares(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]ShareResp, 0, len(shares))
+	for i := range shares {
+		resp = append(resp, toShareResp(c, &shares[i]))
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   total,
+	})
+}
+
+func AdminDisableShare(c *gin.Context) {
+	var req ShareDeleteReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	share, err := db.GetShareByShareID(req.ShareID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err := db.DisableShareByShareID(share.CreatorID, share.ShareID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func AdminDeleteShare(c *gin.Context) {
+	var req ShareDeleteReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	share, err := db.GetShareByShareID(req.ShareID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err := db.DeleteShareByShareID(share.CreatorID, share.ShareID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ownedShare returns the share of the query of the current user, any share
+// for the admin, it returns nil if the request has been responded
+func ownedShare(c *gin.Context) *model.Share {
+	shareID := c.Query("share_id")
+	if shareID == "" {
+		common.ErrorStrResp(c, "share_id is required", 400)
+		return nil
+	}
+	user := c.MustGet("user").(*model.User)
+	var share *model.Share
+	var err error
+	if user.IsAdmin() {
+		share, err = db.GetShareByShareID(shareID)
+	} else {
+		share, err = db.GetShareByCreatorAndShareID(user.ID, shareID)
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil
+	}
+	return share
+}
+
+func GetShareStats(c *gin.Context) {
+	share := ownedShare(c)
+	if share == nil {
+		return
+	}
+	stats, err := db.GetShareStats(share.ShareID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, stats)
+}
+
+func ListShareAccesses(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	share := ownedShare(c)
+	if share == nil {
+		return
+	}
+	accesses, total, err := db.GetShareAccesses(share.ShareID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: accesses,
+		Total:   total,
+	})
+}
@@ -8,6 +8,7 @@ import (
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +34,7 @@ func GetPublicShareInfo(c *gin.Context) {
 	}
 	if authed {
 		_ = db.TouchShareView(share.ShareID)
+		recordShareEvent(c, share, model.ShareAccessView, "")
 	}
 	common.SuccessResp(c, PublicShareInfoResp{
 		ShareID:           share.ShareID,
@@ -86,6 +88,7 @@ func AuthPublicShare(c *gin.Context) {
 		token = shareauth.SignAccess(share, ttl)
 	}
 	_ = db.TouchShareView(share.ShareID)
+	recordShareEvent(c, share, model.ShareAccessView, "")
 	common.SuccessResp(c, gin.H{"token": token})
 }
 
@@ -208,7 +211,7 @@ func ShareDown(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -222,7 +225,8 @@ func ShareDown(c *gin.Context) {
 		common.ErrorStrResp(c, "directory download is not supported", 400)
 		return
 	}
-	if shouldTrackShareContentAccess(c) {
+	track := shouldTrackShareContentAccess(c, share, relPath, obj.GetSize())
+	if track {
 		_ = db.TouchShareDownload(share.ShareID)
 		if err := recordShareAccess(share); err != nil {
 			common.ErrorResp(c, err, 500, true)
@@ -231,6 +235,9 @@ func ShareDown(c *gin.Context) {
 	}
 	c.Set("path", targetPath)
 	Down(c)
+	if track {
+		recordShareEvent(c, share, model.ShareAccessDownload, relPath)
+	}
 }
 
 func ShareProxy(c *gin.Context) {
@@ -250,7 +257,7 @@ func ShareProxy(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -264,7 +271,8 @@ func ShareProxy(c *gin.Context) {
 		common.ErrorStrResp(c, "directory preview is not supported", 400)
 		return
 	}
-	if shouldTrackShareContentAccess(c) {
+	track := shouldTrackShareContentAccess(c, share, relPath, obj.GetSize())
+	if track {
 		if err := recordShareAccess(share); err != nil {
 			common.ErrorResp(c, err, 500, true)
 			return
@@ -272,4 +280,7 @@ func ShareProxy(c *gin.Context) {
 	}
 	c.Set("path", targetPath)
 	Proxy(c)
+	if track {
+		recordShareEvent(c, share, model.ShareAccessPreview, relPath)
+	}
 }
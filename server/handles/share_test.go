@@ -0,0 +1,45 @@
+package handles
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShouldTrackShareContentAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	share := &model.Share{ShareID: "track"}
+	datas := []struct {
+		method string
+		ip     string
+		rng    string
+		result bool
+	}{
+		{method: http.MethodHead, ip: "192.0.2.1", result: false},
+		// a range from elsewhere counts unless it continues a counted access
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=1-", result: true},
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=1-", result: false},
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=50-99", result: false},
+		{method: http.MethodGet, ip: "192.0.2.2", rng: "bytes=1-", result: true},
+		// the ranges from the start are parsed, not matched by their text
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=0-", result: true},
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=00-", result: true},
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=1-, 0-0", result: true},
+		{method: http.MethodGet, ip: "192.0.2.1", rng: "bytes=x-", result: true},
+		{method: http.MethodGet, ip: "192.0.2.1", result: true},
+	}
+	for i, data := range datas {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(data.method, "/sd/track", nil)
+		c.Request.RemoteAddr = data.ip + ":1234"
+		if data.rng != "" {
+			c.Request.Header.Set("Range", data.rng)
+		}
+		if shouldTrackShareContentAccess(c, share, "/a.mp4", 100) != data.result {
+			t.Errorf("TestShouldTrackShareContentAccess %d failed", i)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/snapshot"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListSnapshots(c *gin.Context) {
+	path := c.Query("path")
+	if path != "" {
+		path = utils.FixAndCleanPath(path)
+	}
+	snapshots, err := db.GetSnapshots(path)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, snapshots)
+}
+
+func GetSnapshot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s, err := db.GetSnapshotById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	entries, err := snapshot.GetEntries(s)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"snapshot": s,
+		"entries":  entries,
+	})
+}
+
+type CreateSnapshotReq struct {
+	Path string `json:"path"`
+}
+
+func CreateSnapshot(c *gin.Context) {
+	var req CreateSnapshotReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	t := snapshot.TakeAsTask(user, utils.FixAndCleanPath(req.Path))
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+type RestoreSnapshotReq struct {
+	ID uint `json:"id"`
+	// BackupDir overrides the snapshot_backup_dir setting when not empty
+	BackupDir string `json:"backup_dir"`
+}
+
+func RestoreSnapshot(c *gin.Context) {
+	var req RestoreSnapshotReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s, err := db.GetSnapshotById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	backupDir := req.BackupDir
+	if backupDir == "" {
+		backupDir = setting.GetStr(conf.SnapshotBackupDir)
+	}
+	if backupDir != "" {
+		backupDir = utils.FixAndCleanPath(backupDir)
+	}
+	user := c.MustGet("user").(*model.User)
+	t := snapshot.RestoreAsTask(user, s, backupDir)
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+func DeleteSnapshot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.DeleteSnapshotById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -260,9 +261,21 @@ func OIDCLoginCallback(c *gin.Context) {
 				return
 			}
 		}
+		// the admin is left as is, not to be locked out by the provider
+		if roles, ok := oidcRoles(payload); ok && !user.IsAdmin() && !slices.Equal(roles, user.Role) {
+			user.Role = roles
+			if err = op.UpdateUser(user); err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+		}
+		if !loginIPAllowed(c, user, "sso") {
+			return
+		}
 		token, err := common.GenerateToken(user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
+			return
 		}
 		if useCompatibility {
 			c.Redirect(302, common.GetApiUrl(c.Request)+"/@login?token="+token)
@@ -281,6 +294,71 @@ func OIDCLoginCallback(c *gin.Context) {
 	}
 }
 
+// oidcRoles maps the values of the role claim to the roles by the
+// sso_oidc_role_mapping setting, the default role if none is mapped. ok is
+// false if the roles are not given by the provider
+func oidcRoles(payload []byte) (model.Roles, bool) {
+	claim := setting.GetStr(conf.SSOOIDCRoleClaim)
+	if claim == "" {
+		return nil, false
+	}
+	// the nested claims are given by their path, like realm_access.roles
+	var keys []any
+	for _, key := range strings.Split(claim, ".") {
+		keys = append(keys, key)
+	}
+	var values []string
+	switch v := utils.Json.Get(payload, keys...).GetInterface().(type) {
+	case string:
+		values = []string{v}
+	case []any:
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return mapRoles(values, setting.GetStr(conf.SSOOIDCRoleMapping), false), true
+}
+
+// mapRoles gives the roles of values by mapping, a line of "value:role name"
+// for each role given by a value, the default role if none is given
+func mapRoles(values []string, mapping string, fold bool) model.Roles {
+	names := make(map[string][]string)
+	for _, line := range strings.Split(mapping, "\n") {
+		// the values may have colons, like the urns, but not the role names
+		i := strings.LastIndex(line, ":")
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[:i])
+		if fold {
+			value = strings.ToLower(value)
+		}
+		names[value] = append(names[value], strings.TrimSpace(line[i+1:]))
+	}
+	var roles model.Roles
+	for _, value := range values {
+		if fold {
+			value = strings.ToLower(value)
+		}
+		for _, name := range names[value] {
+			role, err := op.GetRoleByName(name)
+			if err != nil {
+				utils.Log.Warnf("failed get role [%s] mapped from [%s]: %v", name, value, err)
+				continue
+			}
+			if !slices.Contains(roles, int(role.ID)) {
+				roles = append(roles, int(role.ID))
+			}
+		}
+	}
+	if len(roles) == 0 {
+		roles = model.Roles{op.GetDefaultRoleID()}
+	}
+	return roles
+}
+
 func SSOLoginCallback(c *gin.Context) {
 	enabled := setting.GetBool(conf.SSOLoginEnabled)
 	usecompatibility := setting.GetBool(conf.SSOCompatibilityMode)
@@ -426,6 +504,9 @@ func SSOLoginCallback(c *gin.Context) {
 			return
 		}
 	}
+	if !loginIPAllowed(c, user, "sso") {
+		return
+	}
 	token, err := common.GenerateToken(user)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
@@ -8,6 +8,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/probe"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -38,7 +39,9 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c, req); err != nil {
+	id, err := op.CreateStorage(c, req)
+	common.Audit(c, nil, model.AuditStorageCreate, "", req.MountPath, err)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -55,7 +58,9 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c, req); err != nil {
+	err := op.UpdateStorage(c, req)
+	common.Audit(c, nil, model.AuditStorageUpdate, "", req.MountPath, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -69,7 +74,13 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteStorageById(c, uint(id)); err != nil {
+	var mountPath string
+	if s, err := db.GetStorageById(uint(id)); err == nil {
+		mountPath = s.MountPath
+	}
+	err = op.DeleteStorageById(c, uint(id))
+	common.Audit(c, nil, model.AuditStorageDelete, "", mountPath, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -119,6 +130,27 @@ func GetStorage(c *gin.Context) {
 	common.SuccessResp(c, storage)
 }
 
+// ReloadStorage re-initializes the storage, the other storages and the
+// transfers running on it go on meanwhile
+func ReloadStorage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err = op.ReloadStorage(c, uint(id))
+	var mountPath string
+	if s, err := db.GetStorageById(uint(id)); err == nil {
+		mountPath = s.MountPath
+	}
+	common.Audit(c, nil, model.AuditStorageUpdate, "", mountPath, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
 func LoadAllStorages(c *gin.Context) {
 	storages, err := db.GetEnabledStorages()
 	if err != nil {
@@ -129,18 +161,8 @@ func LoadAllStorages(c *gin.Context) {
 	conf.StoragesLoaded = false
 	go func(storages []model.Storage) {
 		for _, storage := range storages {
-			storageDriver, err := op.GetStorageByMountPath(storage.MountPath)
-			if err != nil {
-				log.Errorf("failed get storage driver: %+v", err)
-				continue
-			}
-			// drop the storage in the driver
-			if err := storageDriver.Drop(context.Background()); err != nil {
-				log.Errorf("failed drop storage: %+v", err)
-				continue
-			}
-			if err := op.LoadStorage(context.Background(), storage); err != nil {
-				log.Errorf("failed get enabled storages: %+v", err)
+			if err := op.ReloadStorage(context.Background(), storage.ID); err != nil {
+				log.Errorf("failed reload storage: %+v", err)
 				continue
 			}
 			log.Infof("success load storage: [%s], driver: [%s]",
@@ -150,3 +172,14 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+// GetStorageLatency returns the latency percentiles and the recent probes of the
+// storages, or of the storage at the mount_path query only
+func GetStorageLatency(c *gin.Context) {
+	recent, err := strconv.Atoi(c.DefaultQuery("recent", "20"))
+	if err != nil || recent < 0 {
+		common.ErrorStrResp(c, "invalid recent", 400)
+		return
+	}
+	common.SuccessResp(c, probe.Stats(c.Query("mount_path"), recent))
+}
@@ -0,0 +1,137 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/schedule"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListSyncPairs(c *gin.Context) {
+	pairs, err := db.GetSyncPairs()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, pairs)
+}
+
+func CreateSyncPair(c *gin.Context) {
+	var req model.SyncPair
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID, req.LastRunAt = 0, nil
+	if err := schedule.CheckSyncPair(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.CreateSyncPair(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateSyncPair updates a pair, the states of the last run are kept, so the
+// paths shouldn't be changed to other dirs
+func UpdateSyncPair(c *gin.Context) {
+	var req model.SyncPair
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	old, err := db.GetSyncPairById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err = schedule.CheckSyncPair(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.LastRunAt, req.CreatedAt = old.LastRunAt, old.CreatedAt
+	if err = db.UpdateSyncPair(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteSyncPair(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = db.DeleteSyncPairById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunSyncPair runs a pair now, out of its schedule
+func RunSyncPair(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	pair, err := db.GetSyncPairById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	t, err := schedule.RunSyncPair(pair)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+func ListSyncConflicts(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	conflicts, err := db.GetSyncConflicts(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, conflicts)
+}
+
+type ResolveSyncConflictReq struct {
+	ID uint `json:"id"`
+	// Keep is a, b or both
+	Keep string `json:"keep"`
+}
+
+func ResolveSyncConflict(c *gin.Context) {
+	var req ResolveSyncConflictReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	conflict, err := db.GetSyncConflictById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err = fs.ResolveSyncConflict(c, conflict, req.Keep); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/snapshot"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,7 @@ type TaskInfo struct {
 	EndTime     *time.Time  `json:"end_time"`
 	TotalBytes  int64       `json:"total_bytes"`
 	Error       string      `json:"error"`
+	Priority    int         `json:"priority"`
 }
 
 func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
@@ -56,6 +58,7 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		EndTime:     task.GetEndTime(),
 		TotalBytes:  task.GetTotalBytes(),
 		Error:       errMsg,
+		Priority:    task.GetPriority(),
 	}
 }
 
@@ -218,9 +221,31 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager)
 	taskRoute(g.Group("/copy"), fs.CopyTaskManager)
-	taskRoute(g.Group("/offline_download"), tool.DownloadTaskManager)
+	taskRoute(g.Group("/move"), fs.MoveTaskManager)
+	taskRoute(g.Group("/compress"), fs.CompressTaskManager)
+	offlineDownload := g.Group("/offline_download")
+	taskRoute(offlineDownload, tool.DownloadTaskManager)
+	offlineDownload.POST("/cancel_cleanup", getTargetedHandler(tool.DownloadTaskManager, OfflineDownloadCancel))
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/s3_transition"), fs.S3TransitionTaskManager)
+	taskRoute(g.Group("/rehash"), fs.RehashTaskManager)
+	taskRoute(g.Group("/archive_verify"), fs.ArchiveVerifyTaskManager)
+	taskRoute(g.Group("/ext_check"), fs.ExtCheckTaskManager)
+	taskRoute(g.Group("/path_trim"), fs.LongPathTrimTaskManager)
+	taskRoute(g.Group("/sync"), fs.SyncTaskManager)
+	taskRoute(g.Group("/sync_pair"), fs.SyncPairTaskManager)
+	taskRoute(g.Group("/dedup"), fs.DedupTaskManager)
+	taskRoute(g.Group("/snapshot"), snapshot.TaskManager)
+	transfer := g.Group("/fs_transfer")
+	taskRoute(transfer, fs.TransferTaskManager)
+	transfer.POST("/pause", getTargetedHandler(fs.TransferTaskManager, func(c *gin.Context, t *fs.TransferTask) {
+		t.Pause()
+		common.SuccessResp(c)
+	}))
+	transfer.POST("/resume", getTargetedHandler(fs.TransferTaskManager, func(c *gin.Context, t *fs.TransferTask) {
+		t.Resume()
+		common.SuccessResp(c)
+	}))
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
 }
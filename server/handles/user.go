@@ -44,10 +44,16 @@ func CreateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
 	}
+	if _, err := utils.ParseCIDRs(req.AllowedIPs); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
-	if err := op.CreateUser(&req); err != nil {
+	err := op.CreateUser(&req)
+	common.Audit(c, nil, model.AuditUserCreate, req.Username, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -65,6 +71,15 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	if _, err := utils.ParseCIDRs(req.AllowedIPs); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// not to lock the admin out of the account in use
+	if current := c.MustGet("user").(*model.User); current.ID == req.ID && !common.IPAllowed(&req, c.ClientIP()) {
+		common.ErrorStrResp(c, "your IP isn't in the allowed IPs", 400)
+		return
+	}
 
 	if user.Username == "admin" {
 		if !utils.SliceEqual(user.Role, req.Role) {
@@ -80,6 +95,8 @@ func UpdateUser(c *gin.Context) {
 	if req.Password == "" {
 		req.PwdHash = user.PwdHash
 		req.Salt = user.Salt
+		// the tokens and sessions are only revoked by a new password
+		req.PwdTS = user.PwdTS
 	} else {
 		req.SetPassword(req.Password)
 		req.Password = ""
@@ -87,6 +104,9 @@ func UpdateUser(c *gin.Context) {
 	if req.OtpSecret == "" {
 		req.OtpSecret = user.OtpSecret
 	}
+	// the usages are only counted by the writes or reset
+	req.UploadUsed = user.UploadUsed
+	req.HomeUsed = user.HomeUsed
 	if req.Disabled && user.IsAdmin() {
 		count, err := op.CountEnabledAdminsExcluding(user.ID)
 		if err != nil {
@@ -106,7 +126,9 @@ func UpdateUser(c *gin.Context) {
 		}
 	}
 
-	if err := op.UpdateUser(&req); err != nil {
+	err = op.UpdateUser(&req)
+	common.Audit(c, nil, model.AuditUserUpdate, req.Username, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -120,7 +142,13 @@ func DeleteUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteUserById(uint(id)); err != nil {
+	target := strconv.Itoa(id)
+	if u, err := op.GetUserById(uint(id)); err == nil {
+		target = u.Username
+	}
+	err = op.DeleteUserById(uint(id))
+	common.Audit(c, nil, model.AuditUserDelete, target, "", err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -156,6 +184,20 @@ func Cancel2FAById(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+func ResetUserUploadUsed(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.ResetUploadUsed(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
 func DelUserCache(c *gin.Context) {
 	username := c.Query("username")
 	err := op.DelUserCache(username)
@@ -0,0 +1,83 @@
+package handles
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ValidatePathsReq struct {
+	Paths    []string `json:"paths"`
+	Password string   `json:"password"`
+}
+
+type ValidatePathResp struct {
+	Raw string `json:"raw"`
+	// Path is the normalized absolute path, empty if the raw path is invalid
+	Path       string `json:"path"`
+	Accessible bool   `json:"accessible"`
+	Exists     bool   `json:"exists"`
+	IsDir      bool   `json:"is_dir"`
+	// Error is why the path is invalid or inaccessible
+	Error string `json:"error,omitempty"`
+}
+
+// FsValidatePaths runs the checks the fs handlers do on each path of a batch,
+// so a selection can be validated before a bulk operation is submitted
+func FsValidatePaths(c *gin.Context) {
+	var req ValidatePathsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) > maxBatchSignItems {
+		common.ErrorStrResp(c, fmt.Sprintf("paths can't > %d", maxBatchSignItems), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	resp := make([]ValidatePathResp, 0, len(req.Paths))
+	for _, raw := range req.Paths {
+		resp = append(resp, validatePath(c, user, raw, req.Password))
+	}
+	common.SuccessResp(c, resp)
+}
+
+func validatePath(c *gin.Context, user *model.User, raw, password string) ValidatePathResp {
+	res := ValidatePathResp{Raw: raw}
+	reqPath, err := user.JoinPath(raw)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Path = reqPath
+	if !common.CheckPathLimitWithRoles(user, reqPath) || !common.CanReadPathByRole(user, reqPath) {
+		res.Error = errs.PermissionDenied.Error()
+		return res
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		res.Error = err.Error()
+		return res
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, password) {
+		res.Error = "password is incorrect or you have no permission"
+		return res
+	}
+	res.Accessible = true
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		if !errs.IsObjectNotFound(err) {
+			res.Error = err.Error()
+		}
+		return res
+	}
+	res.Exists = true
+	res.IsDir = obj.IsDir()
+	return res
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 
 	"github.com/alist-org/alist/v3/internal/authn"
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -110,6 +112,9 @@ func FinishAuthnLogin(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if !loginIPAllowed(c, user, "webauthn") {
+		return
+	}
 
 	token, err := common.GenerateToken(user)
 	if err != nil {
@@ -219,12 +224,17 @@ func DeleteAuthnLogin(c *gin.Context) {
 	common.SuccessResp(c, "Deleted Successfully")
 }
 
+type WebAuthnCredentials struct {
+	ID          []byte `json:"id"`
+	FingerPrint string `json:"fingerprint"`
+}
+
 func GetAuthnCredentials(c *gin.Context) {
-	type WebAuthnCredentials struct {
-		ID          []byte `json:"id"`
-		FingerPrint string `json:"fingerprint"`
-	}
 	user := c.MustGet("user").(*model.User)
+	common.SuccessResp(c, authnCredentials(user))
+}
+
+func authnCredentials(user *model.User) []WebAuthnCredentials {
 	credentials := user.WebAuthnCredentials()
 	res := make([]WebAuthnCredentials, 0, len(credentials))
 	for _, v := range credentials {
@@ -234,5 +244,54 @@ func GetAuthnCredentials(c *gin.Context) {
 		}
 		res = append(res, credential)
 	}
-	common.SuccessResp(c, res)
+	return res
+}
+
+// ListUserAuthnCredentials lists the passkeys of a user for the admin
+func ListUserAuthnCredentials(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	user, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	common.SuccessResp(c, authnCredentials(user))
+}
+
+// DeleteUserAuthn revokes a passkey of a user for the admin, the id is the
+// base64 of the one listed
+func DeleteUserAuthn(c *gin.Context) {
+	type DeleteUserAuthnReq struct {
+		UserID uint   `json:"uid"`
+		ID     string `json:"id"`
+	}
+	var req DeleteUserAuthnReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(req.UserID)
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	if !slices.ContainsFunc(user.WebAuthnCredentials(), func(v webauthn.Credential) bool {
+		return base64.StdEncoding.EncodeToString(v.ID) == req.ID
+	}) {
+		common.ErrorStrResp(c, "credential not found", 404)
+		return
+	}
+	if err = db.RemoveAuthn(user, req.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if err = op.DelUserCache(user.Username); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	log "github.com/sirupsen/logrus"
 )
@@ -32,6 +33,14 @@ func HTTPContextFunc(ctx context.Context, r *http.Request) context.Context {
 		log.Debugf("MCP auth failed: %v", err)
 		return ctx
 	}
+	if !common.IPAllowed(user, utils.ClientIP(r)) {
+		log.Debugf("MCP auth failed: %s can't be used from %s", user.Username, utils.ClientIP(r))
+		return ctx
+	}
+	if common.Needs2FAEnrollment(user) {
+		log.Debugf("MCP auth failed: %s has to enroll 2FA first", user.Username)
+		return ctx
+	}
 
 	return context.WithValue(ctx, userKey, user)
 }
@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// the apis of each scope, the ones of the account itself such as the tokens,
+// the password and 2FA can't be reached with any api token
+var apiTokenScopeApis = map[string][]string{
+	model.APITokenScopeRead: {
+		"/api/me", "/api/fs/list", "/api/fs/get", "/api/fs/dirs", "/api/fs/search",
+		"/api/fs/tree", "/api/fs/dirsize", "/api/fs/hash", "/api/fs/batch_sign",
+		"/api/fs/zip", "/api/fs/archive/meta", "/api/fs/archive/list",
+	},
+	model.APITokenScopeUpload: {
+		"/api/me", "/api/fs/mkdir", "/api/fs/put", "/api/fs/form", "/api/fs/form_tree",
+		"/api/fs/tus", "/api/fs/tus/:id",
+	},
+}
+
+var apiTokenDeniedPrefixes = []string{"/api/me/", "/api/auth/", "/api/authn/"}
+
+// the admin apis aren't limited by a path, a token limited to one can't reach them
+const apiTokenAdminPrefix = "/api/admin/"
+
+// the presigned links are used as the owner of the token, out of its path
+var apiTokenPathDeniedApis = []string{"/api/fs/presign"}
+
+func apiTokenAllowed(t *model.APIToken, fullPath string) bool {
+	// the routes may be under a site path
+	if i := strings.Index(fullPath, "/api/"); i >= 0 {
+		fullPath = fullPath[i:]
+	}
+	if t.Scope == model.APITokenScopeAll {
+		for _, prefix := range apiTokenDeniedPrefixes {
+			if strings.HasPrefix(fullPath, prefix) {
+				return false
+			}
+		}
+		if t.Path == "/" {
+			return true
+		}
+		return !strings.HasPrefix(fullPath, apiTokenAdminPrefix) && !utils.SliceContains(apiTokenPathDeniedApis, fullPath)
+	}
+	for _, api := range apiTokenScopeApis[t.Scope] {
+		if fullPath == api {
+			return true
+		}
+	}
+	return false
+}
+
+// authAPIToken authenticates the request with an api token, the user is
+// given the path of the token as base path
+func authAPIToken(c *gin.Context, token string) {
+	t, err := op.GetAPIToken(token)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if !apiTokenAllowed(t, c.FullPath()) {
+		common.ErrorStrResp(c, fmt.Sprintf("the api token of scope %s can't access this api", t.Scope), 403)
+		c.Abort()
+		return
+	}
+	owner, err := op.GetUserById(t.UserID)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if owner.Disabled {
+		common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+		c.Abort()
+		return
+	}
+	// the user may be cached, it is copied to be restricted
+	user := *owner
+	if len(user.Role) > 0 {
+		roles, err := op.GetRolesByUserID(user.ID)
+		if err != nil {
+			common.ErrorStrResp(c, fmt.Sprintf("Fail to load roles: %v", err), 500)
+			c.Abort()
+			return
+		}
+		user.RolesDetail = roles
+	}
+	if t.Path != "/" {
+		base, err := user.JoinPath(t.Path)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			c.Abort()
+			return
+		}
+		user.BasePath = base
+	}
+	if !checkIP(c, &user) || !HandleSession(c, &user) {
+		return
+	}
+	if common.Needs2FAEnrollment(&user) {
+		common.ErrorStrResp(c, "2FA is required for your role, enroll it first", 403)
+		c.Abort()
+		return
+	}
+	// an upload token adds files, it can't replace the existing ones
+	if t.Scope == model.APITokenScopeUpload {
+		c.Request.Header.Set("Overwrite", "false")
+	}
+	c.Set("api_token", t)
+	log.Debugf("use api token %d of user: %+v", t.ID, user)
+	c.Next()
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestAPITokenAllowed(t *testing.T) {
+	datas := []struct {
+		scope    string
+		path     string
+		fullPath string
+		result   bool
+	}{
+		{scope: model.APITokenScopeRead, path: "/", fullPath: "/api/fs/list", result: true},
+		{scope: model.APITokenScopeRead, path: "/", fullPath: "/api/fs/put", result: false},
+		{scope: model.APITokenScopeRead, path: "/", fullPath: "/alist/api/fs/get", result: true},
+		// fs/other can run driver actions which write
+		{scope: model.APITokenScopeRead, path: "/", fullPath: "/api/fs/other", result: false},
+		{scope: model.APITokenScopeUpload, path: "/", fullPath: "/api/fs/tus/:id", result: true},
+		{scope: model.APITokenScopeUpload, path: "/", fullPath: "/api/fs/remove", result: false},
+		{scope: model.APITokenScopeAll, path: "/", fullPath: "/api/fs/remove", result: true},
+		{scope: model.APITokenScopeAll, path: "/", fullPath: "/api/admin/user/list", result: true},
+		// the admin apis aren't limited by a path
+		{scope: model.APITokenScopeAll, path: "/a", fullPath: "/api/admin/user/list", result: false},
+		// nor can it presign links used as the owner
+		{scope: model.APITokenScopeAll, path: "/", fullPath: "/api/fs/presign", result: true},
+		{scope: model.APITokenScopeAll, path: "/a", fullPath: "/api/fs/presign", result: false},
+		{scope: model.APITokenScopeAll, path: "/a", fullPath: "/alist/api/fs/presign", result: false},
+		// nor can a token manage the account
+		{scope: model.APITokenScopeAll, path: "/", fullPath: "/api/me/api_token/create", result: false},
+		{scope: model.APITokenScopeAll, path: "/", fullPath: "/api/auth/2fa/generate", result: false},
+		{scope: "unknown", path: "/", fullPath: "/api/fs/list", result: false},
+	}
+	for i, data := range datas {
+		token := &model.APIToken{Scope: data.scope, Path: data.path}
+		if apiTokenAllowed(token, data.fullPath) != data.result {
+			t.Errorf("TestAPITokenAllowed %d failed", i)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/device"
@@ -28,7 +29,7 @@ func Auth(c *gin.Context) {
 			c.Abort()
 			return
 		}
-		if !HandleSession(c, admin) {
+		if !checkIP(c, admin) || !HandleSession(c, admin) {
 			return
 		}
 		log.Debugf("use admin token: %+v", admin)
@@ -56,13 +57,17 @@ func Auth(c *gin.Context) {
 			}
 			guest.RolesDetail = roles
 		}
-		if !HandleSession(c, guest) {
+		if !checkIP(c, guest) || !HandleSession(c, guest) {
 			return
 		}
 		log.Debugf("use empty token: %+v", guest)
 		c.Next()
 		return
 	}
+	if strings.HasPrefix(token, op.APITokenPrefix) {
+		authAPIToken(c, token)
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -77,7 +82,7 @@ func Auth(c *gin.Context) {
 	}
 	// validate password timestamp
 	if userClaims.PwdTS != user.PwdTS {
-		common.ErrorStrResp(c, "Password has been changed, login please", 401)
+		common.ErrorStrResp(c, "Password has been changed or the sessions revoked, login please", 401)
 		c.Abort()
 		return
 	}
@@ -95,13 +100,49 @@ func Auth(c *gin.Context) {
 		}
 		user.RolesDetail = roles
 	}
-	if !HandleSession(c, user) {
+	if !checkIP(c, user) || !HandleSession(c, user) || !check2FAEnrollment(c, user) {
 		return
 	}
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
 
+// the apis the users who have to enroll 2FA can still use
+var enrollmentApis = []string{"/api/me", "/api/auth/2fa/generate", "/api/auth/2fa/verify", "/api/auth/logout"}
+
+func enrollmentAllowed(c *gin.Context) bool {
+	// the routes are under the path of the site
+	route := strings.TrimPrefix(c.FullPath(), strings.TrimSuffix(conf.URL.Path, "/"))
+	for _, api := range enrollmentApis {
+		if route == api {
+			return true
+		}
+	}
+	return false
+}
+
+// check2FAEnrollment responds if user has to enroll 2FA before using the api,
+// it returns false if so
+func check2FAEnrollment(c *gin.Context, user *model.User) bool {
+	if !common.Needs2FAEnrollment(user) || enrollmentAllowed(c) {
+		return true
+	}
+	common.ErrorStrResp(c, "2FA is required for your role, enroll it first", 403)
+	c.Abort()
+	return false
+}
+
+// checkIP responds if user can't be used from the client ip, it returns
+// false if so
+func checkIP(c *gin.Context, user *model.User) bool {
+	if common.IPAllowed(user, c.ClientIP()) {
+		return true
+	}
+	common.ErrorStrResp(c, "The account can't be used from your IP", 403)
+	c.Abort()
+	return false
+}
+
 // HandleSession verifies device sessions and stores context values.
 func HandleSession(c *gin.Context, user *model.User) bool {
 	clientID := c.GetHeader("Client-Id")
@@ -134,6 +175,9 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		if !checkIP(c, admin) {
+			return
+		}
 		c.Set("user", admin)
 		log.Debugf("use admin token: %+v", admin)
 		c.Next()
@@ -146,6 +190,9 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		if !checkIP(c, guest) {
+			return
+		}
 		c.Set("user", guest)
 		log.Debugf("use empty token: %+v", guest)
 		c.Next()
@@ -165,7 +212,7 @@ func Authn(c *gin.Context) {
 	}
 	// validate password timestamp
 	if userClaims.PwdTS != user.PwdTS {
-		common.ErrorStrResp(c, "Password has been changed, login please", 401)
+		common.ErrorStrResp(c, "Password has been changed or the sessions revoked, login please", 401)
 		c.Abort()
 		return
 	}
@@ -187,6 +234,9 @@ func Authn(c *gin.Context) {
 		}
 		user.RolesDetail = roles
 	}
+	if !checkIP(c, user) || !check2FAEnrollment(c, user) {
+		return
+	}
 	c.Set("user", user)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnrollmentAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	datas := []struct {
+		sitePath string
+		route    string
+		result   bool
+	}{
+		{sitePath: "", route: "/api/me", result: true},
+		{sitePath: "", route: "/api/auth/2fa/generate", result: true},
+		{sitePath: "", route: "/api/auth/logout", result: true},
+		{sitePath: "", route: "/api/fs/list", result: false},
+		{sitePath: "", route: "/api/me/update", result: false},
+		{sitePath: "/alist", route: "/alist/api/auth/2fa/verify", result: true},
+		{sitePath: "/alist/", route: "/alist/api/auth/logout", result: true},
+		{sitePath: "/alist", route: "/alist/api/me/update", result: false},
+		// the apis have to be the ones under the site path
+		{sitePath: "/alist", route: "/other/api/me", result: false},
+		{sitePath: "", route: "/api/admin/me", result: false},
+	}
+	for i, data := range datas {
+		conf.URL = &url.URL{Path: data.sitePath}
+		var result bool
+		r := gin.New()
+		r.GET(data.route, func(c *gin.Context) {
+			result = enrollmentAllowed(c)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, data.route, nil))
+		if result != data.result {
+			t.Errorf("TestEnrollmentAllowed %d failed", i)
+		}
+	}
+}
+
+func TestCheckIPForwarded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{AllowedIPs: "1.2.3.4"}
+	datas := []struct {
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		result         bool
+	}{
+		{remoteAddr: "1.2.3.4:1234", result: true},
+		{remoteAddr: "5.6.7.8:1234", result: false},
+		// the forwarded ip of an untrusted client is ignored
+		{remoteAddr: "5.6.7.8:1234", forwardedFor: "1.2.3.4", result: false},
+		{trustedProxies: []string{"5.6.7.8"}, remoteAddr: "5.6.7.8:1234", forwardedFor: "1.2.3.4", result: true},
+		{trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "5.6.7.8:1234", forwardedFor: "1.2.3.4", result: false},
+	}
+	for i, data := range datas {
+		var result bool
+		r := gin.New()
+		if err := r.SetTrustedProxies(data.trustedProxies); err != nil {
+			t.Fatalf("TestCheckIPForwarded %d: %+v", i, err)
+		}
+		r.GET("/api/fs/list", func(c *gin.Context) {
+			result = checkIP(c, user)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/api/fs/list", nil)
+		req.RemoteAddr = data.remoteAddr
+		if data.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", data.forwardedFor)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if result != data.result {
+			t.Errorf("TestCheckIPForwarded %d failed", i)
+		}
+	}
+}
@@ -54,6 +54,10 @@ func needSign(meta *model.Meta, path string) bool {
 	if common.IsStorageSignEnabled(path) {
 		return true
 	}
+	// the signs of a file drop are only given to its owners
+	if meta != nil && meta.Drop && utils.IsSubPath(meta.Path, path) {
+		return true
+	}
 	if meta == nil || meta.Password == "" {
 		return false
 	}
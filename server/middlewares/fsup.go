@@ -2,11 +2,9 @@ package middlewares
 
 import (
 	"net/url"
-	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
-	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -27,20 +25,19 @@ func FsUp(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	meta, err := op.GetNearestMeta(stdpath.Dir(path))
-	if err != nil {
-		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
-			common.ErrorResp(c, err, 500, true)
-			c.Abort()
-			return
-		}
+	drop, err := common.CheckUpload(user, path, password)
+	if errors.Is(err, errs.PermissionDenied) {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
 	}
-	perm := common.MergeRolePermissions(user, path)
-	if !(common.CanAccessWithRoles(user, meta, path, password) &&
-		(common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, stdpath.Dir(path)))) {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
 		c.Abort()
 		return
 	}
+	if drop {
+		c.Request.Header.Set("Overwrite", "false")
+	}
 	c.Next()
 }
@@ -1,9 +1,16 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"io"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
-	"io"
 )
 
 func MaxAllowed(n int) gin.HandlerFunc {
@@ -50,3 +57,43 @@ func DownloadRateLimiter(limiter stream.Limiter) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// UserDownloadRateLimiter limits a download to the download speed of its user,
+// on top of the global limit. The downloads of a user share one limiter, so
+// more connections don't make them faster.
+func UserDownloadRateLimiter(c *gin.Context) {
+	user := downloadUser(c)
+	if user == nil {
+		c.Next()
+		return
+	}
+	speed := op.DownloadSpeedOf(user)
+	if speed <= 0 {
+		c.Next()
+		return
+	}
+	DownloadRateLimiter(stream.UserDownloadLimit(user.ID, speed))(c)
+}
+
+// downloadUser returns the user of the token of a download, the guest when
+// there is none or it's invalid, as the sign of a link doesn't tell the user
+func downloadUser(c *gin.Context) *model.User {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		token = c.Query("token")
+	}
+	if token != "" {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
+			admin, _ := op.GetAdmin()
+			return admin
+		}
+		if claims, err := common.ParseToken(token); err == nil {
+			if user, err := op.GetUserByName(claims.Username); err == nil &&
+				user.PwdTS == claims.PwdTS && !user.Disabled {
+				return user
+			}
+		}
+	}
+	guest, _ := op.GetGuest()
+	return guest
+}
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of the requests by the route they
+// matched, so the paths of the files don't make a label each
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	handler := c.FullPath()
+	if handler == "" {
+		handler = "unmatched"
+	}
+	method := c.Request.Method
+	metrics.HTTPRequests.WithLabelValues(handler, method, strconv.Itoa(c.Writer.Status())).Inc()
+	metrics.HTTPDuration.WithLabelValues(handler, method).Observe(time.Since(start).Seconds())
+}
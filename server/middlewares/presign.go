@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// PresignedUp authenticates an upload by a presigned link as the user who
+// made it, the permissions are checked again by FsUp
+func PresignedUp(c *gin.Context) {
+	path := parsePath(c.Param("path"))
+	user, err := op.GetUserByName(c.Query("user"))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+		c.Abort()
+		return
+	}
+	err = sign.VerifyUpload(common.PresignUploadData(user, path), strings.TrimSuffix(c.Query("sign"), "/"))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if len(user.Role) > 0 {
+		roles, err := op.GetRolesByUserID(user.ID)
+		if err != nil {
+			common.ErrorStrResp(c, fmt.Sprintf("Fail to load roles: %v", err), 500)
+			c.Abort()
+			return
+		}
+		user.RolesDetail = roles
+	}
+	if !checkIP(c, user) {
+		return
+	}
+	c.Set("user", user)
+	// the upload handlers take the path from the header
+	c.Request.Header.Set("File-Path", url.PathEscape(path))
+	c.Next()
+}
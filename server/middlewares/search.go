@@ -1,8 +1,11 @@
 package middlewares
 
 import (
+	"time"
+
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/search"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -17,3 +20,11 @@ func SearchIndex(c *gin.Context) {
 		c.Next()
 	}
 }
+
+// ObserveLatency feeds the latency of the request to the indexer, which backs off
+// while the user requests are slow
+func ObserveLatency(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	search.ObserveLatency(time.Since(start))
+}
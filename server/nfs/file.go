@@ -0,0 +1,131 @@
+package nfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/server/ftp"
+)
+
+// the readers idle longer than this are closed, NFS has no close
+const openFileIdle = 30 * time.Second
+
+type openFile struct {
+	mu   sync.Mutex
+	r    *ftp.FileDownloadProxy
+	off  int64
+	used time.Time
+}
+
+// openFiles keeps the readers of the files being read, a client reads a file
+// by many READ calls which mostly follow each other
+type openFiles struct {
+	mu   sync.Mutex
+	m    map[string]*openFile
+	stop chan struct{}
+	once sync.Once
+}
+
+func newOpenFiles() *openFiles {
+	o := &openFiles{m: map[string]*openFile{}, stop: make(chan struct{})}
+	go o.janitor()
+	return o
+}
+
+func (o *openFiles) janitor() {
+	t := time.NewTicker(openFileIdle / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-t.C:
+		}
+		o.mu.Lock()
+		for k, f := range o.m {
+			if f.mu.TryLock() {
+				if time.Since(f.used) > openFileIdle {
+					_ = f.r.Close()
+					f.r = nil
+					delete(o.m, k)
+				}
+				f.mu.Unlock()
+			}
+		}
+		o.mu.Unlock()
+	}
+}
+
+func (o *openFiles) closeAll() {
+	o.once.Do(func() { close(o.stop) })
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for k, f := range o.m {
+		if f.r != nil {
+			_ = f.r.Close()
+		}
+		delete(o.m, k)
+	}
+}
+
+// drop closes f, which is locked
+func (o *openFiles) drop(reqPath string, f *openFile) {
+	o.mu.Lock()
+	if o.m[reqPath] == f {
+		delete(o.m, reqPath)
+	}
+	o.mu.Unlock()
+	_ = f.r.Close()
+	f.r = nil
+}
+
+// read reads the file at reqPath from off into p, eof tells the end of the
+// file is reached
+func (o *openFiles) read(ctx context.Context, reqPath string, off int64, p []byte) (n int, eof bool, err error) {
+	o.mu.Lock()
+	f, ok := o.m[reqPath]
+	if !ok {
+		f = &openFile{}
+		f.mu.Lock()
+		o.m[reqPath] = f
+		o.mu.Unlock()
+		f.r, err = ftp.OpenDownload(ctx, reqPath, off)
+		if err != nil {
+			o.mu.Lock()
+			delete(o.m, reqPath)
+			o.mu.Unlock()
+			f.mu.Unlock()
+			return 0, false, err
+		}
+		f.off = off
+	} else {
+		o.mu.Unlock()
+		f.mu.Lock()
+		if f.r == nil {
+			// its opening failed or it was closed
+			f.mu.Unlock()
+			return o.read(ctx, reqPath, off, p)
+		}
+	}
+	defer f.mu.Unlock()
+	f.used = time.Now()
+	if f.off != off {
+		if _, err = f.r.Seek(off, io.SeekStart); err != nil {
+			o.drop(reqPath, f)
+			return 0, false, err
+		}
+		f.off = off
+	}
+	n, err = io.ReadFull(f.r, p)
+	f.off += int64(n)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, true, nil
+	}
+	if err != nil {
+		o.drop(reqPath, f)
+	}
+	return n, false, err
+}
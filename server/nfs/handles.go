@@ -0,0 +1,80 @@
+package nfs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	stdpath "path"
+	"strings"
+	"sync"
+)
+
+// handleSize is the size of the file handles given out, NFSv3 takes up to 64
+const handleSize = 16
+
+// handle is what a file handle stands for, the path relative to the user
+// and the export it was reached from
+type handle struct {
+	path string
+	root string
+}
+
+// handles maps the file handles to the paths, the handles are derived from
+// the paths so a path keeps its handle, but they go stale on a restart until
+// the export is mounted again
+type handles struct {
+	mu sync.RWMutex
+	m  map[[handleSize]byte]handle
+}
+
+func newHandles() *handles {
+	return &handles{m: map[[handleSize]byte]handle{}}
+}
+
+func handleOf(path string) [handleSize]byte {
+	sum := sha256.Sum256([]byte(path))
+	var fh [handleSize]byte
+	copy(fh[:], sum[:])
+	return fh
+}
+
+// fileID is the inode number of the path
+func fileID(path string) uint64 {
+	fh := handleOf(path)
+	return binary.BigEndian.Uint64(fh[:8])
+}
+
+func (h *handles) put(path, root string) []byte {
+	fh := handleOf(path)
+	h.mu.Lock()
+	h.m[fh] = handle{path: path, root: root}
+	h.mu.Unlock()
+	return fh[:]
+}
+
+func (h *handles) get(b []byte) (handle, bool) {
+	if len(b) != handleSize {
+		return handle{}, false
+	}
+	var fh [handleSize]byte
+	copy(fh[:], b)
+	h.mu.RLock()
+	v, ok := h.m[fh]
+	h.mu.RUnlock()
+	return v, ok
+}
+
+// child is the path of name in the dir of h, ".." doesn't leave the export
+func (h handle) child(name string) (string, bool) {
+	switch {
+	case name == "" || strings.ContainsAny(name, "/\x00"):
+		return "", false
+	case name == ".":
+		return h.path, true
+	case name == "..":
+		if h.path == h.root {
+			return h.path, true
+		}
+		return stdpath.Dir(h.path), true
+	}
+	return stdpath.Join(h.path, name), true
+}
@@ -0,0 +1,72 @@
+package nfs
+
+import (
+	stdpath "path"
+)
+
+const (
+	mntOK     = 0
+	mntNoEnt  = 2
+	mntAccess = 13
+)
+
+// the auth flavor of the mounts, AUTH_UNIX, the calls are served as the user
+// of the export whatever the credential
+const authUnix = 1
+
+// mount serves the MOUNT program, the exports are mounted by their paths
+func (cn *conn) mount(proc uint32, r *xdrReader, w *xdrWriter) bool {
+	switch proc {
+	case 0, 3, 4: // NULL, UMNT, UMNTALL
+		if proc == 3 {
+			r.string(1024)
+		}
+	case 1: // MNT
+		dir := r.string(1024)
+		if r.err != nil {
+			return true
+		}
+		cn.mnt(dir, w)
+	case 2: // DUMP, the mounts aren't recorded
+		w.bool(false)
+	case 5: // EXPORT
+		for _, e := range cn.s.exports {
+			w.bool(true)
+			w.string(e)
+			// no groups
+			w.bool(false)
+		}
+		w.bool(false)
+	default:
+		return false
+	}
+	return true
+}
+
+func (cn *conn) mnt(dir string, w *xdrWriter) {
+	dir = stdpath.Clean("/" + dir)
+	exported := false
+	for _, e := range cn.s.exports {
+		if e == dir {
+			exported = true
+			break
+		}
+	}
+	if !exported {
+		w.uint32(mntAccess)
+		return
+	}
+	fi, err := cn.stat(dir)
+	if err != nil {
+		w.uint32(mntNoEnt)
+		return
+	}
+	if !fi.IsDir() {
+		w.uint32(mntNoEnt)
+		return
+	}
+	w.uint32(mntOK)
+	w.opaque(cn.s.handles.put(dir, dir))
+	w.uint32(1)
+	w.uint32(authUnix)
+}
@@ -0,0 +1,499 @@
+package nfs
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/ftp"
+)
+
+const (
+	nfsOK          = 0
+	nfsErrNoEnt    = 2
+	nfsErrIO       = 5
+	nfsErrAccess   = 13
+	nfsErrNotDir   = 20
+	nfsErrIsDir    = 21
+	nfsErrInval    = 22
+	nfsErrROFS     = 30
+	nfsErrStale    = 70
+	nfsErrBadHdl   = 10001
+	nfsErrBadCook  = 10003
+	nfsErrNotSupp  = 10004
+	nfsErrTooSmall = 10005
+)
+
+const (
+	typeReg = 1
+	typeDir = 2
+)
+
+const (
+	accessRead    = 0x01
+	accessLookup  = 0x02
+	accessExecute = 0x20
+)
+
+// the largest read served and the size of the dirs reads
+const (
+	maxRead = 1 << 20
+	dirPref = 64 << 10
+)
+
+// the size of a fattr3 and of a post_op_attr holding one
+const (
+	fattrSize      = 84
+	postOpAttrSize = 4 + fattrSize
+)
+
+// nfs serves the NFS program, the calls which would change something are
+// refused with NFS3ERR_ROFS
+func (cn *conn) nfs(proc uint32, r *xdrReader, w *xdrWriter) bool {
+	switch proc {
+	case 0: // NULL
+	case 1:
+		cn.getattr(r, w)
+	case 3:
+		cn.lookup(r, w)
+	case 4:
+		cn.access(r, w)
+	case 5: // READLINK, there are no links
+		w.uint32(nfsErrNotSupp)
+		w.bool(false)
+	case 6:
+		cn.read(r, w)
+	case 16:
+		cn.readdir(r, w, false)
+	case 17:
+		cn.readdir(r, w, true)
+	case 18:
+		cn.fsstat(r, w)
+	case 19:
+		cn.fsinfo(r, w)
+	case 20:
+		cn.pathconf(r, w)
+	// the empty wcc_data and post_op_attr of the refused changes
+	case 2, 7, 8, 9, 10, 11, 12, 13, 21: // SETATTR, WRITE, CREATE, MKDIR, SYMLINK, MKNOD, REMOVE, RMDIR, COMMIT
+		w.uint32(nfsErrROFS)
+		w.bool(false)
+		w.bool(false)
+	case 14: // RENAME
+		w.uint32(nfsErrROFS)
+		for i := 0; i < 4; i++ {
+			w.bool(false)
+		}
+	case 15: // LINK
+		w.uint32(nfsErrROFS)
+		for i := 0; i < 3; i++ {
+			w.bool(false)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (cn *conn) stat(path string) (os.FileInfo, error) {
+	return ftp.Stat(cn.ctx, path)
+}
+
+// status is the NFS status of an error of the fs
+func status(err error) uint32 {
+	switch {
+	case errs.IsNotFoundError(err):
+		return nfsErrNoEnt
+	case errs.IsNotSupportError(err):
+		return nfsErrNotSupp
+	case errors.Is(err, errs.PermissionDenied):
+		return nfsErrAccess
+	}
+	return nfsErrIO
+}
+
+// fh reads a file handle, the status isn't nfsOK for the unknown ones
+func (cn *conn) fh(r *xdrReader) (handle, uint32) {
+	b := r.opaque(64)
+	if r.err != nil {
+		return handle{}, nfsErrBadHdl
+	}
+	if len(b) != handleSize {
+		return handle{}, nfsErrBadHdl
+	}
+	h, ok := cn.s.handles.get(b)
+	if !ok {
+		return handle{}, nfsErrStale
+	}
+	return h, nfsOK
+}
+
+func writeTime(w *xdrWriter, t time.Time) {
+	if t.IsZero() || t.Unix() < 0 {
+		w.uint32(0)
+		w.uint32(0)
+		return
+	}
+	w.uint32(uint32(t.Unix()))
+	w.uint32(uint32(t.Nanosecond()))
+}
+
+func writeAttr(w *xdrWriter, path string, fi os.FileInfo) {
+	size := uint64(0)
+	if fi.IsDir() {
+		w.uint32(typeDir)
+		w.uint32(0o555)
+		w.uint32(2)
+	} else {
+		w.uint32(typeReg)
+		w.uint32(0o444)
+		w.uint32(1)
+		if fi.Size() > 0 {
+			size = uint64(fi.Size())
+		}
+	}
+	// uid, gid
+	w.uint32(0)
+	w.uint32(0)
+	// size, used
+	w.uint64(size)
+	w.uint64(size)
+	// rdev
+	w.uint32(0)
+	w.uint32(0)
+	// fsid
+	w.uint64(1)
+	w.uint64(fileID(path))
+	// atime, mtime, ctime
+	writeTime(w, fi.ModTime())
+	writeTime(w, fi.ModTime())
+	writeTime(w, fi.ModTime())
+}
+
+// writePostOpAttr writes the attributes of path, none if fi is nil
+func writePostOpAttr(w *xdrWriter, path string, fi os.FileInfo) {
+	if fi == nil {
+		w.bool(false)
+		return
+	}
+	w.bool(true)
+	writeAttr(w, path, fi)
+}
+
+func (cn *conn) getattr(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	if st != nfsOK {
+		w.uint32(st)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		return
+	}
+	w.uint32(nfsOK)
+	writeAttr(w, h.path, fi)
+}
+
+func (cn *conn) lookup(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	name := r.string(1024)
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	dir, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	if !dir.IsDir() {
+		w.uint32(nfsErrNotDir)
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	path, ok := h.child(name)
+	if !ok {
+		w.uint32(nfsErrNoEnt)
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	fi, err := cn.stat(path)
+	if err != nil {
+		w.uint32(status(err))
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	w.uint32(nfsOK)
+	w.opaque(cn.s.handles.put(path, h.root))
+	writePostOpAttr(w, path, fi)
+	writePostOpAttr(w, h.path, dir)
+}
+
+func (cn *conn) access(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	want := r.uint32()
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, fi)
+	w.uint32(want & (accessRead | accessLookup | accessExecute))
+}
+
+func (cn *conn) read(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	off, count := r.uint64(), r.uint32()
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	if fi.IsDir() {
+		w.uint32(nfsErrIsDir)
+		writePostOpAttr(w, h.path, fi)
+		return
+	}
+	if off > 1<<62 {
+		w.uint32(nfsErrInval)
+		writePostOpAttr(w, h.path, fi)
+		return
+	}
+	if count > maxRead {
+		count = maxRead
+	}
+	var data []byte
+	eof := true
+	if size := fi.Size(); int64(off) < size {
+		if rest := size - int64(off); int64(count) > rest {
+			count = uint32(rest)
+		}
+		reqPath, err := cn.user.JoinPath(h.path)
+		if err != nil {
+			w.uint32(nfsErrAccess)
+			writePostOpAttr(w, h.path, fi)
+			return
+		}
+		data = make([]byte, count)
+		n, end, err := cn.s.files.read(cn.ctx, reqPath, int64(off), data)
+		if err != nil {
+			utils.Log.Debugf("[NFS] failed read %s: %v", reqPath, err)
+			w.uint32(status(err))
+			writePostOpAttr(w, h.path, fi)
+			return
+		}
+		data = data[:n]
+		eof = end || int64(off)+int64(n) >= size
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, fi)
+	w.uint32(uint32(len(data)))
+	w.bool(eof)
+	w.opaque(data)
+}
+
+// readdir serves READDIR and READDIRPLUS, the cookie of an entry is its index
+// in the dir plus one
+func (cn *conn) readdir(r *xdrReader, w *xdrWriter, plus bool) {
+	h, st := cn.fh(r)
+	cookie := r.uint64()
+	r.next(8)
+	dirCount := r.uint32()
+	maxCount := dirCount
+	if plus {
+		maxCount = r.uint32()
+	}
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	dir, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	if !dir.IsDir() {
+		w.uint32(nfsErrNotDir)
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	objs, err := ftp.List(cn.ctx, h.path)
+	if err != nil {
+		w.uint32(status(err))
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	if cookie > uint64(len(objs)) {
+		w.uint32(nfsErrBadCook)
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	// status, dir attributes, verifier, the end of the list and eof
+	size := 4 + postOpAttrSize + 8 + 4 + 4
+	names := 0
+	entries := &xdrWriter{}
+	i := int(cookie)
+	for ; i < len(objs); i++ {
+		fi := objs[i]
+		path, ok := h.child(fi.Name())
+		if !ok {
+			continue
+		}
+		n := 4 + 8 + xdrSize(len(fi.Name())) + 8
+		entrySize := n
+		if plus {
+			entrySize += postOpAttrSize + 4 + xdrSize(handleSize)
+		}
+		if uint32(size+entrySize) > maxCount || (plus && uint32(names+n) > dirCount) {
+			break
+		}
+		size += entrySize
+		names += n
+		entries.bool(true)
+		entries.uint64(fileID(path))
+		entries.string(fi.Name())
+		entries.uint64(uint64(i + 1))
+		if plus {
+			writePostOpAttr(entries, path, fi)
+			entries.bool(true)
+			entries.opaque(cn.s.handles.put(path, h.root))
+		}
+	}
+	if i < len(objs) && entries.Len() == 0 {
+		w.uint32(nfsErrTooSmall)
+		writePostOpAttr(w, h.path, dir)
+		return
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, dir)
+	// the cookies don't change with the dir, the verifier is kept zero
+	w.uint64(0)
+	w.Write(entries.Bytes())
+	w.bool(false)
+	w.bool(i >= len(objs))
+}
+
+func (cn *conn) fsstat(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, fi)
+	// the bytes and the files, total, free and free to the user, nothing is free
+	// on a read-only export
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	// invarsec
+	w.uint32(0)
+}
+
+func (cn *conn) fsinfo(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, fi)
+	// rtmax, rtpref, rtmult
+	w.uint32(maxRead)
+	w.uint32(maxRead)
+	w.uint32(4096)
+	// wtmax, wtpref, wtmult
+	w.uint32(maxRead)
+	w.uint32(maxRead)
+	w.uint32(4096)
+	w.uint32(dirPref)
+	// maxfilesize
+	w.uint64(1<<63 - 1)
+	// time_delta, a second
+	w.uint32(1)
+	w.uint32(0)
+	// FSF3_HOMOGENEOUS
+	w.uint32(0x8)
+}
+
+func (cn *conn) pathconf(r *xdrReader, w *xdrWriter) {
+	h, st := cn.fh(r)
+	if r.err != nil {
+		return
+	}
+	if st != nfsOK {
+		w.uint32(st)
+		w.bool(false)
+		return
+	}
+	fi, err := cn.stat(h.path)
+	if err != nil {
+		w.uint32(status(err))
+		w.bool(false)
+		return
+	}
+	w.uint32(nfsOK)
+	writePostOpAttr(w, h.path, fi)
+	// linkmax, name_max
+	w.uint32(1)
+	w.uint32(255)
+	// no_trunc, chown_restricted, case_insensitive, case_preserving
+	w.bool(true)
+	w.bool(true)
+	w.bool(false)
+	w.bool(true)
+}
@@ -0,0 +1,335 @@
+// Package nfs serves the dirs of alist read-only over NFSv3, with the MOUNT
+// protocol on the same port and a portmapper answering for both. The clients
+// mount it with the port given, e.g. on linux
+// mount -t nfs -o vers=3,tcp,nolock,port=5249,mountport=5249 host:/ /mnt
+package nfs
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+)
+
+const (
+	progPortmap = 100000
+	progNFS     = 100003
+	progMount   = 100005
+)
+
+const (
+	msgCall  = 0
+	msgReply = 1
+
+	replyAccepted = 0
+	replyDenied   = 1
+
+	acceptSuccess      = 0
+	acceptProgUnavail  = 1
+	acceptProgMismatch = 2
+	acceptProcUnavail  = 3
+	acceptGarbageArgs  = 4
+
+	rejectRPCMismatch = 0
+)
+
+// the largest call taken, the calls of a read-only export are small
+const maxRecord = 64 << 10
+
+// the calls of a connection served at once
+const connConcurrency = 8
+
+// Server is the NFS server, Serve serves its connections
+type Server struct {
+	exports []string
+	user    string
+	handles *handles
+	files   *openFiles
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	port     uint32
+}
+
+// NewServer exports the dirs of exports, separated by commas, as the user
+// named username
+func NewServer(exports, username string) *Server {
+	s := &Server{
+		user:    username,
+		handles: newHandles(),
+		files:   newOpenFiles(),
+		conns:   map[net.Conn]struct{}{},
+	}
+	for _, e := range strings.Split(exports, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			s.exports = append(s.exports, utils.FixAndCleanPath(e))
+		}
+	}
+	if len(s.exports) == 0 {
+		s.exports = []string{"/"}
+	}
+	return s
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	if a, ok := l.Addr().(*net.TCPAddr); ok {
+		s.port = uint32(a.Port)
+	}
+	s.mu.Unlock()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(c)
+	}
+}
+
+// Close stops listening and closes the connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.files.closeAll()
+	return err
+}
+
+// conn is a client connection, all its calls are served as user
+type conn struct {
+	s    *Server
+	ctx  context.Context
+	user *model.User
+	wmu  sync.Mutex
+	c    net.Conn
+}
+
+// loadUser gets the user of the export if it can be used from ip
+func (s *Server) loadUser(ip string) (*model.User, error) {
+	user, err := op.GetUserByName(s.user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.New("the user of the export is disabled")
+	}
+	if !common.IPAllowed(user, ip) {
+		return nil, errors.New("the user of the export can't be used from " + ip)
+	}
+	if common.Needs2FAEnrollment(user) {
+		return nil, errors.New("the user of the export has to enroll 2FA first")
+	}
+	if roles, err := op.GetRolesByUserID(user.ID); err == nil {
+		user.RolesDetail = roles
+	}
+	return user, nil
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer func() {
+		_ = c.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+	}()
+	ip, _, err := net.SplitHostPort(c.RemoteAddr().String())
+	if err != nil {
+		ip = c.RemoteAddr().String()
+	}
+	user, err := s.loadUser(ip)
+	if err != nil {
+		utils.Log.Infof("[NFS] refused %s: %v", ip, err)
+		return
+	}
+	ctx := context.WithValue(context.Background(), "user", user)
+	ctx = context.WithValue(ctx, "meta_pass", "")
+	ctx = context.WithValue(ctx, "client_ip", ip)
+	ctx = context.WithValue(ctx, "proxy_header", &http.Header{})
+	cn := &conn{s: s, ctx: ctx, user: user, c: c}
+	br := bufio.NewReader(c)
+	sem := make(chan struct{}, connConcurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		record, err := readRecord(br)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				utils.Log.Debugf("[NFS] %s: %v", ip, err)
+			}
+			return
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if reply := cn.handle(record); reply != nil {
+				cn.wmu.Lock()
+				err := writeRecord(c, reply)
+				cn.wmu.Unlock()
+				if err != nil {
+					_ = c.Close()
+				}
+			}
+		}()
+	}
+}
+
+// readRecord reads the fragments of a record of the record marking standard
+func readRecord(r io.Reader) ([]byte, error) {
+	var record []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		h := binary.BigEndian.Uint32(header[:])
+		n := int(h & 0x7fffffff)
+		if len(record)+n > maxRecord {
+			return nil, errors.New("record too large")
+		}
+		start := len(record)
+		record = append(record, make([]byte, n)...)
+		if _, err := io.ReadFull(r, record[start:]); err != nil {
+			return nil, err
+		}
+		if h&0x80000000 != 0 {
+			return record, nil
+		}
+	}
+}
+
+func writeRecord(w io.Writer, b []byte) error {
+	buf := make([]byte, 4, 4+len(b))
+	binary.BigEndian.PutUint32(buf, 0x80000000|uint32(len(b)))
+	_, err := w.Write(append(buf, b...))
+	return err
+}
+
+// handle serves a call and returns the reply, nil for a record which isn't
+// a call
+func (cn *conn) handle(record []byte) []byte {
+	r := &xdrReader{b: record}
+	xid := r.uint32()
+	if r.uint32() != msgCall {
+		return nil
+	}
+	rpcVers := r.uint32()
+	prog, vers, proc := r.uint32(), r.uint32(), r.uint32()
+	// the credential and the verifier, the calls are all served as the user
+	r.uint32()
+	r.opaque(400)
+	r.uint32()
+	r.opaque(400)
+	if r.err != nil {
+		return nil
+	}
+	w := &xdrWriter{}
+	w.uint32(xid)
+	w.uint32(msgReply)
+	if rpcVers != 2 {
+		w.uint32(replyDenied)
+		w.uint32(rejectRPCMismatch)
+		w.uint32(2)
+		w.uint32(2)
+		return w.Bytes()
+	}
+	w.uint32(replyAccepted)
+	// AUTH_NONE verifier
+	w.uint32(0)
+	w.uint32(0)
+	var want uint32
+	switch prog {
+	case progNFS:
+		want = 3
+	case progMount:
+		want = 3
+	case progPortmap:
+		want = 2
+	default:
+		w.uint32(acceptProgUnavail)
+		return w.Bytes()
+	}
+	if vers != want {
+		w.uint32(acceptProgMismatch)
+		w.uint32(want)
+		w.uint32(want)
+		return w.Bytes()
+	}
+	body := &xdrWriter{}
+	var ok bool
+	switch prog {
+	case progNFS:
+		ok = cn.nfs(proc, r, body)
+	case progMount:
+		ok = cn.mount(proc, r, body)
+	case progPortmap:
+		ok = cn.portmap(proc, r, body)
+	}
+	switch {
+	case !ok:
+		w.uint32(acceptProcUnavail)
+	case r.err != nil:
+		w.uint32(acceptGarbageArgs)
+	default:
+		w.uint32(acceptSuccess)
+		w.Write(body.Bytes())
+	}
+	return w.Bytes()
+}
+
+// portmap answers where the NFS and the MOUNT programs are, for the clients
+// asking the port they are connected to
+func (cn *conn) portmap(proc uint32, r *xdrReader, w *xdrWriter) bool {
+	switch proc {
+	case 0:
+	case 3: // GETPORT
+		prog, vers, prot := r.uint32(), r.uint32(), r.uint32()
+		r.uint32()
+		var port uint32
+		// tcp only
+		if (prog == progNFS || prog == progMount) && vers == 3 && prot == 6 {
+			cn.s.mu.Lock()
+			port = cn.s.port
+			cn.s.mu.Unlock()
+		}
+		w.uint32(port)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package nfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errGarbage = errors.New("garbage args")
+
+// xdrReader decodes the XDR of RFC 4506, the first error is kept and the
+// reads after it return zero values
+type xdrReader struct {
+	b   []byte
+	err error
+}
+
+func (r *xdrReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = errGarbage
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *xdrReader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *xdrReader) uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// opaque reads a variable length opaque of at most max bytes
+func (r *xdrReader) opaque(max int) []byte {
+	n := int(r.uint32())
+	if n > max {
+		r.err = errGarbage
+		return nil
+	}
+	b := r.next(n)
+	r.next((4 - n%4) % 4)
+	return b
+}
+
+func (r *xdrReader) string(max int) string {
+	return string(r.opaque(max))
+}
+
+type xdrWriter struct {
+	bytes.Buffer
+}
+
+func (w *xdrWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func (w *xdrWriter) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	w.Write(b[:])
+}
+
+func (w *xdrWriter) bool(v bool) {
+	if v {
+		w.uint32(1)
+	} else {
+		w.uint32(0)
+	}
+}
+
+func (w *xdrWriter) opaque(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.Write(b)
+	w.Write(make([]byte, (4-len(b)%4)%4))
+}
+
+func (w *xdrWriter) string(s string) {
+	w.opaque([]byte(s))
+}
+
+// xdrSize is the size of an opaque or a string of n bytes
+func xdrSize(n int) int {
+	return 4 + n + (4-n%4)%4
+}
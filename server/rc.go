@@ -0,0 +1,49 @@
+package server
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/server/handles"
+	"github.com/alist-org/alist/v3/server/middlewares"
+	"github.com/alist-org/alist/v3/server/rc"
+	"github.com/gin-gonic/gin"
+)
+
+func RC(g *gin.RouterGroup) {
+	g.Use(rcEnabled, RCAuth)
+	g.POST("/*path", rc.Handle)
+}
+
+func rcEnabled(c *gin.Context) {
+	if !setting.GetBool(conf.RCEnabled) {
+		c.JSON(404, gin.H{"error": "the remote control is disabled", "status": 404})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// RCAuth takes the basic auth of `rclone rc --user --pass`, or the token of
+// the api
+func RCAuth(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		middlewares.Auth(c)
+		return
+	}
+	user, status, err := handles.BasicLogin(c, username, password, "rc")
+	if err != nil {
+		if status == 401 {
+			c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="alist"`}
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "status": status})
+		c.Abort()
+		return
+	}
+	if roles, err := op.GetRolesByUserID(user.ID); err == nil {
+		user.RolesDetail = roles
+	}
+	c.Set("user", user)
+	c.Next()
+}
@@ -0,0 +1,360 @@
+// Package rc serves a part of the remote control of rclone, so the tools made
+// for it can drive alist. See https://rclone.org/rc/
+package rc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	stdpath "path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// params are the input of a call, from the json body and the query
+type params map[string]any
+
+// errParam is a missing or invalid param, answered with 400
+type errParam struct {
+	msg string
+}
+
+func (e *errParam) Error() string {
+	return e.msg
+}
+
+func (p params) str(key string) (string, error) {
+	v, ok := p[key]
+	if !ok {
+		return "", &errParam{fmt.Sprintf("Didn't find key %q in input", key)}
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", &errParam{fmt.Sprintf("expecting string value for key %q", key)}
+	}
+	return s, nil
+}
+
+func (p params) boolean(key string) bool {
+	switch v := p[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func (p params) sub(key string) params {
+	v, _ := p[key].(map[string]any)
+	return v
+}
+
+type call func(c *gin.Context, user *model.User, in params) (any, error)
+
+var calls = map[string]call{
+	"rc/noop":             noop,
+	"core/version":        version,
+	"core/stats":          stats,
+	"operations/list":     list,
+	"operations/copyfile": copyFile,
+}
+
+// Handle answers a call as rclone does, the output or the error is the json
+// body without the envelope of the api
+func Handle(c *gin.Context) {
+	name := strings.Trim(c.Param("path"), "/")
+	in, err := readParams(c)
+	if err != nil {
+		writeError(c, name, in, &errParam{err.Error()})
+		return
+	}
+	fn, ok := calls[name]
+	if !ok {
+		writeError(c, name, in, errs.NewErr(errs.ObjectNotFound, "couldn't find method %q", name))
+		return
+	}
+	out, err := fn(c, c.MustGet("user").(*model.User), in)
+	if err != nil {
+		writeError(c, name, in, err)
+		return
+	}
+	if out == nil {
+		out = gin.H{}
+	}
+	c.JSON(200, out)
+}
+
+func readParams(c *gin.Context) (params, error) {
+	in := params{}
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		err := json.NewDecoder(c.Request.Body).Decode(&in)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return in, err
+		}
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		return in, err
+	}
+	for k, v := range c.Request.Form {
+		if len(v) > 0 {
+			in[k] = v[0]
+		}
+	}
+	return in, nil
+}
+
+func writeError(c *gin.Context, name string, in params, err error) {
+	var pErr *errParam
+	status := 500
+	switch {
+	case errors.As(err, &pErr):
+		status = 400
+	case errors.Is(errors.Cause(err), errs.PermissionDenied):
+		status = 403
+	case errs.IsNotFoundError(err):
+		status = 404
+	}
+	c.JSON(status, gin.H{
+		"error":  err.Error(),
+		"input":  in,
+		"path":   name,
+		"status": status,
+	})
+}
+
+// joinRemote gives the path of remote in the fs named like "alist:/dir", the
+// name of the remote is ignored as there is only one
+func joinRemote(user *model.User, fsName, remote string) (string, error) {
+	if i := strings.Index(fsName, ":"); i >= 0 {
+		fsName = fsName[i+1:]
+	}
+	return user.JoinPath(stdpath.Join("/", fsName, remote))
+}
+
+func noop(c *gin.Context, user *model.User, in params) (any, error) {
+	return in, nil
+}
+
+func version(c *gin.Context, user *model.User, in params) (any, error) {
+	return gin.H{
+		"version":   conf.Version,
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+		"goVersion": runtime.Version(),
+	}, nil
+}
+
+// the entries walked by a recursive list, rclone would take a partial list for
+// the whole tree so the list fails past it
+const maxListNodes = 5000
+
+type listItem struct {
+	Path     string
+	Name     string
+	Size     int64
+	MimeType string
+	ModTime  time.Time
+	IsDir    bool
+}
+
+func list(c *gin.Context, user *model.User, in params) (any, error) {
+	fsName, err := in.str("fs")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := in.str("remote")
+	if err != nil {
+		return nil, err
+	}
+	opt := in.sub("opt")
+	reqPath, err := joinRemote(user, fsName, remote)
+	if err != nil {
+		return nil, err
+	}
+	l := &lister{c: c, user: user, opt: opt, items: []listItem{}}
+	if err = l.listDir(reqPath, strings.Trim(remote, "/"), true); err != nil {
+		return nil, err
+	}
+	return gin.H{"list": l.items}, nil
+}
+
+type lister struct {
+	c     *gin.Context
+	user  *model.User
+	opt   params
+	items []listItem
+	nodes int
+}
+
+// listDir lists dir as the user sees it, the dirs under it which can't be
+// accessed are left out
+func (l *lister) listDir(dir, rel string, top bool) error {
+	c, user, opt := l.c, l.user, l.opt
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return err
+	}
+	if !common.CanAccessWithRoles(user, meta, dir, "") {
+		if top {
+			return errs.PermissionDenied
+		}
+		return nil
+	}
+	objs, err := fs.List(context.WithValue(c, "meta", meta), dir, &fs.ListArgs{})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		childPath := stdpath.Join(dir, obj.GetName())
+		if !common.CanReadPathByRole(user, childPath) {
+			continue
+		}
+		if l.nodes++; opt.boolean("recurse") && l.nodes > maxListNodes {
+			return &errParam{fmt.Sprintf("more than %d entries to list, list the dirs under it one by one", maxListNodes)}
+		}
+		item := listItem{
+			Path:    strings.TrimPrefix(stdpath.Join(rel, obj.GetName()), "/"),
+			Name:    obj.GetName(),
+			Size:    obj.GetSize(),
+			ModTime: obj.ModTime(),
+			IsDir:   obj.IsDir(),
+		}
+		if obj.IsDir() {
+			item.Size = -1
+			item.MimeType = "inode/directory"
+		} else {
+			item.MimeType = utils.GetMimeType(obj.GetName())
+		}
+		if !(item.IsDir && opt.boolean("filesOnly")) && !(!item.IsDir && opt.boolean("dirsOnly")) {
+			l.items = append(l.items, item)
+		}
+		if obj.IsDir() && opt.boolean("recurse") {
+			if err = l.listDir(childPath, item.Path, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file as the copy api does, the copies between the
+// storages are done by the tasks in the background
+func copyFile(c *gin.Context, user *model.User, in params) (any, error) {
+	var p [4]string
+	for i, key := range []string{"srcFs", "srcRemote", "dstFs", "dstRemote"} {
+		v, err := in.str(key)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	srcPath, err := joinRemote(user, p[0], p[1])
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := joinRemote(user, p[2], p[3])
+	if err != nil {
+		return nil, err
+	}
+	if srcPath == dstPath {
+		return nil, &errParam{"source and destination are the same"}
+	}
+	dstDir, dstName := stdpath.Split(dstPath)
+	if !common.CheckPathLimitWithRoles(user, srcPath) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		return nil, errs.PermissionDenied
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, stdpath.Dir(srcPath)), common.PermCopy) {
+		return nil, errs.PermissionDenied
+	}
+	if !common.HasPermission(common.MergeRolePermissions(user, dstDir), common.PermWrite) {
+		meta, err := op.GetNearestMeta(dstDir)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return nil, err
+		}
+		if !common.CanWrite(meta, dstDir) {
+			return nil, errs.PermissionDenied
+		}
+	}
+	_, err = fs.CopyAs(c, srcPath, dstDir, dstName)
+	return nil, err
+}
+
+// stats sums up the copy tasks of the user, all of them for the admin
+func stats(c *gin.Context, user *model.User, in params) (any, error) {
+	var (
+		bytes, totalBytes        int64
+		transfers, failed, total int
+		transferring             []gin.H
+		start                    time.Time
+	)
+	for _, t := range fs.CopyTaskManager.GetAll() {
+		if !user.IsAdmin() && (t.GetCreator() == nil || t.GetCreator().ID != user.ID) {
+			continue
+		}
+		total++
+		progress := t.GetProgress()
+		if math.IsNaN(progress) {
+			progress = 100
+		}
+		size := t.GetTotalBytes()
+		done := int64(float64(size) * progress / 100)
+		bytes += done
+		totalBytes += size
+		switch t.GetState() {
+		case tache.StateSucceeded:
+			transfers++
+		case tache.StateFailed, tache.StateCanceled:
+			failed++
+		case tache.StateRunning:
+			transferring = append(transferring, gin.H{
+				"name":       t.GetName(),
+				"size":       size,
+				"bytes":      done,
+				"percentage": int(progress),
+			})
+		}
+		if st := t.GetStartTime(); st != nil && (start.IsZero() || st.Before(start)) {
+			start = *st
+		}
+	}
+	var elapsed, speed float64
+	if !start.IsZero() {
+		elapsed = time.Since(start).Seconds()
+	}
+	if elapsed > 0 {
+		speed = float64(bytes) / elapsed
+	}
+	out := gin.H{
+		"bytes":          bytes,
+		"totalBytes":     totalBytes,
+		"transfers":      transfers,
+		"totalTransfers": total,
+		"errors":         failed,
+		"checks":         0,
+		"deletes":        0,
+		"renames":        0,
+		"elapsedTime":    elapsed,
+		"speed":          speed,
+		"fatalError":     false,
+		"retryError":     failed > 0,
+	}
+	if len(transferring) > 0 {
+		out["transferring"] = transferring
+	}
+	return out, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/message"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/handles"
@@ -21,8 +22,16 @@ func Init(e *gin.Engine) {
 			c.Redirect(302, conf.URL.Path)
 		})
 	}
+	// the client ip is checked against the allowed ranges of the users, only
+	// the configured proxies can forward it
+	if err := e.SetTrustedProxies(conf.Conf.TrustedProxies); err != nil {
+		utils.Log.Fatalf("failed to set trusted proxies: %s", err.Error())
+	}
 	Cors(e)
-	e.Use(middlewares.SessionRefresh)
+	e.Use(middlewares.SessionRefresh, middlewares.Metrics)
+	if tracing.Enabled() {
+		e.Use(middlewares.Tracing)
+	}
 	g := e.Group(conf.URL.Path)
 	if conf.Conf.Scheme.HttpPort != -1 && conf.Conf.Scheme.HttpsPort != -1 && conf.Conf.Scheme.ForceHttps {
 		e.Use(middlewares.ForceHttps)
@@ -32,6 +41,7 @@ func Init(e *gin.Engine) {
 	})
 	g.GET("/favicon.ico", handles.Favicon)
 	g.GET("/robots.txt", handles.Robots)
+	g.GET("/metrics", handles.Metrics)
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
@@ -40,11 +50,12 @@ func Init(e *gin.Engine) {
 	}
 	WebDav(g.Group("/dav"))
 	S3(g.Group("/s3"))
+	RC(g.Group("/rc"))
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
-	g.GET("/d/*path", signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", signCheck, downloadLimiter, handles.Proxy)
+	g.GET("/d/*path", signCheck, downloadLimiter, middlewares.UserDownloadRateLimiter, handles.Down)
+	g.GET("/p/*path", signCheck, downloadLimiter, middlewares.UserDownloadRateLimiter, handles.Proxy)
 	g.HEAD("/d/*path", signCheck, handles.Down)
 	g.HEAD("/p/*path", signCheck, handles.Proxy)
 	g.GET("/s/:share_id", handles.GetSharePage)
@@ -64,6 +75,8 @@ func Init(e *gin.Engine) {
 	g.HEAD("/ad/*path", archiveSignCheck, handles.ArchiveDown)
 	g.HEAD("/ap/*path", archiveSignCheck, handles.ArchiveProxy)
 	g.HEAD("/ae/*path", archiveSignCheck, handles.ArchiveInternalExtract)
+	g.PUT("/u/*path", middlewares.PresignedUp, middlewares.UploadRateLimiter(stream.ClientUploadLimit),
+		middlewares.FsUp, handles.FsStream)
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
@@ -78,11 +91,15 @@ func Init(e *gin.Engine) {
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	auth.GET("/me/tokens", handles.ListMyAPITokens)
+	auth.POST("/me/tokens", handles.CreateMyAPIToken)
+	auth.POST("/me/tokens/delete", handles.DeleteMyAPIToken)
 	auth.POST("/auth/2fa/generate", handles.Generate2FA)
 	auth.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 	auth.GET("/me/sessions", handles.ListMySessions)
 	auth.POST("/me/sessions/evict", handles.EvictMySession)
+	auth.POST("/me/sessions/evict_all", handles.EvictMySessions)
 
 	// auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
@@ -115,6 +132,10 @@ func Init(e *gin.Engine) {
 	share.POST("/disable", handles.DisableShare)
 	share.GET("/list", handles.ListShares)
 	share.POST("/delete", handles.DeleteShare)
+	share.GET("/qrcode", handles.ShareQRCode)
+	share.GET("/stats", handles.GetShareStats)
+	// the raw accesses hold the ip of the visitors, the owners get the stats
+	share.GET("/accesses", middlewares.AuthAdmin, handles.ListShareAccesses)
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_label(auth.Group("/label"))
 	_labelFileBinding(auth.Group("/label_file_binding"))
@@ -128,6 +149,8 @@ func Init(e *gin.Engine) {
 }
 
 func admin(g *gin.RouterGroup) {
+	g.GET("/health", handles.Health)
+
 	meta := g.Group("/meta")
 	meta.GET("/list", handles.ListMetas)
 	meta.GET("/get", handles.GetMeta)
@@ -143,8 +166,11 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.POST("/reset_upload_used", handles.ResetUserUploadUsed)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/authn/list", handles.ListUserAuthnCredentials)
+	user.POST("/authn/delete", handles.DeleteUserAuthn)
 
 	role := g.Group("/role")
 	role.GET("/list", handles.ListRoles)
@@ -161,7 +187,28 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/delete", handles.DeleteStorage)
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
+	storage.POST("/reload", handles.ReloadStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/latency", handles.GetStorageLatency)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/get", handles.GetWebhook)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.POST("/test", handles.TestWebhook)
+
+	notifier := g.Group("/notify")
+	notifier.GET("/list", handles.ListNotifiers)
+	notifier.POST("/test", handles.TestNotifier)
+
+	plugin := g.Group("/plugin")
+	plugin.GET("/list", handles.ListDriverPlugins)
+	plugin.POST("/create", handles.CreateDriverPlugin)
+	plugin.POST("/update", handles.UpdateDriverPlugin)
+	plugin.POST("/delete", handles.DeleteDriverPlugin)
+	plugin.POST("/reload", handles.ReloadDriverPlugin)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -199,6 +246,11 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
+	index.GET("/throttle", middlewares.SearchIndex, handles.GetIndexThrottle)
+	index.POST("/pause", middlewares.SearchIndex, handles.PauseIndex)
+	index.POST("/resume", middlewares.SearchIndex, handles.ResumeIndex)
+	g.POST("/rehash", handles.Rehash)
+	g.POST("/link_check", handles.CheckLinks)
 
 	label := g.Group("/label")
 	label.POST("/create", handles.CreateLabel)
@@ -212,39 +264,114 @@ func admin(g *gin.RouterGroup) {
 	labelFileBinding.POST("/delete", handles.DelLabelByFileName)
 	labelFileBinding.POST("/restore", handles.RestoreLabelFileBinding)
 
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+
+	share := g.Group("/share")
+	share.GET("/list", handles.ListAllShares)
+	share.POST("/disable", handles.AdminDisableShare)
+	share.POST("/delete", handles.AdminDeleteShare)
+
 	session := g.Group("/session")
 	session.GET("/list", handles.ListSessions)
 	session.POST("/evict", handles.EvictSession)
+	session.POST("/evict_user", handles.EvictUserSessions)
+
+	snapshot := g.Group("/snapshot")
+	snapshot.GET("/list", handles.ListSnapshots)
+	snapshot.GET("/get", handles.GetSnapshot)
+	snapshot.POST("/create", handles.CreateSnapshot)
+	snapshot.POST("/restore", handles.RestoreSnapshot)
+	snapshot.POST("/delete", handles.DeleteSnapshot)
+
+	copyJob := g.Group("/copy_job")
+	copyJob.GET("/list", handles.ListCopyJobs)
+	copyJob.POST("/create", handles.CreateCopyJob)
+	copyJob.POST("/update", handles.UpdateCopyJob)
+	copyJob.POST("/delete", handles.DeleteCopyJob)
+	copyJob.POST("/run", handles.RunCopyJob)
+	copyJob.GET("/runs", handles.ListCopyJobRuns)
+
+	syncPair := g.Group("/sync_pair")
+	syncPair.GET("/list", handles.ListSyncPairs)
+	syncPair.POST("/create", handles.CreateSyncPair)
+	syncPair.POST("/update", handles.UpdateSyncPair)
+	syncPair.POST("/delete", handles.DeleteSyncPair)
+	syncPair.POST("/run", handles.RunSyncPair)
+	syncPair.GET("/conflicts", handles.ListSyncConflicts)
+	syncPair.POST("/resolve", handles.ResolveSyncConflict)
+
+	dedup := g.Group("/dedup")
+	dedup.POST("/scan", handles.DedupScan)
+	dedup.GET("/sets", handles.DedupSets)
+	dedup.POST("/apply", handles.DedupApply)
 
 }
 
 func _fs(g *gin.RouterGroup) {
-	g.Any("/list", handles.FsList)
+	g.Any("/list", middlewares.ObserveLatency, handles.FsList)
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
-	g.Any("/get", handles.FsGet)
+	g.Any("/get", middlewares.ObserveLatency, handles.FsGet)
+	g.GET("/qrcode", handles.FsQRCode)
+	g.Any("/activity", handles.FsActivity)
+	g.Any("/tree", handles.FsTree)
+	g.Any("/dirsize", handles.FsDirSize)
+	g.POST("/hash", handles.FsHash)
+	g.Any("/zip", middlewares.DownloadRateLimiter(stream.ClientDownloadLimit), handles.FsZip)
+	g.POST("/batch_sign", handles.FsBatchSign)
+	g.POST("/presign", handles.FsPresign)
+	g.POST("/manifest", handles.FsManifest)
+	g.POST("/validate_paths", handles.FsValidatePaths)
+	g.POST("/ext_check", handles.FsExtCheck)
+	g.POST("/long_paths", handles.FsLongPaths)
 	g.Any("/other", handles.FsOther)
 	g.GET("/lark/export/download", handles.LarkExportDownload)
-	g.Any("/dirs", handles.FsDirs)
+	g.Any("/dirs", middlewares.ObserveLatency, handles.FsDirs)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
 	g.POST("/batch_rename", handles.FsBatchRename)
 	g.POST("/regex_rename", handles.FsRegexRename)
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
+	g.POST("/flatten", handles.FsFlatten)
 	g.POST("/copy", handles.FsCopy)
+	g.POST("/copy_rename", handles.FsCopyRename)
+	g.POST("/decompress", handles.FsArchiveDecompress)
+	g.POST("/compress", handles.FsCompress)
+	g.POST("/sync", handles.FsSync)
+	g.POST("/transfer", handles.FsTransfer)
 	g.POST("/remove", handles.FsRemove)
+	g.Any("/trash/list", handles.FsTrashList)
+	g.POST("/trash/restore", handles.FsTrashRestore)
+	g.POST("/trash/purge", handles.FsTrashPurge)
+	g.Any("/versions/list", handles.FsVersionList)
+	g.POST("/versions/restore", handles.FsVersionRestore)
+	g.POST("/versions/delete", handles.FsVersionDelete)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.POST("/route", handles.FsRouteContent)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
+	g.PUT("/form_tree", uploadLimiter, handles.FsFormTree)
+	g.OPTIONS("/tus", handles.TusOptions)
+	g.POST("/tus", middlewares.FsUp, handles.TusCreate)
+	g.HEAD("/tus/:id", handles.TusHead)
+	g.PATCH("/tus/:id", uploadLimiter, handles.TusPatch)
+	g.DELETE("/tus/:id", handles.TusDelete)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
 	// g.POST("/add_aria2", handles.AddOfflineDownload)
 	// g.POST("/add_qbit", handles.AddQbittorrent)
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
+	acl := g.Group("/acl", middlewares.AuthNotGuest)
+	acl.GET("/list", handles.FsACLList)
+	acl.POST("/grant", handles.FsACLGrant)
+	acl.POST("/revoke", handles.FsACLRevoke)
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMeta)
 	a.Any("/list", handles.FsArchiveList)
+	a.POST("/verify", handles.FsArchiveVerify)
 	a.POST("/decompress", handles.FsArchiveDecompress)
 }
 
@@ -107,6 +107,10 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	// the trash and the versions hold the objs of every user, they aren't served
+	if fs.InManagedDir(fp) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -150,6 +154,9 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if fs.InManagedDir(fp) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -160,6 +167,14 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		return nil, gofakes3.KeyNotFound(objectName)
 	}
 
+	// the read lasts until the contents are closed
+	release := op.AcquireRead(fp)
+	reading := false
+	defer func() {
+		if !reading {
+			release()
+		}
+	}()
 	link, file, err := fs.Link(ctx, fp, model.LinkArgs{})
 	if err != nil {
 		return nil, err
@@ -224,6 +239,13 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		}
 	}
 
+	reading = true
+	contents := rdr
+	rdr = utils.NewReadCloser(contents, func() error {
+		defer release()
+		return contents.Close()
+	})
+
 	return &gofakes3.Object{
 		// Name: gofakes3.URLEncode(objectName),
 		Name: objectName,
@@ -0,0 +1,409 @@
+package s3
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/gofakes3"
+	"github.com/alist-org/gofakes3/signature"
+	log "github.com/sirupsen/logrus"
+)
+
+// the uploads not touched for this long are dropped with their parts
+const multipartExpire = 24 * time.Hour
+
+// multipartHandler serves the multipart uploads instead of gofakes3, which
+// keeps the parts in memory. The parts are spooled to the temp dir and
+// streamed to the storage once the upload is completed, the other requests
+// are passed to next.
+type multipartHandler struct {
+	backend gofakes3.Backend
+	next    http.Handler
+	mu      sync.Mutex
+	uploads map[string]*multipartUpload
+}
+
+type multipartUpload struct {
+	bucket  string
+	object  string
+	meta    map[string]string
+	dir     string
+	mu      sync.Mutex
+	parts   map[int]multipartPart
+	touched time.Time
+}
+
+type multipartPart struct {
+	size     int64
+	etag     string
+	modified time.Time
+}
+
+func newMultipartHandler(backend gofakes3.Backend, next http.Handler) http.Handler {
+	return &multipartHandler{
+		backend: backend,
+		next:    next,
+		uploads: map[string]*multipartUpload{},
+	}
+}
+
+func multipartDir() string {
+	return filepath.Join(conf.Conf.TempDir, "s3_multipart")
+}
+
+func (h *multipartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	_, initiate := query["uploads"]
+	uploadID := query.Get("uploadId")
+	initiate = initiate && r.Method == http.MethodPost
+	if !initiate && uploadID == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	// the same auth as gofakes3, which has stored the keys
+	if authlistResolver() != nil {
+		result := signature.V4SignVerify(r)
+		if result == signature.ErrUnsupportAlgorithm {
+			result = signature.V2SignVerify(r)
+		}
+		if result != signature.ErrNone {
+			resp := signature.GetAPIError(result)
+			w.Header().Add("Content-Type", "application/xml")
+			w.WriteHeader(resp.HTTPStatusCode)
+			_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+			return
+		}
+	}
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket == "" || object == "" {
+		writeError(w, r, gofakes3.ErrInvalidURI)
+		return
+	}
+	var err error
+	switch {
+	case initiate:
+		err = h.initiate(w, r, bucket, object)
+	case r.Method == http.MethodPut:
+		err = h.putPart(w, r, bucket, object, uploadID)
+	case r.Method == http.MethodPost:
+		err = h.complete(w, r, bucket, object, uploadID)
+	case r.Method == http.MethodDelete:
+		err = h.abort(w, bucket, object, uploadID)
+	case r.Method == http.MethodGet:
+		err = h.listParts(w, bucket, object, uploadID)
+	default:
+		err = gofakes3.ErrMethodNotAllowed
+	}
+	if err != nil {
+		writeError(w, r, err)
+	}
+}
+
+func (h *multipartHandler) get(bucket, object, id string) (*multipartUpload, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	u, ok := h.uploads[id]
+	if !ok || u.bucket != bucket || u.object != object {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return u, nil
+}
+
+// remove takes the upload away, a part being put to it fails to be renamed
+func (h *multipartHandler) remove(bucket, object, id string) (*multipartUpload, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	u, ok := h.uploads[id]
+	if !ok || u.bucket != bucket || u.object != object {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	delete(h.uploads, id)
+	return u, nil
+}
+
+// expire drops the abandoned uploads, h.mu is held
+func (h *multipartHandler) expire() {
+	for id, u := range h.uploads {
+		u.mu.Lock()
+		expired := time.Since(u.touched) > multipartExpire
+		u.mu.Unlock()
+		if expired {
+			delete(h.uploads, id)
+			_ = os.RemoveAll(u.dir)
+			log.Infof("s3: dropped the expired multipart upload of %s/%s", u.bucket, u.object)
+		}
+	}
+}
+
+func (h *multipartHandler) initiate(w http.ResponseWriter, r *http.Request, bucket, object string) error {
+	id := random.String(32)
+	dir := filepath.Join(multipartDir(), id)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	meta := map[string]string{"Last-Modified": time.Now().UTC().Format(http.TimeFormat)}
+	for k, v := range r.Header {
+		if strings.HasPrefix(k, "X-Amz-") || strings.HasPrefix(k, "Content-") || k == "Cache-Control" {
+			meta[k] = v[0]
+		}
+	}
+	h.mu.Lock()
+	h.expire()
+	h.uploads[id] = &multipartUpload{
+		bucket:  bucket,
+		object:  object,
+		meta:    meta,
+		dir:     dir,
+		parts:   map[int]multipartPart{},
+		touched: time.Now(),
+	}
+	h.mu.Unlock()
+	return writeXML(w, gofakes3.InitiateMultipartUpload{
+		UploadID: gofakes3.UploadID(id),
+		Bucket:   bucket,
+		Key:      object,
+	})
+}
+
+func (h *multipartHandler) putPart(w http.ResponseWriter, r *http.Request, bucket, object, id string) (err error) {
+	defer r.Body.Close()
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > gofakes3.MaxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	u, err := h.get(bucket, object, id)
+	if err != nil {
+		return err
+	}
+	var body io.Reader = r.Body
+	size, err := strconv.ParseInt(r.Header.Get("Content-Length"), 10, 64)
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		body = &chunkedReader{r: bufio.NewReader(r.Body)}
+		size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+	}
+	if err != nil {
+		return gofakes3.ErrMissingContentLength
+	}
+	tmp, err := os.CreateTemp(u.dir, "part-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	md5Hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, md5Hash), body)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	if err = checkContentMD5(r, md5Hash); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// the upload may have been completed or aborted meanwhile, then its dir is gone
+	if err = os.Rename(tmp.Name(), filepath.Join(u.dir, strconv.Itoa(number))); err != nil {
+		return gofakes3.ErrNoSuchUpload
+	}
+	etag := `"` + hex.EncodeToString(md5Hash.Sum(nil)) + `"`
+	u.parts[number] = multipartPart{size: size, etag: etag, modified: time.Now()}
+	u.touched = time.Now()
+	w.Header().Set("ETag", etag)
+	return nil
+}
+
+func checkContentMD5(r *http.Request, md5Hash hash.Hash) error {
+	if _, ok := r.Header["Content-Md5"]; !ok {
+		return nil
+	}
+	want, err := base64.StdEncoding.DecodeString(r.Header.Get("Content-MD5"))
+	if err != nil || len(want) != md5.Size {
+		return gofakes3.ErrInvalidDigest
+	}
+	if string(want) != string(md5Hash.Sum(nil)) {
+		return gofakes3.ErrBadDigest
+	}
+	return nil
+}
+
+func (h *multipartHandler) complete(w http.ResponseWriter, r *http.Request, bucket, object, id string) error {
+	var in gofakes3.CompleteMultipartUploadRequest
+	if err := xml.NewDecoder(r.Body).Decode(&in); err != nil {
+		return gofakes3.ErrMalformedXML
+	}
+	if len(in.Parts) == 0 {
+		return gofakes3.ErrMalformedXML
+	}
+	u, err := h.remove(bucket, object, id)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(u.dir)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var (
+		files []*os.File
+		size  int64
+		sums  []byte
+	)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for i, p := range in.Parts {
+		if i > 0 && p.PartNumber <= in.Parts[i-1].PartNumber {
+			return gofakes3.ErrInvalidPartOrder
+		}
+		part, ok := u.parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != strings.Trim(part.etag, `"`) {
+			return gofakes3.ErrInvalidPart
+		}
+		f, err := os.Open(filepath.Join(u.dir, strconv.Itoa(p.PartNumber)))
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		size += part.size
+		sum, _ := hex.DecodeString(strings.Trim(part.etag, `"`))
+		sums = append(sums, sum...)
+	}
+	readers := make([]io.Reader, len(files))
+	for i, f := range files {
+		readers[i] = f
+	}
+	if _, err = h.backend.PutObject(r.Context(), bucket, object, u.meta, io.MultiReader(readers...), size); err != nil {
+		return err
+	}
+	// the etag of a multipart object is the md5 of the md5s of its parts
+	sum := md5.Sum(sums)
+	return writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Bucket: bucket,
+		Key:    object,
+		ETag:   fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(in.Parts)),
+	})
+}
+
+func (h *multipartHandler) abort(w http.ResponseWriter, bucket, object, id string) error {
+	u, err := h.remove(bucket, object, id)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_ = os.RemoveAll(u.dir)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *multipartHandler) listParts(w http.ResponseWriter, bucket, object, id string) error {
+	u, err := h.get(bucket, object, id)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	res := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:   bucket,
+		Key:      object,
+		UploadID: gofakes3.UploadID(id),
+		MaxParts: gofakes3.MaxUploadPartNumber,
+	}
+	for number, p := range u.parts {
+		res.Parts = append(res.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   number,
+			LastModified: gofakes3.NewContentTime(p.modified),
+			ETag:         p.etag,
+			Size:         p.size,
+		})
+	}
+	u.mu.Unlock()
+	sort.Slice(res.Parts, func(i, j int) bool {
+		return res.Parts[i].PartNumber < res.Parts[j].PartNumber
+	})
+	return writeXML(w, res)
+}
+
+func writeXML(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/xml")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := gofakes3.ErrInternal
+	if e, ok := err.(interface{ ErrorCode() gofakes3.ErrorCode }); ok {
+		code = e.ErrorCode()
+	} else {
+		log.Errorf("s3: multipart upload of %s: %+v", r.URL.Path, err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(code.Status())
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(&gofakes3.ErrorResponse{Code: code, Message: code.Message()})
+}
+
+// chunkedReader decodes the aws-chunked body of the streaming signature v4,
+// like gofakes3 the signatures of the chunks aren't checked
+type chunkedReader struct {
+	r    *bufio.Reader
+	left int64
+	done bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.left == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		sizeStr, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil || size < 0 {
+			return 0, gofakes3.ErrIncompleteBody
+		}
+		c.left, c.done = size, size == 0
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if c.left == 0 && err == nil {
+		// the CRLF ending the data of the chunk
+		_, err = c.r.Discard(2)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
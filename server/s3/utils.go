@@ -41,6 +41,9 @@ func getBucketByName(name string) (Bucket, error) {
 }
 
 func getDirEntries(path string) ([]model.Obj, error) {
+	if fs.InManagedDir(path) {
+		return nil, gofakes3.ErrNoSuchKey
+	}
 	ctx := context.Background()
 	meta, _ := op.GetNearestMeta(path)
 	fi, err := fs.Get(context.WithValue(ctx, "meta", meta), path, &fs.GetArgs{})
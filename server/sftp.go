@@ -83,6 +83,9 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 	if guest.Disabled || !common.HasPermission(permGuest, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if !common.RemoteIPAllowed(guest, conn.RemoteAddr().String()) {
+		return nil, errors.New("the account can't be used from your IP")
+	}
 	return nil, nil
 }
 
@@ -95,6 +98,12 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if !common.RemoteIPAllowed(userObj, conn.RemoteAddr().String()) {
+		return nil, errors.New("the account can't be used from your IP")
+	}
+	if common.Needs2FAEnrollment(userObj) {
+		return nil, errors.New("2FA is required for the role of the account, enroll it first")
+	}
 	passHash := model.StaticHash(string(password))
 	if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
 		return nil, err
@@ -111,6 +120,12 @@ func (d *SftpDriver) PublicKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if !common.RemoteIPAllowed(userObj, conn.RemoteAddr().String()) {
+		return nil, errors.New("the account can't be used from your IP")
+	}
+	if common.Needs2FAEnrollment(userObj) {
+		return nil, errors.New("2FA is required for the role of the account, enroll it first")
+	}
 	keys, _, err := op.GetSSHPublicKeyByUserId(userObj.ID, 1, -1)
 	if err != nil {
 		return nil, err
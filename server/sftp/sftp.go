@@ -47,8 +47,18 @@ func (s *DriverAdapter) Stat(name string, _ bool) (*sftpd.Attr, error) {
 	return fileInfoToSftpAttr(stat), nil
 }
 
-func (s *DriverAdapter) SetStat(_ string, _ *sftpd.Attr) error {
-	return errs.NotSupport
+func (s *DriverAdapter) SetStat(name string, attr *sftpd.Attr) error {
+	stat, err := s.FtpDriver.Stat(name)
+	if err != nil {
+		return err
+	}
+	// the storages keep neither the modes nor the times, they are accepted and
+	// ignored as the clients like rsync or `put -p` give up on a failure, but
+	// the size can't be changed
+	if attr.Flags&sftpd.ATTR_SIZE != 0 && int64(attr.Size) != stat.Size() {
+		return errs.NotSupport
+	}
+	return nil
 }
 
 func (s *DriverAdapter) ReadLink(_ string) (string, error) {
@@ -79,6 +79,11 @@ func WebDAVAuth(c *gin.Context) {
 					c.Abort()
 					return
 				}
+				if !common.IPAllowed(admin, c.ClientIP()) {
+					c.Status(http.StatusForbidden)
+					c.Abort()
+					return
+				}
 				key := utils.GetMD5EncodeStr(fmt.Sprintf("%d-%s", admin.ID, c.ClientIP()))
 				if err := device.Handle(admin.ID, key, c.Request.UserAgent(), c.ClientIP()); err != nil {
 					c.Status(http.StatusForbidden)
@@ -112,6 +117,11 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if !common.IPAllowed(user, c.ClientIP()) || common.Needs2FAEnrollment(user) {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
 	if roles, err := op.GetRolesByUserID(user.ID); err == nil {
 		user.RolesDetail = roles
 	}
@@ -15,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 // slashClean is equivalent to but slightly more efficient than
@@ -56,6 +57,9 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
+	if err = op.CopyDavProps(src, dst, true); err != nil {
+		log.Warnf("failed move the dav props of %s to %s: %+v", src, dst, err)
+	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
 }
@@ -69,6 +73,9 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
+	if err = op.CopyDavProps(src, path.Join(dstDir, path.Base(src)), false); err != nil {
+		log.Warnf("failed copy the dav props of %s to %s: %+v", src, dst, err)
+	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
 }
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
@@ -91,6 +93,16 @@ type LockSystem interface {
 	// See http://www.webdav.org/specs/rfc4918.html#rfc.section.9.11.1 for
 	// when to use each error.
 	Unlock(now time.Time, token string) error
+
+	// Discover returns the locks on the named resource, the ones of its
+	// ancestors with infinite depth included. The durations are what remain.
+	Discover(now time.Time, name string) []ActiveLock
+}
+
+// ActiveLock is a lock and its token.
+type ActiveLock struct {
+	Token string
+	LockDetails
 }
 
 // LockDetails are a lock's metadata.
@@ -109,6 +121,8 @@ type LockDetails struct {
 	// ZeroDepth is whether the lock has zero depth. If it does not have zero
 	// depth, it has infinite depth.
 	ZeroDepth bool
+	// Href is the URL of the root as the client sees it, for the lockroot.
+	Href string
 }
 
 // NewMemLS returns a new in-memory LockSystem.
@@ -116,7 +130,6 @@ func NewMemLS() LockSystem {
 	return &memLS{
 		byName:  make(map[string]*memLSNode),
 		byToken: make(map[string]*memLSNode),
-		gen:     uint64(time.Now().Unix()),
 	}
 }
 
@@ -124,15 +137,15 @@ type memLS struct {
 	mu      sync.Mutex
 	byName  map[string]*memLSNode
 	byToken map[string]*memLSNode
-	gen     uint64
 	// byExpiry only contains those nodes whose LockDetails have a finite
 	// Duration and are yet to expire.
 	byExpiry byExpiry
 }
 
+// nextToken returns an opaquelocktoken URI, as some clients such as MS Office
+// only accept the tokens which are URIs.
 func (m *memLS) nextToken() string {
-	m.gen++
-	return strconv.FormatUint(m.gen, 10)
+	return "opaquelocktoken:" + uuid.NewString()
 }
 
 func (m *memLS) collectExpiredNodes(now time.Time) {
@@ -288,6 +301,27 @@ func (m *memLS) Unlock(now time.Time, token string) error {
 	return nil
 }
 
+func (m *memLS) Discover(now time.Time, name string) []ActiveLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectExpiredNodes(now)
+
+	var locks []ActiveLock
+	walkToRoot(slashClean(name), func(name0 string, first bool) bool {
+		n := m.byName[name0]
+		if n == nil || n.token == "" || (!first && n.details.ZeroDepth) {
+			return true
+		}
+		lock := ActiveLock{Token: n.token, LockDetails: n.details}
+		if lock.Duration >= 0 {
+			lock.Duration = n.expiry.Sub(now)
+		}
+		locks = append(locks, lock)
+		return true
+	})
+	return locks
+}
+
 func (m *memLS) canCreate(name string, zeroDepth bool) bool {
 	return walkToRoot(name, func(name0 string, first bool) bool {
 		n := m.byName[name0]
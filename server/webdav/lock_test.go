@@ -321,6 +321,49 @@ func TestMemLSNonCanonicalRoot(t *testing.T) {
 	}
 }
 
+func TestMemLSDiscover(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemLS()
+	deep, err := m.Create(now, LockDetails{Root: "/a", Duration: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("create /a: %v", err)
+	}
+	zero, err := m.Create(now, LockDetails{Root: "/b", Duration: infiniteTimeout, ZeroDepth: true})
+	if err != nil {
+		t.Fatalf("create /b: %v", err)
+	}
+	if !strings.HasPrefix(deep, "opaquelocktoken:") {
+		t.Errorf("token %q is not an opaquelocktoken URI", deep)
+	}
+
+	testCases := []struct {
+		name string
+		want []string
+	}{
+		{"/a", []string{deep}},
+		{"/a/c/d", []string{deep}},
+		{"/b", []string{zero}},
+		{"/b/c", nil},
+		{"/c", nil},
+	}
+	now = now.Add(4 * time.Second)
+	for _, tc := range testCases {
+		var got []string
+		for _, lock := range m.Discover(now, tc.name) {
+			got = append(got, lock.Token)
+			if lock.Token == deep && lock.Duration != 6*time.Second {
+				t.Errorf("name=%q: remaining duration got %v, want 6s", tc.name, lock.Duration)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("name=%q: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := m.Discover(now.Add(6*time.Second), "/a"); len(got) != 0 {
+		t.Errorf("expired lock discovered: %v", got)
+	}
+}
+
 func TestMemLSExpiry(t *testing.T) {
 	m := NewMemLS().(*memLS)
 	testCases := []string{
@@ -4,14 +4,26 @@ import (
 	"path"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 // ResolvePath normalizes the provided raw path and resolves it against the user's base path
 // before delegating to the user-aware JoinPath permission checks.
 func ResolvePath(user *model.User, raw string) (string, error) {
-	cleaned := utils.FixAndCleanPath(raw)
+	return resolvePath(user, raw, setting.GetStr(conf.WebdavPathNormalization, "resolve") != "reject")
+}
+
+func resolvePath(user *model.User, raw string, resolveParent bool) (string, error) {
+	// the clients on windows may send backslashes, which are separators once
+	// the path is cleaned, so the dot segments are looked for between them too
+	raw = strings.ReplaceAll(raw, "\\", "/")
+	cleaned, err := utils.NormalizeRequestPath(raw, resolveParent)
+	if err != nil {
+		return "", err
+	}
 	basePath := utils.FixAndCleanPath(user.BasePath)
 
 	if cleaned != "/" && basePath != "/" && !utils.IsSubPath(basePath, cleaned) {
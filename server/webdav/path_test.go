@@ -0,0 +1,76 @@
+package webdav
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestResolvePath(t *testing.T) {
+	user := &model.User{BasePath: "/base"}
+	testCases := []struct {
+		raw  string
+		want string
+	}{
+		{"/", "/base"},
+		{"", "/base"},
+		{"//a//b/", "/base/a/b"},
+		{"/./a/./b", "/base/a/b"},
+		{"/a/b/.", "/base/a/b"},
+		{`\a\b`, "/base/a/b"},
+		{"/base//a", "/base/a"},
+		{"/a/../b", "/base/b"},
+		{"/a/..", "/base"},
+		{"/a/..b/c..", "/base/a/..b/c.."},
+		// dot-segments never leave the base path
+		{"/../../etc/passwd", "/base/etc/passwd"},
+		{"/base/../etc", "/base/etc"},
+		{"/base/../base2", "/base/base2"},
+		{"//..//..//x", "/base/x"},
+	}
+	for _, tc := range testCases {
+		got, err := resolvePath(user, tc.raw, true)
+		if err != nil {
+			t.Fatalf("resolvePath(%q): %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Errorf("resolvePath(%q) = %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestResolvePathRejectParent(t *testing.T) {
+	user := &model.User{BasePath: "/base"}
+	for _, raw := range []string{"/a/../b", "/..", "/a/..", `\a\..\b`, "/base/../etc"} {
+		if _, err := resolvePath(user, raw, false); !errors.Is(err, errs.RelativePath) {
+			t.Errorf("resolvePath(%q) error = %v, want %v", raw, err, errs.RelativePath)
+		}
+	}
+	got, err := resolvePath(user, "//a/./b//", false)
+	if err != nil {
+		t.Fatalf("resolvePath: %v", err)
+	}
+	if got != "/base/a/b" {
+		t.Errorf("resolvePath = %q, want %q", got, "/base/a/b")
+	}
+}
+
+func TestResolvePathRootUser(t *testing.T) {
+	user := &model.User{BasePath: "/"}
+	testCases := map[string]string{
+		"//a/./b/":  "/a/b",
+		"/a/../../": "/",
+		"/../a":     "/a",
+	}
+	for raw, want := range testCases {
+		got, err := resolvePath(user, raw, true)
+		if err != nil {
+			t.Fatalf("resolvePath(%q): %v", raw, err)
+		}
+		if got != want {
+			t.Errorf("resolvePath(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"mime"
 	"net/http"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 )
 
@@ -109,6 +112,9 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, LockSystem, string, model.Obj) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// expensive is true if the property is only returned when named, as
+	// RFC 4331 asks for the quota properties.
+	expensive bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -154,9 +160,10 @@ var liveProps = map[xml.Name]struct {
 		dir: false,
 	},
 
-	// TODO: The lockdiscovery property requires LockSystem to list the
-	// active locks on a resource.
-	{Space: "DAV:", Local: "lockdiscovery"}: {},
+	{Space: "DAV:", Local: "lockdiscovery"}: {
+		findFn: findLockDiscovery,
+		dir:    true,
+	},
 	{Space: "DAV:", Local: "supportedlock"}: {
 		findFn: findSupportedLock,
 		dir:    true,
@@ -165,6 +172,16 @@ var liveProps = map[xml.Name]struct {
 		findFn: findChecksums,
 		dir:    false,
 	},
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn:    findQuotaAvailableBytes,
+		dir:       true,
+		expensive: true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn:    findQuotaUsedBytes,
+		dir:       true,
+		expensive: true,
+	},
 }
 
 // TODO(nigeltao) merge props and allprop?
@@ -173,7 +190,7 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -186,13 +203,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 	isDir := fi.IsDir()
 
 	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	// the dead properties are only read when some are asked for
+	for _, pn := range pnames {
+		if _, ok := liveProps[pn]; !ok {
+			deadProps = getDeadProps(name)
+			break
+		}
+	}
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
@@ -204,7 +221,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
+			if errors.Is(err, errPropNotFound) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -222,7 +245,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
+func propnames(ctx context.Context, ls LockSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -234,14 +257,7 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 	//}
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps := getDeadProps(name)
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
@@ -263,11 +279,14 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, name, fi)
 	if err != nil {
 		return nil, err
 	}
+	pnames = slices.DeleteFunc(pnames, func(pn xml.Name) bool {
+		return liveProps[pn].expensive
+	})
 	// Add names from include if they are not already covered in pnames.
 	nameset := make(map[xml.Name]bool)
 	for _, pn := range pnames {
@@ -278,7 +297,7 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -314,40 +333,72 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
+	// the properties are kept by the storages, so the virtual dirs of the
+	// mount paths have none
+	if _, _, err := op.GetStorageAndActualPath(name); err != nil {
+		pstat := Propstat{Status: http.StatusForbidden}
+		for _, patch := range patches {
+			for _, p := range patch.Props {
+				pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			}
+		}
+		return []Propstat{pstat}, nil
+	}
+	dead, err := op.GetDavProps(name)
+	if err != nil {
+		return nil, err
+	}
+	// the instructions are applied in order, and all of them or none
+	pstat := Propstat{Status: http.StatusOK}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
 			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			i := slices.IndexFunc(dead, func(d model.DavProp) bool {
+				return d.Space == p.XMLName.Space && d.Local == p.XMLName.Local
+			})
+			if patch.Remove {
+				if i >= 0 {
+					dead = slices.Delete(dead, i, i+1)
+				}
+				continue
+			}
+			prop := model.DavProp{
+				Space:    p.XMLName.Space,
+				Local:    p.XMLName.Local,
+				Lang:     p.Lang,
+				InnerXML: string(p.InnerXML),
+			}
+			if i >= 0 {
+				dead[i] = prop
+			} else {
+				dead = append(dead, prop)
+			}
 		}
 	}
+	if err = op.SetDavProps(name, dead); err != nil {
+		return nil, err
+	}
 	return []Propstat{pstat}, nil
 }
 
+// getDeadProps returns the properties set with PROPPATCH on name
+func getDeadProps(name string) map[xml.Name]Property {
+	dead, err := op.GetDavProps(name)
+	if err != nil || len(dead) == 0 {
+		return nil
+	}
+	props := make(map[xml.Name]Property, len(dead))
+	for _, d := range dead {
+		pn := xml.Name{Space: d.Space, Local: d.Local}
+		props[pn] = Property{
+			XMLName:  pn,
+			Lang:     d.Lang,
+			InnerXML: []byte(d.InnerXML),
+		}
+	}
+	return props
+}
+
 func escapeXML(s string) string {
 	for i := 0; i < len(s); i++ {
 		// As an optimization, if s contains only ASCII letters, digits or a
@@ -489,6 +540,14 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`</D:lockentry>`, nil
 }
 
+func findLockDiscovery(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	var b strings.Builder
+	for _, lock := range ls.Discover(time.Now(), name) {
+		b.WriteString(activeLock(lock.Token, lock.LockDetails))
+	}
+	return b.String(), nil
+}
+
 func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
 	checksums := ""
 	for hashType, hashValue := range fi.GetHash().All() {
@@ -496,3 +555,40 @@ func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj
 	}
 	return checksums, nil
 }
+
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	available, _, ok := findQuota(ctx, name)
+	if !ok {
+		return "", errPropNotFound
+	}
+	return strconv.FormatInt(available, 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	_, used, ok := findQuota(ctx, name)
+	if !ok {
+		return "", errPropNotFound
+	}
+	return strconv.FormatInt(used, 10), nil
+}
+
+// findQuota returns the space of the user's home quota or of the storage of
+// name, the one with less available space if both are known
+func findQuota(ctx context.Context, name string) (available, used int64, ok bool) {
+	if user, _ := ctx.Value("user").(*model.User); user != nil && user.HomeQuota > 0 &&
+		utils.IsSubPath(user.BasePath, name) {
+		available, used, ok = max(user.HomeQuota-user.HomeUsed, 0), user.HomeUsed, true
+	}
+	storage, _, err := op.GetStorageAndActualPath(name)
+	if err != nil {
+		return
+	}
+	usage, err := op.GetDiskUsage(ctx, storage)
+	if err != nil {
+		return
+	}
+	if !ok || usage.FreeSpace < available {
+		available, used, ok = usage.FreeSpace, usage.UsedSpace(), true
+	}
+	return
+}
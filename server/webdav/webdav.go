@@ -21,8 +21,10 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 type Handler struct {
@@ -169,6 +171,11 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 			if err != nil {
 				return nil, status, err
 			}
+			// the locks are on the resolved paths, the same as src and dst
+			lsrc, err = ResolvePath(r.Context().Value("user").(*model.User), lsrc)
+			if err != nil {
+				continue
+			}
 		}
 		release, err = h.LockSystem.Confirm(time.Now(), lsrc, dst, l.conditions...)
 		if err == ErrConfirmationFailed {
@@ -225,6 +232,10 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	// the content of a file drop is only for its owners
+	if meta, _ := op.GetNearestMeta(reqPath); common.InDropFolder(user, meta, reqPath) && !utils.PathEqual(meta.Path, reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
 	if err != nil {
 		return http.StatusNotFound, err
@@ -240,6 +251,7 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	storage, _ := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	downProxyUrl := storage.GetStorage().DownProxyUrl
 	if storage.GetStorage().WebdavNative() || (storage.GetStorage().WebdavProxy() && downProxyUrl == "") {
+		defer op.AcquireRead(reqPath)()
 		link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{Header: r.Header, HttpReq: r})
 		if err != nil {
 			return http.StatusInternalServerError, err
@@ -270,18 +282,18 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = ResolvePath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -296,6 +308,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err := fs.Remove(ctx, reqPath); err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	if err := op.RemoveDavProps(reqPath); err != nil {
+		log.Warnf("failed remove the dav props of %s: %+v", reqPath, err)
+	}
 	//fs.ClearCache(path.Dir(reqPath))
 	return http.StatusNoContent, nil
 }
@@ -308,11 +323,6 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if reqPath == "" {
 		return http.StatusMethodNotAllowed, nil
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
 	// TODO(rost): Support the If-Match, If-None-Match headers? See bradfitz'
 	// comments in http.checkEtag.
 	ctx := r.Context()
@@ -321,6 +331,11 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	obj := model.Object{
 		Name:     path.Base(reqPath),
 		Size:     r.ContentLength,
@@ -363,18 +378,17 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = ResolvePath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 
 	if r.ContentLength > 0 {
 		return http.StatusUnsupportedMediaType, nil
@@ -545,6 +559,7 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 			Duration:  duration,
 			OwnerXML:  li.Owner.InnerXML,
 			ZeroDepth: depth == 0,
+			Href:      r.URL.EscapedPath(),
 		}
 		token, err = h.LockSystem.Create(now, ld)
 		if err != nil {
@@ -694,7 +709,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			for _, item := range infos {
 				var pstats []Propstat
 				if pf.Propname != nil {
-					pnames, err := propnames(ctx, h.LockSystem, item.info)
+					pnames, err := propnames(ctx, h.LockSystem, item.path, item.info)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -704,12 +719,12 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 					}
 					pstats = append(pstats, pstat)
 				} else if pf.Allprop != nil {
-					pstats, err = allprop(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = allprop(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
 				} else {
-					pstats, err = props(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = props(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -742,7 +757,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -752,9 +767,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -789,18 +804,18 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = ResolvePath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
+
 	if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err != nil {
 		if errs.IsObjectNotFound(err) {
 			return http.StatusNotFound, err
@@ -915,6 +930,7 @@ var (
 	errNoLockSystem            = errors.New("webdav: no lock system")
 	errNotADirectory           = errors.New("webdav: not a directory")
 	errPrefixMismatch          = errors.New("webdav: prefix mismatch")
+	errPropNotFound            = errors.New("webdav: property not found")
 	errRecursionTooDeep        = errors.New("webdav: recursion too deep")
 	errUnsupportedLockInfo     = errors.New("webdav: unsupported lock info")
 	errUnsupportedMethod       = errors.New("webdav: unsupported method")